@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/gen/avro"
+	"github.com/defsrc/proton/protosrc"
+)
+
+var (
+	avroPackage string
+	avroOut     string
+)
+
+var avroCmd = &command{
+	name:    "avro",
+	args:    "schema.avsc ...",
+	summary: "convert Avro schemas to a .proto file, see proton generate avro for the other way",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&avroPackage, "package", "", "the `package` of the file")
+		fs.StringVar(&avroOut, "out", "", "write to `file.proto` instead of stdout")
+	},
+	run:   runAvro,
+	watch: true,
+}
+
+func runAvro(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	var schemas []*avro.Schema
+	for _, name := range fs.Args() {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		s := &avro.Schema{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		schemas = append(schemas, s)
+	}
+	name := "avro.proto"
+	if avroOut != "" {
+		name = filepath.Base(avroOut)
+	}
+	f, err := avro.ToFile(name, avroPackage, schemas...)
+	if err != nil {
+		return err
+	}
+	s := newSchema([]*descriptor.File{f})
+	src, err := protosrc.MarshalOptions{Resolver: s.syms}.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if avroOut != "" {
+		return os.WriteFile(avroOut, src, 0o666)
+	}
+	_, err = os.Stdout.Write(src)
+	return err
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// number returns the value of a JSON number, as an int64, uint64 or float64.
+func number(n json.Number) any {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return u
+	}
+	f, _ := strconv.ParseFloat(n.String(), 64)
+	return f
+}
+
+// toCBOR converts JSON to CBOR (RFC 8949), objects become maps with text keys in JSON order.
+func toCBOR(js []byte) ([]byte, error) {
+	n, err := parseJSON(js)
+	if err != nil {
+		return nil, err
+	}
+	return appendCBOR(nil, n), nil
+}
+
+// cborHead appends the initial byte of a data item with its argument.
+func cborHead(b []byte, major byte, v uint64) []byte {
+	major <<= 5
+	switch {
+	case v < 24:
+		return append(b, major|byte(v))
+	case v <= math.MaxUint8:
+		return append(b, major|24, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(v))
+	default:
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), v)
+}
+
+func appendCBOR(b []byte, n *node) []byte {
+	switch {
+	case n.object:
+		b = cborHead(b, 5, uint64(len(n.keys)))
+		for i, k := range n.keys {
+			b = append(cborHead(b, 3, uint64(len(k))), k...)
+			b = appendCBOR(b, n.values[i])
+		}
+		return b
+	case n.array:
+		b = cborHead(b, 4, uint64(len(n.values)))
+		for _, v := range n.values {
+			b = appendCBOR(b, v)
+		}
+		return b
+	default:
+	}
+	switch v := n.scalar.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case string:
+		return append(cborHead(b, 3, uint64(len(v))), v...)
+	case json.Number:
+		switch x := number(v).(type) {
+		case int64:
+			if x < 0 {
+				return cborHead(b, 1, uint64(-(x + 1)))
+			}
+			return cborHead(b, 0, uint64(x))
+		case uint64:
+			return cborHead(b, 0, x)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(x))
+		}
+	default:
+	}
+	return append(b, 0xf7) // undefined
+}
+
+// toMsgpack converts JSON to MessagePack, objects become maps with str keys in JSON order.
+func toMsgpack(js []byte) ([]byte, error) {
+	n, err := parseJSON(js)
+	if err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, n), nil
+}
+
+// msgpackLen appends the header of a str, array or map of length l,
+// fix is the fix format prefix for lengths below fixMax, codes the 8 (str only), 16 and 32 bit formats.
+func msgpackLen(b []byte, l int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case l < fixMax:
+		return append(b, fix|byte(l))
+	case code8 != 0 && l <= math.MaxUint8:
+		return append(b, code8, byte(l))
+	case l <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(l))
+	default:
+	}
+	return binary.BigEndian.AppendUint32(append(b, code32), uint32(l))
+}
+
+func msgpackString(b []byte, s string) []byte {
+	return append(msgpackLen(b, len(s), 0xa0, 32, 0xd9, 0xda, 0xdb), s...)
+}
+
+func appendMsgpack(b []byte, n *node) []byte {
+	switch {
+	case n.object:
+		b = msgpackLen(b, len(n.keys), 0x80, 16, 0, 0xde, 0xdf)
+		for i, k := range n.keys {
+			b = msgpackString(b, k)
+			b = appendMsgpack(b, n.values[i])
+		}
+		return b
+	case n.array:
+		b = msgpackLen(b, len(n.values), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, v := range n.values {
+			b = appendMsgpack(b, v)
+		}
+		return b
+	default:
+	}
+	switch v := n.scalar.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case string:
+		return msgpackString(b, v)
+	case json.Number:
+		switch x := number(v).(type) {
+		case int64:
+			return msgpackInt(b, x)
+		case uint64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), x)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(x))
+		}
+	default:
+	}
+	return append(b, 0xc0)
+}
+
+// msgpackInt appends v in the smallest integer format.
+func msgpackInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v < 128:
+		return append(b, byte(v))
+	case v >= 0 && v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v >= 0 && v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v >= 0 && v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	case v >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	default:
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	breakingOut    output
+	breakingConfig string
+	breakingFail   = descriptor.SeverityError
+	breakingList   bool
+)
+
+var breakingCmd = &command{
+	name:    "breaking",
+	args:    "old_set new_set",
+	summary: "check a new descriptor set for changes breaking users of the old one, failing at -fail severity",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		breakingOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		fs.StringVar(&breakingConfig, "config", "", "JSON `file` setting the severity of rules by name, like {\"rules\": {\"FIELD_NO_RENAME\": \"off\"}}")
+		fs.TextVar(&breakingFail, "fail", descriptor.SeverityError, "exit with status 1 for findings of this `severity` or above: info, warning, error or off")
+		fs.BoolVar(&breakingList, "list", false, "list the rules and their default severities")
+	},
+	run:   runBreaking,
+	watch: true,
+}
+
+// breakingRules is the -config file.
+type breakingRules struct {
+	Rules map[string]descriptor.Severity `json:"rules"`
+}
+
+func runBreaking(fs *flag.FlagSet) error {
+	if breakingList {
+		for _, r := range descriptor.BreakingRules {
+			fmt.Printf("%-40s %-8s %s\n", r.Name, r.Severity, r.Doc)
+		}
+		return nil
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	if err := breakingOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	var config breakingRules
+	if breakingConfig != "" {
+		b, err := os.ReadFile(breakingConfig)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("%s: %w", breakingConfig, err)
+		}
+		for name := range config.Rules {
+			if !slices.ContainsFunc(descriptor.BreakingRules, func(r descriptor.BreakingRule) bool { return r.Name == name }) {
+				return fmt.Errorf("%s: unknown rule %s", breakingConfig, name)
+			}
+		}
+	}
+	old, err := loadSets(fs.Args()[:1])
+	if err != nil {
+		return err
+	}
+	new, err := loadSets(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	findings := descriptor.CheckBreaking(old, new, config.Rules)
+	if breakingOut.format == "text" {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	} else {
+		if findings == nil {
+			findings = []descriptor.Finding{}
+		}
+		out, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := breakingOut.write(out); err != nil {
+			return err
+		}
+	}
+	n := 0
+	for _, f := range findings {
+		if breakingFail != descriptor.SeverityOff && f.Severity >= breakingFail {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("%d breaking changes of severity %s or above", n, breakingFail)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// moduleRefs are the -module flags, modulesRead is set once loadSets read them for a run of the command.
+var (
+	moduleRefs  []string
+	modulesRead bool
+)
+
+// moduleCacheAge is how long a downloaded module is used before it is downloaded again.
+const moduleCacheAge = time.Hour
+
+// A module is a reference to a module of a Buf Schema Registry like buf.build/acme/payments:main.
+type module struct {
+	remote, owner, repo string
+	ref                 string // commit, label or branch, "" for the default one
+}
+
+// parseModule parses a module reference, names of existing files are not ones.
+func parseModule(name string) (module, bool) {
+	if _, err := os.Stat(name); err == nil {
+		return module{}, false
+	}
+	path, ref, _ := strings.Cut(name, ":")
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || !strings.Contains(parts[0], ".") || strings.HasPrefix(parts[0], ".") ||
+		parts[1] == "" || parts[2] == "" || strings.Contains(parts[2], ".") {
+		return module{}, false
+	}
+	return module{remote: parts[0], owner: parts[1], repo: parts[2], ref: ref}, true
+}
+
+func (m module) String() string {
+	s := m.remote + "/" + m.owner + "/" + m.repo
+	if m.ref != "" {
+		s += ":" + m.ref
+	}
+	return s
+}
+
+// load returns the image of the module as a descriptor set, from the cache if it is recent enough.
+// If the download fails, an older cached image is used.
+func (m module) load() ([]byte, error) {
+	cache := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		ref := m.ref
+		if ref == "" {
+			ref = "_default"
+		}
+		cache = filepath.Join(dir, "proton", "bsr", m.remote, m.owner, m.repo, ref+".binpb")
+		if fi, err := os.Stat(cache); err == nil && time.Since(fi.ModTime()) < moduleCacheAge {
+			return os.ReadFile(cache)
+		}
+	}
+	set, err := m.download()
+	if err != nil {
+		if cached, cerr := os.ReadFile(cache); cache != "" && cerr == nil {
+			log.Printf("%v: %v, using the cached image", m, err)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("%v: %w", m, err)
+	}
+	if cache != "" {
+		if err := os.MkdirAll(filepath.Dir(cache), 0o777); err == nil {
+			os.WriteFile(cache, set, 0o666)
+		}
+	}
+	return set, nil
+}
+
+// download calls the ImageService of the registry with the Connect protocol.
+// An image is a FileDescriptorSet whose files may have Buf specific fields, which are kept as unknown ones.
+func (m module) download() ([]byte, error) {
+	var req wire.Encoder
+	req.EncodeString(1, m.owner)
+	req.EncodeString(2, m.repo)
+	if m.ref != "" {
+		req.EncodeString(3, m.ref)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	url := "https://" + m.remote + "/buf.alpha.registry.v1alpha1.ImageService/GetImage"
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(req.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Content-Type", "application/proto")
+	hreq.Header.Set("Connect-Protocol-Version", "1")
+	if token := bufToken(m.remote); token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var cerr struct{ Code, Message string }
+		if json.Unmarshal(body, &cerr) == nil && cerr.Code != "" {
+			return nil, fmt.Errorf("registry: %s: %s", cerr.Code, cerr.Message)
+		}
+		return nil, fmt.Errorf("registry: %s", resp.Status)
+	}
+	// GetImageResponse.image
+	for r, err := range wire.Fields(body) {
+		if err != nil {
+			return nil, err
+		}
+		if r.Tag == 1 && r.Kind == wire.TagSequence {
+			return r.Bytes, nil
+		}
+	}
+	return nil, errors.New("registry: no image in the response")
+}
+
+// bufToken returns the token for remote like the buf CLI finds it: in $BUF_TOKEN,
+// either alone or as a comma separated list of token@remote, or else in ~/.netrc.
+func bufToken(remote string) string {
+	if env := os.Getenv("BUF_TOKEN"); env != "" {
+		if !strings.Contains(env, "@") {
+			return env
+		}
+		for _, pair := range strings.Split(env, ",") {
+			if token, r, ok := strings.Cut(strings.TrimSpace(pair), "@"); ok && r == remote {
+				return token
+			}
+		}
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open(filepath.Join(home, ".netrc"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return netrcPassword(f, remote)
+}
+
+// netrcPassword returns the password of machine in a .netrc file.
+func netrcPassword(r io.Reader, machine string) string {
+	s := bufio.NewScanner(r)
+	s.Split(bufio.ScanWords)
+	current := ""
+	for s.Scan() {
+		switch s.Text() {
+		case "machine":
+			s.Scan()
+			current = s.Text()
+		case "default":
+			current = ""
+		case "password":
+			s.Scan()
+			if current == machine {
+				return s.Text()
+			}
+		default:
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	compileOut        string
+	compileImports    bool
+	compileSourceInfo bool
+)
+
+var compileCmd = &command{
+	name:    "compile",
+	args:    "file.proto ...",
+	summary: "compile .proto files to a binary descriptor set like protoc --descriptor_set_out",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		fs.StringVar(&compileOut, "out", "", "write to `file` instead of stdout")
+		fs.BoolVar(&compileImports, "include_imports", false, "also include the imported files parsed from source")
+		fs.BoolVar(&compileSourceInfo, "include_source_info", false, "keep the source locations and comments")
+	},
+	run:   runCompile,
+	watch: true,
+}
+
+func runCompile(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		names[i] = sourceName(name)
+	}
+	files, err := compile(names, compileSourceInfo)
+	if err != nil {
+		return err
+	}
+	if !compileImports {
+		files = slices.DeleteFunc(files, func(f *descriptor.File) bool { return !slices.Contains(names, f.Name) })
+	}
+	out, err := descriptor.Marshal(files)
+	if err != nil {
+		return err
+	}
+	if compileOut != "" {
+		return os.WriteFile(compileOut, out, 0o666)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/defsrc/proton/protojson"
+	"github.com/defsrc/proton/prototext"
+)
+
+var (
+	decodeOut       output
+	decodeType      typed
+	decodeDelimited bool
+)
+
+var decodeCmd = &command{
+	name:    "decode",
+	args:    "[data ...]",
+	summary: "decode binary messages of any type, from stdin without data files",
+	flags: func(fs *flag.FlagSet) {
+		decodeType.register(fs, "google.protobuf.FileDescriptorSet")
+		decodeOut.register(fs, "json", "json, protojson, text, yaml, cbor or msgpack")
+		registerWrap(fs)
+		registerServer(fs)
+		registerRedact(fs)
+		fs.BoolVar(&decodeDelimited, "delimited", false, "the data holds messages each preceded by its size as a varint")
+	},
+	run:   runDecode,
+	watch: true,
+}
+
+func runDecode(fs *flag.FlagSet) error {
+	if err := decodeOut.check("json", "protojson", "text", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	s, desc, err := decodeType.message()
+	if err != nil {
+		return err
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	i := 0
+	for _, name := range names {
+		msgs, err := readMessages(name, decodeDelimited)
+		if err != nil {
+			return err
+		}
+		for j, in := range msgs {
+			s = s.resolveAny(desc, in)
+			if in, err = redact(s, desc, in); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			var out []byte
+			if decodeOut.format == "text" {
+				out, err = prototext.MarshalOptions{Resolver: s.syms, Extensions: s.exts, WellKnown: decodeOut.wellKnown}.Format(desc, in)
+			} else {
+				o := protojson.MarshalOptions{Indent: "  ", UseProtoNames: decodeOut.protoNames, Resolver: s.syms, Extensions: s.exts}
+				out, err = o.Format(desc, in)
+			}
+			if err != nil {
+				if decodeDelimited {
+					err = fmt.Errorf("message %d: %w", j, err)
+				}
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if i > 0 && (decodeOut.format == "yaml" || decodeOut.format == "text") {
+				os.Stdout.WriteString(separators[decodeOut.format])
+			}
+			if err := decodeOut.write(out); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// separators go between the messages of multiple inputs, the other formats are self delimiting.
+var separators = map[string]string{"yaml": "---\n", "text": "\n"}
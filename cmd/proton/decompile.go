@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/defsrc/proton/protosrc"
+)
+
+var (
+	decompileDir  string
+	decompileFile string
+)
+
+var decompileCmd = &command{
+	name:    "decompile",
+	args:    "[descriptor_set ...]",
+	summary: "reconstruct the .proto source files of descriptor sets, with their comments if included",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		fs.StringVar(&decompileDir, "out", "", "write the files below `dir` instead of printing them")
+		fs.StringVar(&decompileFile, "file", "", "only decompile the files matching the `pattern`, like foo/*.proto")
+	},
+	run:   runDecompile,
+	watch: true,
+}
+
+func runDecompile(fs *flag.FlagSet) error {
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	s := newSchema(x)
+	o := protosrc.MarshalOptions{Resolver: s.syms, Extensions: s.exts}
+	first := true
+	for _, f := range x {
+		if decompileFile != "" {
+			if ok, err := path.Match(decompileFile, f.Name); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+		}
+		src, err := o.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if decompileDir == "" {
+			if !first {
+				fmt.Println()
+			}
+			first = false
+			fmt.Printf("==> %s <==\n", f.Name)
+			os.Stdout.Write(src)
+			continue
+		}
+		name := filepath.Join(decompileDir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, src, 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
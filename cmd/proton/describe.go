@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/protojson"
+	"github.com/defsrc/proton/prototext"
+	"github.com/defsrc/proton/wellknown"
+)
+
+var describeOut output
+
+var describeCmd = &command{
+	name:    "describe",
+	args:    "[descriptor_set ...]",
+	summary: "print the files of descriptor sets or of a gRPC server, merged by file name",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		registerServer(fs)
+		describeOut.register(fs, "json", "json, protojson (the descriptor.proto schema), text, yaml, cbor or msgpack")
+	},
+	run:   runDescribe,
+	watch: true,
+}
+
+func runDescribe(fs *flag.FlagSet) error {
+	if err := describeOut.check("json", "protojson", "text", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	x, err := loadInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+	exts := dynamic.NewExtensionRegistry(x)
+	var out []byte
+	switch describeOut.format {
+	case "protojson":
+		o := protojson.MarshalOptions{Indent: "  ", UseProtoNames: describeOut.protoNames, Extensions: exts}
+		out, err = o.MarshalFiles(x)
+	case "text":
+		out, err = descriptor.Marshal(x)
+		if err == nil {
+			out, err = prototext.MarshalOptions{Extensions: exts}.Format(wellknown.Message("google.protobuf.FileDescriptorSet"), out)
+		}
+	default:
+		out, err = json.MarshalIndent(x, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return describeOut.write(out)
+}
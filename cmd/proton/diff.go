@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var diffOut output
+
+var diffCmd = &command{
+	name:    "diff",
+	args:    "old_set new_set",
+	summary: "compare two descriptor sets and list the added, removed, renamed and changed elements",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		diffOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+	},
+	run:   runDiff,
+	watch: true,
+}
+
+func runDiff(fs *flag.FlagSet) error {
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	if err := diffOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	old, err := loadSets(fs.Args()[:1])
+	if err != nil {
+		return err
+	}
+	new, err := loadSets(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	changes := descriptor.Diff(old, new)
+	if diffOut.format != "text" {
+		if changes == nil {
+			changes = []descriptor.Change{}
+		}
+		out, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		return diffOut.write(out)
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return nil
+}
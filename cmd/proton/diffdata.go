@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+)
+
+var (
+	diffDataType typed
+	diffDataOut  output
+)
+
+var diffDataCmd = &command{
+	name:    "diff-data",
+	args:    "old.bin new.bin",
+	summary: "compare two binary messages and list the added, removed and changed fields, by field number without -type",
+	flags: func(fs *flag.FlagSet) {
+		diffDataType.register(fs, "")
+		diffDataOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		registerWrap(fs)
+	},
+	run:   runDiffData,
+	watch: true,
+}
+
+// diffRow is the JSON form of a dynamic.Difference.
+type diffRow struct {
+	Kind, Path string
+	Old        any `json:",omitempty"`
+	New        any `json:",omitempty"`
+}
+
+func runDiffData(fs *flag.FlagSet) error {
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	if err := diffDataOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	old, err := readData(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := readData(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	var diffs []dynamic.Difference
+	if diffDataType.typeName == "" {
+		if diffs, err = dynamic.DiffRaw(old, new); err != nil {
+			return err
+		}
+	} else {
+		s, desc, err := diffDataType.message()
+		if err != nil {
+			return err
+		}
+		o := dynamic.UnmarshalOptions{Extensions: s.exts}
+		a, err := o.Unmarshal(desc, old)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(0), err)
+		}
+		b, err := o.Unmarshal(desc, new)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(1), err)
+		}
+		diffs = dynamic.Diff(a, b)
+	}
+	if diffDataOut.format != "text" {
+		rows := []diffRow{}
+		for _, d := range diffs {
+			rows = append(rows, diffRow{d.Kind, d.Path, diffValue(d.Field, d.Old), diffValue(d.Field, d.New)})
+		}
+		out, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		return diffDataOut.write(out)
+	}
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	return nil
+}
+
+// diffValue returns the JSON form of a value of a difference: enum names, empty objects for messages
+// and strings for the floats JSON lacks.
+func diffValue(f *descriptor.Field, v any) any {
+	switch v := v.(type) {
+	case *dynamic.Message:
+		return struct{}{}
+	case int32:
+		if f != nil && f.Type == descriptor.TypeEnum && f.EnumType != nil {
+			for _, ev := range f.EnumType.Value {
+				if ev.Number == v {
+					return ev.Name
+				}
+			}
+		}
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Sprint(v)
+		}
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprint(v)
+		}
+	default:
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/defsrc/proton/protojson"
+	"github.com/defsrc/proton/prototext"
+)
+
+var (
+	encodeFormat  string
+	encodeType    typed
+	encodeOut     string
+	encodeDiscard bool
+)
+
+var encodeCmd = &command{
+	name:    "encode",
+	args:    "[data]",
+	summary: "encode a JSON or text format message to binary, from stdin without a data file",
+	flags: func(fs *flag.FlagSet) {
+		encodeType.register(fs, "google.protobuf.FileDescriptorSet")
+		fs.StringVar(&encodeFormat, "i", "auto", "input `format`: json, text or auto to detect it from the file extension or content")
+		fs.StringVar(&encodeOut, "out", "", "write to `file` instead of stdout")
+		fs.BoolVar(&encodeDiscard, "discard_unknown", false, "ignore unknown fields instead of failing")
+		registerRedact(fs)
+	},
+	run:   runEncode,
+	watch: true,
+}
+
+func runEncode(fs *flag.FlagSet) error {
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	s, desc, err := encodeType.message()
+	if err != nil {
+		return err
+	}
+	name := cmp.Or(fs.Arg(0), "-")
+	in, err := readInput(name)
+	if err != nil {
+		return err
+	}
+	format := encodeFormat
+	if format == "auto" {
+		format = detectFormat(name, in)
+	}
+	var out []byte
+	switch format {
+	case "json", "protojson":
+		out, err = protojson.UnmarshalOptions{DiscardUnknown: encodeDiscard, Resolver: s.syms, Extensions: s.exts}.Encode(desc, in)
+	case "text":
+		out, err = prototext.UnmarshalOptions{DiscardUnknown: encodeDiscard, Resolver: s.syms, Extensions: s.exts}.Encode(desc, in)
+	default:
+		return fmt.Errorf("unknown input format %s", encodeFormat)
+	}
+	if err == nil {
+		out, err = redact(s, desc, out)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if encodeOut != "" {
+		return os.WriteFile(encodeOut, out, 0o666)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// detectFormat guesses the input format from the file extension,
+// then from the content: JSON messages are objects, text format ones are not.
+func detectFormat(name string, in []byte) string {
+	switch filepath.Ext(name) {
+	case ".json":
+		return "json"
+	case ".txt", ".txtpb", ".textproto", ".pbtxt", ".prototxt":
+		return "text"
+	default:
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(in), []byte("{")) {
+		return "json"
+	}
+	return "text"
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wire"
+)
+
+var explainType typed
+
+var explainCmd = &command{
+	name:    "explain",
+	args:    "[data ...]",
+	summary: "print an annotated hex dump of binary messages, guessing the field types without -type",
+	flags: func(fs *flag.FlagSet) {
+		explainType.register(fs, "")
+		registerWrap(fs)
+	},
+	run:   runExplain,
+	watch: true,
+}
+
+func runExplain(fs *flag.FlagSet) error {
+	var desc *descriptor.Message
+	var exts *dynamic.ExtensionRegistry
+	if explainType.typeName != "" {
+		s, d, err := explainType.message()
+		if err != nil {
+			return err
+		}
+		desc, exts = d, s.exts
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		in, err := readData(name)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if len(names) > 1 {
+			fmt.Printf("==> %s <==\n", name)
+		}
+		if err := explain(os.Stdout, in, desc, exts); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// explainer prints an annotated hex dump of binary data:
+// each row holds the offset and bytes of a key, length prefix or value next to what they decode to.
+type explainer struct {
+	w    *bufio.Writer
+	exts *dynamic.ExtensionRegistry
+}
+
+// rowBytes is the number of bytes per row, longer values continue on the next rows.
+const rowBytes = 12
+
+var wireNames = [...]string{"VARINT", "I64", "LEN", "SGROUP", "EGROUP", "I32", "6", "7"}
+
+// explain writes the dump of data as a message of type desc, nil guesses the types like protoc --decode_raw.
+// The dump ends at the first wire error, which is also returned.
+func explain(w io.Writer, data []byte, desc *descriptor.Message, exts *dynamic.ExtensionRegistry) error {
+	x := &explainer{w: bufio.NewWriter(w), exts: exts}
+	fmt.Fprintf(x.w, "%6s  %-*s  %s\n", "offset", rowBytes*3-1, "bytes", "field")
+	err := x.message(0, data, desc, 0)
+	if ferr := x.w.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// row prints b starting at off with text, wrapping after rowBytes.
+func (x *explainer) row(off int, b []byte, depth int, text string) {
+	for first := true; first || len(b) > 0; first = false {
+		n := min(len(b), rowBytes)
+		var hex strings.Builder
+		for i, c := range b[:n] {
+			if i > 0 {
+				hex.WriteByte(' ')
+			}
+			fmt.Fprintf(&hex, "%02x", c)
+		}
+		line := fmt.Sprintf("%6d  %-*s  %s%s", off, rowBytes*3-1, hex.String(), strings.Repeat("  ", depth), text)
+		fmt.Fprintln(x.w, strings.TrimRight(line, " "))
+		off, b, text = off+n, b[n:], ""
+	}
+}
+
+// message dumps the fields of data, which starts at off.
+func (x *explainer) message(off int, data []byte, desc *descriptor.Message, depth int) error {
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			x.row(off+r.Offset, data[r.Offset:], depth, "error: "+err.Error())
+			return shiftErr(err, off)
+		}
+		if err := x.field(off+r.Offset, r, x.lookup(desc, r.Tag), depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the field or extension of desc with the given number, nil if unknown.
+func (x *explainer) lookup(desc *descriptor.Message, tag wire.TagNum) *descriptor.Field {
+	if desc == nil {
+		return nil
+	}
+	for _, f := range desc.Field {
+		if f.Tag == tag {
+			return f
+		}
+	}
+	if x.exts != nil {
+		return x.exts.Find(desc.FullName(), tag)
+	}
+	return nil
+}
+
+// field dumps the field r at off, f is its descriptor or nil.
+func (x *explainer) field(off int, r wire.FieldRef, f *descriptor.Field, depth int) error {
+	label := fmt.Sprintf("%d %s", r.Tag, wireNames[r.Kind])
+	if f != nil {
+		if f.Extendee != "" {
+			label += " [" + f.FullName() + "]"
+		} else {
+			label += " " + f.Name
+		}
+		label += " " + descriptor.TypeKeyword(f.Type)
+		if !matches(f, r.Kind) {
+			label += " (wire type mismatch)"
+			f = nil
+		}
+	}
+	end := 0 // the end key of groups
+	if r.Kind == wire.TagStart {
+		end = wire.SizeVarint(uint64(r.Tag)<<3 | uint64(wire.TagEnd))
+	}
+	head := len(r.Raw) - len(r.Bytes) - end // the key and length prefix
+	switch r.Kind {
+	case wire.TagSequence:
+		label += " len=" + strconv.Itoa(len(r.Bytes))
+	case wire.TagStart:
+		sub := x.submessage(f)
+		x.row(off, r.Raw[:head], depth, label+" {")
+		if err := x.message(off+head, r.Bytes, sub, depth+1); err != nil {
+			return err
+		}
+		x.row(off+len(r.Raw)-end, r.Raw[len(r.Raw)-end:], depth, "}")
+		return nil
+	default:
+		x.row(off, r.Raw, depth, label+" = "+scalarText(f, r.Value, r.Kind))
+		return nil
+	}
+
+	if f != nil && f.Packable() {
+		x.row(off, r.Raw[:head], depth, label+" packed")
+		return x.packed(off+head, r.Bytes, f, depth+1)
+	}
+	if sub := x.submessage(f); sub != nil || f == nil && isMessage(r.Bytes) {
+		x.row(off, r.Raw[:head], depth, label+" {")
+		if err := x.message(off+head, r.Bytes, sub, depth+1); err != nil {
+			return err
+		}
+		return nil
+	}
+	text := fmt.Sprintf("%q", r.Bytes)
+	if f == nil && !isText(r.Bytes) || f != nil && f.Type == descriptor.TypeBytes {
+		text = fmt.Sprintf("% x", r.Bytes)
+	}
+	x.row(off, r.Raw[:head], depth, label)
+	if len(r.Bytes) > 0 {
+		x.row(off+head, r.Bytes, depth+1, text)
+	}
+	return nil
+}
+
+// matches reports whether a field of type f may be encoded with kind,
+// like dynamic messages and groups accept both encodings.
+func matches(f *descriptor.Field, kind wire.TagClass) bool {
+	switch {
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		return kind == wire.TagSequence || kind == wire.TagStart
+	case f.Packable() && kind == wire.TagSequence:
+		return true
+	default:
+	}
+	return descriptor.WireClass(f.Type) == kind
+}
+
+// submessage returns the message type of f, nil if f is not a linked message field.
+func (x *explainer) submessage(f *descriptor.Field) *descriptor.Message {
+	if f == nil || f.Type != descriptor.TypeMessage && f.Type != descriptor.TypeGroup {
+		return nil
+	}
+	return f.MessageType
+}
+
+// packed dumps the elements of a packed repeated field, one per row.
+func (x *explainer) packed(off int, b []byte, f *descriptor.Field, depth int) error {
+	kind := descriptor.WireClass(f.Type)
+	for i := 0; len(b) > 0; i++ {
+		var v uint64
+		var n int
+		switch kind {
+		case wire.TagUvarint:
+			v, n = wire.ReadVarint(b)
+		case wire.Tag32bit:
+			var u uint32
+			u, n = wire.ReadFixed32(b)
+			v = uint64(u)
+		default:
+			v, n = wire.ReadFixed64(b)
+		}
+		if n <= 0 {
+			x.row(off, b, depth, "error: truncated packed element")
+			return &wire.Error{Offset: off, Tag: f.Tag, Kind: wire.TagSequence, Err: wire.ErrTruncated}
+		}
+		x.row(off, b[:n], depth, fmt.Sprintf("[%d] = %s", i, scalarText(f, v, kind)))
+		off, b = off+n, b[n:]
+	}
+	return nil
+}
+
+// scalarText formats a varint or fixed value as the type of f, guessing if f is nil.
+func scalarText(f *descriptor.Field, v uint64, kind wire.TagClass) string {
+	if f == nil {
+		switch kind {
+		case wire.Tag32bit:
+			return fmt.Sprintf("0x%08x (float %g)", v, wire.DecodeFloat(v))
+		case wire.Tag64bit:
+			return fmt.Sprintf("0x%016x (double %g)", v, wire.DecodeDouble(v))
+		default:
+		}
+		if int64(v) < 0 {
+			return fmt.Sprintf("%d (int64 %d)", v, int64(v))
+		}
+		return strconv.FormatUint(v, 10)
+	}
+	switch f.Type {
+	case descriptor.TypeDouble:
+		return strconv.FormatFloat(wire.DecodeDouble(v), 'g', -1, 64)
+	case descriptor.TypeFloat:
+		return strconv.FormatFloat(float64(wire.DecodeFloat(v)), 'g', -1, 32)
+	case descriptor.TypeInt64:
+		return strconv.FormatInt(int64(v), 10)
+	case descriptor.TypeInt32:
+		return strconv.FormatInt(int64(wire.DecodeInt32(v)), 10)
+	case descriptor.TypeBool:
+		return strconv.FormatBool(wire.DecodeBool(v))
+	case descriptor.TypeSfixed32:
+		return strconv.FormatInt(int64(wire.DecodeSfixed32(v)), 10)
+	case descriptor.TypeSfixed64:
+		return strconv.FormatInt(wire.DecodeSfixed64(v), 10)
+	case descriptor.TypeSint32:
+		return strconv.FormatInt(int64(wire.DecodeSint32(v)), 10)
+	case descriptor.TypeSint64:
+		return strconv.FormatInt(wire.DecodeSint64(v), 10)
+	case descriptor.TypeEnum:
+		n := wire.DecodeEnum(v)
+		if f.EnumType != nil {
+			for _, ev := range f.EnumType.Value {
+				if ev.Number == n {
+					return fmt.Sprintf("%s (%d)", ev.Name, n)
+				}
+			}
+		}
+		return strconv.FormatInt(int64(n), 10)
+	default:
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// isText reports whether b is printable UTF-8.
+func isText(b []byte) bool {
+	return utf8.Valid(b) && !slices.ContainsFunc([]rune(string(b)), func(c rune) bool {
+		return !unicode.IsPrint(c) && !unicode.IsSpace(c)
+	})
+}
+
+// isMessage reports whether untyped bytes look like a message rather than text.
+func isMessage(b []byte) bool {
+	if len(b) == 0 || isText(b) {
+		return false
+	}
+	for _, err := range wire.Fields(b) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// shiftErr adds off to the offset of a wire error from nested data.
+func shiftErr(err error, off int) error {
+	if werr, ok := err.(*wire.Error); ok {
+		werr.Offset += off
+	}
+	return err
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/gen/avro"
+	"github.com/defsrc/proton/gen/docs"
+	"github.com/defsrc/proton/gen/flatbuffers"
+	"github.com/defsrc/proton/gen/golang"
+	"github.com/defsrc/proton/gen/graphql"
+	"github.com/defsrc/proton/gen/jsonschema"
+	"github.com/defsrc/proton/gen/openapi"
+	"github.com/defsrc/proton/gen/sql"
+	"github.com/defsrc/proton/gen/typescript"
+	"github.com/defsrc/proton/plugin"
+	"github.com/defsrc/proton/protosrc"
+)
+
+// A generator produces files from descriptors, run by the generate command and the plugin mode.
+type generator struct {
+	name    string
+	summary string
+	run     func(g *generation) error
+}
+
+// generation is a run of a generator.
+type generation struct {
+	files  []*descriptor.File // to generate
+	schema *schema            // of all the files
+	opts   map[string]string  // the key=value options, "true" for bare keys
+	out    []*plugin.GeneratedFile
+}
+
+// add adds a generated file.
+func (g *generation) add(name, content string) {
+	g.out = append(g.out, &plugin.GeneratedFile{Name: name, Content: content})
+}
+
+// option adds an option given as key=value or key.
+func (g *generation) option(s string) {
+	if k, v, ok := strings.Cut(s, "="); ok {
+		g.opts[k] = v
+	} else if s != "" {
+		g.opts[s] = "true"
+	}
+}
+
+var generators = []*generator{
+	{name: "describe", summary: "the JSON model of the files, as FILE.json", run: genDescribe},
+	{name: "decompile", summary: "the normalized .proto source of the files", run: genDecompile},
+	{name: "lint", summary: "no files, fails with the lint problems of the files, config=FILE", run: genLint},
+	{name: "stats", summary: "the counts of the elements of the files, as proton.stats.json", run: genStats},
+	{name: "go", summary: "Go structs encoded with the wire package, paths=source_relative, module=PREFIX, MFILE=IMPORT_PATH", run: genGo},
+	{name: "jsonschema", summary: "a JSON Schema of the JSON encoding per message and enum, proto_names, open, base_uri=URI", run: genJSONSchema},
+	{name: "typescript", summary: "TypeScript declarations of the JSON encoding, as FILE.d.ts, proto_names, enum_numbers", run: genTypeScript},
+	{name: "openapi", summary: "an OpenAPI document of the google.api.http rules of the services, as openapi.json, proto_names, unannotated, title=TITLE, version=VERSION", run: genOpenAPI},
+	{name: "sql", summary: "CREATE TABLE statements of the messages, as FILE.sql, dialect=postgres|mysql|sqlite, child_tables", run: genSQL},
+	{name: "avro", summary: "an Avro schema per top level message, as NAME.avsc", run: genAvro},
+	{name: "graphql", summary: "GraphQL types of the messages and enums, as FILE.graphql, proto_names, qualified, inputs", run: genGraphQL},
+	{name: "flatbuffers", summary: "FlatBuffers schemas, as FILE.fbs, and what does not translate, as flatbuffers.report.txt", run: genFlatBuffers},
+	{name: "docs", summary: "reference documentation per package, as PACKAGE.md and index.md, format=markdown|html", run: genDocs},
+}
+
+func lookupGenerator(name string) (*generator, error) {
+	i := slices.IndexFunc(generators, func(g *generator) bool { return g.name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown generator %s, see proton plugin -list", name)
+	}
+	return generators[i], nil
+}
+
+var (
+	generateDir  string
+	generateFile string
+	generateOpts []string
+)
+
+var generateCmd = &command{
+	name:    "generate",
+	args:    "generator [descriptor_set ...]",
+	summary: "generate files from descriptor sets like the plugin mode does, see proton plugin -list for the generators",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		fs.StringVar(&generateDir, "out", ".", "write the files below `dir`")
+		fs.StringVar(&generateFile, "file", "", "only generate the files matching the `pattern`, like foo/*.proto")
+		fs.Func("opt", "`key=value` option of the generator, may be repeated", func(s string) error {
+			generateOpts = append(generateOpts, s)
+			return nil
+		})
+	},
+	run:   runGenerate,
+	watch: true,
+}
+
+func runGenerate(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	gen, err := lookupGenerator(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	x, err := loadSets(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	g := &generation{schema: newSchema(x), opts: map[string]string{}}
+	for _, f := range x {
+		if generateFile != "" {
+			if ok, err := path.Match(generateFile, f.Name); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+		}
+		g.files = append(g.files, f)
+	}
+	for _, s := range generateOpts {
+		g.option(s)
+	}
+	if err := gen.run(g); err != nil {
+		return err
+	}
+	for _, f := range g.out {
+		name := filepath.Join(generateDir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(f.Content), 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genDescribe(g *generation) error {
+	for _, f := range g.files {
+		out, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return err
+		}
+		g.add(f.Name+".json", string(out)+"\n")
+	}
+	return nil
+}
+
+func genDecompile(g *generation) error {
+	o := protosrc.MarshalOptions{Resolver: g.schema.syms, Extensions: g.schema.exts}
+	for _, f := range g.files {
+		src, err := o.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		g.add(f.Name, string(src))
+	}
+	return nil
+}
+
+func genLint(g *generation) error {
+	var config descriptor.LintConfig
+	if name := g.opts["config"]; name != "" {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	problems := descriptor.Lint(g.files, config)
+	if len(problems) == 0 {
+		return nil
+	}
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = p.String()
+	}
+	return fmt.Errorf("%d lint problems:\n%s", len(problems), strings.Join(lines, "\n"))
+}
+
+func genStats(g *generation) error {
+	out, err := json.MarshalIndent(descriptor.NewStats(g.files, 10), "", "  ")
+	if err != nil {
+		return err
+	}
+	g.add("proton.stats.json", string(out)+"\n")
+	return nil
+}
+
+func genGo(g *generation) error {
+	o := golang.Options{Module: g.opts["module"], Packages: map[string]string{}}
+	switch g.opts["paths"] {
+	case "", "import":
+	case "source_relative":
+		o.SourceRelative = true
+	default:
+		return fmt.Errorf("paths=%s is not import or source_relative", g.opts["paths"])
+	}
+	for k, v := range g.opts {
+		if name, ok := strings.CutPrefix(k, "M"); ok {
+			o.Packages[name] = v
+		}
+	}
+	for _, f := range g.files {
+		name, src, err := o.Generate(f, g.schema.files)
+		if err != nil {
+			return err
+		}
+		g.add(name, string(src))
+	}
+	return nil
+}
+
+func genJSONSchema(g *generation) error {
+	o := jsonschema.Options{UseProtoNames: g.opts["proto_names"] == "true", Open: g.opts["open"] == "true", BaseURI: g.opts["base_uri"]}
+	for _, s := range o.Generate(g.files) {
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		g.add(jsonschema.Name(s.Title), string(out)+"\n")
+	}
+	return nil
+}
+
+func genTypeScript(g *generation) error {
+	o := typescript.Options{UseProtoNames: g.opts["proto_names"] == "true", UseEnumNumbers: g.opts["enum_numbers"] == "true"}
+	for _, f := range g.files {
+		name, src, err := o.Generate(f, g.schema.files)
+		if err != nil {
+			return err
+		}
+		g.add(name, string(src))
+	}
+	return nil
+}
+
+func genOpenAPI(g *generation) error {
+	o := openapi.Options{
+		UseProtoNames: g.opts["proto_names"] == "true",
+		Unannotated:   g.opts["unannotated"] == "true",
+		Title:         g.opts["title"],
+		Version:       g.opts["version"],
+	}
+	doc, err := o.Generate(g.files)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	g.add("openapi.json", string(out)+"\n")
+	return nil
+}
+
+func genSQL(g *generation) error {
+	o := sql.Options{ChildTables: g.opts["child_tables"] == "true"}
+	if name := g.opts["dialect"]; name != "" {
+		d, err := sql.ParseDialect(name)
+		if err != nil {
+			return err
+		}
+		o.Dialect = d
+	}
+	for _, f := range g.files {
+		name, src, err := o.Generate(f)
+		if err != nil {
+			return err
+		}
+		g.add(name, string(src))
+	}
+	return nil
+}
+
+func genAvro(g *generation) error {
+	for _, f := range g.files {
+		for _, m := range f.Message {
+			s, err := avro.FromMessage(m)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			out, err := json.MarshalIndent(s, "", "  ")
+			if err != nil {
+				return err
+			}
+			g.add(m.FullName()+".avsc", string(out)+"\n")
+		}
+	}
+	return nil
+}
+
+func genGraphQL(g *generation) error {
+	o := graphql.Options{
+		UseProtoNames: g.opts["proto_names"] == "true",
+		Qualified:     g.opts["qualified"] == "true",
+		Inputs:        g.opts["inputs"] == "true",
+	}
+	for _, f := range g.files {
+		name, src, err := o.Generate(f, g.schema.files)
+		if err != nil {
+			return err
+		}
+		g.add(name, string(src))
+	}
+	return nil
+}
+
+func genFlatBuffers(g *generation) error {
+	var report []string
+	for _, f := range g.files {
+		name, src, problems, err := flatbuffers.Generate(f, g.schema.files)
+		if err != nil {
+			return err
+		}
+		g.add(name, string(src))
+		for _, p := range problems {
+			report = append(report, f.Name+": "+p.String()+"\n")
+		}
+	}
+	if len(report) > 0 {
+		g.add("flatbuffers.report.txt", strings.Join(report, ""))
+	}
+	return nil
+}
+
+func genDocs(g *generation) error {
+	var o docs.Options
+	if name := g.opts["format"]; name != "" {
+		f, err := docs.ParseFormat(name)
+		if err != nil {
+			return err
+		}
+		o.Format = f
+	}
+	for _, p := range o.Generate(g.files) {
+		g.add(p.Name, string(p.Content))
+	}
+	return nil
+}
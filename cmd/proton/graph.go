@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	graphKind     string
+	graphPackages []string
+	graphDepth    int
+	graphOut      string
+)
+
+var graphCmd = &command{
+	name:    "graph",
+	args:    "[descriptor_set ...]",
+	summary: "print the import graph of the files or the reference graph of the messages in the Graphviz DOT language",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		fs.StringVar(&graphKind, "kind", "imports", "the `graph`: imports, files to the files they import, or types, messages to the messages of their fields")
+		fs.Func("package", "start from the files or messages of the `package` and its subpackages, may be repeated, all without", func(s string) error {
+			graphPackages = append(graphPackages, s)
+			return nil
+		})
+		fs.IntVar(&graphDepth, "depth", -1, "follow `n` edges from the start, 0 only shows the start and all edges if negative")
+		fs.StringVar(&graphOut, "out", "", "write to `file.dot` instead of stdout")
+	},
+	run:   runGraph,
+	watch: true,
+}
+
+// A graphNode is a file or message of the graph.
+type graphNode struct {
+	name  string
+	pkg   string
+	edges []graphEdge
+}
+
+type graphEdge struct {
+	to    string
+	label string // the field names for types
+	style string // dashed for weak imports, bold for public ones
+}
+
+func runGraph(fs *flag.FlagSet) error {
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	files := newSchema(x).files
+	var nodes []*graphNode
+	switch graphKind {
+	case "imports":
+		nodes = importNodes(files)
+	case "types":
+		nodes = typeNodes(files)
+	default:
+		return fmt.Errorf("-kind %s is not imports or types", graphKind)
+	}
+	out := dot(graphKind, reachable(nodes, graphPackages, graphDepth))
+	if graphOut != "" {
+		return os.WriteFile(graphOut, out, 0o666)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+func importNodes(files []*descriptor.File) []*graphNode {
+	var nodes []*graphNode
+	for _, f := range files {
+		n := &graphNode{name: f.Name, pkg: f.Package}
+		public, weak := f.PublicImports(), f.WeakImports()
+		for _, dep := range f.Dependency {
+			e := graphEdge{to: dep}
+			switch {
+			case slices.Contains(public, dep):
+				e.style = "bold"
+			case slices.Contains(weak, dep):
+				e.style = "dashed"
+			default:
+			}
+			n.edges = append(n.edges, e)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+func typeNodes(files []*descriptor.File) []*graphNode {
+	var nodes []*graphNode
+	var add func(pkg string, msgs []*descriptor.Message)
+	add = func(pkg string, msgs []*descriptor.Message) {
+		for _, m := range msgs {
+			if m.IsMapEntry() {
+				continue
+			}
+			n := &graphNode{name: m.FullName(), pkg: pkg}
+			var order []string
+			fields := map[string][]string{}
+			for _, f := range m.Field {
+				t := f.MessageType
+				if f.IsMap() {
+					t = f.Map.Value.MessageType
+				}
+				if t == nil {
+					continue
+				}
+				if fields[t.FullName()] == nil {
+					order = append(order, t.FullName())
+				}
+				fields[t.FullName()] = append(fields[t.FullName()], f.Name)
+			}
+			for _, to := range order {
+				n.edges = append(n.edges, graphEdge{to: to, label: strings.Join(fields[to], ", ")})
+			}
+			nodes = append(nodes, n)
+			add(pkg, m.Nested)
+		}
+	}
+	for _, f := range files {
+		add(f.Package, f.Message)
+	}
+	return nodes
+}
+
+// reachable returns the nodes of the packages, or all, and the nodes up to depth edges away, depth < 0 is unlimited.
+// The edges to other nodes are dropped.
+func reachable(nodes []*graphNode, packages []string, depth int) []*graphNode {
+	byName := map[string]*graphNode{}
+	for _, n := range nodes {
+		byName[n.name] = n
+	}
+	kept := map[string]bool{}
+	var next []*graphNode
+	for _, n := range nodes {
+		if len(packages) == 0 || slices.ContainsFunc(packages, func(p string) bool { return n.pkg == p || strings.HasPrefix(n.pkg, p+".") }) {
+			kept[n.name] = true
+			next = append(next, n)
+		}
+	}
+	for ; depth != 0 && len(next) > 0; depth-- {
+		var reached []*graphNode
+		for _, n := range next {
+			for _, e := range n.edges {
+				if to := byName[e.to]; to != nil && !kept[e.to] {
+					kept[e.to] = true
+					reached = append(reached, to)
+				}
+			}
+		}
+		next = reached
+	}
+	var out []*graphNode
+	for _, n := range nodes {
+		if !kept[n.name] {
+			continue
+		}
+		k := &graphNode{name: n.name, pkg: n.pkg}
+		for _, e := range n.edges {
+			if kept[e.to] {
+				k.edges = append(k.edges, e)
+			}
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+// dot returns the graph in the DOT language, the nodes of a package in a cluster.
+func dot(name string, nodes []*graphNode) []byte {
+	b := fmt.Appendf(nil, "digraph %s {\n  rankdir=LR;\n  node [shape=box, fontname=\"Helvetica\"];\n", name)
+	var packages []string
+	members := map[string][]string{}
+	for _, n := range nodes {
+		if members[n.pkg] == nil {
+			packages = append(packages, n.pkg)
+		}
+		members[n.pkg] = append(members[n.pkg], n.name)
+	}
+	slices.Sort(packages)
+	for i, pkg := range packages {
+		indent := "  "
+		if pkg != "" {
+			b = fmt.Appendf(b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(pkg))
+			indent = "    "
+		}
+		for _, name := range members[pkg] {
+			b = fmt.Appendf(b, "%s%s;\n", indent, dotQuote(name))
+		}
+		if pkg != "" {
+			b = append(b, "  }\n"...)
+		}
+	}
+	for _, n := range nodes {
+		for _, e := range n.edges {
+			var attrs []string
+			if e.label != "" {
+				attrs = append(attrs, "label="+dotQuote(e.label))
+			}
+			if e.style != "" {
+				attrs = append(attrs, "style="+e.style)
+			}
+			b = fmt.Appendf(b, "  %s -> %s", dotQuote(n.name), dotQuote(e.to))
+			if len(attrs) > 0 {
+				b = fmt.Appendf(b, " [%s]", strings.Join(attrs, ", "))
+			}
+			b = append(b, ";\n"...)
+		}
+	}
+	return append(b, "}\n"...)
+}
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
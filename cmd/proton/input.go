@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/grpc"
+	"github.com/defsrc/proton/protosrc"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// importPaths are the -I directories searched for .proto files and their imports.
+var importPaths []string
+
+// parseStrict and parseRecover are the -strict and -recover flags, see descriptor.ParseOptions.
+var parseStrict, parseRecover bool
+
+// registerImports adds the -I, -module, -strict and -recover flags of the commands reading descriptor sets.
+func registerImports(fs *flag.FlagSet) {
+	fs.Func("module", "also read the image of the Buf Schema Registry `module` like buf.build/acme/payments:main, may be repeated", func(s string) error {
+		if _, ok := parseModule(s); !ok {
+			return fmt.Errorf("%q is not remote/owner/repository[:reference]", s)
+		}
+		moduleRefs = append(moduleRefs, s)
+		return nil
+	})
+	fs.BoolVar(&parseStrict, "strict", false, "reject descriptor sets with out of range or reserved tags, non-minimal varints, invalid UTF-8 or padding")
+	fs.BoolVar(&parseRecover, "recover", false, "report malformed parts of descriptor sets and use what can be read")
+	fs.Func("I", "`dir` to search for .proto inputs and their imports, may be repeated", func(s string) error {
+		importPaths = append(importPaths, s)
+		return nil
+	})
+}
+
+// The server flags, see registerServer.
+var (
+	serverAddr     string
+	serverInsecure bool
+	serverPlain    bool
+	serverHeader   = http.Header{}
+)
+
+// registerServer adds the flags of the commands downloading descriptors from a gRPC server.
+func registerServer(fs *flag.FlagSet) {
+	fs.StringVar(&serverAddr, "server", "", "`host:port` of a gRPC server to download the descriptors from with the reflection service")
+	registerCall(fs)
+}
+
+// registerCall adds the flags of the connection to the server.
+func registerCall(fs *flag.FlagSet) {
+	fs.BoolVar(&serverInsecure, "insecure", false, "skip the verification of the server certificate")
+	fs.BoolVar(&serverPlain, "plaintext", false, "connect to the server without TLS, with unencrypted HTTP/2")
+	fs.Func("H", "`name: value` metadata header sent to the server, may be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, ":")
+		if !ok {
+			return fmt.Errorf("%q is not name: value", s)
+		}
+		serverHeader.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+		return nil
+	})
+}
+
+func newClient() *grpc.Client {
+	c := grpc.NewPlaintextClient(serverAddr)
+	if !serverPlain {
+		c = grpc.NewClient(serverAddr, &tls.Config{InsecureSkipVerify: serverInsecure})
+	}
+	c.Header = serverHeader
+	return c
+}
+
+// loadInputs is loadSets for the commands with server flags: with -server,
+// the files of the services of the server are added to the descriptor sets of args, which may be none.
+func loadInputs(args []string) ([]*descriptor.File, error) {
+	if serverAddr == "" {
+		return loadSets(args)
+	}
+	var files []*descriptor.File
+	if len(args) > 0 {
+		x, err := loadSets(args)
+		if err != nil {
+			return nil, err
+		}
+		files = x
+	}
+	c := newClient()
+	ctx := context.Background()
+	services, err := c.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", serverAddr, err)
+	}
+	x, err := c.Files(ctx, services...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", serverAddr, err)
+	}
+	for _, f := range x {
+		if !slices.ContainsFunc(files, func(g *descriptor.File) bool { return g.Name == f.Name }) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// stdinRead is set once stdin has been consumed, it can only be read once.
+var stdinRead bool
+
+// expand returns the file names of args, expanding glob patterns.
+// "-" stands for stdin, no arguments default to it.
+func expand(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+	var names []string
+	for _, arg := range args {
+		if arg == "-" || !strings.ContainsAny(arg, "*?[") {
+			names = append(names, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		names = append(names, matches...)
+	}
+	return names, nil
+}
+
+// readInput reads the file name, "-" reads stdin.
+func readInput(name string) ([]byte, error) {
+	if name != "-" {
+		watch(name)
+		return os.ReadFile(name)
+	}
+	if stdinRead {
+		return nil, errors.New("stdin can only be read once")
+	}
+	stdinRead = true
+	return io.ReadAll(os.Stdin)
+}
+
+// readMessages reads an input holding a message, or with delimited any number of them each preceded by its size.
+func readMessages(name string, delimited bool) ([][]byte, error) {
+	in, err := readData(name)
+	if err != nil || !delimited {
+		return [][]byte{in}, err
+	}
+	var msgs [][]byte
+	for msg, err := range wire.NewStreamReader(bytes.NewReader(in)).All() {
+		if err != nil {
+			return nil, fmt.Errorf("%s: message %d: %w", name, len(msgs), err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// loadSets reads and merges the descriptor sets named by args, files are kept once by name.
+// .proto files are compiled together with the files they import,
+// module references like buf.build/acme/payments:main are downloaded from the registry.
+// The first call also reads the -module flags, without args it then does not default to stdin.
+func loadSets(args []string) ([]*descriptor.File, error) {
+	var names []string
+	if len(args) > 0 || modulesRead || moduleRefs == nil {
+		var err error
+		if names, err = expand(args); err != nil {
+			return nil, err
+		}
+	}
+	if !modulesRead {
+		names = append(names, moduleRefs...)
+		modulesRead = true
+	}
+	var err error
+	var files, sources []*descriptor.File
+	var protos []string
+	for _, name := range names {
+		if filepath.Ext(name) == ".proto" {
+			protos = append(protos, sourceName(name))
+			continue
+		}
+		var b []byte
+		if m, ok := parseModule(name); ok {
+			b, err = m.load()
+		} else if b, err = readInput(name); err == nil {
+			if b, err = unwrap(b, inputWraps); err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		x, err := parseSet(name, b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files = append(files, x...)
+	}
+	if protos != nil {
+		if sources, err = compile(protos, false); err != nil {
+			return nil, err
+		}
+	}
+	var merged []*descriptor.File
+	seen := map[string]bool{}
+	for _, f := range append(files, sources...) {
+		if !seen[f.Name] {
+			seen[f.Name] = true
+			merged = append(merged, f)
+		}
+	}
+	return merged, nil
+}
+
+// parseSet parses the descriptor set read from name, in parallel unless -strict or -recover is set.
+// With -recover the errors are logged and the salvaged files returned.
+func parseSet(name string, b []byte) ([]*descriptor.File, error) {
+	files, err := descriptor.ParseOptions{Strict: parseStrict, Recover: parseRecover}.ParseParallel(b, 0)
+	var errs descriptor.ParseErrors
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			log.Printf("%s: %s", name, errorText(err))
+		}
+		return files, nil
+	}
+	return files, err
+}
+
+// compile compiles .proto files, the results include the imports parsed from source but not the well-known descriptors.
+func compile(names []string, sourceInfo bool) ([]*descriptor.File, error) {
+	files, err := protosrc.UnmarshalOptions{ImportPaths: importPaths, SourceInfo: sourceInfo}.Compile(names...)
+	if err != nil {
+		return nil, err
+	}
+	var parsed []*descriptor.File
+	for _, f := range files {
+		if wellknown.File(f.Name) != f {
+			watchSource(f.Name)
+			parsed = append(parsed, f)
+		}
+	}
+	return parsed, nil
+}
+
+// sourceName returns the name of a .proto file relative to the import path containing it,
+// the name as given if there is none.
+func sourceName(name string) string {
+	for _, dir := range importPaths {
+		if rel, err := filepath.Rel(dir, name); err == nil && filepath.IsLocal(rel) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(name)
+}
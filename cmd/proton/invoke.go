@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/protojson"
+	"github.com/defsrc/proton/prototext"
+)
+
+var (
+	invokeOut     output
+	invokeData    string
+	invokeFormat  string
+	invokeSets    []string
+	invokeTimeout time.Duration
+)
+
+var invokeCmd = &command{
+	name:    "invoke",
+	args:    "host:port pkg.Service/Method",
+	summary: "call a method of a gRPC server, resolving it from descriptor sets or the reflection service",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		registerCall(fs)
+		fs.Func("descriptors", "descriptor set or .proto `file` or glob defining the method, may be repeated, the server reflection is used without any", func(s string) error {
+			invokeSets = append(invokeSets, s)
+			return nil
+		})
+		fs.StringVar(&invokeData, "d", "{}", "the request `data`, @file reads a file and @ stdin, JSON may hold several objects for client streaming")
+		fs.StringVar(&invokeFormat, "i", "json", "request `format`: json or text")
+		fs.DurationVar(&invokeTimeout, "timeout", 0, "cancel the call after the `duration`, 0 waits forever")
+		invokeOut.register(fs, "json", "json, protojson, text, yaml, cbor or msgpack")
+	},
+	run: runInvoke,
+}
+
+func runInvoke(fs *flag.FlagSet) error {
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	if err := invokeOut.check("json", "protojson", "text", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	serverAddr = fs.Arg(0)
+	service, name, ok := splitMethod(fs.Arg(1))
+	if !ok {
+		return fmt.Errorf("method %s is not pkg.Service/Method", fs.Arg(1))
+	}
+	ctx := context.Background()
+	if invokeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, invokeTimeout)
+		defer cancel()
+	}
+	c := newClient()
+	var files []*descriptor.File
+	var err error
+	if len(invokeSets) > 0 {
+		files, err = loadSets(invokeSets)
+	} else if files, err = c.Files(ctx, service); err != nil {
+		err = fmt.Errorf("%s: %w", serverAddr, err)
+	}
+	if err != nil {
+		return err
+	}
+	s := newSchema(files)
+	svc := s.syms.Service(service)
+	if svc == nil {
+		return fmt.Errorf("service %s not found", service)
+	}
+	var m *descriptor.Method
+	for _, x := range svc.Method {
+		if x.Name == name {
+			m = x
+		}
+	}
+	if m == nil {
+		return fmt.Errorf("service %s has no method %s", service, name)
+	}
+	if m.Input == nil || m.Output == nil {
+		return fmt.Errorf("the types of %s.%s are not resolved", service, name)
+	}
+	reqs, err := requests(s, m)
+	if err != nil {
+		return err
+	}
+	if len(reqs) != 1 && !m.ClientStreaming {
+		return fmt.Errorf("%d requests for a method without client streaming", len(reqs))
+	}
+	resps, callErr := c.Stream(ctx, service+"/"+name, reqs)
+	for i, resp := range resps {
+		var out []byte
+		if invokeOut.format == "text" {
+			out, err = prototext.MarshalOptions{Resolver: s.syms, Extensions: s.exts, WellKnown: invokeOut.wellKnown}.Format(m.Output, resp)
+		} else {
+			o := protojson.MarshalOptions{Indent: "  ", UseProtoNames: invokeOut.protoNames, Resolver: s.syms, Extensions: s.exts}
+			out, err = o.Format(m.Output, resp)
+		}
+		if err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+		if i > 0 && (invokeOut.format == "yaml" || invokeOut.format == "text") {
+			os.Stdout.WriteString(separators[invokeOut.format])
+		}
+		if err := invokeOut.write(out); err != nil {
+			return err
+		}
+	}
+	return callErr
+}
+
+// splitMethod splits pkg.Service/Method, or pkg.Service.Method, into the service and the method name.
+func splitMethod(s string) (service, method string, ok bool) {
+	s = strings.TrimPrefix(s, "/")
+	i := strings.LastIndexAny(s, "/.")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", false
+	}
+	return strings.TrimPrefix(s[:i], "."), s[i+1:], true
+}
+
+// requests encodes the -d data as requests of m.
+func requests(s *schema, m *descriptor.Method) ([][]byte, error) {
+	in := []byte(invokeData)
+	if name, ok := strings.CutPrefix(invokeData, "@"); ok {
+		var err error
+		if in, err = readInput(cmp.Or(name, "-")); err != nil {
+			return nil, err
+		}
+	}
+	if invokeFormat == "text" {
+		req, err := prototext.UnmarshalOptions{Resolver: s.syms, Extensions: s.exts}.Encode(m.Input, in)
+		if err != nil {
+			return nil, fmt.Errorf("request: %w", err)
+		}
+		return [][]byte{req}, nil
+	}
+	if invokeFormat != "json" {
+		return nil, fmt.Errorf("unknown input format %s", invokeFormat)
+	}
+	var reqs [][]byte
+	d := json.NewDecoder(bytes.NewReader(in))
+	for {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("request %d: %w", len(reqs), err)
+		}
+		req, err := protojson.UnmarshalOptions{Resolver: s.syms, Extensions: s.exts}.Encode(m.Input, raw)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", len(reqs), err)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
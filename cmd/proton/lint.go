@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	lintOut    output
+	lintConfig string
+	lintList   bool
+)
+
+var lintCmd = &command{
+	name:    "lint",
+	args:    "[descriptor_set ...]",
+	summary: "check descriptor sets for naming, documentation and size conventions",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		lintOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		fs.StringVar(&lintConfig, "config", "", "JSON `file` with the disable, ignore and max_fields settings")
+		fs.BoolVar(&lintList, "list", false, "list the rules")
+	},
+	run:   runLint,
+	watch: true,
+}
+
+func runLint(fs *flag.FlagSet) error {
+	if lintList {
+		for _, r := range descriptor.LintRules {
+			fmt.Printf("%-30s %s\n", r.Name, r.Doc)
+		}
+		return nil
+	}
+	if err := lintOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	var config descriptor.LintConfig
+	if lintConfig != "" {
+		watch(lintConfig)
+		b, err := os.ReadFile(lintConfig)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("%s: %w", lintConfig, err)
+		}
+	}
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	problems := descriptor.Lint(x, config)
+	if lintOut.format == "text" {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+	} else {
+		if problems == nil {
+			problems = []descriptor.Problem{}
+		}
+		out, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := lintOut.write(out); err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d lint problems", len(problems))
+	}
+	return nil
+}
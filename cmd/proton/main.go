@@ -0,0 +1,268 @@
+// Command proton inspects protocol buffers descriptor sets and converts messages between encodings.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/plugin"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// A command is a subcommand of proton.
+type command struct {
+	name    string
+	args    string // synopsis of the arguments after the flags
+	summary string
+	flags   func(fs *flag.FlagSet) // registers the command specific flags, may be nil
+	run     func(fs *flag.FlagSet) error
+	watch   bool // supports -watch
+}
+
+// watchMode is the -watch flag of the commands supporting it.
+var watchMode bool
+
+var commands = []*command{describeCmd, decodeCmd, rawCmd, encodeCmd, explainCmd, diffCmd, breakingCmd, lintCmd, decompileCmd, compileCmd, statsCmd, invokeCmd, generateCmd, pluginCmd, avroCmd, reflectServeCmd, graphCmd, unusedCmd, numbersCmd, profileCmd, diffDataCmd}
+
+// errUsage makes main print the usage of the command and exit with status 2.
+var errUsage = errors.New("usage")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("proton: ")
+	if isPlugin() {
+		if err := plugin.Run(os.Stdin, os.Stdout, generate); err != nil {
+			log.Fatal(errorText(err))
+		}
+		return
+	}
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(os.Args) > 2 {
+			if cmd := lookup(os.Args[2]); cmd != nil {
+				newFlagSet(cmd).Usage()
+				return
+			}
+		}
+		usage()
+		return
+	}
+	cmd := lookup(name)
+	if cmd == nil {
+		log.Printf("unknown command %s", name)
+		usage()
+		os.Exit(2)
+	}
+	fs := newFlagSet(cmd)
+	fs.Parse(os.Args[2:])
+	run := cmd.run
+	if watchMode {
+		run = func(fs *flag.FlagSet) error { return runWatch(cmd, fs) }
+	}
+	if err := run(fs); err != nil {
+		if errors.Is(err, errUsage) {
+			fs.Usage()
+			os.Exit(2)
+		}
+		log.Fatal(errorText(err))
+	}
+}
+
+// wireTypes name the wire types in errors.
+var wireTypes = [...]string{"varint", "fixed64", "length prefixed", "group start", "group end", "fixed32", "invalid 6", "invalid 7"}
+
+// errorText renders err, with the details of malformed descriptors on their own lines.
+func errorText(err error) string {
+	var perr *descriptor.ParseError
+	if !errors.As(err, &perr) {
+		return err.Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%smalformed descriptor: %v", strings.TrimSuffix(err.Error(), perr.Error()), perr.Err)
+	path := perr.Path
+	if path == "" {
+		path = "the set"
+	}
+	fmt.Fprintf(&b, "\n  element: %s\n  offset:  %d", path, perr.Offset)
+	if perr.Tag != 0 {
+		fmt.Fprintf(&b, "\n  field:   %d, %s", perr.Tag, wireTypes[perr.Kind&7])
+	}
+	fmt.Fprintf(&b, "\n  while:   %s", perr.Op)
+	return b.String()
+}
+
+func lookup(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func usage() {
+	w := os.Stderr
+	fmt.Fprintf(w, "usage: proton command [flags] [arguments]\n\ncommands:\n")
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-*s %s\n", width, cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nrun proton help command for the flags of a command\n")
+}
+
+// newFlagSet returns the flags of cmd, exiting on errors.
+func newFlagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	if cmd.watch {
+		fs.BoolVar(&watchMode, "watch", false, "run again whenever the input files change")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: proton %s [flags] %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// schema holds a descriptor set linked with the well-known types it depends on.
+type schema struct {
+	files []*descriptor.File
+	syms  *descriptor.Symbols
+	exts  *dynamic.ExtensionRegistry
+}
+
+// output holds the flags shared by the commands printing messages.
+type output struct {
+	format     string
+	protoNames bool
+	wellKnown  bool
+	path       string
+}
+
+func (o *output) register(fs *flag.FlagSet, def, formats string) {
+	fs.StringVar(&o.format, "o", def, "output `format`: "+formats)
+	fs.BoolVar(&o.protoNames, "proto_names", false, "use the .proto field names in protojson and yaml output")
+	fs.BoolVar(&o.wellKnown, "wellknown", false, "print Timestamp, Duration and FieldMask messages as strings and wrappers as their values in text output, like in JSON")
+	fs.StringVar(&o.path, "path", "", "print only the values at the path `expression`, like file[0].message[*].field[*].name")
+	fs.StringVar(&o.path, "filter", "", "alias of -path")
+}
+
+// check returns an error if the format is not one of formats.
+func (o *output) check(formats ...string) error {
+	if o.path != "" && o.format == "text" {
+		return fmt.Errorf("-path needs a JSON based output format")
+	}
+	for _, f := range formats {
+		if o.format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %s", o.format)
+}
+
+// typed holds the flags selecting the message type of binary data.
+type typed struct {
+	descriptors []string
+	typeName    string
+}
+
+func (t *typed) register(fs *flag.FlagSet, def string) {
+	fs.Func("descriptors", "descriptor set or .proto `file` or glob defining the type, may be repeated, - reads stdin", func(s string) error {
+		t.descriptors = append(t.descriptors, s)
+		return nil
+	})
+	registerImports(fs)
+	fs.StringVar(&t.typeName, "type", def, "fully qualified message `type` of the data")
+}
+
+// load reads the descriptor sets, without any only the well-known types are known.
+func (t *typed) load() (*schema, error) {
+	if len(t.descriptors) == 0 {
+		return newSchema(nil), nil
+	}
+	x, err := loadSets(t.descriptors)
+	if err != nil {
+		return nil, err
+	}
+	return newSchema(x), nil
+}
+
+// message loads the descriptor sets and looks up the type, downloading it with -server if unknown.
+func (t *typed) message() (*schema, *descriptor.Message, error) {
+	s, err := t.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	name := strings.TrimPrefix(t.typeName, ".")
+	desc, err := s.message(name)
+	if err != nil && serverAddr != "" {
+		s = s.fetch(name)
+		desc, err = s.message(name)
+	}
+	return s, desc, err
+}
+
+// newSchema links files, link errors are logged as the resolvable types remain usable.
+func newSchema(files []*descriptor.File) *schema {
+	files = wellknown.Complete(files)
+	syms, err := descriptor.Link(files)
+	if err != nil {
+		log.Print(err)
+	}
+	return &schema{files: files, syms: syms, exts: dynamic.NewExtensionRegistry(files)}
+}
+
+// message returns the message type called name, falling back to the well-known types.
+func (s *schema) message(name string) (*descriptor.Message, error) {
+	desc := s.syms.Message(name)
+	if desc == nil {
+		desc = wellknown.Message(name)
+	}
+	if desc == nil {
+		return nil, fmt.Errorf("message type %s not found", name)
+	}
+	return desc, nil
+}
+
+// write prints out in the output format, selecting the path from JSON
+// and converting it to YAML, CBOR or MessagePack.
+func (o *output) write(out []byte) error {
+	var err error
+	if o.path != "" {
+		if out, err = filter(out, o.path); err != nil {
+			return err
+		}
+	}
+	switch o.format {
+	case "yaml":
+		out, err = toYAML(out)
+	case "cbor":
+		out, err = toCBOR(out)
+	case "msgpack":
+		out, err = toMsgpack(out)
+	default:
+	}
+	if err != nil {
+		return err
+	}
+	if o.format == "json" || o.format == "protojson" {
+		out = append(out, '\n')
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
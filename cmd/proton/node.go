@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The YAML, CBOR and MessagePack outputs are converted from JSON.
+
+// node is a parsed JSON value: an ordered object, an array or a scalar.
+type node struct {
+	keys   []string
+	values []*node // of the keys or the array elements
+	array  bool
+	object bool
+	scalar any // json.Number, string, bool or nil
+}
+
+func parseNode(d *json.Decoder) (*node, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		n := &node{object: true}
+		for d.More() {
+			k, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := parseNode(d)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, k.(string))
+			n.values = append(n.values, v)
+		}
+		_, err = d.Token()
+		return n, err
+	case json.Delim('['):
+		n := &node{array: true}
+		for d.More() {
+			v, err := parseNode(d)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, v)
+		}
+		_, err = d.Token()
+		return n, err
+	default:
+	}
+	return &node{scalar: t}, nil
+}
+
+// parseJSON parses a single JSON value.
+func parseJSON(js []byte) (*node, error) {
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.UseNumber()
+	return parseNode(d)
+}
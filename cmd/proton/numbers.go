@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	numbersOut  output
+	numbersList bool
+)
+
+var numbersCmd = &command{
+	name:    "numbers",
+	args:    "[descriptor_set ...]",
+	summary: "report gaps, high numbers, 1 byte tag usage and reuse risks of the field numbers of descriptor sets",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		numbersOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		fs.BoolVar(&numbersList, "list", false, "list the checks")
+	},
+	run:   runNumbers,
+	watch: true,
+}
+
+func runNumbers(fs *flag.FlagSet) error {
+	if numbersList {
+		for _, r := range descriptor.NumberRules {
+			fmt.Printf("%-30s %s\n", r.Name, r.Doc)
+		}
+		return nil
+	}
+	if err := numbersOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	problems := descriptor.NumberReport(x)
+	if numbersOut.format == "text" {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		return nil
+	}
+	if problems == nil {
+		problems = []descriptor.Problem{}
+	}
+	out, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		return err
+	}
+	return numbersOut.write(out)
+}
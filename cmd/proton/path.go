@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A step of a path expression selects object members by key or array elements by index,
+// "*" selects all of them.
+type step struct {
+	key   string // "" for an index step
+	index int    // -1 selects all elements
+}
+
+// parsePath parses expressions like file[0].message[*].field[*].name,
+// a leading index applies to a top level array.
+func parsePath(expr string) ([]step, error) {
+	var steps []step
+	for i, seg := range strings.Split(expr, ".") {
+		key, rest, _ := strings.Cut(seg, "[")
+		if key == "" && (i > 0 || rest == "") {
+			return nil, fmt.Errorf("path %s: empty key", expr)
+		}
+		if key != "" {
+			steps = append(steps, step{key: key})
+		}
+		if rest == "" {
+			continue
+		}
+		for _, idx := range strings.Split("["+rest, "[")[1:] {
+			idx, ok := strings.CutSuffix(idx, "]")
+			if !ok {
+				return nil, fmt.Errorf("path %s: missing ]", expr)
+			}
+			if idx == "*" {
+				steps = append(steps, step{index: -1})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("path %s: bad index %s", expr, idx)
+			}
+			steps = append(steps, step{index: n})
+		}
+	}
+	return steps, nil
+}
+
+// wild reports whether the path may select more than one value.
+func wild(steps []step) bool {
+	for _, s := range steps {
+		if s.key == "*" || s.key == "" && s.index < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPath returns the values at the path in document order.
+// Keys match exactly or else by normalized name, see member.
+func (n *node) selectPath(steps []step) []*node {
+	if len(steps) == 0 {
+		return []*node{n}
+	}
+	s, rest := steps[0], steps[1:]
+	var next []*node
+	switch {
+	case s.key == "*" && n.object, s.key == "" && s.index < 0 && n.array:
+		next = n.values
+	case s.key == "" && n.array:
+		if s.index < len(n.values) {
+			next = n.values[s.index : s.index+1]
+		}
+	case s.key != "" && n.object:
+		if v := n.member(s.key); v != nil {
+			next = []*node{v}
+		}
+	default:
+	}
+	var out []*node
+	for _, v := range next {
+		out = append(out, v.selectPath(rest)...)
+	}
+	return out
+}
+
+// member returns the value of key, matched exactly or else ignoring case and underscores,
+// so snake_case, lowerCamel and Go names select the same member. The descriptor.proto names
+// also match the names of the Go model, like message_type for Message, and the other way round.
+func (n *node) member(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	want := normalizeKey(key)
+	alias := modelNames[want]
+	for i, k := range n.keys {
+		if k := normalizeKey(k); k == want || k == alias {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// modelNames maps the normalized descriptor.proto names to the descriptor model ones, and back.
+var modelNames = map[string]string{}
+
+func init() {
+	for proto, model := range map[string]string{
+		"messagetype": "message",
+		"nestedtype":  "nested",
+		"enumtype":    "enum",
+		"number":      "tag",
+		"oneofdecl":   "oneof",
+	} {
+		modelNames[proto] = model
+		modelNames[model] = proto
+	}
+}
+
+// normalizeKey lowercases key and drops its underscores.
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+// filter returns the JSON value at the path expression of js,
+// an array of the matches if expr has wildcards.
+func filter(js []byte, expr string) ([]byte, error) {
+	steps, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	n, err := parseJSON(js)
+	if err != nil {
+		return nil, err
+	}
+	// the files of a FileDescriptorSet are printed as a top level array
+	if n.array && len(steps) > 0 && normalizeKey(steps[0].key) == "file" {
+		steps = steps[1:]
+	}
+	matches := n.selectPath(steps)
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("path %s matches nothing", expr)
+	case wild(steps):
+		n = &node{array: true, values: matches}
+	default:
+		n = matches[0]
+	}
+	var b bytes.Buffer
+	err = n.writeJSON(&b, 0)
+	return b.Bytes(), err
+}
+
+// writeJSON writes n indented by two spaces per depth.
+func (n *node) writeJSON(b *bytes.Buffer, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case n.object || n.array:
+		open, close := "[", "]"
+		if n.object {
+			open, close = "{", "}"
+		}
+		if len(n.values) == 0 {
+			b.WriteString(open + close)
+			return nil
+		}
+		b.WriteString(open + "\n")
+		for i, v := range n.values {
+			b.WriteString(indent + "  ")
+			if n.object {
+				if err := writeScalar(b, n.keys[i]); err != nil {
+					return err
+				}
+				b.WriteString(": ")
+			}
+			if err := v.writeJSON(b, depth+1); err != nil {
+				return err
+			}
+			if i < len(n.values)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + close)
+		return nil
+	default:
+	}
+	return writeScalar(b, n.scalar)
+}
+
+func writeScalar(b *bytes.Buffer, v any) error {
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return err
+	}
+	b.Truncate(b.Len() - 1) // the newline added by Encode
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/plugin"
+)
+
+var pluginCmd = &command{
+	name:    "plugin",
+	args:    "< CodeGeneratorRequest",
+	summary: "run as the protoc plugin protoc-gen-proton, --proton_out=GENERATOR[,key=value,...]:DIR selects the output",
+	flags: func(fs *flag.FlagSet) {
+		fs.BoolFunc("list", "list the generators", func(string) error {
+			for _, g := range generators {
+				fmt.Printf("%-12s %s\n", g.name, g.summary)
+			}
+			os.Exit(0)
+			return nil
+		})
+	},
+	run: runPlugin,
+}
+
+// isPlugin reports whether the binary is called like a protoc plugin, protoc-gen-NAME.
+func isPlugin() bool {
+	name := strings.TrimSuffix(path.Base(strings.ReplaceAll(os.Args[0], `\`, "/")), ".exe")
+	return strings.HasPrefix(name, "protoc-gen-")
+}
+
+func runPlugin(fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	return plugin.Run(os.Stdin, os.Stdout, generate)
+}
+
+// generate runs the generator named by the first element of the parameter, "describe" if there is none.
+func generate(req *plugin.Request, resp *plugin.Response) error {
+	resp.SupportedFeatures = plugin.FeatureProto3Optional | plugin.FeatureSupportsEditions
+	resp.MinimumEdition, resp.MaximumEdition = descriptor.EditionProto2, descriptor.Edition2023
+	name, params, _ := strings.Cut(req.Parameter, ",")
+	if strings.Contains(name, "=") {
+		name, params = "", req.Parameter
+	}
+	if name == "" {
+		name = "describe"
+	}
+	gen, err := lookupGenerator(name)
+	if err != nil {
+		return err
+	}
+	g := &generation{files: req.Generate(), schema: newSchema(req.ProtoFile), opts: map[string]string{}}
+	for _, p := range strings.Split(params, ",") {
+		g.option(p)
+	}
+	if err := gen.run(g); err != nil {
+		return err
+	}
+	resp.File = g.out
+	return nil
+}
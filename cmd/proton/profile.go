@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/defsrc/proton/dynamic"
+)
+
+var (
+	profileType      typed
+	profileOut       output
+	profileDelimited bool
+)
+
+var profileCmd = &command{
+	name:    "profile",
+	args:    "data ...",
+	summary: "attribute the bytes of binary messages to their fields, the bytes of nested fields count for their parents too",
+	flags: func(fs *flag.FlagSet) {
+		profileType.register(fs, "")
+		profileOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		registerWrap(fs)
+		fs.BoolVar(&profileDelimited, "delimited", false, "the data holds messages each preceded by its size as a varint")
+	},
+	run:   runProfile,
+	watch: true,
+}
+
+// profileRow is the JSON form of a dynamic.FieldSize.
+type profileRow struct {
+	*dynamic.FieldSize
+	Percent              float64 // of the bytes of all messages
+	AveragePerMessage    float64 // of all messages
+	AveragePerOccurrence float64
+}
+
+func runProfile(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 || profileType.typeName == "" {
+		return errUsage
+	}
+	if err := profileOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	s, desc, err := profileType.message()
+	if err != nil {
+		return err
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	p := dynamic.NewProfile(desc, s.exts)
+	for _, name := range names {
+		msgs, err := readMessages(name, profileDelimited)
+		if err != nil {
+			return err
+		}
+		for _, in := range msgs {
+			if err := p.Add(in); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	var rows []profileRow
+	for _, size := range p.Sizes() {
+		r := profileRow{FieldSize: size, AveragePerMessage: float64(size.Bytes) / float64(p.Messages), AveragePerOccurrence: float64(size.Bytes) / float64(size.Occurrences)}
+		if p.Bytes > 0 {
+			r.Percent = 100 * float64(size.Bytes) / float64(p.Bytes)
+		}
+		rows = append(rows, r)
+	}
+	if profileOut.format != "text" {
+		if rows == nil {
+			rows = []profileRow{}
+		}
+		out, err := json.MarshalIndent(map[string]any{"Messages": p.Messages, "Bytes": p.Bytes, "Fields": rows}, "", "  ")
+		if err != nil {
+			return err
+		}
+		return profileOut.write(out)
+	}
+	if p.Messages == 0 {
+		fmt.Println("0 messages")
+		return nil
+	}
+	fmt.Printf("%d messages, %d bytes, %.1f bytes per message\n\n", p.Messages, p.Bytes, float64(p.Bytes)/float64(p.Messages))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "BYTES\t%\tPER MESSAGE\tPER FIELD\tCOUNT\tMESSAGES\t  FIELD")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%d\t%.1f\t%.1f\t%.1f\t%d\t%d\t  %s\n", r.Bytes, r.Percent, r.AveragePerMessage, r.AveragePerOccurrence, r.Occurrences, r.Messages, r.Path)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/wire"
+)
+
+var rawOut output
+
+var rawCmd = &command{
+	name:    "decode-raw",
+	args:    "[data ...]",
+	summary: "decode binary messages without a schema, guessing the field types like protoc --decode_raw",
+	flags: func(fs *flag.FlagSet) {
+		rawOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		registerWrap(fs)
+	},
+	run:   runRaw,
+	watch: true,
+}
+
+func runRaw(fs *flag.FlagSet) error {
+	if err := rawOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		in, err := readData(name)
+		if err != nil {
+			return err
+		}
+		if i > 0 && (rawOut.format == "text" || rawOut.format == "yaml") {
+			os.Stdout.WriteString(separators[rawOut.format])
+		}
+		if rawOut.format != "text" {
+			// groups the values by field number, see wire.UnknownFieldSet.MarshalJSON
+			out, err := json.MarshalIndent(wire.UnknownFieldSet(in), "", "  ")
+			if err == nil {
+				err = rawOut.write(out)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		var p rawPrinter
+		err = p.message(in)
+		os.Stdout.Write(p.buf)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// rawPrinter prints fields in wire order in the text format,
+// annotating the other interpretations of fixed width values in comments.
+type rawPrinter struct {
+	buf   []byte
+	depth int
+}
+
+func (p *rawPrinter) line(format string, args ...any) {
+	p.buf = append(p.buf, strings.Repeat("  ", p.depth)...)
+	p.buf = fmt.Appendf(p.buf, format, args...)
+	p.buf = append(p.buf, '\n')
+}
+
+// message prints the fields of data, stopping at the first wire error.
+func (p *rawPrinter) message(data []byte) error {
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			p.line("# malformed: %v", err)
+			return err
+		}
+		switch r.Kind {
+		case wire.TagUvarint:
+			if int64(r.Value) < 0 {
+				p.line("%d: %d  # int64 %d", r.Tag, r.Value, int64(r.Value))
+			} else {
+				p.line("%d: %d", r.Tag, r.Value)
+			}
+		case wire.Tag32bit:
+			p.line("%d: 0x%08x  # float %g, int32 %d", r.Tag, r.Value, wire.DecodeFloat(r.Value), int32(r.Value))
+		case wire.Tag64bit:
+			p.line("%d: 0x%016x  # double %g, int64 %d", r.Tag, r.Value, wire.DecodeDouble(r.Value), int64(r.Value))
+		case wire.TagStart:
+			if err := p.nested(r.Tag, r.Bytes); err != nil {
+				return err
+			}
+		default:
+			switch {
+			case isMessage(r.Bytes):
+				if err := p.nested(r.Tag, r.Bytes); err != nil {
+					return err
+				}
+			case isText(r.Bytes):
+				p.line("%d: %s", r.Tag, strconv.Quote(string(r.Bytes)))
+			default:
+				p.line("%d: %q  # bytes % x", r.Tag, r.Bytes, r.Bytes)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *rawPrinter) nested(tag wire.TagNum, b []byte) error {
+	p.line("%d {", tag)
+	p.depth++
+	err := p.message(b)
+	p.depth--
+	p.line("}")
+	return err
+}
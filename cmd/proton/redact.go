@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+)
+
+// The redaction flags, see registerRedact.
+var (
+	redactMode        string
+	redactFields      []string
+	redactOption      string
+	redactConfig      string
+	redactPlaceholder string
+
+	redactor *dynamic.Redactor
+)
+
+// registerRedact adds the flags of the commands redacting binary messages.
+func registerRedact(fs *flag.FlagSet) {
+	fs.StringVar(&redactMode, "redact", "", "`mode` of the fields with debug_redact, -redact_option or named by -redact_field and -redact_config: strip removes them, replace blanks them out")
+	fs.Func("redact_field", "full `name` of a field to redact, like shop.Order.email, may be repeated", func(s string) error {
+		redactFields = append(redactFields, s)
+		return nil
+	})
+	fs.StringVar(&redactOption, "redact_option", "", "FieldOptions `extension` marking the fields to redact, like acme.sensitive")
+	fs.StringVar(&redactConfig, "redact_config", "", "`file` of the full names of fields to redact, one per line, # starts a comment")
+	fs.StringVar(&redactPlaceholder, "redact_placeholder", "REDACTED", "`text` replacing the strings and bytes with -redact replace")
+}
+
+// redact applies the redaction flags to data of type desc.
+func redact(s *schema, desc *descriptor.Message, data []byte) ([]byte, error) {
+	if redactMode == "" {
+		return data, nil
+	}
+	if redactor == nil {
+		r, err := newRedactor(s)
+		if err != nil {
+			return nil, err
+		}
+		redactor = r
+	}
+	return redactor.Redact(desc, data)
+}
+
+func newRedactor(s *schema) (*dynamic.Redactor, error) {
+	if redactMode != "strip" && redactMode != "replace" {
+		return nil, fmt.Errorf("unknown -redact mode %s, not strip or replace", redactMode)
+	}
+	names := redactFields
+	if redactConfig != "" {
+		b, err := os.ReadFile(redactConfig)
+		if err != nil {
+			return nil, err
+		}
+		for sc := bufio.NewScanner(bytes.NewReader(b)); sc.Scan(); {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			if line = strings.TrimSpace(line); line != "" {
+				names = append(names, line)
+			}
+		}
+	}
+	// a misspelled name would leave the field in the clear
+	for _, name := range names {
+		sym, _ := s.syms.Lookup(name)
+		if _, ok := sym.Value.(*descriptor.Field); !ok {
+			return nil, fmt.Errorf("%s is not a field to redact", name)
+		}
+	}
+	r, err := dynamic.NewRedactor(s.exts, redactOption, names...)
+	if err != nil {
+		return nil, err
+	}
+	r.Replace, r.Placeholder = redactMode == "replace", redactPlaceholder
+	return r, nil
+}
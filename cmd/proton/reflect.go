@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/defsrc/proton/grpc"
+)
+
+var (
+	reflectSets   []string
+	reflectListen string
+	reflectCert   string
+	reflectKey    string
+	reflectPlain  bool
+)
+
+var reflectServeCmd = &command{
+	name:    "reflect-serve",
+	summary: "serve the gRPC reflection service over descriptor sets, with TLS or without, for clients like grpcurl",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		fs.Func("descriptors", "descriptor set or .proto `file` or glob to serve, may be repeated", func(s string) error {
+			reflectSets = append(reflectSets, s)
+			return nil
+		})
+		fs.StringVar(&reflectListen, "listen", ":8080", "the `address` to listen on")
+		fs.StringVar(&reflectCert, "cert", "", "the certificate `file`, PEM encoded, a self-signed one is made without")
+		fs.StringVar(&reflectKey, "key", "", "the private key `file` of -cert")
+		fs.BoolVar(&reflectPlain, "plaintext", false, "serve without TLS, with unencrypted HTTP/2 like grpcurl -plaintext expects")
+	},
+	run: runReflectServe,
+}
+
+func runReflectServe(fs *flag.FlagSet) error {
+	if fs.NArg() != 0 || len(reflectSets) == 0 || (reflectCert == "") != (reflectKey == "") || reflectPlain && reflectCert != "" {
+		return errUsage
+	}
+	files, err := loadSets(reflectSets)
+	if err != nil {
+		return err
+	}
+	h, err := grpc.NewReflectionServer(newSchema(files).files)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", reflectListen)
+	if err != nil {
+		return err
+	}
+	if reflectPlain {
+		log.Printf("serving the reflection service without TLS on %s", ln.Addr())
+		return grpc.NewPlaintextServer(h).Serve(ln)
+	}
+	srv := &http.Server{Handler: h, TLSConfig: &tls.Config{}}
+	if reflectCert == "" {
+		cert, err := selfSigned()
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig.Certificates = []tls.Certificate{cert}
+		log.Print("serving with a self-signed certificate, clients must skip its verification like grpcurl -insecure")
+	}
+	log.Printf("serving the reflection service on %s", ln.Addr())
+	return srv.ServeTLS(ln, reflectCert, reflectKey)
+}
+
+// selfSigned returns a certificate of localhost valid for a year.
+func selfSigned() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      pkix.Name{CommonName: "proton reflect-serve"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
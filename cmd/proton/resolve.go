@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/grpc"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// fetchClient downloads the types missing from the schemas with -server, see (*schema).fetch.
+var fetchClient *grpc.Client
+
+// fetchMissed holds the names the server does not know, they are not asked for again.
+var fetchMissed = map[string]bool{}
+
+// fetch returns s with the files defining names downloaded from the -server added,
+// s itself without -server or when the server knows none of the names, which is logged.
+func (s *schema) fetch(names ...string) *schema {
+	if serverAddr == "" {
+		return s
+	}
+	if fetchClient == nil {
+		fetchClient = newClient()
+	}
+	files := s.files
+	for _, name := range names {
+		if fetchMissed[name] {
+			continue
+		}
+		x, err := fetchClient.Files(context.Background(), name)
+		if err != nil {
+			log.Printf("%s: %v", serverAddr, err)
+			fetchMissed[name] = true
+			continue
+		}
+		for _, f := range x {
+			if !slices.ContainsFunc(files, func(g *descriptor.File) bool { return g.Name == f.Name }) {
+				files = append(files, f)
+			}
+		}
+	}
+	if len(files) == len(s.files) {
+		return s
+	}
+	return newSchema(files)
+}
+
+// resolveAny returns s with the types of the Any messages in data downloaded from the -server,
+// including the types of Any messages packed in others. Data that does not decode is left to the caller.
+func (s *schema) resolveAny(desc *descriptor.Message, data []byte) *schema {
+	if serverAddr == "" {
+		return s
+	}
+	for {
+		var missing []string
+		s.unresolvedAny(desc, data, &missing)
+		missing = slices.DeleteFunc(missing, func(name string) bool { return fetchMissed[name] })
+		if len(missing) == 0 {
+			return s
+		}
+		next := s.fetch(missing...)
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// unresolvedAny adds the names of the types of the Any messages in data that s does not resolve to missing.
+func (s *schema) unresolvedAny(desc *descriptor.Message, data []byte, missing *[]string) {
+	m, err := dynamic.UnmarshalOptions{Extensions: s.exts}.Unmarshal(desc, data)
+	if err != nil {
+		return
+	}
+	for _, x := range m.AnyMessages() {
+		url, _ := x.Get("type_url").(string)
+		value, _ := x.Get("value").([]byte)
+		if url == "" {
+			continue
+		}
+		name := url[strings.LastIndexByte(url, '/')+1:]
+		inner := s.syms.Message(name)
+		if inner == nil {
+			inner = wellknown.Message(name)
+		}
+		switch {
+		case inner != nil:
+			s.unresolvedAny(inner, value, missing)
+		case !slices.Contains(*missing, name):
+			*missing = append(*missing, name)
+		default:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	statsOut     output
+	statsLargest int
+)
+
+var statsCmd = &command{
+	name:    "stats",
+	args:    "[descriptor_set ...]",
+	summary: "count the elements of descriptor sets, with field number usage, the largest messages and a breakdown by package",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		statsOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		fs.IntVar(&statsLargest, "largest", 10, "list the `n` messages with the most fields, all if negative")
+	},
+	run:   runStats,
+	watch: true,
+}
+
+func runStats(fs *flag.FlagSet) error {
+	if err := statsOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	s := descriptor.NewStats(x, statsLargest)
+	if statsOut.format != "text" {
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		return statsOut.write(out)
+	}
+	fmt.Printf("%-12s %d\n", "files", s.Files)
+	printCounts(s.Counts)
+	fmt.Printf("%-12s %d\n", "max depth", s.MaxDepth)
+	fmt.Println("\nfield numbers:")
+	for _, b := range s.Numbers {
+		fmt.Printf("  %9d-%-9d %d byte tags: %d\n", b.Min, b.Max, b.Bytes, b.Count)
+	}
+	if len(s.Largest) > 0 {
+		fmt.Println("\nlargest messages:")
+		for _, m := range s.Largest {
+			fmt.Printf("  %5d %s (%s)\n", m.Fields, m.Name, m.File)
+		}
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(s.Packages)) {
+		name := pkg
+		if name == "" {
+			name = "(no package)"
+		}
+		c := s.Packages[pkg]
+		fmt.Printf("\npackage %s: %d files\n", name, c.Files)
+		printCounts(c)
+	}
+	return nil
+}
+
+func printCounts(c descriptor.Counts) {
+	for _, n := range []struct {
+		name  string
+		count int
+	}{
+		{"messages", c.Messages},
+		{"fields", c.Fields},
+		{"extensions", c.Extensions},
+		{"enums", c.Enums},
+		{"enum values", c.EnumValues},
+		{"services", c.Services},
+		{"methods", c.Methods},
+	} {
+		fmt.Printf("%-12s %d\n", n.name, n.count)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+var (
+	unusedOut   output
+	unusedRoots []string
+)
+
+var unusedCmd = &command{
+	name:    "unused",
+	args:    "[descriptor_set ...]",
+	summary: "report the messages and enums of descriptor sets not reachable from root services or types",
+	flags: func(fs *flag.FlagSet) {
+		registerImports(fs)
+		unusedOut.register(fs, "text", "text, json, yaml, cbor or msgpack")
+		fs.Func("root", "fully qualified `name` of a service, method, message or enum in use, may be repeated, all services without", func(s string) error {
+			unusedRoots = append(unusedRoots, s)
+			return nil
+		})
+	},
+	run:   runUnused,
+	watch: true,
+}
+
+func runUnused(fs *flag.FlagSet) error {
+	if err := unusedOut.check("text", "json", "yaml", "cbor", "msgpack"); err != nil {
+		return err
+	}
+	x, err := loadSets(fs.Args())
+	if err != nil {
+		return err
+	}
+	roots := unusedRoots
+	if len(roots) == 0 {
+		for _, f := range x {
+			for _, svc := range f.Service {
+				if f.Package != "" {
+					roots = append(roots, f.Package+"."+svc.Name)
+				} else {
+					roots = append(roots, svc.Name)
+				}
+			}
+		}
+		if len(roots) == 0 {
+			return fmt.Errorf("no services to start from, use -root")
+		}
+	}
+	problems, err := descriptor.Unused(x, newSchema(x).syms, roots...)
+	if err != nil {
+		return err
+	}
+	if unusedOut.format == "text" {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+	} else {
+		if problems == nil {
+			problems = []descriptor.Problem{}
+		}
+		out, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := unusedOut.write(out); err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d unused types", len(problems))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// watchInterval is how often -watch checks the inputs for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watched holds the modification times of the files read by the command and of their directories,
+// so added and removed files are noticed too.
+var watched = map[string]time.Time{}
+
+// watch records name as an input of the command.
+func watch(name string) {
+	for _, name := range []string{name, filepath.Dir(name)} {
+		if fi, err := os.Stat(name); err == nil {
+			watched[name] = fi.ModTime()
+		}
+	}
+}
+
+// watchSource records the .proto file called name like protosrc finds it in the import paths.
+func watchSource(name string) {
+	if len(importPaths) == 0 {
+		watch(filepath.FromSlash(name))
+		return
+	}
+	for _, dir := range importPaths {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(path); err == nil {
+			watch(path)
+			return
+		}
+	}
+}
+
+// runWatch runs cmd again whenever one of its inputs changes, its errors are logged without stopping.
+func runWatch(cmd *command, fs *flag.FlagSet) error {
+	for {
+		clear(watched)
+		modulesRead = false
+		err := cmd.run(fs)
+		if errors.Is(err, errUsage) {
+			return err
+		}
+		if err != nil {
+			log.Print(err)
+		}
+		if len(watched) == 0 {
+			return errors.New("-watch needs input files, stdin cannot be watched")
+		}
+		changed := waitChange()
+		fmt.Fprintf(os.Stderr, "\n==> %s %s changed <==\n", time.Now().Format(time.TimeOnly), changed)
+	}
+}
+
+// waitChange polls the watched files and returns the first one modified, created or removed.
+func waitChange() string {
+	names := slices.Sorted(maps.Keys(watched))
+	for {
+		time.Sleep(watchInterval)
+		for _, name := range names {
+			fi, err := os.Stat(name)
+			if err != nil || !fi.ModTime().Equal(watched[name]) {
+				return name
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/internal/zstd"
+)
+
+// inputWraps are the wrappings removed from binary inputs in order, nil detects them.
+var inputWraps []string
+
+var wrapNames = []string{"raw", "base64", "hex", "gzip", "zstd"}
+
+func registerWrap(fs *flag.FlagSet) {
+	fs.Func("wrap", "comma separated `wrappings` of the data and descriptor sets removed in order, like base64,gzip: "+strings.Join(wrapNames, ", ")+", detected without", func(s string) error {
+		inputWraps = nil
+		for _, w := range strings.Split(s, ",") {
+			if !slices.Contains(wrapNames, w) {
+				return fmt.Errorf("unknown wrapping %s, not one of %s", w, strings.Join(wrapNames, ", "))
+			}
+			inputWraps = append(inputWraps, w)
+		}
+		return nil
+	})
+}
+
+// readData reads a binary input and removes its wrappings.
+func readData(name string) ([]byte, error) {
+	in, err := readInput(name)
+	if err != nil {
+		return nil, err
+	}
+	if in, err = unwrap(in, inputWraps); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return in, nil
+}
+
+// maxWraps limits the wrappings detected around each other.
+const maxWraps = 4
+
+// unwrap removes the wrappings of data, without any it detects them:
+// gzip and zstd by their magic numbers, hex and base64 text by their alphabets, hex first.
+// Text that does not decode in its alphabet is kept raw.
+func unwrap(data []byte, wraps []string) ([]byte, error) {
+	if wraps != nil {
+		for _, w := range wraps {
+			var err error
+			if data, err = unwrapAs(data, w); err != nil {
+				return nil, fmt.Errorf("%s: %w", w, err)
+			}
+		}
+		return data, nil
+	}
+	for range maxWraps {
+		w := detectWrap(data)
+		if w == "raw" {
+			break
+		}
+		out, err := unwrapAs(data, w)
+		if err != nil {
+			if w == "zstd" || w == "gzip" {
+				return nil, fmt.Errorf("%s: %w", w, err)
+			}
+			break // text that is not encoded binary after all
+		}
+		data = out
+	}
+	return data, nil
+}
+
+// detectWrap returns the wrapping data appears to have, raw if none.
+func detectWrap(data []byte) string {
+	text := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return "gzip"
+	case bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return "zstd"
+	case len(text) == 0:
+	case onlyBytes(text, "0123456789abcdefABCDEF \t\r\n") && len(strings.Join(strings.Fields(string(text)), ""))%2 == 0:
+		return "hex"
+	case len(text) >= 4 && onlyBytes(text, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/-_= \t\r\n"):
+		return "base64"
+	default:
+	}
+	return "raw"
+}
+
+func onlyBytes(b []byte, set string) bool {
+	for _, c := range b {
+		if strings.IndexByte(set, c) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func unwrapAs(data []byte, w string) ([]byte, error) {
+	switch w {
+	case "raw":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	case "base64":
+		s := strings.TrimRight(strings.Join(strings.Fields(string(data)), ""), "=")
+		if strings.ContainsAny(s, "-_") {
+			return base64.RawURLEncoding.DecodeString(s)
+		}
+		return base64.RawStdEncoding.DecodeString(s)
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	case "zstd":
+		return zstd.Decompress(data)
+	default:
+	}
+	return nil, fmt.Errorf("unknown wrapping %s", w)
+}
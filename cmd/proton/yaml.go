@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// toYAML converts JSON to YAML, keeping the key order of the JSON objects.
+func toYAML(js []byte) ([]byte, error) {
+	n, err := parseJSON(js)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	writeYAML(&b, n, 0)
+	return b.Bytes(), nil
+}
+
+// writeYAML writes n in block style, nested collections indented by two spaces.
+func writeYAML(b *bytes.Buffer, n *node, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch {
+	case n.object && len(n.keys) == 0:
+		b.WriteString("{}\n")
+	case n.array && len(n.values) == 0:
+		b.WriteString("[]\n")
+	case n.object:
+		for i, k := range n.keys {
+			if i > 0 {
+				b.WriteString(pad)
+			}
+			b.WriteString(yamlString(k))
+			b.WriteByte(':')
+			writeNested(b, n.values[i], indent)
+		}
+	case n.array:
+		for i, v := range n.values {
+			if i > 0 {
+				b.WriteString(pad)
+			}
+			b.WriteString("- ")
+			if v.object || v.array {
+				writeYAML(b, v, indent+1)
+				continue
+			}
+			b.WriteString(yamlScalar(v.scalar))
+			b.WriteByte('\n')
+		}
+	default:
+		b.WriteString(yamlScalar(n.scalar))
+		b.WriteByte('\n')
+	}
+}
+
+// writeNested writes a mapping value after its key.
+func writeNested(b *bytes.Buffer, v *node, indent int) {
+	if (v.object || v.array) && len(v.values) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat("  ", indent+1))
+		writeYAML(b, v, indent+1)
+		return
+	}
+	b.WriteByte(' ')
+	writeYAML(b, v, indent+1)
+}
+
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	default:
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlString quotes s when a plain scalar would be read as something else.
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#\"'\\\n\t{}[],&*!|>%@`") ||
+		strings.ContainsFunc(s, func(r rune) bool { return r < 0x20 || r == 0x7f }) ||
+		strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "?") || s == "-" || plainScalar(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// plainScalar reports whether s reads as a YAML null, bool or number.
+func plainScalar(s string) bool {
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", ".nan":
+		return true
+	default:
+	}
+	_, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+	return err == nil || strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o")
+}
@@ -0,0 +1,165 @@
+package descriptor
+
+import "unsafe"
+
+// An Arena allocates the elements and names of parsed files in blocks,
+// so parsing many descriptor sets makes a few large allocations instead of one per element.
+// Reset frees everything at once for the next parses.
+// The zero value is ready to use, an Arena must not be used concurrently.
+type Arena struct {
+	// Alias makes the names views of the parsed data instead of copies, saving their allocation.
+	// The data must then stay unmodified for as long as the files are used, even after Reset.
+	Alias bool
+
+	files      slab[File]
+	messages   slab[Message]
+	fields     slab[Field]
+	oneofs     slab[OneOf]
+	enums      slab[Enum]
+	enumValues slab[EnumValue]
+	services   slab[Service]
+	methods    slab[Method]
+
+	strs []byte // the current block of names, its used bytes are never reused as the strings alias them
+}
+
+// The numbers of elements and bytes of names in the blocks of an Arena.
+const (
+	arenaBlock   = 256
+	arenaStrings = 32 << 10
+)
+
+// Parse parses a FileDescriptorSet like the Parse function.
+// The files remain valid until the next call of Reset.
+func (a *Arena) Parse(msg []byte) ([]*File, error) {
+	return ParseOptions{Arena: a}.Parse(msg)
+}
+
+// Reset makes the memory of the parsed elements available to the next parses,
+// the files parsed before must not be used any more. Their names stay valid.
+func (a *Arena) Reset() {
+	a.files.each((*File).Reset)
+	a.messages.each((*Message).Reset)
+	a.fields.each((*Field).Reset)
+	a.oneofs.reset()
+	a.enums.reset()
+	a.enumValues.reset()
+	a.services.reset()
+	a.methods.reset()
+	a.strs = a.strs[len(a.strs):] // keeps the unused capacity only
+}
+
+// A slab hands out the elements of its blocks in order.
+type slab[T any] struct {
+	blocks [][]T
+	block  int // index of the current block
+	used   int // elements used of the current block
+}
+
+func (s *slab[T]) alloc() *T {
+	for s.block < len(s.blocks) && s.used == len(s.blocks[s.block]) {
+		s.block++
+		s.used = 0
+	}
+	if s.block == len(s.blocks) {
+		s.blocks = append(s.blocks, make([]T, arenaBlock))
+	}
+	x := &s.blocks[s.block][s.used]
+	s.used++
+	return x
+}
+
+// reset zeroes the used elements, so they do not keep the previous ones alive.
+func (s *slab[T]) reset() {
+	s.each(func(x *T) {
+		var zero T
+		*x = zero
+	})
+}
+
+// each calls reset for the used elements and starts over.
+func (s *slab[T]) each(reset func(*T)) {
+	for i := 0; i <= s.block && i < len(s.blocks); i++ {
+		n := len(s.blocks[i])
+		if i == s.block {
+			n = s.used
+		}
+		for j := range s.blocks[i][:n] {
+			reset(&s.blocks[i][j])
+		}
+	}
+	s.block, s.used = 0, 0
+}
+
+// string copies b to a string in the current block of names, or aliases it with a.Alias.
+// The block is only appended to, so the strings never change.
+func (a *Arena) string(b []byte) string {
+	if a != nil && a.Alias && len(b) > 0 {
+		return unsafe.String(&b[0], len(b))
+	}
+	if a == nil || len(b) == 0 || len(b) > arenaStrings/8 {
+		return string(b)
+	}
+	if cap(a.strs)-len(a.strs) < len(b) {
+		a.strs = make([]byte, 0, arenaStrings)
+	}
+	i := len(a.strs)
+	a.strs = append(a.strs, b...)
+	return unsafe.String(&a.strs[i], len(b))
+}
+
+func (a *Arena) newFile() *File {
+	if a == nil {
+		return &File{}
+	}
+	return a.files.alloc()
+}
+
+func (a *Arena) newMessage() *Message {
+	if a == nil {
+		return &Message{}
+	}
+	return a.messages.alloc()
+}
+
+func (a *Arena) newField() *Field {
+	if a == nil {
+		return &Field{}
+	}
+	return a.fields.alloc()
+}
+
+func (a *Arena) newOneOf() *OneOf {
+	if a == nil {
+		return &OneOf{}
+	}
+	return a.oneofs.alloc()
+}
+
+func (a *Arena) newEnum() *Enum {
+	if a == nil {
+		return &Enum{}
+	}
+	return a.enums.alloc()
+}
+
+func (a *Arena) newEnumValue() *EnumValue {
+	if a == nil {
+		return &EnumValue{}
+	}
+	return a.enumValues.alloc()
+}
+
+func (a *Arena) newService() *Service {
+	if a == nil {
+		return &Service{}
+	}
+	return a.services.alloc()
+}
+
+func (a *Arena) newMethod() *Method {
+	if a == nil {
+		return &Method{}
+	}
+	return a.methods.alloc()
+}
@@ -0,0 +1,228 @@
+package descriptor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity ranks the findings of CheckBreaking.
+type Severity uint8
+
+const (
+	SeverityOff     Severity = iota // disables a rule
+	SeverityInfo                    // compatible, but worth a look
+	SeverityWarning                 // breaks some users, like JSON or reflection based ones
+	SeverityError                   // breaks the wire compatibility or generated code
+)
+
+var severityNames = [...]string{"off", "info", "warning", "error"}
+
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", s)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(b []byte) error {
+	for i, n := range severityNames {
+		if n == string(b) {
+			*s = Severity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("descriptor: unknown severity %q", b)
+}
+
+// A BreakingRule classifies the changes found by Diff.
+type BreakingRule struct {
+	Name     string
+	Severity Severity // the default
+	Doc      string
+
+	// check returns a description if c violates the rule
+	check func(c Change, s *sets) (string, bool)
+}
+
+// A Finding is a change violating a BreakingRule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	Change   Change
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", f.Change.File, f.Severity, f.Rule, f.Message)
+}
+
+// sets holds the compared elements by fully qualified name.
+type sets struct {
+	old, new map[string]element
+}
+
+// message returns the message of els containing the field or enum value name.
+func (s *sets) message(els map[string]element, name string) *Message {
+	m, _ := els[scope(name)].x.(*Message)
+	return m
+}
+
+// enumName returns the name of the enum of els declaring v.
+func (s *sets) enumName(els map[string]element, v *EnumValue) string {
+	for name, el := range els {
+		if en, ok := el.x.(*Enum); ok && slices.Contains(en.Value, v) {
+			return name
+		}
+	}
+	return ""
+}
+
+// BreakingRules are the rules of CheckBreaking.
+var BreakingRules = []BreakingRule{
+	{"FILE_NO_DELETE", SeverityWarning, "files may not be deleted, their elements may have moved", func(c Change, s *sets) (string, bool) {
+		return "file " + c.Element + " was deleted", c.What == "file" && c.Kind == "removed"
+	}},
+	{"PACKAGE_NO_CHANGE", SeverityError, "files may not change their package", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("package changed from %q to %q", c.Old, c.New), c.What == "file" && c.Property == "package"
+	}},
+	{"SYNTAX_NO_CHANGE", SeverityWarning, "files may not change their syntax, it changes field presence and enum semantics", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("syntax changed from %s to %s", c.Old, c.New), c.What == "file" && c.Property == "syntax"
+	}},
+	{"TYPE_NO_DELETE", SeverityError, "messages, enums, services and extensions may not be deleted", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s was deleted", c.What, c.Element), c.Kind == "removed" && isType(c.What)
+	}},
+	{"TYPE_NO_RENAME", SeverityWarning, "messages and enums may not be renamed, their names appear in Any type URLs and reflection", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s was renamed to %s", c.What, c.Old, c.Element), c.Kind == "renamed" && isType(c.What)
+	}},
+	{"FIELD_NO_DELETE_UNLESS_RESERVED", SeverityError, "deleted fields must have their number and name reserved", func(c Change, s *sets) (string, bool) {
+		f, ok := c.OldElement.(*Field)
+		m := s.message(s.new, c.Element)
+		if c.What != "field" || c.Kind != "removed" || !ok || m == nil {
+			return "", false
+		}
+		var missing []string
+		if !m.IsReserved(f.Tag) {
+			missing = append(missing, fmt.Sprintf("number %d", f.Tag))
+		}
+		if !m.IsReservedName(f.Name) {
+			missing = append(missing, "name "+f.Name)
+		}
+		return fmt.Sprintf("field %s was deleted without reserving its %s", c.Element, strings.Join(missing, " and ")), missing != nil
+	}},
+	{"FIELD_NO_RESERVED_REUSE", SeverityError, "added fields may not use a reserved number or name", func(c Change, s *sets) (string, bool) {
+		f, ok := c.NewElement.(*Field)
+		m := s.message(s.old, c.Element)
+		if c.What != "field" || c.Kind != "added" || !ok || m == nil {
+			return "", false
+		}
+		if m.IsReserved(f.Tag) {
+			return fmt.Sprintf("field %s reuses the reserved number %d", c.Element, f.Tag), true
+		}
+		return fmt.Sprintf("field %s reuses a reserved name", c.Element), m.IsReservedName(f.Name)
+	}},
+	{"FIELD_NUMBER_NO_CHANGE", SeverityError, "fields and enum values may not change their number", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s changed its number from %s to %s", c.What, c.Element, c.Old, c.New), c.Property == "number"
+	}},
+	{"FIELD_TYPE_NO_CHANGE", SeverityError, "fields may not change their type, wire compatible changes are warnings", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s changed its type from %s to %s", c.What, c.Element, c.Old, c.New), c.Property == "type"
+	}},
+	{"FIELD_CARDINALITY_NO_CHANGE", SeverityError, "fields may not change between singular, repeated and required", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s changed its label from %s to %s", c.What, c.Element, quoteEmpty(c.Old), quoteEmpty(c.New)), c.Property == "label"
+	}},
+	{"FIELD_ONEOF_NO_CHANGE", SeverityWarning, "fields may not move into, out of or between oneofs", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("field %s moved from oneof %s to %s", c.Element, quoteEmpty(c.Old), quoteEmpty(c.New)), c.Property == "oneof"
+	}},
+	{"FIELD_NO_RENAME", SeverityWarning, "fields and enum values may not be renamed, their names appear in JSON and text", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s was renamed to %s", c.What, c.Old, c.Element), c.Kind == "renamed" && (c.What == "field" || c.What == "enum value")
+	}},
+	{"FIELD_JSON_NAME_NO_CHANGE", SeverityWarning, "fields may not change their JSON name", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("field %s changed its JSON name from %s to %s", c.Element, quoteEmpty(c.Old), quoteEmpty(c.New)), c.Property == "json_name"
+	}},
+	{"FIELD_DEFAULT_NO_CHANGE", SeverityWarning, "fields may not change their default value", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("field %s changed its default from %s to %s", c.Element, quoteEmpty(c.Old), quoteEmpty(c.New)), c.Property == "default"
+	}},
+	{"ENUM_VALUE_NO_DELETE_UNLESS_RESERVED", SeverityError, "deleted enum values must have their number and name reserved", func(c Change, s *sets) (string, bool) {
+		v, ok := c.OldElement.(*EnumValue)
+		if c.What != "enum value" || c.Kind != "removed" || !ok {
+			return "", false
+		}
+		en, _ := s.new[s.enumName(s.old, v)].x.(*Enum)
+		if en == nil {
+			return "", false // the enum was deleted
+		}
+		return fmt.Sprintf("enum value %s was deleted without reserving it", c.Element), !en.IsReserved(v.Number) || !en.IsReservedName(v.Name)
+	}},
+	{"METHOD_SIGNATURE_NO_CHANGE", SeverityError, "methods may not change their types or streaming", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("method %s changed its %s from %s to %s", c.Element, c.Property, c.Old, c.New), c.What == "method" && c.Kind == "changed"
+	}},
+	{"METHOD_NO_DELETE", SeverityError, "methods may not be deleted", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("method %s was deleted", c.Element), c.What == "method" && c.Kind == "removed"
+	}},
+	{"TYPE_NO_MOVE", SeverityInfo, "elements moved to another file change the imports needed", func(c Change, s *sets) (string, bool) {
+		return fmt.Sprintf("%s %s moved from %s to %s", c.What, c.Element, c.Old, c.New), c.Property == "file"
+	}},
+}
+
+func isType(what string) bool {
+	return what == "message" || what == "enum" || what == "service" || what == "extension"
+}
+
+// CheckBreaking diffs the descriptor sets and classifies the changes by the BreakingRules.
+// severities overrides the default severity of rules by name, SeverityOff disables them.
+// Type changes between wire compatible types are downgraded to warnings.
+func CheckBreaking(old, new []*File, severities map[string]Severity) []Finding {
+	s := &sets{old: collect(old), new: collect(new)}
+	var findings []Finding
+	for _, c := range Diff(old, new) {
+		for _, r := range BreakingRules {
+			sev, ok := severities[r.Name]
+			if !ok {
+				sev = r.Severity
+			}
+			if sev == SeverityOff {
+				continue
+			}
+			msg, ok := r.check(c, s)
+			if !ok {
+				continue
+			}
+			if c.Property == "type" && sev > SeverityWarning && wireCompatible(c) {
+				sev = SeverityWarning
+				msg += ", wire compatible"
+			}
+			findings = append(findings, Finding{Rule: r.Name, Severity: sev, Message: msg, Change: c})
+		}
+	}
+	return findings
+}
+
+// wireCompatible reports whether a type change keeps the encoding readable.
+func wireCompatible(c Change) bool {
+	o, ok1 := c.OldElement.(*Field)
+	n, ok2 := c.NewElement.(*Field)
+	if !ok1 || !ok2 {
+		return false
+	}
+	class := func(typ uint8) string {
+		switch typ {
+		case TypeInt32, TypeUint32, TypeInt64, TypeUint64, TypeBool, TypeEnum:
+			return "varint"
+		case TypeSint32, TypeSint64:
+			return "zigzag"
+		case TypeFixed32, TypeSfixed32:
+			return "fixed32"
+		case TypeFixed64, TypeSfixed64:
+			return "fixed64"
+		case TypeString, TypeBytes, TypeMessage:
+			return "bytes"
+		default:
+		}
+		return TypeKeyword(typ)
+	}
+	return class(o.Type) == class(n.Type)
+}
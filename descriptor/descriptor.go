@@ -0,0 +1,471 @@
+// Package descriptor reads google.protobuf.FileDescriptorSet messages
+// as produced by protoc --descriptor_set_out.
+package descriptor
+
+import (
+	"io"
+	"slices"
+
+	"github.com/defsrc/proton/wire"
+)
+
+type File struct {
+	Name             string          `json:",omitempty"` // 1
+	Package          string          `json:",omitempty"` // 2
+	Dependency       []string        `json:",omitempty"` // 3, imported file names
+	PublicDependency []int32         `json:",omitempty"` // 10, indexes into Dependency
+	WeakDependency   []int32         `json:",omitempty"` // 11, indexes into Dependency
+	Message          []*Message      `json:",omitempty"` // 4
+	Enum             []*Enum         `json:",omitempty"` // 5
+	Service          []*Service      `json:",omitempty"` // 6
+	Extension        []*Field        `json:",omitempty"` // 7
+	Options          *FileOptions    `json:",omitempty"` // 8
+	SourceCodeInfo   *SourceCodeInfo `json:"-"`          // 9
+	Syntax           Syntax          // 12
+	Edition          int32           `json:",omitempty"` // 14, only set for SyntaxEditions
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+
+	explicitSyntax bool // "proto2" was spelled out
+}
+
+type Message struct {
+	Name           string            `json:",omitempty"` // 1
+	Field          []*Field          `json:",omitempty"` // 2
+	Nested         []*Message        `json:",omitempty"` // 3
+	Enum           []*Enum           `json:",omitempty"` // 4
+	ExtensionRange []*ExtensionRange `json:",omitempty"` // 5
+	Extension      []*Field          `json:",omitempty"` // 6
+	Options        *MessageOptions   `json:",omitempty"` // 7
+	OneOf          []*OneOf          `json:",omitempty"` // 8
+	ReservedRange  []*ReservedRange  `json:",omitempty"` // 9
+	ReservedName   []string          `json:",omitempty"` // 10
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+
+	file   *File
+	parent *Message // nil for top level messages
+}
+
+type ExtensionRange struct {
+	Start   int32                  // 1, inclusive
+	End     int32                  // 2, exclusive
+	Options *ExtensionRangeOptions `json:",omitempty"` // 3
+
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+// Contains reports whether tag is in the range.
+func (r *ExtensionRange) Contains(tag wire.TagNum) bool {
+	return int64(tag) >= int64(r.Start) && int64(tag) < int64(r.End)
+}
+
+type OneOf struct {
+	Name      string        `json:",omitempty"` // 1
+	Options   *OneOfOptions `json:",omitempty"` // 2
+	Synthetic bool          `json:",omitempty"` // derived, generated for a proto3 optional field
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+// OneOfFields returns the fields of each oneof, indexed like m.OneOf.
+func (m *Message) OneOfFields() [][]*Field {
+	groups := make([][]*Field, len(m.OneOf))
+	for _, f := range m.Field {
+		if i := f.OneOfIndex; i != nil && *i >= 0 && int(*i) < len(groups) {
+			groups[*i] = append(groups[*i], f)
+		}
+	}
+	return groups
+}
+
+type Field struct {
+	Name           string        `json:",omitempty"` // 1
+	Extendee       string        `json:",omitempty"` // 2, only set for extensions
+	Tag            wire.TagNum   `json:",omitempty"` // 3
+	Label          uint8         `json:",omitempty"` // 4
+	Type           uint8         `json:",omitempty"` // 5
+	TypeName       string        `json:",omitempty"` // 6, of message and enum types, fully qualified if it starts with a dot
+	DefaultValue   string        `json:",omitempty"` // 7, in text format, bytes are C escaped
+	Options        *FieldOptions `json:",omitempty"` // 8
+	OneOfIndex     *int32        `json:",omitempty"` // 9, nil if not part of a oneof
+	JsonName       string        `json:",omitempty"` // 10
+	Proto3Optional bool          `json:",omitempty"` // 17
+	Map            *MapEntry     `json:",omitempty"` // derived, set for map fields
+
+	// set by Link
+	MessageType  *Message `json:"-"` // of TypeMessage and TypeGroup fields
+	EnumType     *Enum    `json:"-"` // of TypeEnum fields
+	ExtendeeType *Message `json:"-"` // of extensions
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+
+	file   *File    // containing file, nil for fields not parsed as part of one
+	parent *Message // containing message, nil for top level extensions
+}
+
+// Parse parses a FileDescriptorSet.
+func Parse(msg []byte) ([]*File, error) {
+	return ParseOptions{}.Parse(msg)
+}
+
+// ParseReader parses a FileDescriptorSet from r.
+// Only one FileDescriptorProto at a time is held in memory,
+// so it is suitable for descriptor sets too large to read at once.
+func ParseReader(r io.Reader) ([]*File, error) {
+	var files []*File
+	d := wire.NewDecoder(r)
+	for {
+		t, kind, err := d.ReadTag()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+		if t != 1 || kind != wire.TagSequence {
+			if err := d.Skip(kind); err != nil {
+				return files, err
+			}
+			continue
+		}
+		b, err := d.ReadBytes()
+		if err != nil {
+			return files, err
+		}
+		f, perr := (&parser{depth: 1}).parseFile(b)
+		if perr != nil {
+			return files, perr[0].at(int(d.Offset())-len(b), "file", len(files))
+		}
+		files = append(files, f)
+	}
+}
+
+func (p *parser) parseFile(msg []byte) (*File, ParseErrors) {
+	f := p.arena.newFile()
+	var errs ParseErrors
+	if err := p.check(msg, 1, 2, 3, 12); err != nil && p.failed(&errs, err) {
+		return f, errs
+	}
+	var n [8]int
+	countTags(msg, n[:])
+	f.Dependency = slices.Grow(f.Dependency, n[3])
+	f.Message = slices.Grow(f.Message, n[4])
+	f.Enum = slices.Grow(f.Enum, n[5])
+	f.Service = slices.Grow(f.Service, n[6])
+	f.Extension = slices.Grow(f.Extension, n[7])
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			f.Name = p.arena.string(b)
+		case 2:
+			f.Package = p.arena.string(b)
+		case 3:
+			f.Dependency = append(f.Dependency, p.arena.string(b))
+		case 10:
+			f.PublicDependency, err = appendInt32s(f.PublicDependency, r)
+			if err != nil && p.failed(&errs, wireError(OpUnpack, err).in(r, "public_dependency", -1)) {
+				return f, errs
+			}
+		case 11:
+			f.WeakDependency, err = appendInt32s(f.WeakDependency, r)
+			if err != nil && p.failed(&errs, wireError(OpUnpack, err).in(r, "weak_dependency", -1)) {
+				return f, errs
+			}
+		case 4:
+			m, err := p.parseMessage(b)
+			if err != nil && p.failed(&errs, err.in(r, "message_type", len(f.Message))...) {
+				return f, errs
+			}
+			f.Message = append(f.Message, m)
+		case 5:
+			en, err := p.parseEnum(b)
+			if err != nil && p.failed(&errs, err.in(r, "enum_type", len(f.Enum))...) {
+				return f, errs
+			}
+			f.Enum = append(f.Enum, en)
+		case 6:
+			s, err := p.parseService(b)
+			if err != nil && p.failed(&errs, err.in(r, "service", len(f.Service))...) {
+				return f, errs
+			}
+			f.Service = append(f.Service, s)
+		case 7:
+			x, err := p.parseField(b)
+			if err != nil && p.failed(&errs, err.in(r, "extension", len(f.Extension))...) {
+				return f, errs
+			}
+			f.Extension = append(f.Extension, x)
+		case 8:
+			o, err := parseFileOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return f, errs
+			}
+			f.Options = o
+		case 9:
+			info, err := parseSourceCodeInfo(b)
+			if err != nil && p.failed(&errs, err.in(r, "source_code_info", -1)) {
+				return f, errs
+			}
+			f.SourceCodeInfo = info
+		case 12:
+			s, ok := parseSyntax(p.arena.string(b))
+			if ok { // the last syntax wins
+				f.UnknownFields.Delete(12)
+			} else { // unknown syntax, keep it for re-encoding
+				f.UnknownFields.Add(r)
+			}
+			f.Syntax, f.explicitSyntax = s, ok && len(b) > 0
+		case 14:
+			f.Edition = wire.DecodeEnum(r.Value)
+		default:
+			f.UnknownFields.Add(r)
+		}
+	}
+	if f.SourceCodeInfo != nil {
+		f.attachComments()
+	}
+	pkg := ""
+	if f.Package != "" {
+		pkg = "." + f.Package
+	}
+	linkMaps(pkg, f.Message)
+	f.adopt()
+	return f, errs
+}
+
+// countTags counts the top level fields of msg by tag into n, ignoring larger tags,
+// so the repeated fields can be allocated at their final size.
+// The values are skipped without being checked, the parse reports malformed data.
+func countTags(msg []byte, n []int) {
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return
+		}
+		if int(r.Tag) < len(n) {
+			n[r.Tag]++
+		}
+	}
+}
+
+// appendInt32s appends a repeated int32 field, packed or not.
+func appendInt32s(vs []int32, r wire.FieldRef) ([]int32, error) {
+	if r.Kind != wire.TagSequence {
+		return append(vs, wire.DecodeInt32(r.Value)), nil
+	}
+	packed, err := wire.UnpackInt32s(r.Bytes)
+	return append(vs, packed...), err
+}
+
+// PublicImports returns the names of the publicly imported files.
+func (f *File) PublicImports() []string {
+	return f.imports(f.PublicDependency)
+}
+
+// WeakImports returns the names of the weakly imported files.
+func (f *File) WeakImports() []string {
+	return f.imports(f.WeakDependency)
+}
+
+func (f *File) imports(idx []int32) []string {
+	var names []string
+	for _, i := range idx {
+		if i >= 0 && int(i) < len(f.Dependency) {
+			names = append(names, f.Dependency[i])
+		}
+	}
+	return names
+}
+
+func (p *parser) parseMessage(msg []byte) (*Message, ParseErrors) {
+	m := p.arena.newMessage()
+	var errs ParseErrors
+	if err := p.enter(); err != nil {
+		return m, ParseErrors{err}
+	}
+	defer p.leave()
+	if err := p.check(msg, 1, 10); err != nil && p.failed(&errs, err) {
+		return m, errs
+	}
+	var n [11]int
+	countTags(msg, n[:])
+	m.Field = slices.Grow(m.Field, n[2])
+	m.Nested = slices.Grow(m.Nested, n[3])
+	m.Enum = slices.Grow(m.Enum, n[4])
+	m.ExtensionRange = slices.Grow(m.ExtensionRange, n[5])
+	m.Extension = slices.Grow(m.Extension, n[6])
+	m.OneOf = slices.Grow(m.OneOf, n[8])
+	m.ReservedRange = slices.Grow(m.ReservedRange, n[9])
+	m.ReservedName = slices.Grow(m.ReservedName, n[10])
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			m.Name = p.arena.string(b)
+		case 2:
+			f, err := p.parseField(b)
+			if err != nil && p.failed(&errs, err.in(r, "field", len(m.Field))...) {
+				return m, errs
+			}
+			m.Field = append(m.Field, f)
+		case 3:
+			nm, err := p.parseMessage(b)
+			if err != nil && p.failed(&errs, err.in(r, "nested_type", len(m.Nested))...) {
+				return m, errs
+			}
+			m.Nested = append(m.Nested, nm)
+		case 4:
+			en, err := p.parseEnum(b)
+			if err != nil && p.failed(&errs, err.in(r, "enum_type", len(m.Enum))...) {
+				return m, errs
+			}
+			m.Enum = append(m.Enum, en)
+		case 5:
+			er, err := parseExtensionRange(b)
+			if err != nil && p.failed(&errs, err.in(r, "extension_range", len(m.ExtensionRange))) {
+				return m, errs
+			}
+			m.ExtensionRange = append(m.ExtensionRange, er)
+		case 6:
+			x, err := p.parseField(b)
+			if err != nil && p.failed(&errs, err.in(r, "extension", len(m.Extension))...) {
+				return m, errs
+			}
+			m.Extension = append(m.Extension, x)
+		case 7:
+			o, err := parseMessageOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return m, errs
+			}
+			m.Options = o
+		case 8:
+			o, err := p.parseOneOf(b)
+			if err != nil && p.failed(&errs, err.in(r, "oneof_decl", len(m.OneOf))...) {
+				return m, errs
+			}
+			m.OneOf = append(m.OneOf, o)
+		case 9:
+			rr, err := parseReservedRange(b)
+			if err != nil && p.failed(&errs, err.in(r, "reserved_range", len(m.ReservedRange))) {
+				return m, errs
+			}
+			m.ReservedRange = append(m.ReservedRange, rr)
+		case 10:
+			m.ReservedName = append(m.ReservedName, p.arena.string(b))
+		default:
+			m.UnknownFields.Add(r)
+		}
+	}
+	return m, errs
+}
+
+func parseExtensionRange(msg []byte) (*ExtensionRange, *ParseError) {
+	er := &ExtensionRange{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return er, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			er.Start = wire.DecodeInt32(r.Value)
+		case 2:
+			er.End = wire.DecodeInt32(r.Value)
+		case 3:
+			o, err := parseExtensionRangeOptions(r.Bytes)
+			if err != nil {
+				return er, err.in(r, "options", -1)
+			}
+			er.Options = o
+		default:
+			er.UnknownFields.Add(r)
+		}
+	}
+	return er, nil
+}
+
+func (p *parser) parseOneOf(msg []byte) (*OneOf, ParseErrors) {
+	o := p.arena.newOneOf()
+	var errs ParseErrors
+	if err := p.check(msg, 1); err != nil && p.failed(&errs, err) {
+		return o, errs
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		switch r.Tag {
+		case 1:
+			o.Name = p.arena.string(r.Bytes)
+		case 2:
+			opts, err := parseOneOfOptions(r.Bytes)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return o, errs
+			}
+			o.Options = opts
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, errs
+}
+
+func (p *parser) parseField(msg []byte) (*Field, ParseErrors) {
+	f := p.arena.newField()
+	var errs ParseErrors
+	if err := p.check(msg, 1, 2, 6, 7, 10); err != nil && p.failed(&errs, err) {
+		return f, errs
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		d, b := r.Value, r.Bytes
+		switch r.Tag {
+		case 1:
+			f.Name = p.arena.string(b)
+		case 2:
+			f.Extendee = p.arena.string(b)
+		case 3:
+			f.Tag = uint32(d)
+		case 4:
+			f.Label = uint8(d)
+		case 5:
+			f.Type = uint8(d)
+		case 6:
+			f.TypeName = p.arena.string(b)
+		case 7:
+			f.DefaultValue = p.arena.string(b)
+		case 8:
+			o, err := parseFieldOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return f, errs
+			}
+			f.Options = o
+		case 9:
+			i := int32(d)
+			f.OneOfIndex = &i
+		case 10:
+			f.JsonName = p.arena.string(b)
+		case 17:
+			f.Proto3Optional = wire.DecodeBool(d)
+		default:
+			f.UnknownFields.Add(r)
+		}
+	}
+	return f, errs
+}
@@ -0,0 +1,404 @@
+package descriptor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Change is a difference between two descriptor sets found by Diff.
+type Change struct {
+	Kind     string // "added", "removed", "renamed" or "changed"
+	What     string // "file", "message", "field", "enum", "enum value", "service", "method" or "extension"
+	Element  string // fully qualified name without leading dot, the new name of renamed elements, the file name of files
+	File     string // containing the element, in the new set unless it was removed
+	Property string `json:",omitempty"` // of changed elements, like "type" or "number"
+	Old      string `json:",omitempty"` // the old value of the property, the old name of renamed elements
+	New      string `json:",omitempty"` // the new value of the property
+
+	// the compared elements, nil for added or removed ones
+	OldElement any `json:"-"`
+	NewElement any `json:"-"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case "renamed":
+		return fmt.Sprintf("%s: renamed %s %s to %s", c.File, c.What, c.Old, c.Element)
+	case "changed":
+		return fmt.Sprintf("%s: changed %s of %s %s from %s to %s", c.File, c.Property, c.What, c.Element, quoteEmpty(c.Old), quoteEmpty(c.New))
+	default:
+	}
+	return fmt.Sprintf("%s: %s %s %s", c.File, c.Kind, c.What, c.Element)
+}
+
+func quoteEmpty(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+// Diff compares the descriptor sets old and new structurally.
+// Elements are matched by fully qualified name, so moving one to another file is a change of its "file".
+// Fields and enum values with a new name but the same number are renamed,
+// as are files, messages and enums whose contents are unchanged.
+// The changes are sorted by element name.
+func Diff(old, new []*File) []Change {
+	var d differ
+	d.files(old, new)
+	d.elements(collect(old), collect(new))
+	slices.SortStableFunc(d.changes, func(a, b Change) int {
+		return cmp.Compare(a.Element, b.Element)
+	})
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) changed(what, name, file, prop, o, n string, ox, nx any) {
+	if o != n {
+		d.add(Change{Kind: "changed", What: what, Element: name, File: file, Property: prop, Old: o, New: n, OldElement: ox, NewElement: nx})
+	}
+}
+
+func (d *differ) files(old, new []*File) {
+	byName := map[string]*File{}
+	for _, f := range new {
+		byName[f.Name] = f
+	}
+	var removed []*File
+	for _, f := range old {
+		if byName[f.Name] == nil {
+			removed = append(removed, f)
+		}
+	}
+	oldNames := map[string]*File{}
+	for _, f := range old {
+		oldNames[f.Name] = f
+	}
+	for _, f := range new {
+		o := oldNames[f.Name]
+		if o == nil {
+			if i := slices.IndexFunc(removed, func(r *File) bool { return sameFile(r, f) }); i >= 0 {
+				o = removed[i]
+				removed = slices.Delete(removed, i, i+1)
+				d.add(Change{Kind: "renamed", What: "file", Element: f.Name, File: f.Name, Old: o.Name, OldElement: o, NewElement: f})
+			} else {
+				d.add(Change{Kind: "added", What: "file", Element: f.Name, File: f.Name, NewElement: f})
+				continue
+			}
+		}
+		d.changed("file", f.Name, f.Name, "package", o.Package, f.Package, o, f)
+		d.changed("file", f.Name, f.Name, "syntax", fileSyntax(o), fileSyntax(f), o, f)
+	}
+	for _, f := range removed {
+		d.add(Change{Kind: "removed", What: "file", Element: f.Name, File: f.Name, OldElement: f})
+	}
+}
+
+func fileSyntax(f *File) string {
+	if f.Syntax == SyntaxEditions {
+		return "edition " + strconv.Itoa(int(f.Edition))
+	}
+	return f.Syntax.String()
+}
+
+// sameFile reports whether two files declare the same package and top level names.
+func sameFile(a, b *File) bool {
+	if a.Package != b.Package {
+		return false
+	}
+	top := func(f *File) []string {
+		var names []string
+		walkFile(f, func(name string, _ any) {
+			names = append(names, name)
+		})
+		slices.Sort(names)
+		return names
+	}
+	return slices.Equal(top(a), top(b))
+}
+
+// element is a message, enum, service or extension with its file.
+type element struct {
+	file string
+	x    any
+}
+
+// collect returns the messages, enums, services and extensions by fully qualified name without leading dot.
+func collect(files []*File) map[string]element {
+	els := map[string]element{}
+	for _, f := range files {
+		walkFile(f, func(name string, x any) {
+			switch x := x.(type) {
+			case *Message, *Enum, *Service:
+			case *Field:
+				if x.Extendee == "" {
+					return
+				}
+			default:
+				return
+			}
+			els[name[1:]] = element{f.Name, x}
+		})
+	}
+	return els
+}
+
+func what(x any) string {
+	switch x := x.(type) {
+	case *Message:
+		return "message"
+	case *Enum:
+		return "enum"
+	case *Service:
+		return "service"
+	case *Field:
+		if x.Extendee != "" {
+			return "extension"
+		}
+	default:
+	}
+	return "field"
+}
+
+func (d *differ) elements(old, new map[string]element) {
+	var removed []string
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(removed)
+	var added []string
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	slices.Sort(added)
+	for _, name := range added {
+		n := new[name]
+		i := slices.IndexFunc(removed, func(r string) bool {
+			return scope(r) == scope(name) && sameShape(old[r].x, n.x)
+		})
+		if i < 0 {
+			d.add(Change{Kind: "added", What: what(n.x), Element: name, File: n.file, NewElement: n.x})
+			continue
+		}
+		o := old[removed[i]]
+		d.add(Change{Kind: "renamed", What: what(n.x), Element: name, File: n.file, Old: removed[i], OldElement: o.x, NewElement: n.x})
+		removed = slices.Delete(removed, i, i+1)
+		d.element(name, o, n)
+	}
+	for _, name := range removed {
+		o := old[name]
+		d.add(Change{Kind: "removed", What: what(o.x), Element: name, File: o.file, OldElement: o.x})
+	}
+	for name, n := range new {
+		if o, ok := old[name]; ok {
+			d.element(name, o, n)
+		}
+	}
+}
+
+// scope returns the name of the package or message containing name.
+func scope(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return ""
+	}
+	return name[:i]
+}
+
+// sameShape reports whether renamed messages or enums are unchanged otherwise:
+// the same field numbers, names and types or the same values.
+func sameShape(a, b any) bool {
+	switch a := a.(type) {
+	case *Message:
+		b, ok := b.(*Message)
+		return ok && len(a.Field) > 0 && slices.EqualFunc(a.Field, b.Field, func(x, y *Field) bool {
+			return x.Tag == y.Tag && x.Name == y.Name && fieldType(x) == fieldType(y)
+		})
+	case *Enum:
+		b, ok := b.(*Enum)
+		return ok && len(a.Value) > 0 && slices.EqualFunc(a.Value, b.Value, func(x, y *EnumValue) bool {
+			return x.Number == y.Number && x.Name == y.Name
+		})
+	default:
+	}
+	return false
+}
+
+// fieldType returns the type of f as written in .proto files, without leading dot.
+func fieldType(f *Field) string {
+	if f.TypeName != "" {
+		return strings.TrimPrefix(f.TypeName, ".")
+	}
+	return TypeKeyword(f.Type)
+}
+
+// fieldLabel returns the label of f, "optional" only if spelled out in proto3.
+func fieldLabel(f *Field) string {
+	if f.Label == LabelOptional && !f.Proto3Optional && f.file != nil && f.file.Syntax == SyntaxProto3 {
+		return ""
+	}
+	return LabelKeyword(f.Label)
+}
+
+func (d *differ) element(name string, o, n element) {
+	w := what(n.x)
+	if w != what(o.x) {
+		d.changed(w, name, n.file, "kind", what(o.x), w, o.x, n.x)
+		return
+	}
+	d.changed(w, name, n.file, "file", o.file, n.file, o.x, n.x)
+	switch nx := n.x.(type) {
+	case *Message:
+		d.fields(name, n.file, o.x.(*Message), nx)
+	case *Enum:
+		d.values(name, n.file, o.x.(*Enum), nx)
+	case *Service:
+		d.methods(name, n.file, o.x.(*Service), nx)
+	case *Field:
+		d.field(w, name, n.file, nil, o.x.(*Field), nil, nx)
+	default:
+	}
+}
+
+// fields matches fields by name, then unmatched ones by number as renamed.
+func (d *differ) fields(name, file string, o, n *Message) {
+	oldByName := map[string]*Field{}
+	for _, f := range o.Field {
+		oldByName[f.Name] = f
+	}
+	newByName := map[string]*Field{}
+	for _, f := range n.Field {
+		newByName[f.Name] = f
+	}
+	for _, nf := range n.Field {
+		full := name + "." + nf.Name
+		of := oldByName[nf.Name]
+		if of == nil {
+			i := slices.IndexFunc(o.Field, func(f *Field) bool {
+				return f.Tag == nf.Tag && newByName[f.Name] == nil
+			})
+			if i < 0 {
+				d.add(Change{Kind: "added", What: "field", Element: full, File: file, NewElement: nf})
+				continue
+			}
+			of = o.Field[i]
+			d.add(Change{Kind: "renamed", What: "field", Element: full, File: file, Old: name + "." + of.Name, OldElement: of, NewElement: nf})
+		}
+		d.field("field", full, file, o, of, n, nf)
+	}
+	for _, of := range o.Field {
+		if newByName[of.Name] != nil {
+			continue
+		}
+		if slices.ContainsFunc(n.Field, func(f *Field) bool { return f.Tag == of.Tag && oldByName[f.Name] == nil }) {
+			continue // renamed
+		}
+		d.add(Change{Kind: "removed", What: "field", Element: name + "." + of.Name, File: file, OldElement: of})
+	}
+}
+
+// field compares the properties of a field or extension, om and nm are its messages.
+func (d *differ) field(w, name, file string, om *Message, o *Field, nm *Message, n *Field) {
+	d.changed(w, name, file, "number", strconv.Itoa(int(o.Tag)), strconv.Itoa(int(n.Tag)), o, n)
+	d.changed(w, name, file, "label", fieldLabel(o), fieldLabel(n), o, n)
+	d.changed(w, name, file, "type", fieldType(o), fieldType(n), o, n)
+	d.changed(w, name, file, "default", o.DefaultValue, n.DefaultValue, o, n)
+	d.changed(w, name, file, "json_name", o.JsonName, n.JsonName, o, n)
+	d.changed(w, name, file, "extendee", strings.TrimPrefix(o.Extendee, "."), strings.TrimPrefix(n.Extendee, "."), o, n)
+	d.changed(w, name, file, "oneof", oneOfName(om, o), oneOfName(nm, n), o, n)
+}
+
+func oneOfName(m *Message, f *Field) string {
+	if m == nil {
+		return ""
+	}
+	if o := f.RealOneOf(m); o != nil {
+		return o.Name
+	}
+	return ""
+}
+
+func (d *differ) values(name, file string, o, n *Enum) {
+	scope := scope(name) // enum values are scoped like their enum
+	oldByName := map[string]*EnumValue{}
+	for _, v := range o.Value {
+		oldByName[v.Name] = v
+	}
+	newByName := map[string]*EnumValue{}
+	for _, v := range n.Value {
+		newByName[v.Name] = v
+	}
+	for _, nv := range n.Value {
+		full := joinName(scope, nv.Name)
+		ov := oldByName[nv.Name]
+		if ov == nil {
+			i := slices.IndexFunc(o.Value, func(v *EnumValue) bool {
+				return v.Number == nv.Number && newByName[v.Name] == nil
+			})
+			if i < 0 {
+				d.add(Change{Kind: "added", What: "enum value", Element: full, File: file, NewElement: nv})
+				continue
+			}
+			ov = o.Value[i]
+			d.add(Change{Kind: "renamed", What: "enum value", Element: full, File: file, Old: joinName(scope, ov.Name), OldElement: ov, NewElement: nv})
+		}
+		d.changed("enum value", full, file, "number", strconv.Itoa(int(ov.Number)), strconv.Itoa(int(nv.Number)), ov, nv)
+	}
+	for _, ov := range o.Value {
+		if newByName[ov.Name] != nil {
+			continue
+		}
+		if slices.ContainsFunc(n.Value, func(v *EnumValue) bool { return v.Number == ov.Number && oldByName[v.Name] == nil }) {
+			continue
+		}
+		d.add(Change{Kind: "removed", What: "enum value", Element: joinName(scope, ov.Name), File: file, OldElement: ov})
+	}
+}
+
+func joinName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (d *differ) methods(name, file string, o, n *Service) {
+	old := map[string]*Method{}
+	for _, m := range o.Method {
+		old[m.Name] = m
+	}
+	seen := map[string]bool{}
+	for _, nm := range n.Method {
+		full := name + "." + nm.Name
+		seen[nm.Name] = true
+		om := old[nm.Name]
+		if om == nil {
+			d.add(Change{Kind: "added", What: "method", Element: full, File: file, NewElement: nm})
+			continue
+		}
+		d.changed("method", full, file, "input type", strings.TrimPrefix(om.InputType, "."), strings.TrimPrefix(nm.InputType, "."), om, nm)
+		d.changed("method", full, file, "output type", strings.TrimPrefix(om.OutputType, "."), strings.TrimPrefix(nm.OutputType, "."), om, nm)
+		d.changed("method", full, file, "client streaming", strconv.FormatBool(om.ClientStreaming), strconv.FormatBool(nm.ClientStreaming), om, nm)
+		d.changed("method", full, file, "server streaming", strconv.FormatBool(om.ServerStreaming), strconv.FormatBool(nm.ServerStreaming), om, nm)
+	}
+	for _, om := range o.Method {
+		if !seen[om.Name] {
+			d.add(Change{Kind: "removed", What: "method", Element: name + "." + om.Name, File: file, OldElement: om})
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package descriptor
+
+import (
+	"slices"
+
+	"github.com/defsrc/proton/wire"
+)
+
+type Enum struct {
+	Name          string           `json:",omitempty"` // 1
+	Value         []*EnumValue     `json:",omitempty"` // 2
+	Options       *EnumOptions     `json:",omitempty"` // 3
+	ReservedRange []*ReservedRange `json:",omitempty"` // 4
+	ReservedName  []string         `json:",omitempty"` // 5
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+
+	file   *File
+	parent *Message // nil for top level enums
+}
+
+type EnumValue struct {
+	Name    string            `json:",omitempty"` // 1
+	Number  int32             // 2, zero is the default value
+	Options *EnumValueOptions `json:",omitempty"` // 3
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+func (p *parser) parseEnum(msg []byte) (*Enum, ParseErrors) {
+	en := p.arena.newEnum()
+	var errs ParseErrors
+	if err := p.check(msg, 1, 5); err != nil && p.failed(&errs, err) {
+		return en, errs
+	}
+	var n [3]int
+	countTags(msg, n[:])
+	en.Value = slices.Grow(en.Value, n[2])
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			en.Name = p.arena.string(b)
+		case 2:
+			v, err := p.parseEnumValue(b)
+			if err != nil && p.failed(&errs, err.in(r, "value", len(en.Value))...) {
+				return en, errs
+			}
+			en.Value = append(en.Value, v)
+		case 3:
+			o, err := parseEnumOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return en, errs
+			}
+			en.Options = o
+		case 4:
+			rr, err := parseReservedRange(b)
+			if err != nil && p.failed(&errs, err.in(r, "reserved_range", len(en.ReservedRange))) {
+				return en, errs
+			}
+			en.ReservedRange = append(en.ReservedRange, rr)
+		case 5:
+			en.ReservedName = append(en.ReservedName, p.arena.string(b))
+		default:
+			en.UnknownFields.Add(r)
+		}
+	}
+	return en, errs
+}
+
+func (p *parser) parseEnumValue(msg []byte) (*EnumValue, ParseErrors) {
+	v := p.arena.newEnumValue()
+	var errs ParseErrors
+	if err := p.check(msg, 1); err != nil && p.failed(&errs, err) {
+		return v, errs
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			v.Name = p.arena.string(b)
+		case 2:
+			v.Number = wire.DecodeInt32(r.Value)
+		case 3:
+			o, err := parseEnumValueOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return v, errs
+			}
+			v.Options = o
+		default:
+			v.UnknownFields.Add(r)
+		}
+	}
+	return v, errs
+}
+
+// MarshalBinary encodes en as an EnumDescriptorProto.
+func (en *Enum) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, en.Name)
+	for _, v := range en.Value {
+		if err := encodeMessage(&e, 2, v); err != nil {
+			return nil, err
+		}
+	}
+	if en.Options != nil {
+		if err := encodeMessage(&e, 3, en.Options); err != nil {
+			return nil, err
+		}
+	}
+	for _, rr := range en.ReservedRange {
+		if err := encodeMessage(&e, 4, rr); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range en.ReservedName {
+		e.EncodeString(5, n)
+	}
+	return finish(&e, en.UnknownFields)
+}
+
+// MarshalBinary encodes v as an EnumValueDescriptorProto.
+func (v *EnumValue) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, v.Name)
+	e.EncodeVarint(2, wire.EncodeInt32(v.Number))
+	if v.Options != nil {
+		if err := encodeMessage(&e, 3, v.Options); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, v.UnknownFields)
+}
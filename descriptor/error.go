@@ -0,0 +1,98 @@
+package descriptor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// Operations of a ParseError.
+const (
+	OpRead   = "read"   // reading the fields of a message
+	OpUnpack = "unpack" // decoding a packed repeated field
+	OpCheck  = "check"  // the checks of ParseOptions.Strict
+	OpNest   = "nest"   // entering a message nested deeper than ParseOptions.MaxDepth
+	OpLimit  = "limit"  // the checks of ParseOptions.Limits
+)
+
+// A ParseError reports malformed descriptor data.
+type ParseError struct {
+	Offset int           // of the failing value in the parsed data
+	Path   string        // of the failing element like file[2].message_type[0].field[3], "" for the parsed message
+	Tag    wire.TagNum   // of the failing field in the element, 0 if its key could not be read
+	Kind   wire.TagClass // of the failing field
+	Op     string        // the failing operation, one of the Op constants
+	Err    error         // the cause, like wire.ErrTruncated or ErrMaxDepth
+}
+
+func (err *ParseError) Error() string {
+	s := "descriptor: " + err.Op
+	if err.Path != "" {
+		s += " " + err.Path
+	}
+	s += fmt.Sprintf(": %v at offset %d", err.Err, err.Offset)
+	if err.Tag != 0 {
+		s += fmt.Sprintf(" (tag %d, class %d)", err.Tag, err.Kind)
+	}
+	return s
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// wireError converts the *wire.Error of op, keeping its offset.
+func wireError(op string, err error) *ParseError {
+	e := err.(*wire.Error)
+	return &ParseError{Offset: e.Offset, Tag: e.Tag, Kind: e.Kind, Op: op, Err: e.Err}
+}
+
+// in moves err from the value of field r to the message holding it,
+// name is the field name in descriptor.proto and index the position of a repeated element, -1 for others.
+func (err *ParseError) in(r wire.FieldRef, name string, index int) *ParseError {
+	return err.at(r.Offset+len(r.Raw)-len(r.Bytes), name, index)
+}
+
+// at is in for a value starting at offset.
+func (err *ParseError) at(offset int, name string, index int) *ParseError {
+	err.Offset += offset
+	if index >= 0 {
+		name += "[" + strconv.Itoa(index) + "]"
+	}
+	if err.Path != "" {
+		name += "." + err.Path
+	}
+	err.Path = name
+	return err
+}
+
+// ParseErrors are the errors of a parse with ParseOptions.Recover, in the order of the data.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "descriptor: no errors"
+	case 1:
+		return errs[0].Error()
+	default:
+		return fmt.Sprintf("%v (and %d more errors)", errs[0], len(errs)-1)
+	}
+}
+
+func (errs ParseErrors) Unwrap() []error {
+	s := make([]error, len(errs))
+	for i, err := range errs {
+		s[i] = err
+	}
+	return s
+}
+
+// in is ParseError.in for each of errs.
+func (errs ParseErrors) in(r wire.FieldRef, name string, index int) ParseErrors {
+	for _, err := range errs {
+		err.in(r, name, index)
+	}
+	return errs
+}
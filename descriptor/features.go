@@ -0,0 +1,225 @@
+package descriptor
+
+import "github.com/defsrc/proton/wire"
+
+// Editions replace the syntax statement with features,
+// see https://protobuf.dev/editions/features/
+
+// FileDescriptorProto.Edition
+const (
+	EditionProto2 int32 = 998
+	EditionProto3 int32 = 999
+	Edition2023   int32 = 1000
+	Edition2024   int32 = 1001
+)
+
+// FeatureSet values, 0 is unset everywhere.
+const (
+	PresenceExplicit       int32 = 1
+	PresenceImplicit       int32 = 2
+	PresenceLegacyRequired int32 = 3
+
+	EnumOpen   int32 = 1
+	EnumClosed int32 = 2
+
+	RepeatedPacked   int32 = 1
+	RepeatedExpanded int32 = 2
+
+	Utf8Verify int32 = 2
+	Utf8None   int32 = 3
+
+	MessageLengthPrefixed int32 = 1
+	MessageDelimited      int32 = 2
+
+	JsonAllow            int32 = 1
+	JsonLegacyBestEffort int32 = 2
+)
+
+type FeatureSet struct {
+	FieldPresence         int32 `json:",omitempty"` // 1
+	EnumType              int32 `json:",omitempty"` // 2
+	RepeatedFieldEncoding int32 `json:",omitempty"` // 3
+	Utf8Validation        int32 `json:",omitempty"` // 4
+	MessageEncoding       int32 `json:",omitempty"` // 5
+	JsonFormat            int32 `json:",omitempty"` // 6
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+// parseFeatureSet merges msg into fs, or a new set if fs is nil, as the records of a message field merge.
+func parseFeatureSet(fs *FeatureSet, msg []byte) (*FeatureSet, *ParseError) {
+	if fs == nil {
+		fs = &FeatureSet{}
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return fs, wireError(OpRead, err)
+		}
+		v := wire.DecodeEnum(r.Value)
+		switch r.Tag {
+		case 1:
+			fs.FieldPresence = v
+		case 2:
+			fs.EnumType = v
+		case 3:
+			fs.RepeatedFieldEncoding = v
+		case 4:
+			fs.Utf8Validation = v
+		case 5:
+			fs.MessageEncoding = v
+		case 6:
+			fs.JsonFormat = v
+		default:
+			fs.UnknownFields.Add(r)
+		}
+	}
+	return fs, nil
+}
+
+// MarshalBinary encodes fs as FeatureSet.
+func (fs *FeatureSet) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeVarint(&e, 1, wire.EncodeInt32(fs.FieldPresence))
+	encodeVarint(&e, 2, wire.EncodeInt32(fs.EnumType))
+	encodeVarint(&e, 3, wire.EncodeInt32(fs.RepeatedFieldEncoding))
+	encodeVarint(&e, 4, wire.EncodeInt32(fs.Utf8Validation))
+	encodeVarint(&e, 5, wire.EncodeInt32(fs.MessageEncoding))
+	encodeVarint(&e, 6, wire.EncodeInt32(fs.JsonFormat))
+	return finish(&e, fs.UnknownFields)
+}
+
+// merge overrides the set features of fs with those of o.
+func (fs FeatureSet) merge(o *FeatureSet) FeatureSet {
+	if o == nil {
+		return fs
+	}
+	set := func(dst *int32, v int32) {
+		if v != 0 {
+			*dst = v
+		}
+	}
+	set(&fs.FieldPresence, o.FieldPresence)
+	set(&fs.EnumType, o.EnumType)
+	set(&fs.RepeatedFieldEncoding, o.RepeatedFieldEncoding)
+	set(&fs.Utf8Validation, o.Utf8Validation)
+	set(&fs.MessageEncoding, o.MessageEncoding)
+	set(&fs.JsonFormat, o.JsonFormat)
+	return fs
+}
+
+// EditionDefaults returns the features of an edition before any overrides.
+func EditionDefaults(edition int32) FeatureSet {
+	switch {
+	case edition <= EditionProto2:
+		return FeatureSet{PresenceExplicit, EnumClosed, RepeatedExpanded, Utf8None, MessageLengthPrefixed, JsonLegacyBestEffort, nil}
+	case edition == EditionProto3:
+		return FeatureSet{PresenceImplicit, EnumOpen, RepeatedPacked, Utf8Verify, MessageLengthPrefixed, JsonAllow, nil}
+	default:
+	}
+	return FeatureSet{PresenceExplicit, EnumOpen, RepeatedPacked, Utf8Verify, MessageLengthPrefixed, JsonAllow, nil}
+}
+
+// EffectiveEdition returns the edition of the file, mapping the syntax of older files.
+func (f *File) EffectiveEdition() int32 {
+	switch f.Syntax {
+	case SyntaxEditions:
+		return f.Edition
+	case SyntaxProto3:
+		return EditionProto3
+	default:
+	}
+	return EditionProto2
+}
+
+// Features returns the resolved features of the file.
+func (f *File) Features() FeatureSet {
+	fs := EditionDefaults(f.EffectiveEdition())
+	if f.Options != nil {
+		fs = fs.merge(f.Options.Features)
+	}
+	return fs
+}
+
+// Features returns the resolved features of the message.
+func (m *Message) Features() FeatureSet {
+	var fs FeatureSet
+	switch {
+	case m.parent != nil:
+		fs = m.parent.Features()
+	case m.file != nil:
+		fs = m.file.Features()
+	default:
+		fs = EditionDefaults(EditionProto2)
+	}
+	if m.Options != nil {
+		fs = fs.merge(m.Options.Features)
+	}
+	return fs
+}
+
+// Features returns the resolved features of the enum.
+func (en *Enum) Features() FeatureSet {
+	var fs FeatureSet
+	switch {
+	case en.parent != nil:
+		fs = en.parent.Features()
+	case en.file != nil:
+		fs = en.file.Features()
+	default:
+		fs = EditionDefaults(EditionProto2)
+	}
+	if en.Options != nil {
+		fs = fs.merge(en.Options.Features)
+	}
+	return fs
+}
+
+// Features returns the resolved features of the field.
+// For files using syntax the equivalent features are derived from labels and options.
+func (f *Field) Features() FeatureSet {
+	var fs FeatureSet
+	switch {
+	case f.parent != nil:
+		fs = f.parent.Features()
+		if o, ok := at(f.parent.OneOf, f.oneOfIndex()); ok && o.Options != nil {
+			fs = fs.merge(o.Options.Features)
+		}
+	case f.file != nil:
+		fs = f.file.Features()
+	default:
+		fs = EditionDefaults(EditionProto2)
+	}
+	if syntaxOf(f.file) != SyntaxEditions {
+		switch {
+		case f.Label == LabelRequired:
+			fs.FieldPresence = PresenceLegacyRequired
+		case f.Proto3Optional:
+			fs.FieldPresence = PresenceExplicit
+		}
+		if f.Options != nil && f.Options.Packed != nil {
+			fs.RepeatedFieldEncoding = RepeatedExpanded
+			if *f.Options.Packed {
+				fs.RepeatedFieldEncoding = RepeatedPacked
+			}
+		}
+		if f.Type == TypeGroup {
+			fs.MessageEncoding = MessageDelimited
+		}
+	}
+	if f.Options != nil {
+		fs = fs.merge(f.Options.Features)
+	}
+	return fs
+}
+
+func (f *Field) oneOfIndex() int32 {
+	if f.OneOfIndex == nil {
+		return -1
+	}
+	return *f.OneOfIndex
+}
+
+// IsPacked reports whether a repeated field is encoded packed.
+func (f *Field) IsPacked() bool {
+	return f.Packable() && f.Features().RepeatedFieldEncoding == RepeatedPacked
+}
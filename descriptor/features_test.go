@@ -0,0 +1,32 @@
+package descriptor
+
+import "testing"
+
+func TestParseFeaturesMerge(t *testing.T) {
+	// file a with two features records in its options, {field_presence: IMPLICIT} and {repeated_field_encoding: EXPANDED}
+	set := []byte{0x0a, 0x0f, 0x0a, 0x01, 'a', 0x42, 0x0a, 0x92, 0x03, 0x02, 0x08, 0x02, 0x92, 0x03, 0x02, 0x18, 0x02}
+	files, err := Parse(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := files[0].Options.Features
+	if got == nil || got.FieldPresence != PresenceImplicit || got.RepeatedFieldEncoding != RepeatedExpanded {
+		t.Errorf("Features = %+v, want the features of both records", got)
+	}
+}
+
+func TestUnmarshalFeatures(t *testing.T) {
+	data, err := (&File{Name: "a.proto", Syntax: SyntaxEditions, Edition: Edition2023, Message: []*Message{{Name: "M"}}}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f File
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	// the messages inherit options set on the file after unmarshaling it
+	f.Options = &FileOptions{Features: &FeatureSet{RepeatedFieldEncoding: RepeatedExpanded}}
+	if got := f.Message[0].Features().RepeatedFieldEncoding; got != RepeatedExpanded {
+		t.Errorf("RepeatedFieldEncoding = %d, want %d", got, RepeatedExpanded)
+	}
+}
@@ -0,0 +1,58 @@
+package descriptor_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// seedSets returns encoded descriptor sets: the well-known files alone, one by one and
+// with descriptor.proto, whose options hold nested and repeated messages.
+func seedSets(tb testing.TB) [][]byte {
+	set := wellknown.Set()
+	sets := [][]byte{set, {}, set[:len(set)-1]}
+	for _, f := range wellknown.Files() {
+		b, err := descriptor.Marshal([]*descriptor.File{f})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		sets = append(sets, b)
+	}
+	return sets
+}
+
+func FuzzParse(f *testing.F) {
+	for _, set := range seedSets(f) {
+		f.Add(set)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// recovering parses return what they can read of any data
+		descriptor.ParseOptions{Recover: true}.Parse(data)
+		_, strictErr := descriptor.ParseOptions{Strict: true}.Parse(data)
+		files, err := descriptor.Parse(data)
+		if err != nil {
+			if strictErr == nil {
+				t.Fatalf("Parse fails with %v, the strict parse succeeds", err)
+			}
+			return
+		}
+		descriptor.Link(files)
+		enc, err := descriptor.Marshal(files)
+		if err != nil {
+			t.Fatalf("Marshal of parsed files: %v", err)
+		}
+		again, err := descriptor.Parse(enc)
+		if err != nil {
+			t.Fatalf("Parse of marshaled files: %v", err)
+		}
+		enc2, err := descriptor.Marshal(again)
+		if err != nil {
+			t.Fatalf("Marshal of reparsed files: %v", err)
+		}
+		if !bytes.Equal(enc, enc2) {
+			t.Fatalf("Marshal is not stable:\n% x\n% x", enc, enc2)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Graph is the import graph of a set of files.
+type Graph struct {
+	files map[string]*File
+	names []string // in input order
+}
+
+// NewGraph builds the import graph of files, later files with the same name are ignored.
+func NewGraph(files []*File) *Graph {
+	g := &Graph{files: map[string]*File{}}
+	for _, f := range files {
+		if _, dup := g.files[f.Name]; !dup {
+			g.files[f.Name] = f
+			g.names = append(g.names, f.Name)
+		}
+	}
+	return g
+}
+
+// File returns the file with the given name or nil.
+func (g *Graph) File(name string) *File {
+	return g.files[name]
+}
+
+// A MissingDependency is an import of a file not in the graph.
+type MissingDependency struct {
+	File       string // importing file
+	Dependency string // missing file
+}
+
+// Missing returns the imports of files that are not part of the graph.
+func (g *Graph) Missing() []MissingDependency {
+	var missing []MissingDependency
+	for _, name := range g.names {
+		for _, dep := range g.files[name].Dependency {
+			if g.files[dep] == nil {
+				missing = append(missing, MissingDependency{File: name, Dependency: dep})
+			}
+		}
+	}
+	return missing
+}
+
+// A CycleError reports an import cycle.
+type CycleError struct {
+	Cycle []string // file names, the first file is repeated at the end
+}
+
+func (err *CycleError) Error() string {
+	return "descriptor: import cycle " + strings.Join(err.Cycle, " -> ")
+}
+
+// Sort returns all files ordered so that every file follows its imports,
+// otherwise keeping the input order. Missing imports are skipped.
+func (g *Graph) Sort() ([]*File, error) {
+	return g.closure(g.names)
+}
+
+// Closure returns the named files and all files they import, transitively, sorted like Sort.
+func (g *Graph) Closure(names ...string) ([]*File, error) {
+	for _, name := range names {
+		if g.files[name] == nil {
+			return nil, fmt.Errorf("descriptor: file %q not found", name)
+		}
+	}
+	return g.closure(names)
+}
+
+// ForType returns the minimal sorted set of files needed to describe the named type:
+// the file defining it and its transitive imports.
+func (g *Graph) ForType(syms *Symbols, typeName string) ([]*File, error) {
+	sym, ok := syms.Lookup(typeName)
+	if !ok {
+		return nil, fmt.Errorf("descriptor: type %q not found", typeName)
+	}
+	return g.Closure(sym.File.Name)
+}
+
+func (g *Graph) closure(roots []string) ([]*File, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var sorted []*File
+	var stack []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		f := g.files[name]
+		if f == nil {
+			return nil
+		}
+		switch state[name] {
+		case visiting:
+			i := len(stack) - 1
+			for stack[i] != name {
+				i--
+			}
+			cycle := append(append([]string(nil), stack[i:]...), name)
+			return &CycleError{Cycle: cycle}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range f.Dependency {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		sorted = append(sorted, f)
+		return nil
+	}
+	for _, name := range roots {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,208 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// An Index parses the files of a FileDescriptorSet on demand.
+// NewIndex only reads the file names, packages, imports and the names of the types,
+// a file is parsed when it or one of its types is first requested.
+// It is safe for concurrent use.
+type Index struct {
+	mu     sync.Mutex
+	files  []*indexedFile
+	byName map[string]*indexedFile
+	types  map[string]*indexedFile // fully qualified message and enum names with leading dot
+}
+
+type indexedFile struct {
+	name, pkg string
+	deps      []string
+	data      []byte // the FileDescriptorProto, aliasing the set
+	offset    int    // of data in the set
+	index     int    // in the set
+	file      *File  // nil until parsed
+}
+
+// NewIndex indexes a FileDescriptorSet, the set must not be modified while the index is used.
+func NewIndex(set []byte) (*Index, error) {
+	x := &Index{byName: map[string]*indexedFile{}, types: map[string]*indexedFile{}}
+	for r, err := range wire.Fields(set) {
+		if err != nil {
+			return nil, wireError(OpRead, err)
+		}
+		if r.Tag != 1 {
+			continue
+		}
+		f := &indexedFile{data: r.Bytes, offset: r.Offset + len(r.Raw) - len(r.Bytes), index: len(x.files)}
+		var msgs, enums [][]byte
+		for r, err := range wire.Fields(r.Bytes) {
+			if err != nil {
+				return nil, wireError(OpRead, err).at(f.offset, "file", f.index)
+			}
+			switch r.Tag {
+			case 1:
+				f.name = string(r.Bytes)
+			case 2:
+				f.pkg = string(r.Bytes)
+			case 3:
+				f.deps = append(f.deps, string(r.Bytes))
+			case 4:
+				msgs = append(msgs, r.Bytes)
+			case 5:
+				enums = append(enums, r.Bytes)
+			default:
+			}
+		}
+		if _, dup := x.byName[f.name]; dup {
+			continue // like loading merged sets, the first file wins
+		}
+		x.files = append(x.files, f)
+		x.byName[f.name] = f
+		scope := ""
+		if f.pkg != "" {
+			scope = "." + f.pkg
+		}
+		for _, b := range enums {
+			x.addType(scope, b, f, false)
+		}
+		for _, b := range msgs {
+			x.addType(scope, b, f, true)
+		}
+	}
+	return x, nil
+}
+
+// addType indexes the enum or message in b and the types nested in messages.
+// Malformed nested data is left for the parse of the file to report.
+func (x *Index) addType(scope string, b []byte, f *indexedFile, message bool) {
+	var name string
+	var nested, enums [][]byte
+	for r, err := range wire.Fields(b) {
+		if err != nil {
+			return
+		}
+		switch {
+		case r.Tag == 1:
+			name = string(r.Bytes)
+		case message && r.Tag == 3:
+			nested = append(nested, r.Bytes)
+		case message && r.Tag == 4:
+			enums = append(enums, r.Bytes)
+		default:
+		}
+	}
+	full := scope + "." + name
+	if _, dup := x.types[full]; !dup {
+		x.types[full] = f
+	}
+	for _, b := range enums {
+		x.addType(full, b, f, false)
+	}
+	for _, b := range nested {
+		x.addType(full, b, f, true)
+	}
+}
+
+// FileNames returns the names of the indexed files in the order of the set.
+func (x *Index) FileNames() []string {
+	names := make([]string, len(x.files))
+	for i, f := range x.files {
+		names[i] = f.name
+	}
+	return names
+}
+
+// FileOf returns the name of the file defining the message or enum with the fully qualified name,
+// the leading dot is optional.
+func (x *Index) FileOf(typ string) (string, bool) {
+	if !strings.HasPrefix(typ, ".") {
+		typ = "." + typ
+	}
+	f, ok := x.types[typ]
+	if !ok {
+		return "", false
+	}
+	return f.name, true
+}
+
+// File returns the file called name, parsing it on first use.
+func (x *Index) File(name string) (*File, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	f, ok := x.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("descriptor: file %s not in the index", name)
+	}
+	return x.parse(f)
+}
+
+func (x *Index) parse(f *indexedFile) (*File, error) {
+	if f.file == nil {
+		file, err := (&parser{depth: 1}).parseFile(f.data)
+		if err != nil {
+			return nil, err[0].at(f.offset, "file", f.index)
+		}
+		f.file = file
+	}
+	return f.file, nil
+}
+
+// Files returns the files called names with the files they import, directly or not,
+// parsing them on first use. Imports missing from the set are skipped.
+func (x *Index) Files(names ...string) ([]*File, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, name := range names {
+		if x.byName[name] == nil {
+			return nil, fmt.Errorf("descriptor: file %s not in the index", name)
+		}
+	}
+	var files []*File
+	seen := map[string]bool{}
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		f, ok := x.byName[name]
+		if !ok {
+			continue
+		}
+		file, err := x.parse(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		names = append(names, f.deps...)
+	}
+	return files, nil
+}
+
+// Message returns the message with the fully qualified name, the leading dot is optional.
+// Its file and the imported ones are parsed and linked, so the field types are resolved
+// if the set includes them. A *LinkError is returned with the message for the others.
+func (x *Index) Message(name string) (*Message, error) {
+	file, ok := x.FileOf(name)
+	if !ok {
+		return nil, fmt.Errorf("descriptor: message %s not in the index", name)
+	}
+	files, err := x.Files(file)
+	if err != nil {
+		return nil, err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	syms, err := Link(files)
+	m := syms.Message(name)
+	if m == nil {
+		return nil, fmt.Errorf("descriptor: %s is not a message", name)
+	}
+	return m, err
+}
@@ -0,0 +1,110 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Unresolved reference found by Link.
+type Unresolved struct {
+	File    string // name of the file containing the reference
+	Element string // fully qualified name of the referencing field or method
+	Attr    string // type_name, extendee, input_type or output_type
+	Ref     string // the unresolved name
+}
+
+func (u Unresolved) String() string {
+	return fmt.Sprintf("%s: %s %s %q not found", u.File, u.Element, u.Attr, u.Ref)
+}
+
+// A LinkError lists all references Link could not resolve.
+type LinkError struct {
+	Unresolved []Unresolved
+}
+
+func (err *LinkError) Error() string {
+	if len(err.Unresolved) == 1 {
+		return "descriptor: " + err.Unresolved[0].String()
+	}
+	return fmt.Sprintf("descriptor: %s and %d more unresolved references", err.Unresolved[0], len(err.Unresolved)-1)
+}
+
+// Link resolves the type references of files across all of them:
+// Field.MessageType, Field.EnumType, Field.ExtendeeType and Method.Input and Output.
+// Relative names are resolved by scope and replaced with fully qualified ones and
+// fields without a type get TypeMessage or TypeEnum according to what they reference.
+// Everything resolvable is linked even if an error is returned.
+func Link(files []*File) (*Symbols, error) {
+	s := NewSymbols(files)
+	var unresolved []Unresolved
+	for _, f := range files {
+		walkFile(f, func(name string, x any) {
+			scope := name[:strings.LastIndexByte(name, '.')]
+			miss := func(attr, ref string) {
+				unresolved = append(unresolved, Unresolved{File: f.Name, Element: name, Attr: attr, Ref: ref})
+			}
+			switch x := x.(type) {
+			case *Field:
+				if x.TypeName != "" && !s.linkType(scope, x) {
+					miss("type_name", x.TypeName)
+				}
+				if x.Extendee != "" {
+					full, sym, ok := s.Resolve(scope, x.Extendee)
+					if m, isMsg := sym.Value.(*Message); ok && isMsg {
+						x.Extendee, x.ExtendeeType = full, m
+					} else {
+						miss("extendee", x.Extendee)
+					}
+				}
+			case *Method:
+				if m, ok := s.resolveMessage(scope, &x.InputType); ok {
+					x.Input = m
+				} else {
+					miss("input_type", x.InputType)
+				}
+				if m, ok := s.resolveMessage(scope, &x.OutputType); ok {
+					x.Output = m
+				} else {
+					miss("output_type", x.OutputType)
+				}
+			}
+		})
+	}
+	if unresolved != nil {
+		return s, &LinkError{Unresolved: unresolved}
+	}
+	return s, nil
+}
+
+func (s *Symbols) linkType(scope string, f *Field) bool {
+	full, sym, ok := s.Resolve(scope, f.TypeName)
+	if !ok {
+		return false
+	}
+	switch v := sym.Value.(type) {
+	case *Message:
+		if f.Type == 0 {
+			f.Type = TypeMessage
+		}
+		f.MessageType = v
+	case *Enum:
+		if f.Type == 0 {
+			f.Type = TypeEnum
+		}
+		f.EnumType = v
+	default:
+		return false
+	}
+	f.TypeName = full
+	return true
+}
+
+func (s *Symbols) resolveMessage(scope string, ref *string) (*Message, bool) {
+	full, sym, ok := s.Resolve(scope, *ref)
+	m, isMsg := sym.Value.(*Message)
+	if !ok || !isMsg {
+		return nil, false
+	}
+	*ref = full
+	return m, true
+}
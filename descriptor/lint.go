@@ -0,0 +1,285 @@
+package descriptor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// LintConfig configures Lint, the zero value enables all rules.
+type LintConfig struct {
+	Disable   []string            `json:"disable,omitempty"`    // names of rules to skip
+	Ignore    map[string][]string `json:"ignore,omitempty"`     // rule name to fully qualified element names to skip, a trailing ".*" matches a scope
+	MaxFields int                 `json:"max_fields,omitempty"` // of MESSAGE_MAX_FIELDS, DefaultMaxFields if 0
+}
+
+// DefaultMaxFields is the default limit of MESSAGE_MAX_FIELDS.
+const DefaultMaxFields = 100
+
+// IgnoreDirective in the comments of an element suppresses the rules listed after it, all rules if none are.
+const IgnoreDirective = "proton:lint:ignore"
+
+// A LintRule checks the elements of a file.
+type LintRule struct {
+	Name string
+	Doc  string
+
+	// check reports problems of the element called name (without leading dot)
+	check func(l *linter, name string, x any)
+}
+
+// LintRules are the rules of Lint.
+var LintRules = []LintRule{
+	{"PACKAGE_DEFINED", "files declare a package", func(l *linter, name string, x any) {
+		if f, ok := x.(*File); ok && f.Package == "" {
+			l.report(f.Name, "no package declared")
+		}
+	}},
+	{"PACKAGE_LOWER_SNAKE_CASE", "package components are lower_snake_case", func(l *linter, name string, x any) {
+		f, ok := x.(*File)
+		if ok && f.Package != "" && slices.ContainsFunc(strings.Split(f.Package, "."), func(c string) bool { return !isLowerSnake(c) }) {
+			l.report(f.Name, "package %s is not lower_snake_case", f.Package)
+		}
+	}},
+	{"MESSAGE_PASCAL_CASE", "message names are PascalCase", func(l *linter, name string, x any) {
+		if m, ok := x.(*Message); ok && !m.IsMapEntry() && !isPascal(m.Name) {
+			l.report(name, "message name %s is not PascalCase", m.Name)
+		}
+	}},
+	{"FIELD_LOWER_SNAKE_CASE", "field and extension names are lower_snake_case", func(l *linter, name string, x any) {
+		if f, ok := x.(*Field); ok && !isLowerSnake(f.Name) {
+			l.report(name, "field name %s is not lower_snake_case", f.Name)
+		}
+	}},
+	{"ONEOF_LOWER_SNAKE_CASE", "oneof names are lower_snake_case", func(l *linter, name string, x any) {
+		if o, ok := x.(*OneOf); ok && !o.Synthetic && !isLowerSnake(o.Name) {
+			l.report(name, "oneof name %s is not lower_snake_case", o.Name)
+		}
+	}},
+	{"ENUM_PASCAL_CASE", "enum names are PascalCase", func(l *linter, name string, x any) {
+		if en, ok := x.(*Enum); ok && !isPascal(en.Name) {
+			l.report(name, "enum name %s is not PascalCase", en.Name)
+		}
+	}},
+	{"ENUM_VALUE_UPPER_SNAKE_CASE", "enum value names are UPPER_SNAKE_CASE", func(l *linter, name string, x any) {
+		if en, ok := x.(*Enum); ok {
+			for _, v := range en.Value {
+				if !isUpperSnake(v.Name) {
+					l.reportValue(name, v, "enum value name %s is not UPPER_SNAKE_CASE", v.Name)
+				}
+			}
+		}
+	}},
+	{"ENUM_VALUE_PREFIX", "enum value names start with the UPPER_SNAKE_CASE enum name", func(l *linter, name string, x any) {
+		if en, ok := x.(*Enum); ok {
+			prefix := upperSnake(en.Name) + "_"
+			for _, v := range en.Value {
+				if !strings.HasPrefix(v.Name, prefix) {
+					l.reportValue(name, v, "enum value name %s does not start with %s", v.Name, prefix)
+				}
+			}
+		}
+	}},
+	{"ENUM_ZERO_VALUE_SUFFIX", "the zero value of enums ends with _UNSPECIFIED", func(l *linter, name string, x any) {
+		if en, ok := x.(*Enum); ok {
+			for _, v := range en.Value {
+				if v.Number == 0 && !strings.HasSuffix(v.Name, "_UNSPECIFIED") {
+					l.reportValue(name, v, "zero value %s does not end with _UNSPECIFIED", v.Name)
+				}
+			}
+		}
+	}},
+	{"SERVICE_PASCAL_CASE", "service names are PascalCase", func(l *linter, name string, x any) {
+		if s, ok := x.(*Service); ok && !isPascal(s.Name) {
+			l.report(name, "service name %s is not PascalCase", s.Name)
+		}
+	}},
+	{"RPC_PASCAL_CASE", "method names are PascalCase", func(l *linter, name string, x any) {
+		if m, ok := x.(*Method); ok && !isPascal(m.Name) {
+			l.report(name, "method name %s is not PascalCase", m.Name)
+		}
+	}},
+	{"COMMENTS", "messages, enums, services, methods and fields are documented, checked for files with source info", func(l *linter, name string, x any) {
+		if l.file.SourceCodeInfo == nil {
+			return
+		}
+		what := ""
+		switch x := x.(type) {
+		case *Message:
+			if !x.IsMapEntry() {
+				what = "message"
+			}
+		case *Enum:
+			what = "enum"
+		case *Service:
+			what = "service"
+		case *Method:
+			what = "method"
+		case *Field:
+			if m, ok := l.parent.(*Message); !ok || !m.IsMapEntry() {
+				what = "field"
+			}
+		default:
+		}
+		if c := comments(x); what != "" && (c == nil || strings.TrimSpace(c.Leading+c.Trailing) == "") {
+			l.report(name, "%s has no comment", what)
+		}
+	}},
+	{"MESSAGE_MAX_FIELDS", "messages have at most max_fields fields", func(l *linter, name string, x any) {
+		if m, ok := x.(*Message); ok && len(m.Field) > l.maxFields {
+			l.report(name, "message has %d fields, more than %d", len(m.Field), l.maxFields)
+		}
+	}},
+}
+
+// Lint checks files for the style conventions of LintRules.
+// Problems are suppressed by the config or by an IgnoreDirective in the comments of the element or its file.
+func Lint(files []*File, config LintConfig) []Problem {
+	l := &linter{config: config, maxFields: config.MaxFields}
+	if l.maxFields == 0 {
+		l.maxFields = DefaultMaxFields
+	}
+	for _, f := range files {
+		l.file = f
+		l.element(f.Name, f)
+		walkFile(f, func(name string, x any) {
+			switch x.(type) {
+			case *EnumValue:
+				return // checked with their enum
+			case *Message:
+				l.parent = x
+			default:
+			}
+			l.element(name[1:], x)
+		})
+	}
+	return l.problems
+}
+
+type linter struct {
+	config    LintConfig
+	maxFields int
+	file      *File
+	rule      string
+	x         any // the checked element
+	parent    any // the last message before the element in walk order
+	problems  []Problem
+}
+
+func (l *linter) element(name string, x any) {
+	l.x = x
+	for _, r := range LintRules {
+		if slices.Contains(l.config.Disable, r.Name) {
+			continue
+		}
+		l.rule = r.Name
+		r.check(l, name, x)
+	}
+}
+
+func (l *linter) report(name, format string, args ...any) {
+	l.add(name, comments(l.x), format, args...)
+}
+
+// reportValue reports an enum value, which can be suppressed by its comments or those of the enum.
+func (l *linter) reportValue(enum string, v *EnumValue, format string, args ...any) {
+	if ignores(comments(l.x), l.rule) {
+		return
+	}
+	l.add(joinName(scope(enum), v.Name), v.Comments, format, args...)
+}
+
+func (l *linter) add(name string, c *Comments, format string, args ...any) {
+	if ignores(c, l.rule) || ignores(l.file.Comments, l.rule) || l.ignored(name) {
+		return
+	}
+	l.problems = append(l.problems, Problem{File: l.file.Name, Element: name, Message: fmt.Sprintf(format, args...), Rule: l.rule})
+}
+
+// ignored reports whether the config ignores the rule for the element.
+func (l *linter) ignored(name string) bool {
+	for _, pattern := range l.config.Ignore[l.rule] {
+		if pattern == name {
+			return true
+		}
+		if scope, ok := strings.CutSuffix(pattern, ".*"); ok && strings.HasPrefix(name, scope+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ignores reports whether the comments contain an IgnoreDirective for rule.
+func ignores(c *Comments, rule string) bool {
+	if c == nil {
+		return false
+	}
+	for _, text := range append([]string{c.Leading, c.Trailing}, c.LeadingDetached...) {
+		for _, line := range strings.Split(text, "\n") {
+			_, rest, ok := strings.Cut(line, IgnoreDirective)
+			if !ok {
+				continue
+			}
+			rules := strings.Fields(strings.ReplaceAll(rest, ",", " "))
+			if len(rules) == 0 || slices.Contains(rules, rule) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// comments returns the comments of an element.
+func comments(x any) *Comments {
+	switch x := x.(type) {
+	case *File:
+		return x.Comments
+	case *Message:
+		return x.Comments
+	case *Field:
+		return x.Comments
+	case *OneOf:
+		return x.Comments
+	case *Enum:
+		return x.Comments
+	case *EnumValue:
+		return x.Comments
+	case *Service:
+		return x.Comments
+	case *Method:
+		return x.Comments
+	default:
+	}
+	return nil
+}
+
+func isLowerSnake(s string) bool {
+	return s != "" && unicode.IsLower(rune(s[0])) && !strings.ContainsFunc(s, func(c rune) bool {
+		return !unicode.IsLower(c) && !unicode.IsDigit(c) && c != '_'
+	}) && !strings.Contains(s, "__") && !strings.HasSuffix(s, "_")
+}
+
+func isUpperSnake(s string) bool {
+	return s != "" && unicode.IsUpper(rune(s[0])) && !strings.ContainsFunc(s, func(c rune) bool {
+		return !unicode.IsUpper(c) && !unicode.IsDigit(c) && c != '_'
+	}) && !strings.Contains(s, "__") && !strings.HasSuffix(s, "_")
+}
+
+func isPascal(s string) bool {
+	return s != "" && unicode.IsUpper(rune(s[0])) && !strings.ContainsFunc(s, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+// upperSnake converts a PascalCase name to UPPER_SNAKE_CASE.
+func upperSnake(s string) string {
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && unicode.IsUpper(c) && !unicode.IsUpper(rune(s[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
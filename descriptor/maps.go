@@ -0,0 +1,55 @@
+package descriptor
+
+import "encoding/json"
+
+// protoc compiles map<K, V> fields to repeated messages of a synthesized nested
+// KEntry type with key = 1 and value = 2, marked by the map_entry option.
+
+// A MapEntry describes the key and value of a map field.
+type MapEntry struct {
+	Key   *Field
+	Value *Field
+	Entry *Message `json:"-"` // the synthesized nested message
+}
+
+// IsMapEntry reports whether m is a synthesized map entry.
+func (m *Message) IsMapEntry() bool {
+	return m.Options != nil && m.Options.MapEntry
+}
+
+// IsMap reports whether f is a map field.
+func (f *Field) IsMap() bool {
+	return f.Map != nil
+}
+
+// linkMaps sets Field.Map for the map fields of msgs and their nested messages.
+// prefix is the fully qualified name of the scope of msgs.
+func linkMaps(prefix string, msgs []*Message) {
+	for _, m := range msgs {
+		name := prefix + "." + m.Name
+		for _, f := range m.Field {
+			if f.Label != LabelRepeated || f.Type != TypeMessage {
+				continue
+			}
+			for _, nm := range m.Nested {
+				if nm.IsMapEntry() && len(nm.Field) == 2 && f.TypeName == name+"."+nm.Name {
+					f.Map = &MapEntry{Key: nm.Field[0], Value: nm.Field[1], Entry: nm}
+				}
+			}
+		}
+		linkMaps(name, m.Nested)
+	}
+}
+
+// MarshalJSON leaves out the synthesized map entries, the map fields show their types.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	type message Message // without methods
+	x := message(*m)
+	x.Nested = nil
+	for _, nm := range m.Nested {
+		if !nm.IsMapEntry() {
+			x.Nested = append(x.Nested, nm)
+		}
+	}
+	return json.Marshal(&x)
+}
@@ -0,0 +1,235 @@
+package descriptor
+
+import "github.com/defsrc/proton/wire"
+
+// Marshal encodes files as a FileDescriptorSet.
+// Fields are written in ascending field number order, interleaved with the unknown fields,
+// so parsing and marshalling again yields the same bytes.
+func Marshal(files []*File) ([]byte, error) {
+	var e wire.Encoder
+	for _, f := range files {
+		b, err := f.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		e.EncodeBytes(1, b)
+	}
+	return e.Bytes(), nil
+}
+
+// MarshalBinary encodes f as a FileDescriptorProto.
+func (f *File) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, f.Name)
+	encodeString(&e, 2, f.Package)
+	for _, d := range f.Dependency {
+		e.EncodeString(3, d)
+	}
+	for _, m := range f.Message {
+		if err := encodeMessage(&e, 4, m); err != nil {
+			return nil, err
+		}
+	}
+	for _, en := range f.Enum {
+		if err := encodeMessage(&e, 5, en); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range f.Service {
+		if err := encodeMessage(&e, 6, s); err != nil {
+			return nil, err
+		}
+	}
+	for _, x := range f.Extension {
+		if err := encodeMessage(&e, 7, x); err != nil {
+			return nil, err
+		}
+	}
+	if f.Options != nil {
+		if err := encodeMessage(&e, 8, f.Options); err != nil {
+			return nil, err
+		}
+	}
+	if f.SourceCodeInfo != nil {
+		if err := encodeMessage(&e, 9, f.SourceCodeInfo); err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range f.PublicDependency {
+		e.EncodeVarint(10, wire.EncodeInt32(i))
+	}
+	for _, i := range f.WeakDependency {
+		e.EncodeVarint(11, wire.EncodeInt32(i))
+	}
+	if f.Syntax != SyntaxProto2 || f.explicitSyntax {
+		e.EncodeString(12, f.Syntax.String())
+	}
+	encodeVarint(&e, 14, wire.EncodeInt32(f.Edition))
+	return finish(&e, f.UnknownFields)
+}
+
+// MarshalBinary encodes m as a DescriptorProto.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, m.Name)
+	for _, f := range m.Field {
+		if err := encodeMessage(&e, 2, f); err != nil {
+			return nil, err
+		}
+	}
+	for _, nm := range m.Nested {
+		if err := encodeMessage(&e, 3, nm); err != nil {
+			return nil, err
+		}
+	}
+	for _, en := range m.Enum {
+		if err := encodeMessage(&e, 4, en); err != nil {
+			return nil, err
+		}
+	}
+	for _, er := range m.ExtensionRange {
+		if err := encodeMessage(&e, 5, er); err != nil {
+			return nil, err
+		}
+	}
+	for _, x := range m.Extension {
+		if err := encodeMessage(&e, 6, x); err != nil {
+			return nil, err
+		}
+	}
+	if m.Options != nil {
+		if err := encodeMessage(&e, 7, m.Options); err != nil {
+			return nil, err
+		}
+	}
+	for _, o := range m.OneOf {
+		if err := encodeMessage(&e, 8, o); err != nil {
+			return nil, err
+		}
+	}
+	for _, rr := range m.ReservedRange {
+		if err := encodeMessage(&e, 9, rr); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range m.ReservedName {
+		e.EncodeString(10, n)
+	}
+	return finish(&e, m.UnknownFields)
+}
+
+// MarshalBinary encodes r as a DescriptorProto.ExtensionRange.
+func (r *ExtensionRange) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	e.EncodeVarint(1, wire.EncodeInt32(r.Start))
+	e.EncodeVarint(2, wire.EncodeInt32(r.End))
+	if r.Options != nil {
+		if err := encodeMessage(&e, 3, r.Options); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, r.UnknownFields)
+}
+
+// MarshalBinary encodes o as a OneofDescriptorProto.
+func (o *OneOf) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, o.Name)
+	if o.Options != nil {
+		if err := encodeMessage(&e, 2, o.Options); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes f as a FieldDescriptorProto.
+func (f *Field) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, f.Name)
+	encodeString(&e, 2, f.Extendee)
+	encodeVarint(&e, 3, uint64(f.Tag))
+	encodeVarint(&e, 4, uint64(f.Label))
+	encodeVarint(&e, 5, uint64(f.Type))
+	encodeString(&e, 6, f.TypeName)
+	encodeString(&e, 7, f.DefaultValue)
+	if f.Options != nil {
+		if err := encodeMessage(&e, 8, f.Options); err != nil {
+			return nil, err
+		}
+	}
+	if f.OneOfIndex != nil {
+		e.EncodeVarint(9, wire.EncodeInt32(*f.OneOfIndex))
+	}
+	encodeString(&e, 10, f.JsonName)
+	encodeBool(&e, 17, f.Proto3Optional)
+	return finish(&e, f.UnknownFields)
+}
+
+// UnmarshalBinary parses a FileDescriptorProto into f.
+func (f *File) UnmarshalBinary(data []byte) error {
+	x, err := new(parser).parseFile(data)
+	if err != nil {
+		return err[0]
+	}
+	*f = *x
+	f.adopt() // the elements refer to f, not to the copied x
+	return nil
+}
+
+// UnmarshalBinary parses a DescriptorProto into m.
+func (m *Message) UnmarshalBinary(data []byte) error {
+	x, err := (&parser{depth: -1}).parseMessage(data) // the outermost message, at depth 0 once entered
+	if err != nil {
+		return err[0]
+	}
+	*m = *x
+	adoptMessages(nil, nil, []*Message{m})
+	return nil
+}
+
+// UnmarshalBinary parses a FieldDescriptorProto into f.
+func (f *Field) UnmarshalBinary(data []byte) error {
+	x, err := new(parser).parseField(data)
+	if err != nil {
+		return err[0]
+	}
+	*f = *x
+	return nil
+}
+
+// encodeString skips empty strings, the model can't tell them from unset ones.
+func encodeString(e *wire.Encoder, tag wire.TagNum, s string) {
+	if s != "" {
+		e.EncodeString(tag, s)
+	}
+}
+
+// encodeVarint skips zero values, the model can't tell them from unset ones.
+func encodeVarint(e *wire.Encoder, tag wire.TagNum, v uint64) {
+	if v != 0 {
+		e.EncodeVarint(tag, v)
+	}
+}
+
+// encodeBool skips false values, the model can't tell them from unset ones.
+func encodeBool(e *wire.Encoder, tag wire.TagNum, v bool) {
+	if v {
+		e.EncodeVarint(tag, 1)
+	}
+}
+
+func encodeMessage(e *wire.Encoder, tag wire.TagNum, m interface{ MarshalBinary() ([]byte, error) }) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	e.EncodeBytes(tag, b)
+	return nil
+}
+
+// finish merges the unknown fields into the encoded known fields in field number order.
+func finish(e *wire.Encoder, unknown wire.UnknownFieldSet) ([]byte, error) {
+	b := append(e.Bytes(), unknown...)
+	return wire.SortFields(b)
+}
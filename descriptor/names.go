@@ -0,0 +1,52 @@
+package descriptor
+
+import "strings"
+
+// JSONName returns the default json_name of a field like protoc computes it:
+// underscores are dropped and the following letter is capitalized.
+func JSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteByte(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
+
+// FullName returns the fully qualified name of the message without a leading dot, like "pkg.Outer.Inner".
+// It relies on the back references set when parsing a file.
+func (m *Message) FullName() string {
+	return scopeName(m.file, m.parent, m.Name)
+}
+
+// FullName returns the fully qualified name of the enum without a leading dot.
+func (en *Enum) FullName() string {
+	return scopeName(en.file, en.parent, en.Name)
+}
+
+// FullName returns the fully qualified name of the field without a leading dot,
+// for extensions that is the scope they are declared in, not the extended message.
+func (f *Field) FullName() string {
+	return scopeName(f.file, f.parent, f.Name)
+}
+
+func scopeName(file *File, parent *Message, name string) string {
+	switch {
+	case parent != nil:
+		return parent.FullName() + "." + name
+	case file != nil && file.Package != "":
+		return file.Package + "." + name
+	default:
+	}
+	return name
+}
@@ -0,0 +1,108 @@
+package descriptor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// HighNumber is the smallest field number NumberReport reports as high, its tag takes 3 bytes.
+const HighNumber = 1 << 11
+
+// NumberRules are the checks of NumberReport, set as Problem.Rule.
+var NumberRules = []LintRule{
+	{Name: "NUMBER_GAP", Doc: "unused field numbers below the highest one are reserved, or they are reused when their fields were deleted"},
+	{Name: "NUMBER_HIGH", Doc: fmt.Sprintf("field numbers are below %d, larger ones have tags of 3 bytes or more", HighNumber)},
+	{Name: "NUMBER_ONE_BYTE_TAG", Doc: "numbers 1 to 15 with 1 byte tags are used before larger ones and few are left"},
+	{Name: "NUMBER_RESERVED_NAMES_ONLY", Doc: "messages reserving the names of deleted fields also reserve their numbers"},
+}
+
+// NumberReport returns guidance on the field numbers of the messages of files, see NumberRules.
+// The problems are not errors, unlike the ones of Validate about reserved or invalid numbers.
+func NumberReport(files []*File) []Problem {
+	var problems []Problem
+	for _, f := range files {
+		walkFile(f, func(name string, x any) {
+			if m, ok := x.(*Message); ok && !m.IsMapEntry() {
+				for _, p := range messageNumbers(m) {
+					p.File, p.Element = f.Name, name[1:]
+					problems = append(problems, p)
+				}
+			}
+		})
+	}
+	return problems
+}
+
+// numberSpan is a range of taken numbers, end exclusive.
+type numberSpan struct{ start, end int64 }
+
+func messageNumbers(m *Message) []Problem {
+	var problems []Problem
+	report := func(rule, format string, args ...any) {
+		problems = append(problems, Problem{Message: fmt.Sprintf(format, args...), Rule: rule})
+	}
+	if len(m.ReservedName) > 0 && len(m.ReservedRange) == 0 {
+		report("NUMBER_RESERVED_NAMES_ONLY", "reserved names without reserved numbers: %s", strings.Join(m.ReservedName, ", "))
+	}
+	if len(m.Field) == 0 {
+		return problems
+	}
+	taken := []numberSpan{{int64(wire.FirstReservedTag), int64(wire.LastReservedTag) + 1}}
+	var highest int64
+	for _, f := range m.Field {
+		taken = append(taken, numberSpan{int64(f.Tag), int64(f.Tag) + 1})
+		highest = max(highest, int64(f.Tag))
+	}
+	for _, r := range m.ReservedRange {
+		taken = append(taken, numberSpan{int64(r.Start), int64(r.End)})
+	}
+	for _, r := range m.ExtensionRange {
+		taken = append(taken, numberSpan{int64(r.Start), int64(r.End)})
+	}
+	slices.SortFunc(taken, func(a, b numberSpan) int { return cmp.Compare(a.start, b.start) })
+
+	var gaps []string
+	free := 0 // below 16
+	next := int64(1)
+	for _, s := range taken {
+		if s.start > next && next <= highest {
+			end := min(s.start, highest+1)
+			free += int(max(0, min(end, 16)-next))
+			if end-next == 1 {
+				gaps = append(gaps, fmt.Sprint(next))
+			} else {
+				gaps = append(gaps, fmt.Sprintf("%d to %d", next, end-1))
+			}
+		}
+		next = max(next, s.end)
+	}
+	if len(gaps) > 0 {
+		report("NUMBER_GAP", "numbers neither used nor reserved: %s", strings.Join(gaps, ", "))
+	}
+
+	var high, twoBytes []string
+	for _, f := range m.Field {
+		switch {
+		case f.Tag >= HighNumber:
+			high = append(high, fmt.Sprintf("%s = %d", f.Name, f.Tag))
+		case f.Tag >= 16:
+			twoBytes = append(twoBytes, f.Name)
+		default:
+		}
+	}
+	if len(high) > 0 {
+		report("NUMBER_HIGH", "fields with tags of 3 bytes or more: %s", strings.Join(high, ", "))
+	}
+	switch {
+	case free > 0 && (len(twoBytes) > 0 || len(high) > 0):
+		report("NUMBER_ONE_BYTE_TAG", "fields use numbers of 16 and up while %d of the numbers 1 to 15 with 1 byte tags are free", free)
+	case highest >= 13 && highest < 16:
+		report("NUMBER_ONE_BYTE_TAG", "%d of the numbers 1 to 15 with 1 byte tags are left, the next fields get 2 byte tags", 15-highest)
+	default:
+	}
+	return problems
+}
@@ -0,0 +1,525 @@
+package descriptor
+
+import "github.com/defsrc/proton/wire"
+
+// Options messages keep custom options and fields not modeled here in UnknownFields,
+// unlike the other descriptors these are shown in the JSON output.
+
+type FileOptions struct {
+	JavaPackage        string      `json:",omitempty"` // 1
+	JavaOuterClassname string      `json:",omitempty"` // 8
+	OptimizeFor        int32       `json:",omitempty"` // 9, 1 SPEED, 2 CODE_SIZE, 3 LITE_RUNTIME
+	JavaMultipleFiles  bool        `json:",omitempty"` // 10
+	GoPackage          string      `json:",omitempty"` // 11
+	CcGenericServices  bool        `json:",omitempty"` // 16
+	Deprecated         bool        `json:",omitempty"` // 23
+	CcEnableArenas     *bool       `json:",omitempty"` // 31, defaults to true
+	ObjcClassPrefix    string      `json:",omitempty"` // 36
+	CsharpNamespace    string      `json:",omitempty"` // 37
+	SwiftPrefix        string      `json:",omitempty"` // 39
+	PhpNamespace       string      `json:",omitempty"` // 41
+	RubyPackage        string      `json:",omitempty"` // 45
+	Features           *FeatureSet `json:",omitempty"` // 50
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type MessageOptions struct {
+	MessageSetWireFormat bool        `json:",omitempty"` // 1
+	Deprecated           bool        `json:",omitempty"` // 3
+	MapEntry             bool        `json:",omitempty"` // 7
+	Features             *FeatureSet `json:",omitempty"` // 12
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type FieldOptions struct {
+	Ctype       int32       `json:",omitempty"` // 1, 0 STRING, 1 CORD, 2 STRING_PIECE
+	Packed      *bool       `json:",omitempty"` // 2, nil means the syntax default
+	Deprecated  bool        `json:",omitempty"` // 3
+	Lazy        bool        `json:",omitempty"` // 5
+	Jstype      int32       `json:",omitempty"` // 6, 0 JS_NORMAL, 1 JS_STRING, 2 JS_NUMBER
+	Weak        bool        `json:",omitempty"` // 10
+	DebugRedact bool        `json:",omitempty"` // 16
+	Retention   int32       `json:",omitempty"` // 17
+	Features    *FeatureSet `json:",omitempty"` // 21
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type OneOfOptions struct {
+	Features *FeatureSet `json:",omitempty"` // 1
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type ExtensionRangeOptions struct {
+	Features *FeatureSet `json:",omitempty"` // 50
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type EnumOptions struct {
+	AllowAlias bool        `json:",omitempty"` // 2
+	Deprecated bool        `json:",omitempty"` // 3
+	Features   *FeatureSet `json:",omitempty"` // 7
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type EnumValueOptions struct {
+	Deprecated  bool        `json:",omitempty"` // 1
+	DebugRedact bool        `json:",omitempty"` // 3
+	Features    *FeatureSet `json:",omitempty"` // 2
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type ServiceOptions struct {
+	Deprecated bool        `json:",omitempty"` // 33
+	Features   *FeatureSet `json:",omitempty"` // 34
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+type MethodOptions struct {
+	Deprecated       bool        `json:",omitempty"` // 33
+	IdempotencyLevel int32       `json:",omitempty"` // 34, 1 NO_SIDE_EFFECTS, 2 IDEMPOTENT
+	Features         *FeatureSet `json:",omitempty"` // 35
+
+	UnknownFields wire.UnknownFieldSet `json:",omitempty"`
+}
+
+func parseFileOptions(msg []byte) (*FileOptions, *ParseError) {
+	o := &FileOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		b, d := r.Bytes, r.Value
+		switch r.Tag {
+		case 1:
+			o.JavaPackage = string(b)
+		case 8:
+			o.JavaOuterClassname = string(b)
+		case 9:
+			o.OptimizeFor = wire.DecodeEnum(d)
+		case 10:
+			o.JavaMultipleFiles = wire.DecodeBool(d)
+		case 11:
+			o.GoPackage = string(b)
+		case 16:
+			o.CcGenericServices = wire.DecodeBool(d)
+		case 23:
+			o.Deprecated = wire.DecodeBool(d)
+		case 31:
+			v := wire.DecodeBool(d)
+			o.CcEnableArenas = &v
+		case 36:
+			o.ObjcClassPrefix = string(b)
+		case 37:
+			o.CsharpNamespace = string(b)
+		case 39:
+			o.SwiftPrefix = string(b)
+		case 41:
+			o.PhpNamespace = string(b)
+		case 45:
+			o.RubyPackage = string(b)
+		case 50:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseMessageOptions(msg []byte) (*MessageOptions, *ParseError) {
+	o := &MessageOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			o.MessageSetWireFormat = wire.DecodeBool(r.Value)
+		case 3:
+			o.Deprecated = wire.DecodeBool(r.Value)
+		case 7:
+			o.MapEntry = wire.DecodeBool(r.Value)
+		case 12:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseFieldOptions(msg []byte) (*FieldOptions, *ParseError) {
+	o := &FieldOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		d := r.Value
+		switch r.Tag {
+		case 1:
+			o.Ctype = wire.DecodeEnum(d)
+		case 2:
+			v := wire.DecodeBool(d)
+			o.Packed = &v
+		case 3:
+			o.Deprecated = wire.DecodeBool(d)
+		case 5:
+			o.Lazy = wire.DecodeBool(d)
+		case 6:
+			o.Jstype = wire.DecodeEnum(d)
+		case 10:
+			o.Weak = wire.DecodeBool(d)
+		case 16:
+			o.DebugRedact = wire.DecodeBool(d)
+		case 17:
+			o.Retention = wire.DecodeEnum(d)
+		case 21:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseOneOfOptions(msg []byte) (*OneOfOptions, *ParseError) {
+	o := &OneOfOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseExtensionRangeOptions(msg []byte) (*ExtensionRangeOptions, *ParseError) {
+	o := &ExtensionRangeOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 50:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseEnumOptions(msg []byte) (*EnumOptions, *ParseError) {
+	o := &EnumOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 2:
+			o.AllowAlias = wire.DecodeBool(r.Value)
+		case 3:
+			o.Deprecated = wire.DecodeBool(r.Value)
+		case 7:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseEnumValueOptions(msg []byte) (*EnumValueOptions, *ParseError) {
+	o := &EnumValueOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			o.Deprecated = wire.DecodeBool(r.Value)
+		case 3:
+			o.DebugRedact = wire.DecodeBool(r.Value)
+		case 2:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseServiceOptions(msg []byte) (*ServiceOptions, *ParseError) {
+	o := &ServiceOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 33:
+			o.Deprecated = wire.DecodeBool(r.Value)
+		case 34:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+func parseMethodOptions(msg []byte) (*MethodOptions, *ParseError) {
+	o := &MethodOptions{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return o, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 33:
+			o.Deprecated = wire.DecodeBool(r.Value)
+		case 34:
+			o.IdempotencyLevel = wire.DecodeEnum(r.Value)
+		case 35:
+			fs, err := parseFeatureSet(o.Features, r.Bytes)
+			if err != nil {
+				return o, err.in(r, "features", -1)
+			}
+			o.Features = fs
+		default:
+			o.UnknownFields.Add(r)
+		}
+	}
+	return o, nil
+}
+
+// MarshalBinary encodes o as FileOptions.
+func (o *FileOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, o.JavaPackage)
+	encodeString(&e, 8, o.JavaOuterClassname)
+	encodeVarint(&e, 9, wire.EncodeInt32(o.OptimizeFor))
+	encodeBool(&e, 10, o.JavaMultipleFiles)
+	encodeString(&e, 11, o.GoPackage)
+	encodeBool(&e, 16, o.CcGenericServices)
+	encodeBool(&e, 23, o.Deprecated)
+	if o.CcEnableArenas != nil {
+		e.EncodeVarint(31, wire.EncodeBool(*o.CcEnableArenas))
+	}
+	encodeString(&e, 36, o.ObjcClassPrefix)
+	encodeString(&e, 37, o.CsharpNamespace)
+	encodeString(&e, 39, o.SwiftPrefix)
+	encodeString(&e, 41, o.PhpNamespace)
+	encodeString(&e, 45, o.RubyPackage)
+	if o.Features != nil {
+		if err := encodeMessage(&e, 50, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as MessageOptions.
+func (o *MessageOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeBool(&e, 1, o.MessageSetWireFormat)
+	encodeBool(&e, 3, o.Deprecated)
+	encodeBool(&e, 7, o.MapEntry)
+	if o.Features != nil {
+		if err := encodeMessage(&e, 12, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as FieldOptions.
+func (o *FieldOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeVarint(&e, 1, wire.EncodeInt32(o.Ctype))
+	if o.Packed != nil {
+		e.EncodeVarint(2, wire.EncodeBool(*o.Packed))
+	}
+	encodeBool(&e, 3, o.Deprecated)
+	encodeBool(&e, 5, o.Lazy)
+	encodeVarint(&e, 6, wire.EncodeInt32(o.Jstype))
+	encodeBool(&e, 10, o.Weak)
+	encodeBool(&e, 16, o.DebugRedact)
+	encodeVarint(&e, 17, wire.EncodeInt32(o.Retention))
+	if o.Features != nil {
+		if err := encodeMessage(&e, 21, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as OneofOptions.
+func (o *OneOfOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	if o.Features != nil {
+		if err := encodeMessage(&e, 1, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as ExtensionRangeOptions.
+func (o *ExtensionRangeOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	if o.Features != nil {
+		if err := encodeMessage(&e, 50, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as EnumOptions.
+func (o *EnumOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeBool(&e, 2, o.AllowAlias)
+	encodeBool(&e, 3, o.Deprecated)
+	if o.Features != nil {
+		if err := encodeMessage(&e, 7, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as EnumValueOptions.
+func (o *EnumValueOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeBool(&e, 1, o.Deprecated)
+	encodeBool(&e, 3, o.DebugRedact)
+	if o.Features != nil {
+		if err := encodeMessage(&e, 2, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as ServiceOptions.
+func (o *ServiceOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeBool(&e, 33, o.Deprecated)
+	if o.Features != nil {
+		if err := encodeMessage(&e, 34, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// MarshalBinary encodes o as MethodOptions.
+func (o *MethodOptions) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeBool(&e, 33, o.Deprecated)
+	encodeVarint(&e, 34, wire.EncodeInt32(o.IdempotencyLevel))
+	if o.Features != nil {
+		if err := encodeMessage(&e, 35, o.Features); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, o.UnknownFields)
+}
+
+// UnmarshalBinary parses FileOptions into o.
+func (o *FileOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseFileOptions)
+}
+
+// UnmarshalBinary parses MessageOptions into o.
+func (o *MessageOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseMessageOptions)
+}
+
+// UnmarshalBinary parses FieldOptions into o.
+func (o *FieldOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseFieldOptions)
+}
+
+// UnmarshalBinary parses OneofOptions into o.
+func (o *OneOfOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseOneOfOptions)
+}
+
+// UnmarshalBinary parses ExtensionRangeOptions into o.
+func (o *ExtensionRangeOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseExtensionRangeOptions)
+}
+
+// UnmarshalBinary parses EnumOptions into o.
+func (o *EnumOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseEnumOptions)
+}
+
+// UnmarshalBinary parses EnumValueOptions into o.
+func (o *EnumValueOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseEnumValueOptions)
+}
+
+// UnmarshalBinary parses ServiceOptions into o.
+func (o *ServiceOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseServiceOptions)
+}
+
+// UnmarshalBinary parses MethodOptions into o.
+func (o *MethodOptions) UnmarshalBinary(data []byte) error {
+	return unmarshalOptions(o, data, parseMethodOptions)
+}
+
+func unmarshalOptions[T any](o *T, data []byte, parse func([]byte) (*T, *ParseError)) error {
+	x, err := parse(data)
+	if err != nil {
+		return err
+	}
+	*o = *x
+	return nil
+}
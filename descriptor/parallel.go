@@ -0,0 +1,86 @@
+package descriptor
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// ParseParallel is ParseOptions.ParseParallel with the default options.
+func ParseParallel(msg []byte, workers int) ([]*File, error) {
+	return ParseOptions{}.ParseParallel(msg, workers)
+}
+
+// ParseParallel is Parse with the files parsed by up to workers goroutines, GOMAXPROCS if workers <= 0.
+// The files keep the order of the set and the errors are the ones of Parse, but files after the first
+// failing one may have been parsed in vain. The allocations of the limits are counted over the whole set
+// in file order. An Arena is not safe for concurrent use, the files are then parsed one after the other.
+func (o ParseOptions) ParseParallel(msg []byte, workers int) ([]*File, error) {
+	if o.Arena != nil {
+		return o.Parse(msg)
+	}
+	p := o.parser()
+	var errs ParseErrors
+	if err := p.check(msg); err != nil && p.failed(&errs, err) {
+		return nil, p.result(errs)
+	}
+	var jobs []wire.FieldRef
+	var readErr *ParseError
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			readErr = wireError(OpRead, err)
+			break
+		}
+		if r.Tag == 1 {
+			jobs = append(jobs, r)
+		}
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(jobs))
+	parsed := make([]*File, len(jobs))
+	fileErrs := make([]ParseErrors, len(jobs))
+	allocs := make([]int, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fp := o.parser()
+				parsed[i], fileErrs[i] = fp.parseFile(jobs[i].Bytes)
+				allocs[i] = fp.allocs
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	var files []*File
+	for i, r := range jobs {
+		err := fileErrs[i]
+		// the parsers of the files count their own allocations only, the file exceeding the limit
+		// of the whole set is parsed again from the count before it for the error of Parse
+		before := p.allocs
+		p.allocs += allocs[i]
+		if o.Limits.Alloc(&p.allocs, 0) != nil && !slices.ContainsFunc(err, isLimit) {
+			fp := o.parser()
+			fp.allocs = before
+			parsed[i], err = fp.parseFile(r.Bytes)
+		}
+		if err != nil && p.failed(&errs, err.in(r, "file", len(files))...) {
+			return files, p.result(errs)
+		}
+		files = append(files, parsed[i])
+	}
+	if readErr != nil {
+		errs = append(errs, readErr)
+	}
+	return files, p.result(errs)
+}
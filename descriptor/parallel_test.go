@@ -0,0 +1,88 @@
+package descriptor_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// largeSet returns a set of n copies of the well-known files.
+func largeSet(tb testing.TB, n int) []byte {
+	var set []byte
+	for i := range n {
+		for _, f := range wellknown.Files() {
+			c := *f
+			c.Name = fmt.Sprintf("copy%d/%s", i, f.Name)
+			b, err := c.MarshalBinary()
+			if err != nil {
+				tb.Fatal(err)
+			}
+			set = wire.AppendBytes(wire.AppendTag(set, 1, wire.TagSequence), b)
+		}
+	}
+	return set
+}
+
+func TestParseParallel(t *testing.T) {
+	set := largeSet(t, 3)
+	half := len(set) / 2
+	// a file with a truncated field between valid ones
+	broken := wire.AppendBytes(wire.AppendTag(append([]byte{}, set...), 1, wire.TagSequence), []byte{0x0a, 0x05, 'x'})
+	broken = append(broken, set...)
+	inputs := map[string][]byte{"set": set, "truncated": set[:half], "broken": broken}
+	options := map[string]descriptor.ParseOptions{
+		"default":     {},
+		"strict":      {Strict: true},
+		"recover":     {Recover: true},
+		"depth":       {MaxDepth: 2, Recover: true},
+		"allocations": {Limits: &wire.Limits{Allocations: 500}},
+		"recovered":   {Limits: &wire.Limits{Allocations: 500}, Recover: true},
+		"strings":     {Limits: &wire.Limits{StringLength: 10}, Recover: true},
+	}
+	for in, data := range inputs {
+		for name, o := range options {
+			want, wantErr := o.Parse(data)
+			for _, workers := range []int{1, 4} {
+				got, err := o.ParseParallel(data, workers)
+				if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+					t.Errorf("%s %s, %d workers: error %v, want %v", in, name, workers, err, wantErr)
+				}
+				if !bytes.Equal(marshal(t, got), marshal(t, want)) {
+					t.Errorf("%s %s, %d workers: %d files differ from the %d of Parse", in, name, workers, len(got), len(want))
+				}
+			}
+		}
+	}
+}
+
+func marshal(t *testing.T, files []*descriptor.File) []byte {
+	b, err := descriptor.Marshal(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func BenchmarkParse(b *testing.B) {
+	set := largeSet(b, 100)
+	b.Run("serial", func(b *testing.B) {
+		b.SetBytes(int64(len(set)))
+		for b.Loop() {
+			if _, err := descriptor.Parse(set); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.SetBytes(int64(len(set)))
+		for b.Loop() {
+			if _, err := descriptor.ParseParallel(set, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package descriptor
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// DefaultMaxDepth is the default limit of nested messages, see wire.MaxDepth.
+const DefaultMaxDepth = wire.MaxDepth
+
+// ErrMaxDepth is the cause of the error of data nested deeper than the MaxDepth of the options.
+var ErrMaxDepth = errors.New("nesting exceeds the maximum depth")
+
+// ParseOptions configure the parsing of descriptor sets, the zero value parses like Parse.
+type ParseOptions struct {
+	Arena *Arena // allocates the elements if not nil
+
+	// Strict rejects the messages of the elements, not of their options,
+	// with the problems reported by wire.CheckStrict, the causes of the *ParseError with OpCheck.
+	Strict bool
+
+	MaxDepth int // of nested messages, the set is at depth 0, DefaultMaxDepth if 0 and unlimited if negative
+
+	// Limits bound the resources spent on untrusted data, the elements are the allocations counted.
+	// The parse stops at the *ParseError with OpLimit, even if it recovers.
+	Limits *wire.Limits
+
+	// Recover keeps going after an element fails to parse, returning the files with what could be read
+	// and the errors as ParseErrors. The fields of a message after malformed data are lost,
+	// the parse resumes with the next field of the enclosing message.
+	Recover bool
+}
+
+// Parse parses a FileDescriptorSet.
+func (o ParseOptions) Parse(msg []byte) ([]*File, error) {
+	p := o.parser()
+	var files []*File
+	var errs ParseErrors
+	if err := p.check(msg); err != nil && p.failed(&errs, err) {
+		return nil, p.result(errs)
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		switch r.Tag {
+		case 1:
+			f, err := p.parseFile(r.Bytes)
+			if err != nil && p.failed(&errs, err.in(r, "file", len(files))...) {
+				return files, p.result(errs)
+			}
+			files = append(files, f)
+		default: // skip
+		}
+	}
+	return files, p.result(errs)
+}
+
+func (o ParseOptions) parser() *parser {
+	return &parser{arena: o.Arena, strict: o.Strict, maxDepth: o.MaxDepth, recover: o.Recover, limits: o.Limits, depth: 1}
+}
+
+// parser holds the state of a parse.
+type parser struct {
+	arena    *Arena
+	strict   bool
+	maxDepth int
+	recover  bool
+	limits   *wire.Limits
+	depth    int // of the message being parsed, 1 for the files of a set
+	allocs   int // counted for the limits
+}
+
+// result returns the error of a parse with errs.
+func (p *parser) result(errs ParseErrors) error {
+	switch {
+	case errs == nil:
+		return nil
+	case p.recover:
+		return errs
+	default:
+		return errs[0]
+	}
+}
+
+// failed adds the errors of an element to errs and reports whether the parse stops, unless it recovers.
+func (p *parser) failed(errs *ParseErrors, err ...*ParseError) bool {
+	*errs = append(*errs, err...)
+	return !p.recover || slices.ContainsFunc(err, isLimit)
+}
+
+func isLimit(err *ParseError) bool {
+	return err.Op == OpLimit
+}
+
+// enter counts a message nesting level, leave must be called if it succeeds.
+func (p *parser) enter() *ParseError {
+	p.depth++
+	limit := p.maxDepth
+	if limit == 0 {
+		limit = DefaultMaxDepth
+	}
+	if limit > 0 && p.depth > limit {
+		p.depth--
+		return &ParseError{Op: OpNest, Err: ErrMaxDepth}
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+// check applies the limits and strict checks to the element msg, strings are the tags of its string fields.
+func (p *parser) check(msg []byte, strings ...wire.TagNum) *ParseError {
+	if p.limits != nil {
+		if err := p.limits.Check(msg, strings...); err != nil {
+			return wireError(OpLimit, err)
+		}
+		if err := p.limits.Alloc(&p.allocs, 1); err != nil {
+			return wireError(OpLimit, err)
+		}
+	}
+	if !p.strict {
+		return nil
+	}
+	if err := wire.CheckStrict(msg, strings...); err != nil {
+		return wireError(OpCheck, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package descriptor
+
+import "sync"
+
+// A ParserPool reuses the memory of parsed files across parses, it is safe for concurrent use.
+type ParserPool struct {
+	arenas sync.Pool
+}
+
+// Parse parses a FileDescriptorSet like the Parse function, with an Arena of the pool.
+// Calling release returns the memory to the pool, the files must not be used after.
+func (p *ParserPool) Parse(msg []byte) (files []*File, release func(), err error) {
+	a, _ := p.arenas.Get().(*Arena)
+	if a == nil {
+		a = &Arena{}
+	}
+	files, err = a.Parse(msg)
+	var once sync.Once
+	return files, func() {
+		once.Do(func() {
+			a.Reset()
+			p.arenas.Put(a)
+		})
+	}, err
+}
+
+// Reset clears f for reuse, keeping the capacity of its slices,
+// which are zeroed so no previous element or data stays reachable.
+func (f *File) Reset() {
+	clear(f.Dependency)
+	clear(f.PublicDependency)
+	clear(f.WeakDependency)
+	clear(f.Message)
+	clear(f.Enum)
+	clear(f.Service)
+	clear(f.Extension)
+	clear(f.UnknownFields)
+	*f = File{
+		Dependency:       f.Dependency[:0],
+		PublicDependency: f.PublicDependency[:0],
+		WeakDependency:   f.WeakDependency[:0],
+		Message:          f.Message[:0],
+		Enum:             f.Enum[:0],
+		Service:          f.Service[:0],
+		Extension:        f.Extension[:0],
+		UnknownFields:    f.UnknownFields[:0],
+	}
+}
+
+// Reset clears m for reuse like File.Reset.
+func (m *Message) Reset() {
+	clear(m.Field)
+	clear(m.Nested)
+	clear(m.Enum)
+	clear(m.ExtensionRange)
+	clear(m.Extension)
+	clear(m.OneOf)
+	clear(m.ReservedRange)
+	clear(m.ReservedName)
+	clear(m.UnknownFields)
+	*m = Message{
+		Field:          m.Field[:0],
+		Nested:         m.Nested[:0],
+		Enum:           m.Enum[:0],
+		ExtensionRange: m.ExtensionRange[:0],
+		Extension:      m.Extension[:0],
+		OneOf:          m.OneOf[:0],
+		ReservedRange:  m.ReservedRange[:0],
+		ReservedName:   m.ReservedName[:0],
+		UnknownFields:  m.UnknownFields[:0],
+	}
+}
+
+// Reset clears f for reuse, keeping the zeroed capacity of its unknown fields.
+func (f *Field) Reset() {
+	clear(f.UnknownFields)
+	*f = Field{UnknownFields: f.UnknownFields[:0]}
+}
@@ -0,0 +1,63 @@
+package descriptor
+
+// Field presence, see https://protobuf.dev/programming-guides/field_presence/
+
+// HasPresence reports whether the field tracks if it was set,
+// as opposed to only being distinguishable from its zero value.
+func (f *Field) HasPresence() bool {
+	switch {
+	case f.Label == LabelRepeated:
+		return false
+	case f.Type == TypeMessage || f.Type == TypeGroup:
+		return true
+	case f.OneOfIndex != nil || f.Extendee != "":
+		return true
+	case syntaxOf(f.file) == SyntaxProto3:
+		return f.Proto3Optional
+	case syntaxOf(f.file) == SyntaxEditions:
+		return f.Features().FieldPresence != PresenceImplicit
+	default:
+	}
+	return true // proto2
+}
+
+// RealOneOf returns the oneof f is part of, nil for none or the synthetic oneof of a proto3 optional field.
+func (f *Field) RealOneOf(m *Message) *OneOf {
+	if f.OneOfIndex == nil || f.Proto3Optional {
+		return nil
+	}
+	o, _ := at(m.OneOf, *f.OneOfIndex)
+	return o
+}
+
+// adopt sets the back references of the file and marks synthetic oneofs.
+func (f *File) adopt() {
+	for _, x := range f.Extension {
+		x.file = f
+	}
+	for _, en := range f.Enum {
+		en.file = f
+	}
+	adoptMessages(f, nil, f.Message)
+}
+
+func adoptMessages(file *File, parent *Message, msgs []*Message) {
+	for _, m := range msgs {
+		m.file, m.parent = file, parent
+		for _, f := range m.Field {
+			f.file, f.parent = file, m
+			if f.Proto3Optional && f.OneOfIndex != nil {
+				if o, ok := at(m.OneOf, *f.OneOfIndex); ok {
+					o.Synthetic = true
+				}
+			}
+		}
+		for _, x := range m.Extension {
+			x.file, x.parent = file, m
+		}
+		for _, en := range m.Enum {
+			en.file, en.parent = file, m
+		}
+		adoptMessages(file, m, m.Nested)
+	}
+}
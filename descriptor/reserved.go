@@ -0,0 +1,65 @@
+package descriptor
+
+import (
+	"slices"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// A ReservedRange is a range of field or enum numbers that may not be used.
+type ReservedRange struct {
+	Start int32 // 1, inclusive
+	End   int32 // 2, exclusive for messages and inclusive for enums
+
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+func parseReservedRange(msg []byte) (*ReservedRange, *ParseError) {
+	rr := &ReservedRange{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return rr, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			rr.Start = wire.DecodeInt32(r.Value)
+		case 2:
+			rr.End = wire.DecodeInt32(r.Value)
+		default:
+			rr.UnknownFields.Add(r)
+		}
+	}
+	return rr, nil
+}
+
+// MarshalBinary encodes r as a DescriptorProto.ReservedRange or EnumDescriptorProto.EnumReservedRange.
+func (r *ReservedRange) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	e.EncodeVarint(1, wire.EncodeInt32(r.Start))
+	e.EncodeVarint(2, wire.EncodeInt32(r.End))
+	return finish(&e, r.UnknownFields)
+}
+
+// IsReserved reports whether the field number tag is reserved.
+func (m *Message) IsReserved(tag wire.TagNum) bool {
+	return slices.ContainsFunc(m.ReservedRange, func(r *ReservedRange) bool {
+		return int64(tag) >= int64(r.Start) && int64(tag) < int64(r.End)
+	})
+}
+
+// IsReservedName reports whether the field name is reserved.
+func (m *Message) IsReservedName(name string) bool {
+	return slices.Contains(m.ReservedName, name)
+}
+
+// IsReserved reports whether the enum number n is reserved.
+func (en *Enum) IsReserved(n int32) bool {
+	return slices.ContainsFunc(en.ReservedRange, func(r *ReservedRange) bool {
+		return n >= r.Start && n <= r.End
+	})
+}
+
+// IsReservedName reports whether the enum value name is reserved.
+func (en *Enum) IsReservedName(name string) bool {
+	return slices.Contains(en.ReservedName, name)
+}
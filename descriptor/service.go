@@ -0,0 +1,138 @@
+package descriptor
+
+import (
+	"slices"
+
+	"github.com/defsrc/proton/wire"
+)
+
+type Service struct {
+	Name    string          `json:",omitempty"` // 1
+	Method  []*Method       `json:",omitempty"` // 2
+	Options *ServiceOptions `json:",omitempty"` // 3
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+type Method struct {
+	Name            string         `json:",omitempty"` // 1
+	InputType       string         `json:",omitempty"` // 2, fully qualified
+	OutputType      string         `json:",omitempty"` // 3, fully qualified
+	Options         *MethodOptions `json:",omitempty"` // 4
+	ClientStreaming bool           `json:",omitempty"` // 5
+	ServerStreaming bool           `json:",omitempty"` // 6
+
+	// set by Link
+	Input  *Message `json:"-"`
+	Output *Message `json:"-"`
+
+	Comments      *Comments            `json:",omitempty"`
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+func (p *parser) parseService(msg []byte) (*Service, ParseErrors) {
+	s := p.arena.newService()
+	var errs ParseErrors
+	if err := p.check(msg, 1); err != nil && p.failed(&errs, err) {
+		return s, errs
+	}
+	var n [3]int
+	countTags(msg, n[:])
+	s.Method = slices.Grow(s.Method, n[2])
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			s.Name = p.arena.string(b)
+		case 2:
+			m, err := p.parseMethod(b)
+			if err != nil && p.failed(&errs, err.in(r, "method", len(s.Method))...) {
+				return s, errs
+			}
+			s.Method = append(s.Method, m)
+		case 3:
+			o, err := parseServiceOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return s, errs
+			}
+			s.Options = o
+		default:
+			s.UnknownFields.Add(r)
+		}
+	}
+	return s, errs
+}
+
+func (p *parser) parseMethod(msg []byte) (*Method, ParseErrors) {
+	m := p.arena.newMethod()
+	var errs ParseErrors
+	if err := p.check(msg, 1, 2, 3); err != nil && p.failed(&errs, err) {
+		return m, errs
+	}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			errs = append(errs, wireError(OpRead, err))
+			break
+		}
+		b := r.Bytes
+		switch r.Tag {
+		case 1:
+			m.Name = p.arena.string(b)
+		case 2:
+			m.InputType = p.arena.string(b)
+		case 3:
+			m.OutputType = p.arena.string(b)
+		case 4:
+			o, err := parseMethodOptions(b)
+			if err != nil && p.failed(&errs, err.in(r, "options", -1)) {
+				return m, errs
+			}
+			m.Options = o
+		case 5:
+			m.ClientStreaming = wire.DecodeBool(r.Value)
+		case 6:
+			m.ServerStreaming = wire.DecodeBool(r.Value)
+		default:
+			m.UnknownFields.Add(r)
+		}
+	}
+	return m, errs
+}
+
+// MarshalBinary encodes s as a ServiceDescriptorProto.
+func (s *Service) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, s.Name)
+	for _, m := range s.Method {
+		if err := encodeMessage(&e, 2, m); err != nil {
+			return nil, err
+		}
+	}
+	if s.Options != nil {
+		if err := encodeMessage(&e, 3, s.Options); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, s.UnknownFields)
+}
+
+// MarshalBinary encodes m as a MethodDescriptorProto.
+func (m *Method) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodeString(&e, 1, m.Name)
+	encodeString(&e, 2, m.InputType)
+	encodeString(&e, 3, m.OutputType)
+	if m.Options != nil {
+		if err := encodeMessage(&e, 4, m.Options); err != nil {
+			return nil, err
+		}
+	}
+	encodeBool(&e, 5, m.ClientStreaming)
+	encodeBool(&e, 6, m.ServerStreaming)
+	return finish(&e, m.UnknownFields)
+}
@@ -0,0 +1,222 @@
+package descriptor
+
+import "github.com/defsrc/proton/wire"
+
+type SourceCodeInfo struct {
+	Location []*Location `json:",omitempty"` // 1
+
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+type Location struct {
+	// Path of field numbers and indexes from the FileDescriptorProto to the element,
+	// e.g. [4, 0, 2, 1] is message_type[0].field[1].
+	Path                    []int32  `json:",omitempty"` // 1, packed
+	Span                    []int32  `json:",omitempty"` // 2, packed, start line, start column, [end line,] end column
+	LeadingComments         string   `json:",omitempty"` // 3
+	TrailingComments        string   `json:",omitempty"` // 4
+	LeadingDetachedComments []string `json:",omitempty"` // 6
+
+	UnknownFields wire.UnknownFieldSet `json:"-"`
+}
+
+// Comments are attached to the elements from the SourceCodeInfo of their file.
+// They are not encoded by MarshalBinary, the SourceCodeInfo is.
+type Comments struct {
+	Leading         string   `json:",omitempty"`
+	Trailing        string   `json:",omitempty"`
+	LeadingDetached []string `json:",omitempty"`
+}
+
+func parseSourceCodeInfo(msg []byte) (*SourceCodeInfo, *ParseError) {
+	info := &SourceCodeInfo{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return info, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			l, err := parseLocation(r.Bytes)
+			if err != nil {
+				return info, err.in(r, "location", len(info.Location))
+			}
+			info.Location = append(info.Location, l)
+		default:
+			info.UnknownFields.Add(r)
+		}
+	}
+	return info, nil
+}
+
+func parseLocation(msg []byte) (*Location, *ParseError) {
+	l := &Location{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return l, wireError(OpRead, err)
+		}
+		switch r.Tag {
+		case 1:
+			l.Path, err = appendInt32s(l.Path, r)
+		case 2:
+			l.Span, err = appendInt32s(l.Span, r)
+		case 3:
+			l.LeadingComments = string(r.Bytes)
+		case 4:
+			l.TrailingComments = string(r.Bytes)
+		case 6:
+			l.LeadingDetachedComments = append(l.LeadingDetachedComments, string(r.Bytes))
+		default:
+			l.UnknownFields.Add(r)
+		}
+		if err != nil {
+			return l, wireError(OpRead, err)
+		}
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes info as SourceCodeInfo.
+func (info *SourceCodeInfo) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	for _, l := range info.Location {
+		if err := encodeMessage(&e, 1, l); err != nil {
+			return nil, err
+		}
+	}
+	return finish(&e, info.UnknownFields)
+}
+
+// MarshalBinary encodes l as SourceCodeInfo.Location.
+func (l *Location) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	encodePackedInt32s(&e, 1, l.Path)
+	encodePackedInt32s(&e, 2, l.Span)
+	encodeString(&e, 3, l.LeadingComments)
+	encodeString(&e, 4, l.TrailingComments)
+	for _, c := range l.LeadingDetachedComments {
+		e.EncodeString(6, c)
+	}
+	return finish(&e, l.UnknownFields)
+}
+
+func encodePackedInt32s(e *wire.Encoder, tag wire.TagNum, vs []int32) {
+	if len(vs) == 0 {
+		return
+	}
+	var b []byte
+	for _, v := range vs {
+		b = wire.AppendVarint(b, wire.EncodeInt32(v))
+	}
+	e.EncodeBytes(tag, b)
+}
+
+// attachComments sets the Comments of the elements located by f.SourceCodeInfo.
+func (f *File) attachComments() {
+	for _, l := range f.SourceCodeInfo.Location {
+		if l.LeadingComments == "" && l.TrailingComments == "" && len(l.LeadingDetachedComments) == 0 {
+			continue
+		}
+		if c := f.commentsAt(l.Path); c != nil {
+			*c = &Comments{
+				Leading:         l.LeadingComments,
+				Trailing:        l.TrailingComments,
+				LeadingDetached: l.LeadingDetachedComments,
+			}
+		}
+	}
+}
+
+// at returns s[i] if i is in range.
+func at[T any](s []T, i int32) (T, bool) {
+	if i < 0 || int(i) >= len(s) {
+		var zero T
+		return zero, false
+	}
+	return s[i], true
+}
+
+func (f *File) commentsAt(p []int32) **Comments {
+	if len(p) == 1 && p[0] == 2 { // the package statement documents the file
+		return &f.Comments
+	}
+	if len(p) < 2 {
+		return nil
+	}
+	switch p[0] {
+	case 4:
+		if m, ok := at(f.Message, p[1]); ok {
+			return m.commentsAt(p[2:])
+		}
+	case 5:
+		if en, ok := at(f.Enum, p[1]); ok {
+			return en.commentsAt(p[2:])
+		}
+	case 6:
+		if s, ok := at(f.Service, p[1]); ok {
+			return s.commentsAt(p[2:])
+		}
+	case 7:
+		if x, ok := at(f.Extension, p[1]); ok && len(p) == 2 {
+			return &x.Comments
+		}
+	}
+	return nil
+}
+
+func (m *Message) commentsAt(p []int32) **Comments {
+	if len(p) == 0 {
+		return &m.Comments
+	}
+	if len(p) < 2 {
+		return nil
+	}
+	switch p[0] {
+	case 2:
+		if f, ok := at(m.Field, p[1]); ok && len(p) == 2 {
+			return &f.Comments
+		}
+	case 3:
+		if nm, ok := at(m.Nested, p[1]); ok {
+			return nm.commentsAt(p[2:])
+		}
+	case 4:
+		if en, ok := at(m.Enum, p[1]); ok {
+			return en.commentsAt(p[2:])
+		}
+	case 6:
+		if x, ok := at(m.Extension, p[1]); ok && len(p) == 2 {
+			return &x.Comments
+		}
+	case 8:
+		if o, ok := at(m.OneOf, p[1]); ok && len(p) == 2 {
+			return &o.Comments
+		}
+	}
+	return nil
+}
+
+func (en *Enum) commentsAt(p []int32) **Comments {
+	if len(p) == 0 {
+		return &en.Comments
+	}
+	if len(p) != 2 || p[0] != 2 {
+		return nil
+	}
+	if v, ok := at(en.Value, p[1]); ok {
+		return &v.Comments
+	}
+	return nil
+}
+
+func (s *Service) commentsAt(p []int32) **Comments {
+	if len(p) == 0 {
+		return &s.Comments
+	}
+	if len(p) != 2 || p[0] != 2 {
+		return nil
+	}
+	if m, ok := at(s.Method, p[1]); ok {
+		return &m.Comments
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package descriptor
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// Counts are the numbers of elements in a group of files, map entries are not counted as messages.
+type Counts struct {
+	Files      int
+	Messages   int
+	Fields     int // of messages, without extensions
+	Extensions int
+	Enums      int
+	EnumValues int
+	Services   int
+	Methods    int
+}
+
+// Stats summarize a set of files.
+type Stats struct {
+	Counts
+	MaxDepth int               // of message nesting, 1 if all messages are top level
+	Numbers  []NumberBucket    // field and extension numbers by the size of their tags
+	Largest  []MessageSize     // messages with the most fields, most first
+	Packages map[string]Counts // by package, "" for files without one
+}
+
+// A NumberBucket counts the field numbers encoded with tags of Bytes bytes.
+type NumberBucket struct {
+	Min, Max int32
+	Bytes    int
+	Count    int
+}
+
+// MessageSize is the number of fields of a message.
+type MessageSize struct {
+	Name   string // fully qualified, without leading dot
+	File   string
+	Fields int
+}
+
+// numberBuckets are the field number ranges with 1 to 5 byte tags.
+var numberBuckets = []NumberBucket{
+	{Min: 1, Max: 1<<4 - 1, Bytes: 1},
+	{Min: 1 << 4, Max: 1<<11 - 1, Bytes: 2},
+	{Min: 1 << 11, Max: 1<<18 - 1, Bytes: 3},
+	{Min: 1 << 18, Max: 1<<25 - 1, Bytes: 4},
+	{Min: 1 << 25, Max: wire.MaxTag, Bytes: 5},
+}
+
+// NewStats counts the elements of files and keeps the largest messages, all if largest is negative.
+func NewStats(files []*File, largest int) *Stats {
+	s := &Stats{Numbers: slices.Clone(numberBuckets), Packages: make(map[string]Counts)}
+	for _, f := range files {
+		var c Counts
+		c.Files++
+		depth := 0
+		if f.Package != "" {
+			depth = strings.Count(f.Package, ".") + 1
+		}
+		walkFile(f, func(name string, x any) {
+			switch x := x.(type) {
+			case *Message:
+				if x.IsMapEntry() {
+					return
+				}
+				c.Messages++
+				s.MaxDepth = max(s.MaxDepth, strings.Count(name, ".")-depth)
+				s.Largest = append(s.Largest, MessageSize{Name: name[1:], File: f.Name, Fields: len(x.Field)})
+			case *Field:
+				if x.Extendee != "" {
+					c.Extensions++
+				} else if x.parent == nil || !x.parent.IsMapEntry() {
+					c.Fields++
+				} else {
+					return
+				}
+				s.count(x.Tag)
+			case *Enum:
+				c.Enums++
+			case *EnumValue:
+				c.EnumValues++
+			case *Service:
+				c.Services++
+			case *Method:
+				c.Methods++
+			default:
+			}
+		})
+		s.Counts.add(c)
+		p := s.Packages[f.Package]
+		p.add(c)
+		s.Packages[f.Package] = p
+	}
+	slices.SortStableFunc(s.Largest, func(a, b MessageSize) int { return cmp.Compare(b.Fields, a.Fields) })
+	if largest >= 0 && len(s.Largest) > largest {
+		s.Largest = s.Largest[:largest]
+	}
+	return s
+}
+
+func (s *Stats) count(n wire.TagNum) {
+	for i := range s.Numbers {
+		if int64(s.Numbers[i].Min) <= int64(n) && int64(n) <= int64(s.Numbers[i].Max) {
+			s.Numbers[i].Count++
+			return
+		}
+	}
+}
+
+func (c *Counts) add(d Counts) {
+	c.Files += d.Files
+	c.Messages += d.Messages
+	c.Fields += d.Fields
+	c.Extensions += d.Extensions
+	c.Enums += d.Enums
+	c.EnumValues += d.EnumValues
+	c.Services += d.Services
+	c.Methods += d.Methods
+}
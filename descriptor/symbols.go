@@ -0,0 +1,175 @@
+package descriptor
+
+import (
+	"slices"
+	"strings"
+)
+
+// A Symbol is a named element of a descriptor file.
+type Symbol struct {
+	File  *File
+	Value any // *Message, *Field, *OneOf, *Enum, *EnumValue, *Service or *Method
+}
+
+// Symbols indexes the elements of descriptor files by fully qualified name,
+// like ".pkg.Message.Nested" - with the leading dot used by type_name references.
+type Symbols struct {
+	byName   map[string]Symbol
+	names    map[any]string
+	packages map[string]bool // including the parents of nested packages
+	sorted   []string
+}
+
+// NewSymbols indexes files, for duplicate names the first element wins.
+func NewSymbols(files []*File) *Symbols {
+	s := &Symbols{byName: map[string]Symbol{}, names: map[any]string{}, packages: map[string]bool{}}
+	for _, f := range files {
+		s.Add(f)
+	}
+	return s
+}
+
+// Add indexes the elements of f.
+func (s *Symbols) Add(f *File) {
+	for pkg := f.Package; pkg != ""; {
+		s.packages["."+pkg] = true
+		i := strings.LastIndexByte(pkg, '.')
+		pkg = pkg[:max(i, 0)]
+	}
+	walkFile(f, func(name string, x any) {
+		if _, dup := s.byName[name]; dup {
+			return
+		}
+		s.byName[name] = Symbol{File: f, Value: x}
+		s.names[x] = name
+		s.sorted = nil
+	})
+}
+
+// Lookup returns the element with the fully qualified name, the leading dot is optional.
+func (s *Symbols) Lookup(name string) (Symbol, bool) {
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+	sym, ok := s.byName[name]
+	return sym, ok
+}
+
+// Message returns the message with the fully qualified name or nil.
+func (s *Symbols) Message(name string) *Message {
+	sym, _ := s.Lookup(name)
+	m, _ := sym.Value.(*Message)
+	return m
+}
+
+// Enum returns the enum with the fully qualified name or nil.
+func (s *Symbols) Enum(name string) *Enum {
+	sym, _ := s.Lookup(name)
+	en, _ := sym.Value.(*Enum)
+	return en
+}
+
+// Service returns the service with the fully qualified name or nil.
+func (s *Symbols) Service(name string) *Service {
+	sym, _ := s.Lookup(name)
+	svc, _ := sym.Value.(*Service)
+	return svc
+}
+
+// NameOf returns the fully qualified name of an indexed element.
+func (s *Symbols) NameOf(x any) (string, bool) {
+	name, ok := s.names[x]
+	return name, ok
+}
+
+// Resolve finds the element a reference from scope refers to, following the protobuf scoping rules:
+// fully qualified references start with a dot, others are searched from the innermost scope outwards.
+// Like protoc the search ends at the first package or message matching the first component of ref.
+func (s *Symbols) Resolve(scope, ref string) (string, Symbol, bool) {
+	if strings.HasPrefix(ref, ".") {
+		sym, ok := s.byName[ref]
+		return ref, sym, ok
+	}
+	first, _, _ := strings.Cut(ref, ".")
+	for {
+		prefix := scope + "." + first
+		sym, ok := s.byName[prefix]
+		if ok || s.packages[prefix] {
+			name := scope + "." + ref
+			if found, ok := s.byName[name]; ok {
+				return name, found, true
+			}
+			if _, msg := sym.Value.(*Message); msg || s.packages[prefix] {
+				return "", Symbol{}, false
+			}
+		}
+		if scope == "" {
+			return "", Symbol{}, false
+		}
+		scope = scope[:max(strings.LastIndexByte(scope, '.'), 0)]
+	}
+}
+
+// Range calls fn for all elements in name order until it returns false.
+func (s *Symbols) Range(fn func(name string, sym Symbol) bool) {
+	if s.sorted == nil {
+		s.sorted = make([]string, 0, len(s.byName))
+		for name := range s.byName {
+			s.sorted = append(s.sorted, name)
+		}
+		slices.Sort(s.sorted)
+	}
+	for _, name := range s.sorted {
+		if !fn(name, s.byName[name]) {
+			return
+		}
+	}
+}
+
+// walkFile calls fn for every named element of f with its fully qualified name.
+func walkFile(f *File, fn func(name string, x any)) {
+	pkg := ""
+	if f.Package != "" {
+		pkg = "." + f.Package
+	}
+	walkMessages(pkg, f.Message, fn)
+	walkEnums(pkg, f.Enum, fn)
+	for _, x := range f.Extension {
+		fn(pkg+"."+x.Name, x)
+	}
+	for _, svc := range f.Service {
+		name := pkg + "." + svc.Name
+		fn(name, svc)
+		for _, m := range svc.Method {
+			fn(name+"."+m.Name, m)
+		}
+	}
+}
+
+func walkMessages(scope string, msgs []*Message, fn func(name string, x any)) {
+	for _, m := range msgs {
+		name := scope + "." + m.Name
+		fn(name, m)
+		for _, f := range m.Field {
+			fn(name+"."+f.Name, f)
+		}
+		for _, o := range m.OneOf {
+			fn(name+"."+o.Name, o)
+		}
+		for _, x := range m.Extension {
+			fn(name+"."+x.Name, x)
+		}
+		walkMessages(name, m.Nested, fn)
+		walkEnums(name, m.Enum, fn)
+	}
+}
+
+// walkEnums also reports the values, they are scoped like their enum, not inside it.
+func walkEnums(scope string, enums []*Enum, fn func(name string, x any)) {
+	for _, en := range enums {
+		fn(scope+"."+en.Name, en)
+		for _, v := range en.Value {
+			fn(scope+"."+v.Name, v)
+		}
+	}
+}
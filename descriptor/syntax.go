@@ -0,0 +1,57 @@
+package descriptor
+
+import "fmt"
+
+// Syntax is FileDescriptorProto.syntax.
+type Syntax uint8
+
+const (
+	SyntaxProto2   Syntax = iota // also for files without syntax
+	SyntaxProto3                 // "proto3"
+	SyntaxEditions               // "editions", see File.Edition
+)
+
+var syntaxNames = [...]string{"proto2", "proto3", "editions"}
+
+func (s Syntax) String() string {
+	if int(s) < len(syntaxNames) {
+		return syntaxNames[s]
+	}
+	return fmt.Sprintf("Syntax(%d)", s)
+}
+
+func (s Syntax) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Syntax) UnmarshalText(b []byte) error {
+	v, ok := parseSyntax(string(b))
+	if !ok {
+		return fmt.Errorf("descriptor: unknown syntax %q", b)
+	}
+	*s = v
+	return nil
+}
+
+func parseSyntax(s string) (Syntax, bool) {
+	for i, n := range syntaxNames {
+		if n == s {
+			return Syntax(i), true
+		}
+	}
+	return SyntaxProto2, s == ""
+}
+
+// syntaxOf returns the syntax of the file f, proto2 without one.
+func syntaxOf(f *File) Syntax {
+	if f == nil {
+		return SyntaxProto2
+	}
+	return f.Syntax
+}
+
+// IsClosed reports whether unknown values of the enum are treated as unknown fields,
+// like proto2 does, instead of being kept in the field like proto3.
+func (en *Enum) IsClosed() bool {
+	return en.Features().EnumType == EnumClosed
+}
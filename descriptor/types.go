@@ -0,0 +1,80 @@
+package descriptor
+
+import (
+	"fmt"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// FieldDescriptorProto.Type
+const (
+	TypeDouble   uint8 = 1
+	TypeFloat    uint8 = 2
+	TypeInt64    uint8 = 3
+	TypeUint64   uint8 = 4
+	TypeInt32    uint8 = 5
+	TypeFixed64  uint8 = 6
+	TypeFixed32  uint8 = 7
+	TypeBool     uint8 = 8
+	TypeString   uint8 = 9
+	TypeGroup    uint8 = 10
+	TypeMessage  uint8 = 11
+	TypeBytes    uint8 = 12
+	TypeUint32   uint8 = 13
+	TypeEnum     uint8 = 14
+	TypeSfixed32 uint8 = 15
+	TypeSfixed64 uint8 = 16
+	TypeSint32   uint8 = 17
+	TypeSint64   uint8 = 18
+)
+
+// FieldDescriptorProto.Label
+const (
+	LabelOptional uint8 = 1
+	LabelRequired uint8 = 2
+	LabelRepeated uint8 = 3
+)
+
+// WireClass returns the tag class a value of typ is encoded with,
+// packed repeated fields use wire.TagSequence instead.
+func WireClass(typ uint8) wire.TagClass {
+	switch typ {
+	case TypeDouble, TypeFixed64, TypeSfixed64:
+		return wire.Tag64bit
+	case TypeFloat, TypeFixed32, TypeSfixed32:
+		return wire.Tag32bit
+	case TypeString, TypeBytes, TypeMessage:
+		return wire.TagSequence
+	case TypeGroup:
+		return wire.TagStart
+	default:
+	}
+	return wire.TagUvarint
+}
+
+// Packable reports whether the field is a repeated scalar that may be encoded packed.
+// Decoders have to accept packed data for such a field regardless of its options.
+func (f *Field) Packable() bool {
+	return f.Label == LabelRepeated && WireClass(f.Type) != wire.TagSequence && f.Type != TypeGroup
+}
+
+var typeNames = [...]string{"", "double", "float", "int64", "uint64", "int32", "fixed64", "fixed32", "bool",
+	"string", "group", "message", "bytes", "uint32", "enum", "sfixed32", "sfixed64", "sint32", "sint64"}
+
+// TypeKeyword returns the .proto keyword of typ, "group", "message" and "enum" for the named types.
+func TypeKeyword(typ uint8) string {
+	if int(typ) < len(typeNames) && typ > 0 {
+		return typeNames[typ]
+	}
+	return fmt.Sprintf("type(%d)", typ)
+}
+
+var labelNames = [...]string{"", "optional", "required", "repeated"}
+
+// LabelKeyword returns the .proto keyword of label.
+func LabelKeyword(label uint8) string {
+	if int(label) < len(labelNames) && label > 0 {
+		return labelNames[label]
+	}
+	return fmt.Sprintf("label(%d)", label)
+}
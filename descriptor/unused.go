@@ -0,0 +1,91 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unused reports the messages and enums of files that are not reachable from the roots,
+// fully qualified names of services, methods, messages or enums resolved with syms.
+// Services reach the types of their methods, messages the types of their fields and of the
+// extensions of them declared anywhere in syms. Nesting alone does not make a type reachable.
+// The types of custom options, extensions of the options messages of descriptor.proto, are always reachable.
+// The files must be linked, map entries are never reported.
+func Unused(files []*File, syms *Symbols, roots ...string) ([]Problem, error) {
+	reached := map[any]bool{}
+	var queue []*Message
+	var reach func(x any)
+	reach = func(x any) {
+		switch x := x.(type) {
+		case *Message:
+			if x != nil && !reached[x] {
+				reached[x] = true
+				queue = append(queue, x)
+			}
+		case *Enum:
+			if x != nil {
+				reached[x] = true
+			}
+		case *Method:
+			reach(x.Input)
+			reach(x.Output)
+		case *Service:
+			for _, m := range x.Method {
+				reach(m)
+			}
+		case *Field:
+			reach(x.MessageType)
+			reach(x.EnumType)
+		default:
+		}
+	}
+	extensions := map[*Message][]*Field{}
+	syms.Range(func(name string, sym Symbol) bool {
+		if x, ok := sym.Value.(*Field); ok && x.ExtendeeType != nil {
+			extensions[x.ExtendeeType] = append(extensions[x.ExtendeeType], x)
+			if e := x.ExtendeeType.FullName(); strings.HasPrefix(e, "google.protobuf.") && strings.HasSuffix(e, "Options") {
+				reach(x)
+			}
+		}
+		return true
+	})
+	for _, root := range roots {
+		sym, ok := syms.Lookup(root)
+		if !ok {
+			return nil, fmt.Errorf("descriptor: root %q not found", root)
+		}
+		switch sym.Value.(type) {
+		case *Service, *Method, *Message, *Enum:
+			reach(sym.Value)
+		default:
+			return nil, fmt.Errorf("descriptor: root %q is not a service, method, message or enum", root)
+		}
+	}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		for _, f := range m.Field {
+			reach(f)
+		}
+		for _, x := range extensions[m] {
+			reach(x)
+		}
+	}
+	var problems []Problem
+	for _, f := range files {
+		walkFile(f, func(name string, x any) {
+			switch x := x.(type) {
+			case *Message:
+				if !x.IsMapEntry() && !reached[x] {
+					problems = append(problems, Problem{File: f.Name, Element: name[1:], Message: "message is not reachable from the roots"})
+				}
+			case *Enum:
+				if !reached[x] {
+					problems = append(problems, Problem{File: f.Name, Element: name[1:], Message: "enum is not reachable from the roots"})
+				}
+			default:
+			}
+		})
+	}
+	return problems, nil
+}
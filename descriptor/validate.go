@@ -0,0 +1,208 @@
+package descriptor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/wire"
+)
+
+// A Problem is a violation found by Validate or Lint.
+type Problem struct {
+	File    string // name of the file containing the element
+	Element string // fully qualified name of the offending element
+	Message string
+	Rule    string `json:",omitempty"` // the violated lint rule
+}
+
+func (p Problem) String() string {
+	if p.Rule != "" {
+		return fmt.Sprintf("%s: %s: %s (%s)", p.File, p.Element, p.Message, p.Rule)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.File, p.Element, p.Message)
+}
+
+// A ValidationError lists all problems found by Validate.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (err *ValidationError) Error() string {
+	lines := make([]string, len(err.Problems))
+	for i, p := range err.Problems {
+		lines[i] = p.String()
+	}
+	return "descriptor: invalid descriptors:\n\t" + strings.Join(lines, "\n\t")
+}
+
+// Validate reports duplicate fully qualified names across files,
+// duplicate field numbers within a message and duplicate enum numbers without allow_alias,
+// and the other descriptors protoc rejects:
+//   - field numbers out of range 1..536870911 or reserved for the implementation, reserved or in an extension range
+//   - overlapping extension and reserved ranges, fields using reserved names
+//   - oneof indexes out of range, empty oneofs and repeated or required fields in oneofs
+//   - map keys of floating point, bytes, enum or message types
+//   - required fields, default values, groups and closed enum fields in proto3
+//   - extension numbers outside the extension ranges of the extendee, if linked
+//   - enums without values, open enums not starting at zero and values using reserved numbers or names
+func Validate(files []*File) error {
+	var v validator
+	v.names = map[string]string{}
+	for _, f := range files {
+		v.file = f
+		walkFile(f, v.element)
+	}
+	if v.problems != nil {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	file     *File
+	names    map[string]string // fully qualified name to the defining file
+	problems []Problem
+}
+
+func (v *validator) report(name, format string, args ...any) {
+	v.problems = append(v.problems, Problem{File: v.file.Name, Element: name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) element(name string, x any) {
+	if prev, dup := v.names[name]; dup {
+		if prev == v.file.Name {
+			v.report(name, "name defined twice")
+		} else {
+			v.report(name, "name already defined in %s", prev)
+		}
+	} else {
+		v.names[name] = v.file.Name
+	}
+	switch x := x.(type) {
+	case *Message:
+		v.message(name, x)
+	case *Field:
+		v.field(name, x)
+	case *Enum:
+		v.enum(name, x)
+	default:
+	}
+}
+
+func (v *validator) message(name string, m *Message) {
+	seen := map[uint32]string{}
+	for _, f := range m.Field {
+		if prev, dup := seen[f.Tag]; dup {
+			v.report(name, "field number %d used by %s and %s", f.Tag, prev, f.Name)
+		}
+		seen[f.Tag] = f.Name
+	}
+	type span struct {
+		start, end int64 // end exclusive
+		kind       string
+	}
+	var spans []span
+	for _, r := range m.ExtensionRange {
+		spans = append(spans, span{int64(r.Start), int64(r.End), "extension range"})
+	}
+	for _, r := range m.ReservedRange {
+		spans = append(spans, span{int64(r.Start), int64(r.End), "reserved range"})
+	}
+	for _, s := range spans {
+		if s.start < 1 || s.end > wire.MaxTag+1 || s.start >= s.end {
+			v.report(name, "%s %d to %d is invalid", s.kind, s.start, s.end-1)
+		}
+	}
+	slices.SortFunc(spans, func(a, b span) int { return cmp.Compare(a.start, b.start) })
+	for i := 1; i < len(spans); i++ {
+		if a, b := spans[i-1], spans[i]; b.start < a.end {
+			v.report(name, "%s %d to %d overlaps %s %d to %d", b.kind, b.start, b.end-1, a.kind, a.start, a.end-1)
+		}
+	}
+	used := make([]bool, len(m.OneOf))
+	for _, f := range m.Field {
+		if m.IsReserved(f.Tag) {
+			v.report(name, "field %s uses reserved number %d", f.Name, f.Tag)
+		}
+		if m.IsReservedName(f.Name) {
+			v.report(name, "field %s uses a reserved name", f.Name)
+		}
+		if slices.ContainsFunc(m.ExtensionRange, func(r *ExtensionRange) bool { return r.Contains(f.Tag) }) {
+			v.report(name, "field %s uses number %d of an extension range", f.Name, f.Tag)
+		}
+		if i := int(f.oneOfIndex()); i >= len(m.OneOf) || i < -1 {
+			v.report(name, "field %s has oneof index %d out of range", f.Name, i)
+		} else if i >= 0 {
+			used[i] = true
+		}
+	}
+	for i, o := range m.OneOf {
+		if !used[i] {
+			v.report(name, "oneof %s has no fields", o.Name)
+		}
+	}
+}
+
+func (v *validator) field(name string, f *Field) {
+	if f.Tag < 1 || f.Tag > wire.MaxTag {
+		v.report(name, "field number %d out of range 1 to %d", f.Tag, wire.MaxTag)
+	} else if wire.FirstReservedTag <= f.Tag && f.Tag <= wire.LastReservedTag {
+		v.report(name, "field number %d is reserved for the protocol buffers implementation", f.Tag)
+	}
+	if f.OneOfIndex != nil && f.Label != LabelOptional {
+		v.report(name, "fields of oneofs cannot be repeated or required")
+	}
+	if f.Map != nil {
+		switch f.Map.Key.Type {
+		case TypeDouble, TypeFloat, TypeBytes, TypeEnum, TypeMessage, TypeGroup:
+			v.report(name, "map key type %s must be an integral or string type", TypeKeyword(f.Map.Key.Type))
+		default:
+		}
+	}
+	if syntaxOf(v.file) == SyntaxProto3 {
+		switch {
+		case f.Label == LabelRequired:
+			v.report(name, "required fields are not allowed in proto3")
+		case f.DefaultValue != "":
+			v.report(name, "default values are not allowed in proto3")
+		case f.Type == TypeGroup:
+			v.report(name, "groups are not allowed in proto3")
+		case f.Type == TypeEnum && f.EnumType != nil && f.EnumType.IsClosed():
+			v.report(name, "closed enum %s cannot be used in proto3", f.TypeName[1:])
+		default:
+		}
+	}
+	if x := f.ExtendeeType; x != nil && !slices.ContainsFunc(x.ExtensionRange, func(r *ExtensionRange) bool { return r.Contains(f.Tag) }) {
+		v.report(name, "extension number %d is not in an extension range of %s", f.Tag, f.Extendee[1:])
+	}
+}
+
+func (v *validator) enum(name string, en *Enum) {
+	if len(en.Value) == 0 {
+		v.report(name, "enum has no values")
+		return
+	}
+	if !en.IsClosed() && en.Value[0].Number != 0 {
+		v.report(name, "the first value %s of open enums must be zero", en.Value[0].Name)
+	}
+	for _, ev := range en.Value {
+		if en.IsReserved(ev.Number) {
+			v.report(name, "value %s uses reserved number %d", ev.Name, ev.Number)
+		}
+		if en.IsReservedName(ev.Name) {
+			v.report(name, "value %s uses a reserved name", ev.Name)
+		}
+	}
+	if en.Options != nil && en.Options.AllowAlias {
+		return
+	}
+	seen := map[int32]string{}
+	for _, ev := range en.Value {
+		if prev, dup := seen[ev.Number]; dup {
+			v.report(name, "enum number %d used by %s and %s without allow_alias", ev.Number, prev, ev.Name)
+		}
+		seen[ev.Number] = ev.Name
+	}
+}
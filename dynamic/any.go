@@ -0,0 +1,34 @@
+package dynamic
+
+import "github.com/defsrc/proton/descriptor"
+
+// AnyMessages returns the google.protobuf.Any messages in m and its nested messages, m itself if it is one.
+// The packed values are not decoded, their types may be unknown.
+func (m *Message) AnyMessages() []*Message {
+	var anys []*Message
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case *Message:
+			if v.desc.FullName() == "google.protobuf.Any" {
+				anys = append(anys, v)
+				return
+			}
+			v.Range(func(_ *descriptor.Field, x any) bool {
+				walk(x)
+				return true
+			})
+		case []any:
+			for _, x := range v {
+				walk(x)
+			}
+		case map[any]any:
+			for _, x := range v {
+				walk(x)
+			}
+		default:
+		}
+	}
+	walk(m)
+	return anys
+}
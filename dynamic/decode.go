@@ -0,0 +1,290 @@
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// An UnresolvedError reports a message or enum field whose type was not linked.
+type UnresolvedError struct {
+	Field *descriptor.Field
+}
+
+func (err *UnresolvedError) Error() string {
+	return fmt.Sprintf("dynamic: field %s has unresolved type %s", err.Field.Name, err.Field.TypeName)
+}
+
+// UnmarshalOptions configure decoding, the zero value decodes extensions as unknown fields.
+type UnmarshalOptions struct {
+	Extensions *ExtensionRegistry // resolves the extensions of decoded messages
+	Strict     bool               // reject the problems reported by wire.CheckStrict, string fields must be UTF-8
+	MaxDepth   int                // of nested messages, the decoded one is at depth 0, descriptor.DefaultMaxDepth if 0 and unlimited if negative
+	Limits     *wire.Limits       // bound the resources spent on untrusted data, the allocations are messages and values
+
+	depth  int  // of the message being decoded
+	allocs *int // counted for the limits over the whole data
+}
+
+// ErrMaxDepth is returned for data with messages nested deeper than the MaxDepth of the options.
+var ErrMaxDepth = errors.New("dynamic: nesting exceeds the maximum depth")
+
+// nested returns the options for decoding a message nested in the current one.
+func (o UnmarshalOptions) nested() (UnmarshalOptions, error) {
+	o.depth++
+	limit := o.MaxDepth
+	if limit == 0 {
+		limit = descriptor.DefaultMaxDepth
+	}
+	if limit > 0 && o.depth > limit {
+		return o, ErrMaxDepth
+	}
+	return o, nil
+}
+
+// Unmarshal decodes data as a message of type desc.
+func (o UnmarshalOptions) Unmarshal(desc *descriptor.Message, data []byte) (*Message, error) {
+	m := New(desc)
+	if err := o.Merge(m, data); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Merge decodes data and merges it into m:
+// scalars are replaced, repeated fields appended to and messages merged.
+// Wire errors are *wire.Error with an offset relative to data.
+func (o UnmarshalOptions) Merge(m *Message, data []byte) error {
+	if o.Limits != nil {
+		if o.allocs == nil {
+			o.allocs = new(int)
+		}
+		if err := o.Limits.Check(data, stringTags(m.desc, true)...); err != nil {
+			return err
+		}
+		if err := o.Limits.Alloc(o.allocs, 1); err != nil {
+			return err
+		}
+	}
+	if o.Strict {
+		if err := wire.CheckStrict(data, stringTags(m.desc, false)...); err != nil {
+			return err
+		}
+	}
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			return err
+		}
+		if err := o.Limits.Alloc(o.allocs, 1); err != nil {
+			return shift(err, r.Offset)
+		}
+		f := m.FieldByNumber(r.Tag)
+		if f == nil && o.Extensions != nil {
+			f = o.Extensions.Find(m.desc.FullName(), r.Tag)
+		}
+		if f == nil {
+			m.UnknownFields.Add(r)
+			continue
+		}
+		ok, err := m.decodeField(f, r, o)
+		if err != nil {
+			return shift(err, start(r))
+		}
+		if !ok {
+			m.UnknownFields.Add(r)
+		}
+	}
+	return nil
+}
+
+// UnmarshalBinary decodes data and merges it into m, leaving extensions unknown.
+func (m *Message) UnmarshalBinary(data []byte) error {
+	return UnmarshalOptions{}.Merge(m, data)
+}
+
+// stringTags returns the tags of the string fields of desc, with the bytes fields if bytes is set.
+func stringTags(desc *descriptor.Message, bytes bool) []wire.TagNum {
+	var tags []wire.TagNum
+	for _, f := range desc.Field {
+		if f.Type == descriptor.TypeString || bytes && f.Type == descriptor.TypeBytes {
+			tags = append(tags, f.Tag)
+		}
+	}
+	return tags
+}
+
+// start returns the offset of r.Bytes within the data r was read from.
+func start(r wire.FieldRef) int {
+	return r.Offset + len(r.Raw) - len(r.Bytes) - keyLen(r)
+}
+
+// keyLen returns the length of the trailing end key of groups.
+func keyLen(r wire.FieldRef) int {
+	if r.Kind != wire.TagStart {
+		return 0
+	}
+	return wire.SizeVarint(uint64(r.Tag)<<3 | uint64(wire.TagEnd))
+}
+
+// shift adds off to the offset of a wire error from nested data.
+func shift(err error, off int) error {
+	var werr *wire.Error
+	if errors.As(err, &werr) {
+		werr.Offset += off
+	}
+	return err
+}
+
+// decodeField reports false if r does not match the wire type of f.
+func (m *Message) decodeField(f *descriptor.Field, r wire.FieldRef, o UnmarshalOptions) (bool, error) {
+	switch {
+	case f.Map != nil:
+		if r.Kind != wire.TagSequence {
+			return false, nil
+		}
+		return m.decodeMapEntry(f, r.Bytes, o)
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		if r.Kind != wire.TagSequence && r.Kind != wire.TagStart {
+			return false, nil
+		}
+		if f.MessageType == nil {
+			return true, &UnresolvedError{Field: f}
+		}
+		sub, _ := m.values[f.Tag].(*Message)
+		if sub == nil || f.Label == descriptor.LabelRepeated {
+			sub = New(f.MessageType)
+		}
+		o, err := o.nested()
+		if err != nil {
+			return true, err
+		}
+		if err := o.Merge(sub, r.Bytes); err != nil {
+			return true, err
+		}
+		m.store(f, sub)
+		return true, nil
+	default:
+	}
+	if f.Type == descriptor.TypeEnum && f.EnumType == nil {
+		return true, &UnresolvedError{Field: f}
+	}
+	kind := descriptor.WireClass(f.Type)
+	if f.Label == descriptor.LabelRepeated && f.Packable() && r.Kind == wire.TagSequence {
+		vs, err := unpack(f.Type, kind, r.Bytes)
+		if err != nil {
+			return true, err
+		}
+		if err := o.Limits.Alloc(o.allocs, len(vs)); err != nil {
+			return true, err
+		}
+		list, _ := m.values[f.Tag].([]any)
+		for _, v := range vs {
+			if known(f, v) {
+				list = append(list, v)
+				continue
+			}
+			// like protoc, unknown values of closed enums become unpacked unknown fields
+			m.UnknownFields = wire.AppendTag(m.UnknownFields, f.Tag, wire.TagUvarint)
+			m.UnknownFields = wire.AppendVarint(m.UnknownFields, wire.EncodeInt32(v.(int32)))
+		}
+		if list != nil {
+			m.set(f, list)
+		}
+		return true, nil
+	}
+	if r.Kind != kind {
+		return false, nil
+	}
+	v := scalar(f.Type, r.Value, r.Bytes)
+	if !known(f, v) {
+		return false, nil
+	}
+	m.store(f, v)
+	return true, nil
+}
+
+// known reports false for numbers that are not a value of closed enum fields,
+// open enums accept any number.
+func known(f *descriptor.Field, v any) bool {
+	if f.Type != descriptor.TypeEnum || !f.EnumType.IsClosed() {
+		return true
+	}
+	n := v.(int32)
+	return slices.ContainsFunc(f.EnumType.Value, func(ev *descriptor.EnumValue) bool {
+		return ev.Number == n
+	})
+}
+
+// store sets singular fields, clearing the other members of their oneof,
+// and appends to repeated ones.
+func (m *Message) store(f *descriptor.Field, v any) {
+	if f.Label != descriptor.LabelRepeated {
+		m.clearOneOf(f)
+		m.set(f, v)
+		return
+	}
+	list, _ := m.values[f.Tag].([]any)
+	m.set(f, append(list, v))
+}
+
+// unpack decodes a packed sequence of kind values.
+func unpack(typ uint8, kind wire.TagClass, b []byte) ([]any, error) {
+	var raw []uint64
+	var err error
+	switch kind {
+	case wire.TagUvarint:
+		raw, err = wire.UnpackVarints(b)
+	case wire.Tag32bit:
+		var vs []uint32
+		vs, err = wire.UnpackFixed32s(b)
+		for _, v := range vs {
+			raw = append(raw, uint64(v))
+		}
+	case wire.Tag64bit:
+		raw, err = wire.UnpackFixed64s(b)
+	default:
+	}
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]any, len(raw))
+	for i, v := range raw {
+		vs[i] = scalar(typ, v, nil)
+	}
+	return vs, nil
+}
+
+// decodeMapEntry decodes one key value pair of a map field, later entries replace earlier ones with the same key.
+// A missing key or value takes its default, a missing message value is an empty message.
+// Like protoc, entries with an unknown value of a closed enum are kept as unknown fields and reported as false.
+func (m *Message) decodeMapEntry(f *descriptor.Field, b []byte, o UnmarshalOptions) (bool, error) {
+	o, err := o.nested()
+	if err != nil {
+		return true, err
+	}
+	entry, err := o.Unmarshal(f.Map.Entry, b)
+	if err != nil {
+		return true, err
+	}
+	vf := f.Map.Value
+	if vf.Type == descriptor.TypeEnum && entry.UnknownFields.Has(vf.Tag) {
+		return false, nil
+	}
+	val := entry.GetField(vf)
+	if val == nil {
+		if vf.MessageType == nil {
+			return true, &UnresolvedError{Field: vf}
+		}
+		val = New(vf.MessageType)
+	}
+	mp, _ := m.values[f.Tag].(map[any]any)
+	if mp == nil {
+		mp = map[any]any{}
+		m.set(f, mp)
+	}
+	mp[entry.GetField(f.Map.Key)] = val
+	return true, nil
+}
@@ -0,0 +1,25 @@
+package dynamic_test
+
+import (
+	"testing"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+)
+
+func TestMaxDepth(t *testing.T) {
+	// a set with a file of a message nested in a message: the set, the file and the messages are at depths 0 to 3
+	set, err := descriptor.Marshal([]*descriptor.File{{Name: "a.proto", Message: []*descriptor.Message{{Name: "A", Nested: []*descriptor.Message{{Name: "B"}}}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := wellknown.Message("google.protobuf.FileDescriptorSet")
+	for depth := 1; depth <= 4; depth++ {
+		_, err := dynamic.UnmarshalOptions{MaxDepth: depth}.Unmarshal(desc, set)
+		_, perr := descriptor.ParseOptions{MaxDepth: depth}.Parse(set)
+		if ok := depth >= 3; (err == nil) != ok || (perr == nil) != ok {
+			t.Errorf("MaxDepth %d: Unmarshal error %v, Parse error %v, want success %v", depth, err, perr, ok)
+		}
+	}
+}
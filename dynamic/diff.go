@@ -0,0 +1,410 @@
+package dynamic
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// A Difference is a field that differs between two messages, found by Diff or DiffRaw.
+type Difference struct {
+	Kind string // "added", "removed" or "changed"
+	// Path from the top level message, like items[2].sku, labels["key"] or [ext.name], numbers for unknown fields.
+	// Elements of repeated fields have their index in the new message, removed ones in the old message.
+	Path string
+	Old  any `json:",omitempty"` // the value in the old message, nil if added
+	New  any `json:",omitempty"` // the value in the new message, nil if removed
+
+	Field *descriptor.Field `json:"-"` // of the values, nil for unknown fields
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case "changed":
+		return fmt.Sprintf("changed %s: %s -> %s", d.Path, formatValue(d.Field, d.Old), formatValue(d.Field, d.New))
+	case "removed":
+		return fmt.Sprintf("removed %s: %s", d.Path, formatValue(d.Field, d.Old))
+	default:
+	}
+	return fmt.Sprintf("%s %s: %s", d.Kind, d.Path, formatValue(d.Field, d.New))
+}
+
+// formatValue formats a scalar value or an empty message for Difference.String.
+func formatValue(f *descriptor.Field, v any) string {
+	switch v := v.(type) {
+	case *Message:
+		return "{}"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return strconv.Quote(string(v))
+	case int32:
+		if f != nil && f.Type == descriptor.TypeEnum && f.EnumType != nil {
+			for _, ev := range f.EnumType.Value {
+				if ev.Number == v {
+					return ev.Name
+				}
+			}
+		}
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+	}
+	return fmt.Sprint(v)
+}
+
+// Diff compares two messages of the same type and returns the differences of their fields by path,
+// in field number order. Added and removed messages are reported by their set fields, empty ones as {}.
+// The elements of repeated fields are aligned, an inserted element is added rather than changing the ones after it.
+// Map entries are compared by key, unknown fields like DiffRaw.
+func Diff(old, new *Message) []Difference {
+	var d differ
+	d.message("", old, new)
+	return d.out
+}
+
+// DiffRaw compares two encoded messages without their descriptors, see Diff.
+// Fields are compared by number and encoding: varints and fixed values as uint64 and length prefixed values
+// as []byte, both are compared as messages when they parse as ones. Every field is repeated, its index is left
+// out of the path when the number occurs once in both messages.
+func DiffRaw(old, new []byte) ([]Difference, error) {
+	for _, data := range [][]byte{old, new} {
+		for _, err := range wire.Fields(data) {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	var d differ
+	d.raw("", old, new)
+	return d.out, nil
+}
+
+type differ struct {
+	out []Difference
+}
+
+func (d *differ) add(kind, path string, f *descriptor.Field, old, new any) {
+	d.out = append(d.out, Difference{Kind: kind, Path: path, Old: old, New: new, Field: f})
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (d *differ) message(path string, old, new *Message) {
+	var fields []*descriptor.Field
+	for _, f := range append(old.Fields(), new.Fields()...) {
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	slices.SortFunc(fields, func(a, b *descriptor.Field) int { return cmp.Compare(a.Tag, b.Tag) })
+	for _, f := range fields {
+		name := f.Name
+		if f.Extendee != "" {
+			name = "[" + f.FullName() + "]"
+		}
+		p := join(path, name)
+		a, b := old.HasField(f), new.HasField(f)
+		switch {
+		case !a && !b:
+		case f.Map != nil:
+			x, _ := old.GetField(f).(map[any]any)
+			y, _ := new.GetField(f).(map[any]any)
+			d.entries(p, f, x, y)
+		case f.Label == descriptor.LabelRepeated:
+			x, _ := old.GetField(f).([]any)
+			y, _ := new.GetField(f).([]any)
+			d.list(p, f, x, y)
+		default:
+			d.value(p, f, a, b, old.GetField(f), new.GetField(f))
+		}
+	}
+	d.raw(path, old.UnknownFields, new.UnknownFields)
+}
+
+// value compares the values of a singular field, element or entry, a and b report if they are set.
+func (d *differ) value(path string, f *descriptor.Field, a, b bool, x, y any) {
+	switch {
+	case !b:
+		d.all("removed", path, f, x)
+	case !a:
+		d.all("added", path, f, y)
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		d.message(path, x.(*Message), y.(*Message))
+	case !equalValue(x, y):
+		d.add("changed", path, f, x, y)
+	default:
+	}
+}
+
+// all reports the set fields of an added or removed value.
+func (d *differ) all(kind, path string, f *descriptor.Field, v any) {
+	m, ok := v.(*Message)
+	if !ok {
+		if kind == "removed" {
+			d.add(kind, path, f, v, nil)
+		} else {
+			d.add(kind, path, f, nil, v)
+		}
+		return
+	}
+	empty := New(m.desc)
+	n := len(d.out)
+	if kind == "removed" {
+		d.message(path, m, empty)
+	} else {
+		d.message(path, empty, m)
+	}
+	if len(d.out) == n {
+		if kind == "removed" {
+			d.add(kind, path, f, m, nil)
+		} else {
+			d.add(kind, path, f, nil, m)
+		}
+	}
+}
+
+func (d *differ) entries(path string, f *descriptor.Field, old, new map[any]any) {
+	keys := SortedKeys(old)
+	for _, k := range SortedKeys(new) {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, compareKeys)
+	for _, k := range keys {
+		x, a := old[k]
+		y, b := new[k]
+		key := fmt.Sprint(k)
+		if s, ok := k.(string); ok {
+			key = strconv.Quote(s)
+		}
+		d.value(path+"["+key+"]", f.Map.Value, a, b, x, y)
+	}
+}
+
+func (d *differ) list(path string, f *descriptor.Field, old, new []any) {
+	for _, s := range align(len(old), len(new), func(i, j int) bool { return equalValue(old[i], new[j]) }) {
+		switch {
+		case s.j < 0:
+			d.all("removed", fmt.Sprintf("%s[%d]", path, s.i), f, old[s.i])
+		case s.i < 0:
+			d.all("added", fmt.Sprintf("%s[%d]", path, s.j), f, new[s.j])
+		default:
+			d.value(fmt.Sprintf("%s[%d]", path, s.j), f, true, true, old[s.i], new[s.j])
+		}
+	}
+}
+
+// raw compares the fields of encoded messages, which are well-formed.
+func (d *differ) raw(path string, old, new []byte) {
+	x, y := rawFields(old), rawFields(new)
+	var tags []wire.TagNum
+	for tag := range x {
+		tags = append(tags, tag)
+	}
+	for tag := range y {
+		if _, ok := x[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		a, b := x[tag], y[tag]
+		name := func(i int) string {
+			if len(a) <= 1 && len(b) <= 1 {
+				return join(path, strconv.FormatUint(uint64(tag), 10))
+			}
+			return fmt.Sprintf("%s[%d]", join(path, strconv.FormatUint(uint64(tag), 10)), i)
+		}
+		for _, s := range align(len(a), len(b), func(i, j int) bool { return bytes.Equal(a[i].Raw, b[j].Raw) }) {
+			switch {
+			case s.i >= 0 && s.j >= 0 && bytes.Equal(a[s.i].Raw, b[s.j].Raw):
+			case s.j < 0 && isNested(a[s.i]):
+				d.raw(name(s.i), a[s.i].Bytes, nil)
+			case s.j < 0:
+				d.add("removed", name(s.i), nil, refValue(a[s.i]), nil)
+			case s.i < 0 && isNested(b[s.j]):
+				d.raw(name(s.j), nil, b[s.j].Bytes)
+			case s.i < 0:
+				d.add("added", name(s.j), nil, nil, refValue(b[s.j]))
+			case a[s.i].Kind != b[s.j].Kind || a[s.i].Kind != wire.TagSequence && a[s.i].Kind != wire.TagStart:
+				d.add("changed", name(s.j), nil, refValue(a[s.i]), refValue(b[s.j]))
+			case isNested(a[s.i]) && isNested(b[s.j]):
+				d.raw(name(s.j), a[s.i].Bytes, b[s.j].Bytes)
+			default:
+				d.add("changed", name(s.j), nil, refValue(a[s.i]), refValue(b[s.j]))
+			}
+		}
+	}
+}
+
+func rawFields(data []byte) map[wire.TagNum][]wire.FieldRef {
+	fields := map[wire.TagNum][]wire.FieldRef{}
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			break
+		}
+		fields[r.Tag] = append(fields[r.Tag], r)
+	}
+	return fields
+}
+
+func refValue(r wire.FieldRef) any {
+	if r.Kind == wire.TagSequence || r.Kind == wire.TagStart {
+		return r.Bytes
+	}
+	return r.Value
+}
+
+// isNested reports whether a length prefixed value or group parses as a non-empty message.
+// Printable text is taken as a string, it may parse by chance.
+func isNested(r wire.FieldRef) bool {
+	if r.Kind == wire.TagStart {
+		return true
+	}
+	if len(r.Bytes) == 0 || !strings.ContainsFunc(string(r.Bytes), func(c rune) bool { return c < ' ' || c > '~' }) {
+		return false
+	}
+	for _, err := range wire.Fields(r.Bytes) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// A step of an alignment pairs element i of the old list with element j of the new one, -1 for none.
+type step struct{ i, j int }
+
+// maxAlign bounds the elements compared by align, beyond it the remaining elements pair by position.
+const maxAlign = 1 << 22
+
+// align returns the steps turning a list of n elements into one of m elements, keeping the longest common
+// subsequence of equal elements in pairs. Unequal elements between them pair up as changes.
+func align(n, m int, equal func(i, j int) bool) []step {
+	var steps []step
+	lo := 0
+	for lo < n && lo < m && equal(lo, lo) {
+		steps = append(steps, step{lo, lo})
+		lo++
+	}
+	hi := 0
+	for hi < n-lo && hi < m-lo && equal(n-1-hi, m-1-hi) {
+		hi++
+	}
+	a, b := n-lo-hi, m-lo-hi
+	var matches []step
+	if a > 0 && b > 0 && a*b <= maxAlign {
+		// lcs[i][j] is the length of the common subsequence of the elements from i and j on
+		lcs := make([][]int32, a+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, b+1)
+		}
+		for i := a - 1; i >= 0; i-- {
+			for j := b - 1; j >= 0; j-- {
+				if equal(lo+i, lo+j) {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < a && j < b; {
+			switch {
+			case equal(lo+i, lo+j):
+				matches = append(matches, step{lo + i, lo + j})
+				i, j = i+1, j+1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+	// the elements between matches pair up as changes, the rest are removed or added
+	i, j := lo, lo
+	gap := func(to, tj int) {
+		for ; i < to && j < tj; i, j = i+1, j+1 {
+			steps = append(steps, step{i, j})
+		}
+		for ; i < to; i++ {
+			steps = append(steps, step{i, -1})
+		}
+		for ; j < tj; j++ {
+			steps = append(steps, step{-1, j})
+		}
+	}
+	for _, s := range matches {
+		gap(s.i, s.j)
+		steps = append(steps, s)
+		i, j = s.i+1, s.j+1
+	}
+	gap(n-hi, m-hi)
+	for k := range hi {
+		steps = append(steps, step{n - hi + k, m - hi + k})
+	}
+	return steps
+}
+
+// equalValue reports whether two values of the same field are equal, NaN equals NaN.
+func equalValue(x, y any) bool {
+	switch x := x.(type) {
+	case *Message:
+		y, ok := y.(*Message)
+		return ok && equalMessage(x, y)
+	case []byte:
+		y, ok := y.([]byte)
+		return ok && bytes.Equal(x, y)
+	case []any:
+		y, ok := y.([]any)
+		return ok && slices.EqualFunc(x, y, equalValue)
+	case map[any]any:
+		y, ok := y.(map[any]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equalValue(v, w) {
+				return false
+			}
+		}
+		return true
+	case float32:
+		y, ok := y.(float32)
+		return ok && (x == y || math.IsNaN(float64(x)) && math.IsNaN(float64(y)))
+	case float64:
+		y, ok := y.(float64)
+		return ok && (x == y || math.IsNaN(x) && math.IsNaN(y))
+	default:
+	}
+	return x == y
+}
+
+func equalMessage(x, y *Message) bool {
+	if x.desc != y.desc || len(x.values) != len(y.values) || !bytes.Equal(x.UnknownFields, y.UnknownFields) {
+		return false
+	}
+	for tag, v := range x.values {
+		w, ok := y.values[tag]
+		if !ok || !equalValue(v, w) {
+			return false
+		}
+	}
+	return true
+}
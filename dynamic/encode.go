@@ -0,0 +1,177 @@
+package dynamic
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// MarshalBinary encodes m with the fields and extensions in number order followed by the unknown fields.
+// Repeated scalars are packed where the syntax or features say so,
+// map entries are sorted by key for a deterministic output.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	if err := m.encode(&e); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+func (m *Message) encode(e *wire.Encoder) error {
+	fields := append(slices.Clone(m.desc.Field), m.sortedExtensions()...)
+	slices.SortFunc(fields, func(a, b *descriptor.Field) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	for _, f := range fields {
+		v, ok := m.values[f.Tag]
+		if !ok {
+			continue
+		}
+		if err := encodeField(e, f, v); err != nil {
+			return err
+		}
+	}
+	e.EncodeRaw(m.UnknownFields)
+	return nil
+}
+
+func encodeField(e *wire.Encoder, f *descriptor.Field, v any) error {
+	switch {
+	case f.Map != nil:
+		mp := v.(map[any]any)
+		var err error
+		for _, k := range SortedKeys(mp) {
+			e.EncodeMessage(f.Tag, func(e *wire.Encoder) {
+				if err == nil {
+					err = encodeValue(e, f.Map.Key, k)
+				}
+				if err == nil {
+					err = encodeValue(e, f.Map.Value, mp[k])
+				}
+			})
+		}
+		return err
+	case f.Label == descriptor.LabelRepeated:
+		list := v.([]any)
+		if f.IsPacked() {
+			if len(list) > 0 {
+				e.EncodeBytes(f.Tag, appendPacked(nil, f, list))
+			}
+			return nil
+		}
+		for _, x := range list {
+			if err := encodeValue(e, f, x); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+	}
+	return encodeValue(e, f, v)
+}
+
+// encodeValue encodes a single value of f with its key.
+func encodeValue(e *wire.Encoder, f *descriptor.Field, v any) error {
+	switch v := v.(type) {
+	case *Message:
+		var err error
+		fn := func(e *wire.Encoder) { err = v.encode(e) }
+		if f.Type == descriptor.TypeGroup || f.Features().MessageEncoding == descriptor.MessageDelimited {
+			e.EncodeGroup(f.Tag, fn)
+		} else {
+			e.EncodeMessage(f.Tag, fn)
+		}
+		return err
+	case string:
+		e.EncodeString(f.Tag, v)
+		return nil
+	case []byte:
+		e.EncodeBytes(f.Tag, v)
+		return nil
+	default:
+	}
+	raw, ok := rawValue(f.Type, v)
+	if !ok {
+		return fmt.Errorf("dynamic: %T is not a valid value for field %s", v, f.Name)
+	}
+	switch descriptor.WireClass(f.Type) {
+	case wire.Tag32bit:
+		e.EncodeFixed32(f.Tag, uint32(raw))
+	case wire.Tag64bit:
+		e.EncodeFixed64(f.Tag, raw)
+	default:
+		e.EncodeVarint(f.Tag, raw)
+	}
+	return nil
+}
+
+// appendPacked appends the packed encoding of list without a length prefix.
+func appendPacked(b []byte, f *descriptor.Field, list []any) []byte {
+	for _, x := range list {
+		raw, _ := rawValue(f.Type, x)
+		switch descriptor.WireClass(f.Type) {
+		case wire.Tag32bit:
+			b = wire.AppendFixed32(b, uint32(raw))
+		case wire.Tag64bit:
+			b = wire.AppendFixed64(b, raw)
+		default:
+			b = wire.AppendVarint(b, raw)
+		}
+	}
+	return b
+}
+
+// rawValue is the inverse of scalar for the numeric types.
+func rawValue(typ uint8, v any) (uint64, bool) {
+	switch v := v.(type) {
+	case int32:
+		switch typ {
+		case descriptor.TypeSint32:
+			return wire.EncodeSint32(v), true
+		case descriptor.TypeSfixed32:
+			return uint64(uint32(v)), true
+		default:
+		}
+		return wire.EncodeInt32(v), true
+	case int64:
+		if typ == descriptor.TypeSint64 {
+			return wire.EncodeSint64(v), true
+		}
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float32:
+		return uint64(wire.EncodeFloat(v)), true
+	case float64:
+		return wire.EncodeDouble(v), true
+	case bool:
+		return wire.EncodeBool(v), true
+	default:
+	}
+	return 0, false
+}
+
+// compareKeys orders map keys, all of the same type.
+func compareKeys(a, b any) int {
+	switch a := a.(type) {
+	case int32:
+		return cmp.Compare(a, b.(int32))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case uint32:
+		return cmp.Compare(a, b.(uint32))
+	case uint64:
+		return cmp.Compare(a, b.(uint64))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case bool:
+		return cmp.Compare(wire.EncodeBool(a), wire.EncodeBool(b.(bool)))
+	default:
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package dynamic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// An ExtensionRegistry finds extension fields by their extended message and number.
+type ExtensionRegistry struct {
+	byNumber map[extensionKey]*descriptor.Field
+	byName   map[string]*descriptor.Field
+}
+
+type extensionKey struct {
+	extendee string // fully qualified, without leading dot
+	tag      wire.TagNum
+}
+
+// NewExtensionRegistry registers the extensions declared in files, at top level and in messages.
+// The files must be linked for the extendee names to be fully qualified.
+func NewExtensionRegistry(files []*descriptor.File) *ExtensionRegistry {
+	r := &ExtensionRegistry{byNumber: map[extensionKey]*descriptor.Field{}, byName: map[string]*descriptor.Field{}}
+	for _, f := range files {
+		for _, x := range f.Extension {
+			r.Add(x)
+		}
+		r.addMessages(f.Message)
+	}
+	return r
+}
+
+func (r *ExtensionRegistry) addMessages(msgs []*descriptor.Message) {
+	for _, m := range msgs {
+		for _, x := range m.Extension {
+			r.Add(x)
+		}
+		r.addMessages(m.Nested)
+	}
+}
+
+// Add registers an extension field, for conflicting extensions the first one wins.
+func (r *ExtensionRegistry) Add(x *descriptor.Field) {
+	key := extensionKey{strings.TrimPrefix(x.Extendee, "."), x.Tag}
+	if _, dup := r.byNumber[key]; !dup {
+		r.byNumber[key] = x
+	}
+	if name := x.FullName(); r.byName[name] == nil {
+		r.byName[name] = x
+	}
+}
+
+// Find returns the extension of the fully qualified message with the given number or nil.
+func (r *ExtensionRegistry) Find(extendee string, tag wire.TagNum) *descriptor.Field {
+	return r.byNumber[extensionKey{strings.TrimPrefix(extendee, "."), tag}]
+}
+
+// FindByName returns the extension with the fully qualified name, the leading dot is optional.
+func (r *ExtensionRegistry) FindByName(name string) *descriptor.Field {
+	return r.byName[strings.TrimPrefix(name, ".")]
+}
+
+// Options decodes the custom options kept in the unknown fields of an options struct
+// like *descriptor.FieldOptions, as a message of the google.protobuf options type.
+// The standard options are left to the struct, only extensions are set.
+func (o UnmarshalOptions) Options(opts any) (*Message, error) {
+	var name string
+	var unknown wire.UnknownFieldSet
+	switch opts := opts.(type) {
+	case *descriptor.FileOptions:
+		name, unknown = "FileOptions", opts.UnknownFields
+	case *descriptor.MessageOptions:
+		name, unknown = "MessageOptions", opts.UnknownFields
+	case *descriptor.FieldOptions:
+		name, unknown = "FieldOptions", opts.UnknownFields
+	case *descriptor.OneOfOptions:
+		name, unknown = "OneofOptions", opts.UnknownFields
+	case *descriptor.ExtensionRangeOptions:
+		name, unknown = "ExtensionRangeOptions", opts.UnknownFields
+	case *descriptor.EnumOptions:
+		name, unknown = "EnumOptions", opts.UnknownFields
+	case *descriptor.EnumValueOptions:
+		name, unknown = "EnumValueOptions", opts.UnknownFields
+	case *descriptor.ServiceOptions:
+		name, unknown = "ServiceOptions", opts.UnknownFields
+	case *descriptor.MethodOptions:
+		name, unknown = "MethodOptions", opts.UnknownFields
+	default:
+		return nil, fmt.Errorf("dynamic: %T is not an options type", opts)
+	}
+	return o.Unmarshal(wellknown.Message("google.protobuf."+name), unknown)
+}
@@ -0,0 +1,85 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// fuzzTypes are the types FuzzDecode decodes data as, picked by the first fuzzed argument:
+// descriptor.proto has nested, repeated and enum fields, Struct maps, oneofs and recursion.
+var fuzzTypes = []string{
+	"google.protobuf.FileDescriptorSet",
+	"google.protobuf.Struct",
+	"google.protobuf.Any",
+	"google.protobuf.Timestamp",
+	"google.protobuf.FieldOptions",
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add(uint8(0), wellknown.Set())
+	st := dynamic.New(wellknown.Message("google.protobuf.Struct"))
+	err := st.SetStructValue(map[string]any{"a": []any{1.5, "x", nil, true}, "b": map[string]any{"c": map[string]any{}}})
+	if err != nil {
+		f.Fatal(err)
+	}
+	b, err := st.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(uint8(1), b)
+	f.Add(uint8(1), append(b, b...))
+	f.Add(uint8(2), []byte("\n\x29type.googleapis.com/google.protobuf.Struct\x12\x00"))
+	f.Add(uint8(3), []byte{0x08, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0x0f})
+	f.Add(uint8(4), []byte{0x08, 0x05, 0x30, 0x01, 0xf8, 0x06, 0x07, 0x2a, 0x03, 0x01, 0x02, 0x09})
+	f.Fuzz(func(t *testing.T, typ uint8, data []byte) {
+		desc := wellknown.Message(fuzzTypes[int(typ)%len(fuzzTypes)])
+		m, err := dynamic.Unmarshal(desc, data)
+		if err != nil {
+			return
+		}
+		enc, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary of a decoded %s: %v", desc.FullName(), err)
+		}
+		if n := m.Size(); n != len(enc) {
+			t.Fatalf("Size = %d, the encoding has %d bytes", n, len(enc))
+		}
+		again := encode(t, desc, enc)
+		if !bytes.Equal(again, enc) {
+			t.Fatalf("the encoding changes after decoding it:\n% x\n% x", enc, again)
+		}
+		merged, err := dynamic.MergeRaw(desc, data, data)
+		if err != nil {
+			t.Fatalf("MergeRaw of decodable data: %v", err)
+		}
+		twice, err := dynamic.Unmarshal(desc, append(append([]byte{}, data...), data...))
+		if err != nil {
+			t.Fatalf("Unmarshal of the data repeated: %v", err)
+		}
+		want, err := twice.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := encode(t, desc, merged); !bytes.Equal(got, want) {
+			t.Fatalf("MergeRaw merges to\n% x\nwant\n% x", got, want)
+		}
+	})
+}
+
+// encode decodes data and encodes it again.
+func encode(t *testing.T, desc *descriptor.Message, data []byte) []byte {
+	t.Helper()
+	m, err := dynamic.Unmarshal(desc, data)
+	if err != nil {
+		t.Fatalf("Unmarshal of encoded %s: %v", desc.FullName(), err)
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,191 @@
+package dynamic
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// Merge merges src into dst, messages of the same type, like decoding the encoding of src after the one of dst:
+// the set scalars of src replace those of dst, with the other members of their oneof, messages are merged,
+// repeated fields appended to and map entries replace the ones with the same key. The values of src are copied,
+// its unknown fields appended.
+func Merge(dst, src *Message) error {
+	if dst.desc != src.desc {
+		return fmt.Errorf("dynamic: cannot merge %s into %s", src.desc.FullName(), dst.desc.FullName())
+	}
+	dst.merge(src)
+	return nil
+}
+
+// Clone returns a deep copy of m.
+func Clone(m *Message) *Message {
+	c := New(m.desc)
+	c.merge(m)
+	return c
+}
+
+func (m *Message) merge(src *Message) {
+	src.Range(func(f *descriptor.Field, v any) bool {
+		switch v := v.(type) {
+		case map[any]any:
+			mp, _ := m.values[f.Tag].(map[any]any)
+			if mp == nil {
+				mp = make(map[any]any, len(v))
+				m.set(f, mp)
+			}
+			for k, x := range v {
+				mp[k] = cloneValue(x)
+			}
+		case []any:
+			list, _ := m.values[f.Tag].([]any)
+			for _, x := range v {
+				list = append(list, cloneValue(x))
+			}
+			m.set(f, list)
+		case *Message:
+			sub, _ := m.values[f.Tag].(*Message)
+			if sub == nil {
+				sub = New(v.desc)
+				m.clearOneOf(f)
+				m.set(f, sub)
+			}
+			sub.merge(v)
+		default:
+			m.clearOneOf(f)
+			m.set(f, cloneValue(v))
+		}
+		return true
+	})
+	m.UnknownFields = append(m.UnknownFields, src.UnknownFields...)
+}
+
+// cloneValue copies the bytes and messages of a single value.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return bytes.Clone(v)
+	case *Message:
+		return Clone(v)
+	default:
+	}
+	return v
+}
+
+// MergeRaw merges the encoded messages dst and src of type desc, see UnmarshalOptions.MergeRaw.
+func MergeRaw(desc *descriptor.Message, dst, src []byte) ([]byte, error) {
+	return UnmarshalOptions{}.MergeRaw(desc, dst, src)
+}
+
+// MergeRaw returns the encoding of the message decoded from the concatenation of dst and src, messages of type desc,
+// working on the wire data: the last occurrence of a singular scalar is kept, the occurrences of a singular message
+// are merged into one and a oneof member drops the earlier ones of the others. Repeated, map and unknown fields keep
+// all their elements, extensions not in the registry of the options are unknown. The fields are in the order of their
+// first occurrence with their encoding. Wire errors are *wire.Error with an offset relative to dst followed by src.
+func (o UnmarshalOptions) MergeRaw(desc *descriptor.Message, dst, src []byte) ([]byte, error) {
+	return o.mergeRaw(nil, desc, []rawPart{{dst, 0}, {src, len(dst)}})
+}
+
+// A rawPart is encoded data at an offset of the merged data.
+type rawPart struct {
+	data []byte
+	base int
+}
+
+// A rawSlot is a field of a message merged by MergeRaw, the last occurrence of a scalar
+// or the bodies of the occurrences of a singular message.
+type rawSlot struct {
+	f     *descriptor.Field
+	ref   wire.FieldRef
+	parts []rawPart
+	drop  bool // by another member of its oneof
+}
+
+// mergeRaw appends the merge of the fields of parts to out.
+func (o UnmarshalOptions) mergeRaw(out []byte, desc *descriptor.Message, parts []rawPart) ([]byte, error) {
+	var slots []*rawSlot
+	singular := map[wire.TagNum]*rawSlot{}
+	for _, p := range parts {
+		for r, err := range wire.Fields(p.data) {
+			if err != nil {
+				return nil, shift(err, p.base)
+			}
+			r.Offset += p.base
+			f := field(desc, r.Tag)
+			if f == nil && o.Extensions != nil {
+				f = o.Extensions.Find(desc.FullName(), r.Tag)
+			}
+			keep, err := mergeable(f, r)
+			if err != nil {
+				return nil, shift(err, start(r))
+			}
+			if !keep {
+				slots = append(slots, &rawSlot{ref: r})
+				continue
+			}
+			s := singular[r.Tag]
+			if s == nil {
+				s = &rawSlot{f: f, ref: r}
+				slots = append(slots, s)
+				singular[r.Tag] = s
+			}
+			if f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup {
+				s.parts = append(s.parts, rawPart{r.Bytes, start(r)})
+			} else {
+				s.ref = r
+			}
+			if oo := f.RealOneOf(desc); oo != nil {
+				for _, x := range desc.Field {
+					if other := singular[x.Tag]; other != nil && x != f && x.RealOneOf(desc) == oo {
+						other.drop = true
+						delete(singular, x.Tag)
+					}
+				}
+			}
+		}
+	}
+	for _, s := range slots {
+		switch {
+		case s.drop:
+		case s.parts == nil:
+			out = append(out, s.ref.Raw...)
+		default:
+			o, err := o.nested()
+			if err != nil {
+				return nil, err
+			}
+			body, err := o.mergeRaw(nil, s.f.MessageType, s.parts)
+			if err != nil {
+				return nil, err
+			}
+			if s.ref.Kind == wire.TagStart {
+				out = wire.AppendTag(append(wire.AppendTag(out, s.ref.Tag, wire.TagStart), body...), s.ref.Tag, wire.TagEnd)
+			} else {
+				out = wire.AppendBytes(wire.AppendTag(out, s.ref.Tag, wire.TagSequence), body)
+			}
+		}
+	}
+	return out, nil
+}
+
+// mergeable reports whether r is an occurrence of the singular field f that is decoded, not left unknown.
+func mergeable(f *descriptor.Field, r wire.FieldRef) (bool, error) {
+	switch {
+	case f == nil, f.Label == descriptor.LabelRepeated:
+		return false, nil
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		if r.Kind != wire.TagSequence && r.Kind != wire.TagStart {
+			return false, nil
+		}
+		if f.MessageType == nil {
+			return false, &UnresolvedError{Field: f}
+		}
+		return true, nil
+	case f.Type == descriptor.TypeEnum && f.EnumType == nil:
+		return false, &UnresolvedError{Field: f}
+	default:
+	}
+	return r.Kind == descriptor.WireClass(f.Type) && known(f, scalar(f.Type, r.Value, r.Bytes)), nil
+}
@@ -0,0 +1,137 @@
+// Package dynamic decodes messages at runtime, driven by linked descriptors
+// instead of generated code.
+package dynamic
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// A Message holds the fields of a message of a descriptor type.
+//
+// Field values are typed by the field type:
+// int32 for int32, sint32, sfixed32 and enums, int64 for int64, sint64 and sfixed64,
+// uint32 for uint32 and fixed32, uint64 for uint64 and fixed64,
+// float32, float64, bool, string, []byte and *Message for messages and groups.
+// Repeated fields hold a []any of those, map fields a map[any]any keyed by the key value.
+// Extensions are accessed with the field accessors too, passing their descriptors.
+// Setting a member of a oneof clears the others.
+//
+// The descriptors must have been linked by descriptor.Link to resolve message and enum types.
+type Message struct {
+	desc       *descriptor.Message
+	values     map[wire.TagNum]any
+	extensions map[wire.TagNum]*descriptor.Field // of the set extensions
+
+	UnknownFields wire.UnknownFieldSet // fields not in the descriptor or with a mismatched wire type
+}
+
+// New returns an empty message of type desc.
+func New(desc *descriptor.Message) *Message {
+	return &Message{desc: desc, values: map[wire.TagNum]any{}}
+}
+
+// Unmarshal decodes data as a message of type desc, leaving extensions unknown.
+func Unmarshal(desc *descriptor.Message, data []byte) (*Message, error) {
+	return UnmarshalOptions{}.Unmarshal(desc, data)
+}
+
+// Descriptor returns the message type.
+func (m *Message) Descriptor() *descriptor.Message {
+	return m.desc
+}
+
+// FieldByName returns the field of the message type with the given name or nil.
+func (m *Message) FieldByName(name string) *descriptor.Field {
+	for _, f := range m.desc.Field {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FieldByNumber returns the field of the message type with the given number or nil.
+func (m *Message) FieldByNumber(tag wire.TagNum) *descriptor.Field {
+	for _, f := range m.desc.Field {
+		if f.Tag == tag {
+			return f
+		}
+	}
+	return nil
+}
+
+// Has reports whether the named field is set, repeated fields are set if not empty.
+func (m *Message) Has(name string) bool {
+	f := m.FieldByName(name)
+	return f != nil && m.HasField(f)
+}
+
+// HasField reports whether f is set.
+func (m *Message) HasField(f *descriptor.Field) bool {
+	_, ok := m.values[f.Tag]
+	return ok
+}
+
+// Get returns the value of the named field, its default if unset
+// and nil for unknown names.
+func (m *Message) Get(name string) any {
+	f := m.FieldByName(name)
+	if f == nil {
+		return nil
+	}
+	return m.GetField(f)
+}
+
+// GetField returns the value of f or its default if unset:
+// the default value of scalars, nil for messages, repeated and map fields.
+func (m *Message) GetField(f *descriptor.Field) any {
+	if v, ok := m.values[f.Tag]; ok {
+		return v
+	}
+	return Default(f)
+}
+
+// Range calls fn for the set fields in declaration order,
+// followed by the set extensions in number order, until it returns false.
+func (m *Message) Range(fn func(f *descriptor.Field, v any) bool) {
+	for _, f := range m.desc.Field {
+		if v, ok := m.values[f.Tag]; ok && !fn(f, v) {
+			return
+		}
+	}
+	for _, x := range m.sortedExtensions() {
+		if !fn(x, m.values[x.Tag]) {
+			return
+		}
+	}
+}
+
+func (m *Message) sortedExtensions() []*descriptor.Field {
+	xs := slices.Collect(maps.Values(m.extensions))
+	slices.SortFunc(xs, func(a, b *descriptor.Field) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	return xs
+}
+
+// set sets the value of a field or extension.
+func (m *Message) set(f *descriptor.Field, v any) {
+	m.values[f.Tag] = v
+	if f.Extendee != "" {
+		if m.extensions == nil {
+			m.extensions = map[wire.TagNum]*descriptor.Field{}
+		}
+		m.extensions[f.Tag] = f
+	}
+}
+
+// clear unsets a field or extension.
+func (m *Message) clear(f *descriptor.Field) {
+	delete(m.values, f.Tag)
+	delete(m.extensions, f.Tag)
+}
@@ -0,0 +1,228 @@
+package dynamic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// Set sets the named field, see SetField.
+func (m *Message) Set(name string, v any) error {
+	f := m.FieldByName(name)
+	if f == nil {
+		return fmt.Errorf("dynamic: %s has no field %s", m.desc.Name, name)
+	}
+	return m.SetField(f, v)
+}
+
+// SetField sets f to v, which must have the Go type of the field as documented on Message.
+// Repeated and map values are used as is, not copied.
+// Setting a field without presence to its zero value clears it.
+func (m *Message) SetField(f *descriptor.Field, v any) error {
+	if err := m.extends(f); err != nil {
+		return err
+	}
+	if !m.valid(f, v) {
+		return fmt.Errorf("dynamic: %T is not a valid value for field %s", v, f.Name)
+	}
+	if !f.HasPresence() && isZero(v) {
+		m.clear(f)
+		return nil
+	}
+	m.clearOneOf(f)
+	m.set(f, v)
+	return nil
+}
+
+// Put sets an entry of the named map field, see PutField.
+func (m *Message) Put(name string, key, v any) error {
+	f := m.FieldByName(name)
+	if f == nil {
+		return fmt.Errorf("dynamic: %s has no field %s", m.desc.Name, name)
+	}
+	return m.PutField(f, key, v)
+}
+
+// PutField sets the entry of the map field f with the given key to v.
+func (m *Message) PutField(f *descriptor.Field, key, v any) error {
+	if f.Map == nil {
+		return fmt.Errorf("dynamic: field %s is not a map field", f.Name)
+	}
+	if !validElem(f.Map.Key, key) || !validElem(f.Map.Value, v) {
+		return fmt.Errorf("dynamic: %T to %T is not a valid entry for field %s", key, v, f.Name)
+	}
+	mp, _ := m.values[f.Tag].(map[any]any)
+	if mp == nil {
+		mp = map[any]any{}
+		m.set(f, mp)
+	}
+	mp[key] = v
+	return nil
+}
+
+// DeleteKey removes an entry of the named map field.
+func (m *Message) DeleteKey(name string, key any) {
+	if f := m.FieldByName(name); f != nil {
+		m.DeleteKeyField(f, key)
+	}
+}
+
+// DeleteKeyField removes the entry of the map field f with the given key,
+// the field is cleared with its last entry.
+func (m *Message) DeleteKeyField(f *descriptor.Field, key any) {
+	mp, _ := m.values[f.Tag].(map[any]any)
+	delete(mp, key)
+	if len(mp) == 0 {
+		m.clear(f)
+	}
+}
+
+// WhichOneOf returns the set member of the named oneof or nil.
+func (m *Message) WhichOneOf(name string) *descriptor.Field {
+	for i, o := range m.desc.OneOf {
+		if o.Name != name {
+			continue
+		}
+		for _, f := range m.desc.Field {
+			if f.OneOfIndex != nil && *f.OneOfIndex == int32(i) && m.HasField(f) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// clearOneOf clears the other members of the real oneof of f.
+func (m *Message) clearOneOf(f *descriptor.Field) {
+	if f.RealOneOf(m.desc) == nil {
+		return
+	}
+	for _, g := range m.desc.Field {
+		if g != f && g.OneOfIndex != nil && *g.OneOfIndex == *f.OneOfIndex {
+			delete(m.values, g.Tag)
+		}
+	}
+}
+
+// Add appends v to the named repeated field, see AddField.
+func (m *Message) Add(name string, v any) error {
+	f := m.FieldByName(name)
+	if f == nil {
+		return fmt.Errorf("dynamic: %s has no field %s", m.desc.Name, name)
+	}
+	return m.AddField(f, v)
+}
+
+// AddField appends v to the repeated non-map field f.
+func (m *Message) AddField(f *descriptor.Field, v any) error {
+	if f.Label != descriptor.LabelRepeated || f.Map != nil {
+		return fmt.Errorf("dynamic: field %s is not a repeated field", f.Name)
+	}
+	if err := m.extends(f); err != nil {
+		return err
+	}
+	if !validElem(f, v) {
+		return fmt.Errorf("dynamic: %T is not a valid value for field %s", v, f.Name)
+	}
+	m.store(f, v)
+	return nil
+}
+
+// Clear unsets the named field, unknown names are ignored.
+func (m *Message) Clear(name string) {
+	if f := m.FieldByName(name); f != nil {
+		m.ClearField(f)
+	}
+}
+
+// ClearField unsets f.
+func (m *Message) ClearField(f *descriptor.Field) {
+	m.clear(f)
+}
+
+// extends checks that an extension field extends the type of m.
+func (m *Message) extends(f *descriptor.Field) error {
+	if f.Extendee == "" || strings.TrimPrefix(f.Extendee, ".") == m.desc.FullName() {
+		return nil
+	}
+	return fmt.Errorf("dynamic: extension %s does not extend %s", f.FullName(), m.desc.FullName())
+}
+
+// valid reports whether v has the Go type of f.
+func (m *Message) valid(f *descriptor.Field, v any) bool {
+	switch {
+	case f.Map != nil:
+		mp, ok := v.(map[any]any)
+		if !ok {
+			return false
+		}
+		for k, val := range mp {
+			if !validElem(f.Map.Key, k) || !validElem(f.Map.Value, val) {
+				return false
+			}
+		}
+		return true
+	case f.Label == descriptor.LabelRepeated:
+		list, ok := v.([]any)
+		if !ok {
+			return false
+		}
+		for _, x := range list {
+			if !validElem(f, x) {
+				return false
+			}
+		}
+		return true
+	default:
+	}
+	return validElem(f, v)
+}
+
+// validElem reports whether v is a single value of the type of f.
+func validElem(f *descriptor.Field, v any) bool {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		sub, ok := v.(*Message)
+		return ok && sub != nil && (f.MessageType == nil || sub.desc == f.MessageType)
+	case descriptor.TypeBytes:
+		_, ok := v.([]byte)
+		return ok
+	default:
+	}
+	zero := scalar(f.Type, 0, nil)
+	return zero != nil && reflect.TypeOf(zero) == reflect.TypeOf(v)
+}
+
+// isZero reports whether v is a zero scalar or an empty list or map,
+// negative zero floats are not zero as their encoding differs.
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case int32:
+		return v == 0
+	case int64:
+		return v == 0
+	case uint32:
+		return v == 0
+	case uint64:
+		return v == 0
+	case float32:
+		return math.Float32bits(v) == 0
+	case float64:
+		return math.Float64bits(v) == 0
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case []byte:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	case map[any]any:
+		return len(v) == 0
+	default:
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package dynamic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// GetByName returns the value of a field of the message data of type desc at a path of field names,
+// see UnmarshalOptions.GetByName.
+func GetByName(data []byte, desc *descriptor.Message, path string) (any, error) {
+	return UnmarshalOptions{}.GetByName(data, desc, path)
+}
+
+// GetByName returns the value of a field of the message data of type desc at a path of field names
+// joined by dots, like "customer.address.city", decoding only that field, with the Go type of Message.Get.
+// The fields before the last one are singular messages, [full.name] stands for an extension of the options.
+// Unset fields have their default like in Message.GetField, wire errors are *wire.Error with an offset relative to data.
+func (o UnmarshalOptions) GetByName(data []byte, desc *descriptor.Message, path string) (any, error) {
+	fields, err := o.resolvePath(desc, path)
+	if err != nil {
+		return nil, err
+	}
+	// the fields of the same oneof clear the ones on the path
+	others := make([][]wire.TagNum, len(fields))
+	parent := desc
+	for i, f := range fields {
+		if oo := f.RealOneOf(parent); oo != nil {
+			for _, x := range parent.Field {
+				if x != f && x.RealOneOf(parent) == oo {
+					others[i] = append(others[i], x.Tag)
+				}
+			}
+		}
+		parent = f.MessageType
+	}
+	var refs []wire.FieldRef
+	err = wire.Walk(data, wire.VisitFunc(func(p []wire.FieldRef, r wire.FieldRef) wire.Action {
+		d := len(p)
+		switch {
+		case r.Tag == fields[d].Tag && d == len(fields)-1:
+			refs = append(refs, r)
+		case r.Tag == fields[d].Tag:
+			return wire.Descend
+		case slices.Contains(others[d], r.Tag):
+			refs = refs[:0]
+		default:
+		}
+		return wire.Continue
+	}))
+	if err != nil {
+		return nil, err
+	}
+	last := fields[len(fields)-1]
+	m := New(desc)
+	if len(fields) > 1 {
+		m = New(fields[len(fields)-2].MessageType)
+	}
+	for _, r := range refs {
+		if _, err := m.decodeField(last, r, o); err != nil {
+			return nil, shift(err, start(r))
+		}
+	}
+	return m.GetField(last), nil
+}
+
+// resolvePath returns the fields named by the components of path.
+func (o UnmarshalOptions) resolvePath(desc *descriptor.Message, path string) ([]*descriptor.Field, error) {
+	var fields []*descriptor.Field
+	m := desc
+	for _, name := range splitPath(path) {
+		if m == nil {
+			return nil, fmt.Errorf("dynamic: path %s: field %s is not a singular message", path, fields[len(fields)-1].FullName())
+		}
+		var f *descriptor.Field
+		if ext, ok := strings.CutPrefix(name, "["); ok && o.Extensions != nil {
+			f = o.Extensions.FindByName(strings.TrimSuffix(ext, "]"))
+			if f != nil && strings.TrimPrefix(f.Extendee, ".") != m.FullName() {
+				f = nil
+			}
+		} else {
+			for _, x := range m.Field {
+				if x.Name == name {
+					f = x
+				}
+			}
+		}
+		if f == nil {
+			return nil, fmt.Errorf("dynamic: path %s: %s has no field %s", path, m.FullName(), name)
+		}
+		fields = append(fields, f)
+		m = nil
+		if f.Label != descriptor.LabelRepeated && (f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup) {
+			if f.MessageType == nil {
+				return nil, &UnresolvedError{Field: f}
+			}
+			m = f.MessageType
+		}
+	}
+	return fields, nil
+}
+
+// splitPath splits path at the dots outside of brackets.
+func splitPath(path string) []string {
+	var names []string
+	depth, last := 0, 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				names = append(names, path[last:i])
+				last = i + 1
+			}
+		default:
+		}
+	}
+	return append(names, path[last:])
+}
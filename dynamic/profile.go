@@ -0,0 +1,116 @@
+package dynamic
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// A Profile attributes the bytes of encoded messages of a type to their fields, to find the fields
+// that dominate the size of a corpus. The bytes of a field are those of its keys, length prefixes
+// and values, including the ones of its nested fields.
+type Profile struct {
+	Messages int   // added
+	Bytes    int64 // of the added messages
+
+	desc  *descriptor.Message
+	exts  *ExtensionRegistry
+	sizes map[string]*FieldSize
+}
+
+// FieldSize is the share of a field in the messages of a profile.
+type FieldSize struct {
+	Path        string // field names from the top level message joined by dots, [full.name] for extensions and numbers for unknown fields
+	Bytes       int64
+	Occurrences int // of encoded fields, an element of a packed field is not one
+	Messages    int // of the added messages containing the field
+}
+
+// NewProfile returns an empty profile of messages of type desc, exts may be nil.
+func NewProfile(desc *descriptor.Message, exts *ExtensionRegistry) *Profile {
+	return &Profile{desc: desc, exts: exts, sizes: map[string]*FieldSize{}}
+}
+
+// Add adds an encoded message, on a wire error the profile is left unchanged.
+func (p *Profile) Add(data []byte) error {
+	sizes := map[string]*FieldSize{}
+	if err := p.add(sizes, p.desc, "", data); err != nil {
+		return err
+	}
+	p.Messages++
+	p.Bytes += int64(len(data))
+	for path, s := range sizes {
+		t := p.sizes[path]
+		if t == nil {
+			t = &FieldSize{Path: path}
+			p.sizes[path] = t
+		}
+		t.Bytes += s.Bytes
+		t.Occurrences += s.Occurrences
+		t.Messages++
+	}
+	return nil
+}
+
+func (p *Profile) add(sizes map[string]*FieldSize, desc *descriptor.Message, prefix string, data []byte) error {
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			return err
+		}
+		f := p.field(desc, r.Tag)
+		path := prefix + fmt.Sprint(r.Tag)
+		switch {
+		case f == nil:
+		case f.Extendee != "":
+			path = prefix + "[" + f.FullName() + "]"
+		default:
+			path = prefix + f.Name
+		}
+		s := sizes[path]
+		if s == nil {
+			s = &FieldSize{Path: path}
+			sizes[path] = s
+		}
+		s.Bytes += int64(len(r.Raw))
+		s.Occurrences++
+		if f == nil || f.MessageType == nil {
+			continue
+		}
+		switch {
+		case f.Type == descriptor.TypeGroup && r.Kind == wire.TagStart, f.Type == descriptor.TypeMessage && r.Kind == wire.TagSequence:
+			if err := p.add(sizes, f.MessageType, path+".", r.Bytes); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		default:
+		}
+	}
+	return nil
+}
+
+// field returns the field or extension of desc with the number, nil if it is unknown.
+func (p *Profile) field(desc *descriptor.Message, tag wire.TagNum) *descriptor.Field {
+	for _, f := range desc.Field {
+		if f.Tag == tag {
+			return f
+		}
+	}
+	if p.exts != nil {
+		return p.exts.Find(desc.FullName(), tag)
+	}
+	return nil
+}
+
+// Sizes returns the sizes of the fields in the added messages, the largest first.
+func (p *Profile) Sizes() []*FieldSize {
+	var out []*FieldSize
+	for _, s := range p.sizes {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b *FieldSize) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Path, b.Path))
+	})
+	return out
+}
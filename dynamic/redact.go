@@ -0,0 +1,191 @@
+package dynamic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// A Redactor removes or blanks out the sensitive fields of encoded messages, like personal data
+// before logging payloads. It works on the wire data: the other fields, unknown ones included,
+// are kept byte for byte. A Redactor caches the redacted fields, it is not safe for concurrent use.
+type Redactor struct {
+	// Replace keeps the fields with a placeholder instead of removing them: strings and bytes are
+	// set to Placeholder, numbers to zero, messages and packed fields to empty ones and map values
+	// are replaced keeping their keys.
+	Replace     bool
+	Placeholder string
+
+	exts    *ExtensionRegistry
+	option  *descriptor.Field // of FieldOptions marking the fields
+	names   map[string]bool
+	needs   map[*descriptor.Message]bool // whether a message and the ones in it have fields to redact
+	redacts map[*descriptor.Field]bool
+}
+
+// NewRedactor returns a redactor of the fields with the debug_redact option, of the ones setting
+// the FieldOptions extension option, like "acme.sensitive", to true or another non-zero value,
+// and of the fields with the full names, like "shop.Order.email".
+// exts resolves the option, which may be empty, and the extensions of the redacted messages.
+func NewRedactor(exts *ExtensionRegistry, option string, names ...string) (*Redactor, error) {
+	r := &Redactor{exts: exts, names: map[string]bool{}, needs: map[*descriptor.Message]bool{}, redacts: map[*descriptor.Field]bool{}}
+	if option != "" {
+		if exts != nil {
+			r.option = exts.FindByName(option)
+		}
+		if r.option == nil {
+			return nil, fmt.Errorf("dynamic: unknown extension %s", option)
+		}
+		if extendee := strings.TrimPrefix(r.option.Extendee, "."); extendee != "google.protobuf.FieldOptions" {
+			return nil, fmt.Errorf("dynamic: %s extends %s, not google.protobuf.FieldOptions", option, extendee)
+		}
+	}
+	for _, name := range names {
+		r.names[name] = true
+	}
+	return r, nil
+}
+
+// Redacts reports whether f is redacted.
+func (r *Redactor) Redacts(f *descriptor.Field) bool {
+	v, ok := r.redacts[f]
+	if !ok {
+		v = r.selects(f)
+		r.redacts[f] = v
+	}
+	return v
+}
+
+func (r *Redactor) selects(f *descriptor.Field) bool {
+	if r.names[f.FullName()] {
+		return true
+	}
+	if f.Options == nil {
+		return false
+	}
+	if f.Options.DebugRedact {
+		return true
+	}
+	if r.option == nil || !f.Options.UnknownFields.Has(r.option.Tag) {
+		return false
+	}
+	opts, err := UnmarshalOptions{Extensions: r.exts}.Options(f.Options)
+	if err != nil || !opts.HasField(r.option) {
+		return false
+	}
+	return !isZero(opts.GetField(r.option))
+}
+
+// Redact returns a copy of the message data of type desc with the redacted fields removed or replaced,
+// data itself if nothing is redacted. Wire errors are *wire.Error with an offset relative to data.
+func (r *Redactor) Redact(desc *descriptor.Message, data []byte) ([]byte, error) {
+	if !r.need(desc) {
+		return data, nil
+	}
+	return r.message(nil, desc, nil, data)
+}
+
+// need reports whether messages of type desc may hold fields to redact.
+func (r *Redactor) need(desc *descriptor.Message) bool {
+	if v, ok := r.needs[desc]; ok {
+		return v
+	}
+	seen := map[*descriptor.Message]bool{}
+	var walk func(m *descriptor.Message) bool
+	walk = func(m *descriptor.Message) bool {
+		if seen[m] {
+			return false
+		}
+		seen[m] = true
+		fields := m.Field
+		if r.exts != nil {
+			for k, x := range r.exts.byNumber {
+				if k.extendee == m.FullName() {
+					fields = append(fields[:len(fields):len(fields)], x)
+				}
+			}
+		}
+		for _, f := range fields {
+			if r.Redacts(f) || f.MessageType != nil && walk(f.MessageType) {
+				return true
+			}
+		}
+		return false
+	}
+	v := walk(desc)
+	r.needs[desc] = v
+	return v
+}
+
+// message appends the redacted fields of data to out, value is the value field of a map entry to replace.
+func (r *Redactor) message(out []byte, desc *descriptor.Message, value *descriptor.Field, data []byte) ([]byte, error) {
+	for ref, err := range wire.Fields(data) {
+		if err != nil {
+			return nil, err
+		}
+		f := field(desc, ref.Tag)
+		if f == nil && r.exts != nil {
+			f = r.exts.Find(desc.FullName(), ref.Tag)
+		}
+		switch {
+		case f == nil:
+			out = append(out, ref.Raw...)
+		case f == value || r.Redacts(f) && !(r.Replace && f.Map != nil):
+			if r.Replace {
+				out = r.replace(out, f, ref)
+			}
+		case f.Map != nil && ref.Kind == wire.TagSequence && r.Redacts(f):
+			entry, err := r.message(nil, f.Map.Entry, f.Map.Value, ref.Bytes)
+			if err != nil {
+				return nil, shift(err, start(ref))
+			}
+			out = wire.AppendBytes(wire.AppendTag(out, f.Tag, wire.TagSequence), entry)
+		case f.MessageType != nil && ref.Kind == wire.TagSequence && r.need(f.MessageType):
+			sub, err := r.message(nil, f.MessageType, nil, ref.Bytes)
+			if err != nil {
+				return nil, shift(err, start(ref))
+			}
+			out = wire.AppendBytes(wire.AppendTag(out, f.Tag, wire.TagSequence), sub)
+		case f.MessageType != nil && ref.Kind == wire.TagStart && r.need(f.MessageType):
+			out = wire.AppendTag(out, f.Tag, wire.TagStart)
+			if out, err = r.message(out, f.MessageType, nil, ref.Bytes); err != nil {
+				return nil, shift(err, start(ref))
+			}
+			out = wire.AppendTag(out, f.Tag, wire.TagEnd)
+		default:
+			out = append(out, ref.Raw...)
+		}
+	}
+	return out, nil
+}
+
+// replace appends the placeholder of a redacted field in the wire type of ref.
+func (r *Redactor) replace(out []byte, f *descriptor.Field, ref wire.FieldRef) []byte {
+	out = wire.AppendTag(out, ref.Tag, ref.Kind)
+	switch ref.Kind {
+	case wire.TagUvarint:
+		return wire.AppendVarint(out, 0)
+	case wire.Tag32bit:
+		return append(out, 0, 0, 0, 0)
+	case wire.Tag64bit:
+		return append(out, 0, 0, 0, 0, 0, 0, 0, 0)
+	case wire.TagStart:
+		return wire.AppendTag(out, ref.Tag, wire.TagEnd)
+	default:
+	}
+	if f.Type == descriptor.TypeString || f.Type == descriptor.TypeBytes {
+		return wire.AppendString(out, r.Placeholder)
+	}
+	return wire.AppendBytes(out, nil) // empty message or packed field
+}
+
+func field(desc *descriptor.Message, tag wire.TagNum) *descriptor.Field {
+	for _, f := range desc.Field {
+		if f.Tag == tag {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package dynamic
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// Reflection is the generic view of a message, modeled after protoreflect.Message,
+// to write validators, redactors or loggers once for all message types.
+//
+// Values have the Go types documented on Message. GetField returns the stored value or default,
+// Mutable a reference to a message, *List or *Map field that can be modified in place.
+type Reflection interface {
+	Descriptor() *descriptor.Message
+	Fields() []*descriptor.Field
+	HasField(f *descriptor.Field) bool
+	GetField(f *descriptor.Field) any
+	SetField(f *descriptor.Field, v any) error
+	ClearField(f *descriptor.Field)
+	Mutable(f *descriptor.Field) any
+	NewField(f *descriptor.Field) any
+	WhichOneOf(name string) *descriptor.Field
+	Range(fn func(f *descriptor.Field, v any) bool)
+	Unknown() wire.UnknownFieldSet
+	SetUnknown(b wire.UnknownFieldSet)
+}
+
+var _ Reflection = (*Message)(nil)
+
+// Fields returns the fields of the message type followed by the set extensions.
+func (m *Message) Fields() []*descriptor.Field {
+	return append(slices.Clone(m.desc.Field), m.sortedExtensions()...)
+}
+
+// Mutable returns a modifiable reference to a composite field, setting it to an empty value first if unset:
+// a *Message for message fields, a *List for repeated and a *Map for map fields.
+// It panics for scalar fields.
+func (m *Message) Mutable(f *descriptor.Field) any {
+	switch {
+	case f.Map != nil:
+		return &Map{m: m, f: f}
+	case f.Label == descriptor.LabelRepeated:
+		return &List{m: m, f: f}
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		if sub, ok := m.values[f.Tag].(*Message); ok {
+			return sub
+		}
+		sub := m.NewField(f).(*Message)
+		m.clearOneOf(f)
+		m.set(f, sub)
+		return sub
+	default:
+	}
+	panic(fmt.Sprintf("dynamic: Mutable of scalar field %s", f.Name))
+}
+
+// NewField returns a new value for f: an empty message, list or map, or the default of scalars.
+func (m *Message) NewField(f *descriptor.Field) any {
+	switch {
+	case f.Map != nil:
+		return map[any]any{}
+	case f.Label == descriptor.LabelRepeated:
+		return []any{}
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		return New(f.MessageType)
+	default:
+	}
+	return Default(f)
+}
+
+// Unknown returns the unknown fields.
+func (m *Message) Unknown() wire.UnknownFieldSet {
+	return m.UnknownFields
+}
+
+// SetUnknown replaces the unknown fields.
+func (m *Message) SetUnknown(b wire.UnknownFieldSet) {
+	m.UnknownFields = b
+}
+
+// A List references a repeated field of a message.
+type List struct {
+	m *Message
+	f *descriptor.Field
+}
+
+func (l *List) list() []any {
+	list, _ := l.m.values[l.f.Tag].([]any)
+	return list
+}
+
+// Len returns the number of elements.
+func (l *List) Len() int {
+	return len(l.list())
+}
+
+// Get returns the element at index i.
+func (l *List) Get(i int) any {
+	return l.list()[i]
+}
+
+// Set replaces the element at index i.
+func (l *List) Set(i int, v any) error {
+	if !validElem(l.f, v) {
+		return fmt.Errorf("dynamic: %T is not a valid value for field %s", v, l.f.Name)
+	}
+	l.list()[i] = v
+	return nil
+}
+
+// Append appends v.
+func (l *List) Append(v any) error {
+	return l.m.AddField(l.f, v)
+}
+
+// Truncate shortens the list to n elements, clearing the field for 0.
+func (l *List) Truncate(n int) {
+	if n == 0 {
+		l.m.clear(l.f)
+		return
+	}
+	l.m.set(l.f, l.list()[:n])
+}
+
+// A Map references a map field of a message.
+type Map struct {
+	m *Message
+	f *descriptor.Field
+}
+
+func (mp *Map) entries() map[any]any {
+	entries, _ := mp.m.values[mp.f.Tag].(map[any]any)
+	return entries
+}
+
+// Len returns the number of entries.
+func (mp *Map) Len() int {
+	return len(mp.entries())
+}
+
+// Has reports whether there is an entry for key.
+func (mp *Map) Has(key any) bool {
+	_, ok := mp.entries()[key]
+	return ok
+}
+
+// Get returns the value for key or nil.
+func (mp *Map) Get(key any) any {
+	return mp.entries()[key]
+}
+
+// Set sets the value for key.
+func (mp *Map) Set(key, v any) error {
+	return mp.m.PutField(mp.f, key, v)
+}
+
+// Delete removes the entry for key.
+func (mp *Map) Delete(key any) {
+	mp.m.DeleteKeyField(mp.f, key)
+}
+
+// Range calls fn for the entries in key order until it returns false.
+func (mp *Map) Range(fn func(key, v any) bool) {
+	entries := mp.entries()
+	for _, k := range SortedKeys(entries) {
+		if !fn(k, entries[k]) {
+			return
+		}
+	}
+}
+
+// SortedKeys returns the keys of a map field value in ascending order, false before true.
+func SortedKeys(entries map[any]any) []any {
+	keys := make([]any, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, compareKeys)
+	return keys
+}
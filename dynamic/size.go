@@ -0,0 +1,192 @@
+package dynamic
+
+import (
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// Size returns the length of the encoding of m by MarshalBinary, without encoding it.
+func (m *Message) Size() int {
+	n := len(m.UnknownFields)
+	m.Range(func(f *descriptor.Field, v any) bool {
+		n += sizeField(f, v)
+		return true
+	})
+	return n
+}
+
+func sizeField(f *descriptor.Field, v any) int {
+	n := 0
+	switch {
+	case f.Map != nil:
+		for k, x := range v.(map[any]any) {
+			n += sizeLen(f.Tag, sizeValue(f.Map.Key, k)+sizeValue(f.Map.Value, x))
+		}
+	case f.Label == descriptor.LabelRepeated:
+		list := v.([]any)
+		if !f.IsPacked() {
+			for _, x := range list {
+				n += sizeValue(f, x)
+			}
+			break
+		}
+		body := 0
+		for _, x := range list {
+			body += sizeScalar(f.Type, x)
+		}
+		if len(list) > 0 {
+			n = sizeLen(f.Tag, body)
+		}
+	default:
+		n = sizeValue(f, v)
+	}
+	return n
+}
+
+// sizeValue returns the size of a single value of f with its key.
+func sizeValue(f *descriptor.Field, v any) int {
+	switch v := v.(type) {
+	case *Message:
+		return sizeMessage(f, v.Size())
+	case string:
+		return sizeLen(f.Tag, len(v))
+	case []byte:
+		return sizeLen(f.Tag, len(v))
+	default:
+	}
+	return wire.SizeTag(f.Tag) + sizeScalar(f.Type, v)
+}
+
+// sizeScalar returns the size of a number without key.
+func sizeScalar(typ uint8, v any) int {
+	switch descriptor.WireClass(typ) {
+	case wire.Tag32bit:
+		return 4
+	case wire.Tag64bit:
+		return 8
+	default:
+	}
+	raw, _ := rawValue(typ, v)
+	return wire.SizeVarint(raw)
+}
+
+// sizeLen returns the size of a length prefixed value of n bytes with its key.
+func sizeLen(tag wire.TagNum, n int) int {
+	return wire.SizeTag(tag) + wire.SizeVarint(uint64(n)) + n
+}
+
+// sizeMessage returns the size of a message field with a body of n bytes, length prefixed or delimited.
+func sizeMessage(f *descriptor.Field, n int) int {
+	if f.Type == descriptor.TypeGroup || f.Features().MessageEncoding == descriptor.MessageDelimited {
+		return 2*wire.SizeTag(f.Tag) + n
+	}
+	return sizeLen(f.Tag, n)
+}
+
+// EstimateSize returns an upper bound for the encoded size of the messages of type desc with the strings, bytes,
+// repeated and map fields of sample, a message of type desc or nil for empty ones, to budget buffers before their fields
+// are known. Singular scalars count with their largest encoding whether set or not, oneofs with their largest member.
+// Unset messages count as ones with the largest numbers and empty strings and lists, except messages that would nest
+// in themselves, which count as unset. The extensions of sample count like fields, its unknown fields as they are.
+func EstimateSize(desc *descriptor.Message, sample *Message) int {
+	return estimate(desc, sample, map[*descriptor.Message]int{})
+}
+
+// estimate returns EstimateSize of a message nested in the open ones.
+func estimate(desc *descriptor.Message, sample *Message, open map[*descriptor.Message]int) int {
+	open[desc]++
+	defer func() { open[desc]-- }()
+	n := 0
+	oneofs := make([]int, len(desc.OneOf))
+	for _, f := range desc.Field {
+		var v any
+		if sample != nil {
+			v = sample.values[f.Tag]
+		}
+		s := estimateField(f, v, open)
+		if f.RealOneOf(desc) != nil {
+			oneofs[*f.OneOfIndex] = max(oneofs[*f.OneOfIndex], s)
+			continue
+		}
+		n += s
+	}
+	for _, s := range oneofs {
+		n += s
+	}
+	if sample != nil {
+		for _, x := range sample.extensions {
+			n += estimateField(x, sample.values[x.Tag], open)
+		}
+		n += len(sample.UnknownFields)
+	}
+	return n
+}
+
+// estimateField returns the largest size of f with the lengths of v, nil if unset.
+func estimateField(f *descriptor.Field, v any, open map[*descriptor.Message]int) int {
+	n := 0
+	switch {
+	case f.Map != nil:
+		mp, _ := v.(map[any]any)
+		for k, x := range mp {
+			n += sizeLen(f.Tag, estimateValue(f.Map.Key, k, open)+estimateValue(f.Map.Value, x, open))
+		}
+	case f.Label == descriptor.LabelRepeated:
+		list, _ := v.([]any)
+		if !f.IsPacked() {
+			for _, x := range list {
+				n += estimateValue(f, x, open)
+			}
+			break
+		}
+		if len(list) > 0 {
+			n = sizeLen(f.Tag, len(list)*maxScalar(f.Type))
+		}
+	default:
+		n = estimateValue(f, v, open)
+	}
+	return n
+}
+
+// estimateValue returns the largest size of a single value of f with its key, v gives the lengths.
+func estimateValue(f *descriptor.Field, v any, open map[*descriptor.Message]int) int {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		sub, _ := v.(*Message)
+		if sub == nil && (f.MessageType == nil || open[f.MessageType] > 0) {
+			return 0
+		}
+		desc := f.MessageType
+		if sub != nil {
+			desc = sub.desc
+		}
+		return sizeMessage(f, estimate(desc, sub, open))
+	case descriptor.TypeString:
+		s, _ := v.(string)
+		return sizeLen(f.Tag, len(s))
+	case descriptor.TypeBytes:
+		b, _ := v.([]byte)
+		return sizeLen(f.Tag, len(b))
+	default:
+	}
+	return wire.SizeTag(f.Tag) + maxScalar(f.Type)
+}
+
+// maxScalar returns the size of the largest encoding of a number of type typ without key.
+func maxScalar(typ uint8) int {
+	switch typ {
+	case descriptor.TypeBool:
+		return 1
+	case descriptor.TypeUint32, descriptor.TypeSint32:
+		return 5
+	default:
+	}
+	switch descriptor.WireClass(typ) {
+	case wire.Tag32bit:
+		return 4
+	case wire.Tag64bit:
+		return 8
+	default:
+	}
+	return 10 // negative int32 and enum values are sign extended
+}
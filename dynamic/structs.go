@@ -0,0 +1,153 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StructValue returns the Go form of a google.protobuf.Struct, Value or ListValue message,
+// of the types encoding/json decodes JSON to: map[string]any for Struct, []any for ListValue
+// and nil, float64, string, bool, map[string]any or []any for Value.
+func (m *Message) StructValue() (any, error) {
+	switch m.desc.FullName() {
+	case "google.protobuf.Struct":
+		fields, _ := m.Get("fields").(map[any]any)
+		out := make(map[string]any, len(fields))
+		for k, v := range fields {
+			x, err := v.(*Message).StructValue()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k.(string)] = x
+		}
+		return out, nil
+	case "google.protobuf.ListValue":
+		values, _ := m.Get("values").([]any)
+		out := make([]any, len(values))
+		for i, v := range values {
+			x, err := v.(*Message).StructValue()
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			out[i] = x
+		}
+		return out, nil
+	case "google.protobuf.Value":
+		f := m.WhichOneOf("kind")
+		if f == nil {
+			return nil, fmt.Errorf("dynamic: Value without kind")
+		}
+		switch v := m.GetField(f).(type) {
+		case *Message:
+			return v.StructValue()
+		case int32:
+			return nil, nil // null_value
+		default:
+			return v, nil
+		}
+	default:
+	}
+	return nil, fmt.Errorf("dynamic: %s is not a google.protobuf.Struct, Value or ListValue", m.desc.FullName())
+}
+
+// SetStructValue sets a google.protobuf.Struct, Value or ListValue message to the Go form v, see StructValue.
+// Values also accept the other int, uint and float types and json.Number.
+func (m *Message) SetStructValue(v any) error {
+	switch m.desc.FullName() {
+	case "google.protobuf.Struct":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("dynamic: Struct of %T", v)
+		}
+		f := m.FieldByName("fields")
+		m.ClearField(f)
+		for k, x := range obj {
+			val := New(f.Map.Value.MessageType)
+			if err := val.SetStructValue(x); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			if err := m.PutField(f, k, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "google.protobuf.ListValue":
+		list, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("dynamic: ListValue of %T", v)
+		}
+		f := m.FieldByName("values")
+		m.ClearField(f)
+		for i, x := range list {
+			val := New(f.MessageType)
+			if err := val.SetStructValue(x); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			if err := m.AddField(f, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "google.protobuf.Value":
+		return m.setValue(v)
+	default:
+	}
+	return fmt.Errorf("dynamic: %s is not a google.protobuf.Struct, Value or ListValue", m.desc.FullName())
+}
+
+func (m *Message) setValue(v any) error {
+	var num float64
+	switch v := v.(type) {
+	case nil:
+		return m.Set("null_value", int32(0))
+	case bool:
+		return m.Set("bool_value", v)
+	case string:
+		return m.Set("string_value", v)
+	case map[string]any:
+		sub := New(m.FieldByName("struct_value").MessageType)
+		if err := sub.SetStructValue(v); err != nil {
+			return err
+		}
+		return m.Set("struct_value", sub)
+	case []any:
+		sub := New(m.FieldByName("list_value").MessageType)
+		if err := sub.SetStructValue(v); err != nil {
+			return err
+		}
+		return m.Set("list_value", sub)
+	case float64:
+		num = v
+	case float32:
+		num = float64(v)
+	case int:
+		num = float64(v)
+	case int8:
+		num = float64(v)
+	case int16:
+		num = float64(v)
+	case int32:
+		num = float64(v)
+	case int64:
+		num = float64(v)
+	case uint:
+		num = float64(v)
+	case uint8:
+		num = float64(v)
+	case uint16:
+		num = float64(v)
+	case uint32:
+		num = float64(v)
+	case uint64:
+		num = float64(v)
+	case json.Number:
+		x, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("dynamic: Value of %w", err)
+		}
+		num = x
+	default:
+		return fmt.Errorf("dynamic: Value of %T", v)
+	}
+	return m.Set("number_value", num)
+}
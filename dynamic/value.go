@@ -0,0 +1,185 @@
+package dynamic
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// scalar converts a raw value read by wire.Fields to the Go value of typ,
+// v holds varints and fixed values, b length prefixed ones.
+func scalar(typ uint8, v uint64, b []byte) any {
+	switch typ {
+	case descriptor.TypeDouble:
+		return wire.DecodeDouble(v)
+	case descriptor.TypeFloat:
+		return wire.DecodeFloat(v)
+	case descriptor.TypeInt64:
+		return int64(v)
+	case descriptor.TypeUint64, descriptor.TypeFixed64:
+		return v
+	case descriptor.TypeInt32:
+		return wire.DecodeInt32(v)
+	case descriptor.TypeFixed32, descriptor.TypeUint32:
+		return uint32(v)
+	case descriptor.TypeBool:
+		return wire.DecodeBool(v)
+	case descriptor.TypeString:
+		return string(b)
+	case descriptor.TypeBytes:
+		return append([]byte{}, b...)
+	case descriptor.TypeEnum:
+		return wire.DecodeEnum(v)
+	case descriptor.TypeSfixed32:
+		return wire.DecodeSfixed32(v)
+	case descriptor.TypeSfixed64:
+		return wire.DecodeSfixed64(v)
+	case descriptor.TypeSint32:
+		return wire.DecodeSint32(v)
+	case descriptor.TypeSint64:
+		return wire.DecodeSint64(v)
+	default:
+	}
+	return nil
+}
+
+// Default returns the value of an unset field: its default_value or the zero value for scalars,
+// the first value for enums and nil for messages, repeated and map fields.
+func Default(f *descriptor.Field) any {
+	if f.Label == descriptor.LabelRepeated || f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup {
+		return nil
+	}
+	if f.Type == descriptor.TypeEnum {
+		return enumDefault(f)
+	}
+	if f.DefaultValue != "" {
+		if v, ok := parseDefault(f.Type, f.DefaultValue); ok {
+			return v
+		}
+	}
+	return scalar(f.Type, 0, nil)
+}
+
+func enumDefault(f *descriptor.Field) int32 {
+	if f.EnumType == nil || len(f.EnumType.Value) == 0 {
+		return 0
+	}
+	for _, v := range f.EnumType.Value {
+		if v.Name == f.DefaultValue {
+			return v.Number
+		}
+	}
+	return f.EnumType.Value[0].Number
+}
+
+// parseDefault parses a default_value in the text format protoc uses.
+func parseDefault(typ uint8, s string) (any, bool) {
+	switch typ {
+	case descriptor.TypeString:
+		return s, true
+	case descriptor.TypeBytes:
+		b, ok := unescape(s)
+		return b, ok
+	case descriptor.TypeBool:
+		v, err := strconv.ParseBool(s)
+		return v, err == nil
+	case descriptor.TypeDouble, descriptor.TypeFloat:
+		var v float64
+		switch s {
+		case "inf":
+			v = math.Inf(1)
+		case "-inf":
+			v = math.Inf(-1)
+		case "nan":
+			v = math.NaN()
+		default:
+			var err error
+			if v, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, false
+			}
+		}
+		if typ == descriptor.TypeFloat {
+			return float32(v), true
+		}
+		return v, true
+	default:
+	}
+	switch zero := scalar(typ, 0, nil); zero.(type) {
+	case int32:
+		v, err := strconv.ParseInt(s, 0, 32)
+		return int32(v), err == nil
+	case int64:
+		v, err := strconv.ParseInt(s, 0, 64)
+		return v, err == nil
+	case uint32:
+		v, err := strconv.ParseUint(s, 0, 32)
+		return uint32(v), err == nil
+	case uint64:
+		v, err := strconv.ParseUint(s, 0, 64)
+		return v, err == nil
+	}
+	return nil, false
+}
+
+// unescape reverses the C escaping protoc applies to bytes defaults.
+func unescape(s string) ([]byte, bool) {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b = append(b, c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return nil, false
+		}
+		switch c = s[i]; c {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'a':
+			b = append(b, '\a')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'v':
+			b = append(b, '\v')
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && isHex(s[j]) {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], 16, 8)
+			if err != nil {
+				return nil, false
+			}
+			b = append(b, byte(v))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && '0' <= s[j] && s[j] <= '7' {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i:j], 8, 8)
+			if err != nil {
+				return nil, false
+			}
+			b = append(b, byte(v))
+			i = j - 1
+		default:
+			b = append(b, c) // \\, \', \" and \?
+		}
+	}
+	return b, true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,93 @@
+package dynamic
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Valid ranges of Timestamp and Duration, see google/protobuf/timestamp.proto and duration.proto.
+const (
+	minTimestamp = -62135596800 // 0001-01-01T00:00:00Z
+	maxTimestamp = 253402300799 // 9999-12-31T23:59:59Z
+	maxDuration  = 315576000000 // 10000 years
+)
+
+// wrappers are the well-known messages wrapping a single value field.
+var wrappers = map[string]bool{
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.BytesValue":  true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.UInt64Value": true,
+}
+
+func (m *Message) wellKnown(name string) error {
+	if m.desc.FullName() != name {
+		return fmt.Errorf("dynamic: %s is not a %s", m.desc.FullName(), name)
+	}
+	return nil
+}
+
+// Time returns the time of a google.protobuf.Timestamp message in UTC.
+func (m *Message) Time() (time.Time, error) {
+	if err := m.wellKnown("google.protobuf.Timestamp"); err != nil {
+		return time.Time{}, err
+	}
+	secs, nanos := m.Get("seconds").(int64), m.Get("nanos").(int32)
+	if secs < minTimestamp || secs > maxTimestamp || nanos < 0 || nanos >= 1e9 {
+		return time.Time{}, fmt.Errorf("dynamic: Timestamp %d.%09d out of range", secs, nanos)
+	}
+	return time.Unix(secs, int64(nanos)).UTC(), nil
+}
+
+// SetTime sets a google.protobuf.Timestamp message to t.
+func (m *Message) SetTime(t time.Time) error {
+	if err := m.wellKnown("google.protobuf.Timestamp"); err != nil {
+		return err
+	}
+	secs := t.Unix()
+	if secs < minTimestamp || secs > maxTimestamp {
+		return fmt.Errorf("dynamic: time %s out of the range of Timestamp", t)
+	}
+	m.Set("seconds", secs)
+	return m.Set("nanos", int32(t.Nanosecond()))
+}
+
+// Duration returns the duration of a google.protobuf.Duration message,
+// an error for the ones valid in protobuf beyond the 290 years of a time.Duration.
+func (m *Message) Duration() (time.Duration, error) {
+	if err := m.wellKnown("google.protobuf.Duration"); err != nil {
+		return 0, err
+	}
+	secs, nanos := m.Get("seconds").(int64), m.Get("nanos").(int32)
+	if secs < -maxDuration || secs > maxDuration || nanos <= -1e9 || nanos >= 1e9 ||
+		secs > 0 && nanos < 0 || secs < 0 && nanos > 0 {
+		return 0, fmt.Errorf("dynamic: Duration %ds %dns out of range", secs, nanos)
+	}
+	if limit := int64(math.MaxInt64/time.Second) - 1; secs > limit || secs < -limit {
+		return 0, fmt.Errorf("dynamic: Duration of %ds overflows time.Duration", secs)
+	}
+	return time.Duration(secs)*time.Second + time.Duration(nanos), nil
+}
+
+// SetDuration sets a google.protobuf.Duration message to d.
+func (m *Message) SetDuration(d time.Duration) error {
+	if err := m.wellKnown("google.protobuf.Duration"); err != nil {
+		return err
+	}
+	m.Set("seconds", int64(d/time.Second))
+	return m.Set("nanos", int32(d%time.Second))
+}
+
+// Wrapped returns the value of a wrapper message like google.protobuf.Int32Value, false for other types.
+func (m *Message) Wrapped() (any, bool) {
+	if !wrappers[m.desc.FullName()] {
+		return nil, false
+	}
+	return m.Get("value"), true
+}
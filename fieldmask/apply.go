@@ -0,0 +1,89 @@
+package fieldmask
+
+import (
+	"fmt"
+
+	"github.com/defsrc/proton/dynamic"
+)
+
+// Keep clears the fields of m not covered by mask, including its extensions and unknown fields.
+// The paths must be valid for the type of m.
+func Keep(m *dynamic.Message, mask Mask) error {
+	if err := Validate(m.Descriptor(), mask); err != nil {
+		return err
+	}
+	keep(m, newTree(mask))
+	return nil
+}
+
+func keep(m *dynamic.Message, t tree) {
+	for _, f := range m.Fields() {
+		sub, ok := t[f.Name]
+		switch {
+		case !m.HasField(f):
+		case !ok || f.Extendee != "":
+			m.ClearField(f)
+		case sub != nil:
+			keep(m.GetField(f).(*dynamic.Message), sub)
+		default:
+		}
+	}
+	m.SetUnknown(nil)
+}
+
+// Strip clears the fields of m covered by mask, the paths must be valid for the type of m.
+func Strip(m *dynamic.Message, mask Mask) error {
+	if err := Validate(m.Descriptor(), mask); err != nil {
+		return err
+	}
+	strip(m, newTree(mask))
+	return nil
+}
+
+func strip(m *dynamic.Message, t tree) {
+	for name, sub := range t {
+		f := m.FieldByName(name)
+		switch {
+		case !m.HasField(f):
+		case sub == nil:
+			m.ClearField(f)
+		default:
+			strip(m.GetField(f).(*dynamic.Message), sub)
+		}
+	}
+}
+
+// Update sets the fields of dst covered by mask to their values in src, clearing the ones unset in src,
+// like the update mask of an AIP-134 Update method. Messages on the paths are created in dst as needed.
+// The values are shared with src, not copied. The messages must have the same type and the paths be valid for it.
+func Update(dst, src *dynamic.Message, mask Mask) error {
+	if err := Validate(dst.Descriptor(), mask); err != nil {
+		return err
+	}
+	if dst.Descriptor() != src.Descriptor() {
+		return fmt.Errorf("fieldmask: update of %s from %s", dst.Descriptor().FullName(), src.Descriptor().FullName())
+	}
+	return update(dst, src, newTree(mask))
+}
+
+func update(dst, src *dynamic.Message, t tree) error {
+	for name, sub := range t {
+		f := dst.FieldByName(name)
+		switch {
+		case sub == nil && src.HasField(f):
+			if err := dst.SetField(f, src.GetField(f)); err != nil {
+				return err
+			}
+		case sub == nil:
+			dst.ClearField(f)
+		case src.HasField(f):
+			if err := update(dst.Mutable(f).(*dynamic.Message), src.GetField(f).(*dynamic.Message), sub); err != nil {
+				return err
+			}
+		case dst.HasField(f):
+			strip(dst.GetField(f).(*dynamic.Message), sub)
+		default:
+		}
+	}
+	return nil
+}
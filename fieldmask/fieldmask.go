@@ -0,0 +1,217 @@
+// Package fieldmask validates and applies google.protobuf.FieldMask paths to dynamic messages,
+// the building blocks of partial reads and updates like AIP-134 update masks.
+package fieldmask
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// A Mask is a set of paths of fields: .proto field names joined by dots from a message type, like "customer.id".
+// Only the last field of a path may be repeated or a map. The order of paths does not matter.
+type Mask []string
+
+// FromMessage returns the paths of a google.protobuf.FieldMask message.
+func FromMessage(m *dynamic.Message) (Mask, error) {
+	if name := m.Descriptor().FullName(); name != "google.protobuf.FieldMask" {
+		return nil, fmt.Errorf("fieldmask: %s is not a google.protobuf.FieldMask", name)
+	}
+	var mask Mask
+	paths, _ := m.Get("paths").([]any)
+	for _, p := range paths {
+		mask = append(mask, p.(string))
+	}
+	return mask, nil
+}
+
+// Message returns the mask as a google.protobuf.FieldMask message.
+func (mask Mask) Message() *dynamic.Message {
+	m := dynamic.New(wellknown.Message("google.protobuf.FieldMask"))
+	for _, p := range mask {
+		m.Add("paths", p)
+	}
+	return m
+}
+
+// ParseJSON parses the JSON form of a mask, lower camel case paths joined by commas like "customer.displayName".
+// The paths keep the JSON names, Normalize turns them into .proto names.
+func ParseJSON(s string) Mask {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// JSON returns the JSON form of the mask, see ParseJSON.
+func (mask Mask) JSON() string {
+	paths := make([]string, len(mask))
+	for i, p := range mask {
+		names := strings.Split(p, ".")
+		for j, name := range names {
+			names[j] = descriptor.JSONName(name)
+		}
+		paths[i] = strings.Join(names, ".")
+	}
+	return strings.Join(paths, ",")
+}
+
+// Validate checks that the paths of mask name fields of desc by their .proto names.
+func Validate(desc *descriptor.Message, mask Mask) error {
+	for _, p := range mask {
+		if _, err := resolve(desc, p, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Normalize returns the canonical mask of paths naming fields of desc by their .proto or JSON names,
+// with the .proto names, see Canonical.
+func Normalize(desc *descriptor.Message, mask Mask) (Mask, error) {
+	var out Mask
+	for _, p := range mask {
+		fields, err := resolve(desc, p, true)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+		}
+		out = append(out, strings.Join(names, "."))
+	}
+	return Canonical(out), nil
+}
+
+// resolve returns the fields along a path, matching the lower camel case names of the JSON form too if json.
+func resolve(desc *descriptor.Message, path string, json bool) ([]*descriptor.Field, error) {
+	if path == "" {
+		return nil, fmt.Errorf("fieldmask: empty path")
+	}
+	var fields []*descriptor.Field
+	m := desc
+	for i, name := range strings.Split(path, ".") {
+		if m == nil {
+			return nil, fmt.Errorf("fieldmask: path %s: field %s is not a singular message", path, fields[i-1].FullName())
+		}
+		f := field(m, name, json)
+		if f == nil {
+			return nil, fmt.Errorf("fieldmask: path %s: %s has no field %s", path, m.FullName(), name)
+		}
+		fields = append(fields, f)
+		m = nil
+		if f.Label != descriptor.LabelRepeated && (f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup) {
+			if f.MessageType == nil {
+				return nil, &dynamic.UnresolvedError{Field: f}
+			}
+			m = f.MessageType
+		}
+	}
+	return fields, nil
+}
+
+func field(m *descriptor.Message, name string, json bool) *descriptor.Field {
+	for _, f := range m.Field {
+		if f.Name == name || json && descriptor.JSONName(f.Name) == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Canonical returns the sorted paths of mask without duplicates and
+// without the paths covered by others, "a.b" is covered by "a".
+func Canonical(mask Mask) Mask {
+	return newTree(mask).paths("")
+}
+
+// Union returns the canonical mask of the paths in any of the masks.
+func Union(masks ...Mask) Mask {
+	return newTree(slices.Concat(masks...)).paths("")
+}
+
+// Intersect returns the canonical mask of the paths in all the masks,
+// the intersection of "a" and "a.b" is "a.b".
+func Intersect(masks ...Mask) Mask {
+	if len(masks) == 0 {
+		return nil
+	}
+	t := newTree(masks[0])
+	for _, mask := range masks[1:] {
+		t = t.intersect(newTree(mask))
+	}
+	return t.paths("")
+}
+
+// Covers reports whether mask includes path, equal to one of its paths or below one.
+func (mask Mask) Covers(path string) bool {
+	for _, p := range mask {
+		if p == path || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// A tree holds paths by their field names, a leaf covers the whole field.
+type tree map[string]tree
+
+func newTree(mask Mask) tree {
+	t := tree{}
+	for _, p := range mask {
+		t.add(strings.Split(p, "."))
+	}
+	return t
+}
+
+func (t tree) add(names []string) {
+	sub, ok := t[names[0]]
+	switch {
+	case ok && sub == nil: // covered already
+	case len(names) == 1:
+		t[names[0]] = nil
+	default:
+		if sub == nil {
+			sub = tree{}
+			t[names[0]] = sub
+		}
+		sub.add(names[1:])
+	}
+}
+
+func (t tree) intersect(u tree) tree {
+	out := tree{}
+	for name, a := range t {
+		b, ok := u[name]
+		switch {
+		case !ok:
+		case a == nil:
+			out[name] = b
+		case b == nil:
+			out[name] = a
+		default:
+			if x := a.intersect(b); len(x) > 0 {
+				out[name] = x
+			}
+		}
+	}
+	return out
+}
+
+func (t tree) paths(prefix string) Mask {
+	var out Mask
+	for name, sub := range t {
+		if sub == nil {
+			out = append(out, prefix+name)
+		} else {
+			out = append(out, sub.paths(prefix+name+".")...)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
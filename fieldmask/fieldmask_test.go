@@ -0,0 +1,136 @@
+package fieldmask_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/fieldmask"
+	"github.com/defsrc/proton/wellknown"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		mask, want fieldmask.Mask
+	}{
+		{nil, nil},
+		{fieldmask.Mask{"b", "a", "b"}, fieldmask.Mask{"a", "b"}},
+		{fieldmask.Mask{"a.b", "a"}, fieldmask.Mask{"a"}},
+		{fieldmask.Mask{"a", "a.b"}, fieldmask.Mask{"a"}},
+		{fieldmask.Mask{"x", "a.b.c", "a.b", "a.c"}, fieldmask.Mask{"a.b", "a.c", "x"}},
+	}
+	for _, tt := range tests {
+		if got := fieldmask.Canonical(tt.mask); !slices.Equal(got, tt.want) {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		masks []fieldmask.Mask
+		want  fieldmask.Mask
+	}{
+		{nil, nil},
+		{[]fieldmask.Mask{{"a"}, {"a.b"}}, fieldmask.Mask{"a.b"}},
+		{[]fieldmask.Mask{{"a.b"}, {"a"}}, fieldmask.Mask{"a.b"}},
+		{[]fieldmask.Mask{{"a", "b"}, {"b", "c"}}, fieldmask.Mask{"b"}},
+		{[]fieldmask.Mask{{"a.b"}, {"a.c"}}, nil},
+		{[]fieldmask.Mask{{"a"}, {"a.b", "a.c"}, {"a.c.d", "b"}}, fieldmask.Mask{"a.c.d"}},
+	}
+	for _, tt := range tests {
+		if got := fieldmask.Intersect(tt.masks...); !slices.Equal(got, tt.want) {
+			t.Errorf("Intersect(%q) = %q, want %q", tt.masks, got, tt.want)
+		}
+	}
+	if got, want := fieldmask.Union(fieldmask.Mask{"a.b", "c"}, fieldmask.Mask{"a"}), (fieldmask.Mask{"a", "c"}); !slices.Equal(got, want) {
+		t.Errorf("Union = %q, want %q", got, want)
+	}
+}
+
+// fieldProto returns a google.protobuf.FieldDescriptorProto, values are keyed by the paths of the fields.
+func fieldProto(t *testing.T, values map[string]any) *dynamic.Message {
+	t.Helper()
+	m := dynamic.New(wellknown.Message("google.protobuf.FieldDescriptorProto"))
+	for path, v := range values {
+		dst := m
+		if name, sub, ok := strings.Cut(path, "."); ok {
+			dst, path = m.Mutable(m.FieldByName(name)).(*dynamic.Message), sub
+		}
+		if err := dst.Set(path, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestUpdate(t *testing.T) {
+	dst := map[string]any{"name": "old", "number": int32(1), "json_name": "old", "options.packed": true, "options.deprecated": true}
+	tests := []struct {
+		src  map[string]any
+		mask fieldmask.Mask
+		want map[string]any
+	}{
+		{
+			map[string]any{"name": "new", "number": int32(2)},
+			fieldmask.Mask{"name"},
+			map[string]any{"name": "new", "number": int32(1), "json_name": "old", "options.packed": true, "options.deprecated": true},
+		},
+		{
+			// fields unset in src are cleared
+			map[string]any{"name": "new"},
+			fieldmask.Mask{"name", "json_name", "number"},
+			map[string]any{"name": "new", "options.packed": true, "options.deprecated": true},
+		},
+		{
+			map[string]any{"options.deprecated": false},
+			fieldmask.Mask{"options.packed", "options.deprecated"},
+			map[string]any{"name": "old", "number": int32(1), "json_name": "old", "options.deprecated": false},
+		},
+		{
+			// without the message in src only the fields on the paths are cleared
+			map[string]any{},
+			fieldmask.Mask{"options.packed"},
+			map[string]any{"name": "old", "number": int32(1), "json_name": "old", "options.deprecated": true},
+		},
+		{
+			map[string]any{},
+			fieldmask.Mask{"options"},
+			map[string]any{"name": "old", "number": int32(1), "json_name": "old"},
+		},
+		{
+			map[string]any{"options.lazy": true},
+			fieldmask.Mask{"options"},
+			map[string]any{"name": "old", "number": int32(1), "json_name": "old", "options.lazy": true},
+		},
+	}
+	for _, tt := range tests {
+		m := fieldProto(t, dst)
+		if err := fieldmask.Update(m, fieldProto(t, tt.src), tt.mask); err != nil {
+			t.Errorf("Update(%v, %q): %v", tt.src, tt.mask, err)
+			continue
+		}
+		got, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := fieldProto(t, tt.want).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Update(%v, %q) = % x, want % x", tt.src, tt.mask, got, want)
+		}
+	}
+
+	m := fieldProto(t, dst)
+	if err := fieldmask.Update(m, fieldProto(t, nil), fieldmask.Mask{"options.nope"}); err == nil {
+		t.Error("Update with an unknown field succeeds")
+	}
+	other := dynamic.New(wellknown.Message("google.protobuf.FieldOptions"))
+	if err := fieldmask.Update(m, other, fieldmask.Mask{"name"}); err == nil {
+		t.Error("Update from a message of another type succeeds")
+	}
+}
@@ -0,0 +1,119 @@
+// Package avro converts between messages and Avro schemas, both ways.
+//
+// FromMessage maps a message to a record with a field per field: fields with presence are unions with null,
+// repeated fields arrays and maps with string keys Avro maps, keys of other types become their decimal or
+// boolean strings. A oneof is a single union field if the types of its fields differ, else a nullable field each.
+// Records and enums are defined at their first use and referenced by their full name, the name in the .proto file.
+// Unsigned integers are longs, timestamps longs with the timestamp-micros logical type and wrappers nullable primitives.
+//
+// ToFile maps records and enums back to a proto3 file, numbering the fields in order.
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// A Schema is an Avro schema: a primitive or named type, a reference to a named type defined before or a union.
+type Schema struct {
+	Type        string    // null, boolean, int, long, float, double, bytes, string, record, enum, array, map, fixed or a type name; empty for unions
+	Name        string    // of records, enums and fixed types
+	Namespace   string    // of the name, the enclosing namespace if empty
+	Doc         string    // of records and enums
+	Fields      []*Field  // of a record
+	Symbols     []string  // of an enum
+	Default     string    // symbol of an enum, used for unknown symbols
+	Items       *Schema   // of an array
+	Values      *Schema   // of a map
+	Size        int       // of a fixed type
+	LogicalType string    // refining a primitive, like timestamp-micros
+	Union       []*Schema // branches of a union
+}
+
+// A Field is a field of a record.
+type Field struct {
+	Name    string          `json:"name"`
+	Doc     string          `json:"doc,omitempty"`
+	Type    *Schema         `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"` // JSON value of the default, none if nil
+}
+
+// object is the JSON form of a schema other than a union or a bare name.
+type object struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Namespace   string          `json:"namespace,omitempty"`
+	Doc         string          `json:"doc,omitempty"`
+	Fields      *[]*Field       `json:"fields,omitempty"` // present for all records
+	Symbols     []string        `json:"symbols,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+	Items       *Schema         `json:"items,omitempty"`
+	Values      *Schema         `json:"values,omitempty"`
+	Size        int             `json:"size,omitempty"`
+	LogicalType string          `json:"logicalType,omitempty"`
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	switch {
+	case s.Type == "":
+		if s.Union == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(s.Union)
+	case s.Name == "" && s.Items == nil && s.Values == nil && s.LogicalType == "" && s.Type != "record" && s.Type != "enum":
+		return json.Marshal(s.Type)
+	default:
+	}
+	o := object{Type: s.Type, Name: s.Name, Namespace: s.Namespace, Doc: s.Doc, Symbols: s.Symbols,
+		Items: s.Items, Values: s.Values, Size: s.Size, LogicalType: s.LogicalType}
+	if s.Type == "record" {
+		fields := s.Fields
+		if fields == nil {
+			fields = []*Field{}
+		}
+		o.Fields = &fields
+	}
+	if s.Default != "" {
+		o.Default, _ = json.Marshal(s.Default)
+	}
+	return json.Marshal(o)
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	*s = Schema{}
+	switch b := bytes.TrimLeft(data, " \t\r\n"); {
+	case len(b) > 0 && b[0] == '"':
+		return json.Unmarshal(b, &s.Type)
+	case len(b) > 0 && b[0] == '[':
+		return json.Unmarshal(b, &s.Union)
+	default:
+	}
+	var o object
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+	if o.Type == "" {
+		return errors.New("avro: schema without a type")
+	}
+	*s = Schema{Type: o.Type, Name: o.Name, Namespace: o.Namespace, Doc: o.Doc, Symbols: o.Symbols,
+		Items: o.Items, Values: o.Values, Size: o.Size, LogicalType: o.LogicalType}
+	if o.Fields != nil {
+		s.Fields = *o.Fields
+	}
+	if o.Default != nil && o.Type == "enum" {
+		if err := json.Unmarshal(o.Default, &s.Default); err != nil {
+			return fmt.Errorf("avro: default of enum %s: %w", o.Name, err)
+		}
+	}
+	return nil
+}
+
+// FullName returns the full name of a named type, its Namespace and Name joined by a dot.
+func (s *Schema) FullName() string {
+	if s.Namespace == "" {
+		return s.Name
+	}
+	return s.Namespace + "." + s.Name
+}
@@ -0,0 +1,216 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// FromMessage returns the record of a linked message, defining the records and enums it uses at their first use.
+func FromMessage(m *descriptor.Message) (*Schema, error) {
+	c := &fromConverter{defined: map[string]bool{}}
+	s := c.record(m)
+	return s, c.err
+}
+
+type fromConverter struct {
+	defined map[string]bool // full names of the named types defined so far
+	err     error           // the first error, like an unlinked type
+}
+
+func (c *fromConverter) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// named returns a reference to a named type defined before, or nil after marking it as defined.
+func (c *fromConverter) named(name string) *Schema {
+	if c.defined[name] {
+		return &Schema{Type: name}
+	}
+	c.defined[name] = true
+	return nil
+}
+
+func (c *fromConverter) record(m *descriptor.Message) *Schema {
+	name := m.FullName()
+	if ref := c.named(name); ref != nil {
+		return ref
+	}
+	s := &Schema{Type: "record", Name: m.Name, Namespace: strings.TrimSuffix(strings.TrimSuffix(name, m.Name), "."), Doc: doc(m.Comments)}
+	oneofs := m.OneOfFields()
+	done := map[int32]bool{}
+	for _, f := range m.Field {
+		o := f.RealOneOf(m)
+		if o == nil {
+			s.Fields = append(s.Fields, c.field(f))
+			continue
+		}
+		i := *f.OneOfIndex
+		if done[i] {
+			continue
+		}
+		done[i] = true
+		s.Fields = append(s.Fields, c.oneof(o, oneofs[i])...)
+	}
+	return s
+}
+
+// oneof returns the field of a oneof, a union of the types of its fields if they differ, else a field per field.
+func (c *fromConverter) oneof(o *descriptor.OneOf, fields []*descriptor.Field) []*Field {
+	branches := []*Schema{{Type: "null"}}
+	var keys []string
+	for _, f := range fields {
+		t := c.value(f)
+		keys = append(keys, key(t))
+		branches = append(branches, t)
+	}
+	slices.Sort(keys)
+	if len(slices.Compact(keys)) == len(fields) {
+		return []*Field{{Name: o.Name, Doc: doc(o.Comments), Type: &Schema{Union: branches}, Default: json.RawMessage("null")}}
+	}
+	var out []*Field
+	for i, f := range fields {
+		out = append(out, &Field{Name: f.Name, Doc: doc(f.Comments), Type: &Schema{Union: []*Schema{{Type: "null"}, branches[i+1]}}, Default: json.RawMessage("null")})
+	}
+	return out
+}
+
+// key returns what tells the branches of a union apart: the name of named types, else the type.
+func key(s *Schema) string {
+	if s.Name != "" {
+		return s.FullName()
+	}
+	return s.Type
+}
+
+func (c *fromConverter) field(f *descriptor.Field) *Field {
+	out := &Field{Name: f.Name, Doc: doc(f.Comments)}
+	switch {
+	case f.IsMap():
+		out.Type, out.Default = &Schema{Type: "map", Values: c.value(f.Map.Value)}, json.RawMessage("{}")
+	case f.Label == descriptor.LabelRepeated:
+		out.Type, out.Default = &Schema{Type: "array", Items: c.value(f)}, json.RawMessage("[]")
+	case f.Features().FieldPresence == descriptor.PresenceLegacyRequired:
+		out.Type = c.value(f)
+	case f.HasPresence():
+		out.Type, out.Default = &Schema{Union: []*Schema{{Type: "null"}, c.value(f)}}, json.RawMessage("null")
+	default:
+		out.Type = c.value(f)
+		out.Default = zero(f, out.Type)
+	}
+	return out
+}
+
+// zero returns the default of a field without presence, of type s.
+func zero(f *descriptor.Field, s *Schema) json.RawMessage {
+	if f.EnumType != nil && len(f.EnumType.Value) > 0 {
+		b, _ := json.Marshal(f.EnumType.Value[0].Name)
+		return b
+	}
+	switch s.Type {
+	case "int", "long", "float", "double":
+		return json.RawMessage("0")
+	case "boolean":
+		return json.RawMessage("false")
+	case "string", "bytes":
+		return json.RawMessage(`""`)
+	default:
+	}
+	return nil
+}
+
+// value returns the schema of a single value of f.
+func (c *fromConverter) value(f *descriptor.Field) *Schema {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			c.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return &Schema{Type: "null"}
+		}
+		switch name := f.MessageType.FullName(); name {
+		case "google.protobuf.Timestamp":
+			return &Schema{Type: "long", LogicalType: "timestamp-micros"}
+		default:
+			if t, ok := wrappers[name]; ok {
+				return &Schema{Type: primitives[t]}
+			}
+		}
+		return c.record(f.MessageType)
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			c.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return &Schema{Type: "null"}
+		}
+		return c.enum(f.EnumType)
+	default:
+	}
+	return &Schema{Type: primitives[f.Type]}
+}
+
+func (c *fromConverter) enum(en *descriptor.Enum) *Schema {
+	name := en.FullName()
+	if ref := c.named(name); ref != nil {
+		return ref
+	}
+	s := &Schema{Type: "enum", Name: en.Name, Namespace: strings.TrimSuffix(strings.TrimSuffix(name, en.Name), "."), Doc: doc(en.Comments)}
+	var numbers []int32
+	for _, v := range en.Value {
+		if !slices.Contains(numbers, v.Number) { // aliases
+			numbers = append(numbers, v.Number)
+			s.Symbols = append(s.Symbols, v.Name)
+		}
+	}
+	if len(s.Symbols) > 0 {
+		s.Default = s.Symbols[0]
+	}
+	return s
+}
+
+// primitives are the Avro types of the scalar types.
+var primitives = map[uint8]string{
+	descriptor.TypeDouble:   "double",
+	descriptor.TypeFloat:    "float",
+	descriptor.TypeInt64:    "long",
+	descriptor.TypeUint64:   "long",
+	descriptor.TypeInt32:    "int",
+	descriptor.TypeFixed64:  "long",
+	descriptor.TypeFixed32:  "long",
+	descriptor.TypeBool:     "boolean",
+	descriptor.TypeString:   "string",
+	descriptor.TypeBytes:    "bytes",
+	descriptor.TypeUint32:   "long",
+	descriptor.TypeSfixed32: "int",
+	descriptor.TypeSfixed64: "long",
+	descriptor.TypeSint32:   "int",
+	descriptor.TypeSint64:   "long",
+}
+
+// wrappers are the scalar types of the wrapper messages.
+var wrappers = map[string]uint8{
+	"google.protobuf.BoolValue":   descriptor.TypeBool,
+	"google.protobuf.BytesValue":  descriptor.TypeBytes,
+	"google.protobuf.DoubleValue": descriptor.TypeDouble,
+	"google.protobuf.FloatValue":  descriptor.TypeFloat,
+	"google.protobuf.Int32Value":  descriptor.TypeInt32,
+	"google.protobuf.Int64Value":  descriptor.TypeInt64,
+	"google.protobuf.StringValue": descriptor.TypeString,
+	"google.protobuf.UInt32Value": descriptor.TypeUint32,
+	"google.protobuf.UInt64Value": descriptor.TypeUint64,
+}
+
+// doc returns the leading comment of an element.
+func doc(c *descriptor.Comments) string {
+	if c == nil || c.Leading == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,357 @@
+package avro
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// ToFile returns a proto3 file called name in package pkg with a message per record and an enum per enum of schemas,
+// including the named types defined inside them. Types are named like their Avro name without the namespace.
+//
+// Fields with a union of null and a type are optional, unions of several types oneofs with a field per type.
+// Enums start with their default symbol, symbols used by another enum of the package get the enum name as prefix.
+// Fixed types are bytes and longs with a timestamp logical type timestamps, the other logical types are left out.
+func ToFile(name, pkg string, schemas ...*Schema) (*descriptor.File, error) {
+	c := &toConverter{pkg: pkg, defs: map[string]*Schema{}, types: map[string]string{}, symbols: map[string]bool{}}
+	for _, s := range schemas {
+		if err := c.collect(s, ""); err != nil {
+			return nil, err
+		}
+	}
+	f := &descriptor.File{Name: name, Package: pkg, Syntax: descriptor.SyntaxProto3}
+	for _, full := range c.order {
+		s := c.defs[full]
+		switch s.Type {
+		case "record":
+			m, err := c.message(s, namespace(full))
+			if err != nil {
+				return nil, fmt.Errorf("avro: record %s: %w", full, err)
+			}
+			f.Message = append(f.Message, m)
+		case "enum":
+			f.Enum = append(f.Enum, c.enum(s))
+		default: // fixed
+		}
+	}
+	if c.timestamps {
+		f.Dependency = []string{"google/protobuf/timestamp.proto"}
+	}
+	b, err := descriptor.Marshal([]*descriptor.File{f})
+	if err != nil {
+		return nil, err
+	}
+	// parsing sets the back references, the comments are not part of the encoding
+	files, err := descriptor.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	comments(files[0].Message, f.Message)
+	for i, en := range files[0].Enum {
+		en.Comments = f.Enum[i].Comments
+	}
+	return files[0], nil
+}
+
+type toConverter struct {
+	pkg        string
+	defs       map[string]*Schema // the named types by full name
+	order      []string           // of the full names of the named types
+	types      map[string]string  // full names of messages and enums by short name
+	symbols    map[string]bool    // of the enum values so far
+	timestamps bool               // google/protobuf/timestamp.proto is used
+}
+
+// namespace returns the namespace of a full name.
+func namespace(full string) string {
+	if i := strings.LastIndexByte(full, '.'); i >= 0 {
+		return full[:i]
+	}
+	return ""
+}
+
+// fullName returns the full name of a named type in namespace ns.
+func fullName(s *Schema, ns string) string {
+	switch {
+	case strings.Contains(s.Name, "."):
+		return s.Name
+	case s.Namespace != "":
+		return s.Namespace + "." + s.Name
+	case ns != "":
+		return ns + "." + s.Name
+	default:
+	}
+	return s.Name
+}
+
+// collect indexes the named types defined in s.
+func (c *toConverter) collect(s *Schema, ns string) error {
+	switch s.Type {
+	case "":
+		for _, b := range s.Union {
+			if err := c.collect(b, ns); err != nil {
+				return err
+			}
+		}
+	case "array":
+		if s.Items == nil {
+			return fmt.Errorf("avro: array without items")
+		}
+		return c.collect(s.Items, ns)
+	case "map":
+		if s.Values == nil {
+			return fmt.Errorf("avro: map without values")
+		}
+		return c.collect(s.Values, ns)
+	case "record", "enum", "fixed":
+		if s.Name == "" {
+			return fmt.Errorf("avro: %s without a name", s.Type)
+		}
+		full := fullName(s, ns)
+		if c.defs[full] != nil {
+			return fmt.Errorf("avro: %s is defined twice", full)
+		}
+		c.defs[full] = s
+		c.order = append(c.order, full)
+		short := full[strings.LastIndexByte(full, '.')+1:]
+		if s.Type != "fixed" {
+			if other, ok := c.types[short]; ok {
+				return fmt.Errorf("avro: %s and %s are both named %s", other, full, short)
+			}
+			c.types[short] = full
+		}
+		for _, f := range s.Fields {
+			if f.Type == nil {
+				return fmt.Errorf("avro: field %s of %s without a type", f.Name, full)
+			}
+			if err := c.collect(f.Type, namespace(full)); err != nil {
+				return err
+			}
+		}
+	default:
+	}
+	return nil
+}
+
+// resolve returns the definition of a named type referenced in namespace ns, nil if there is none.
+func (c *toConverter) resolve(name, ns string) *Schema {
+	if s := c.defs[name]; s != nil {
+		return s
+	}
+	if ns != "" && !strings.Contains(name, ".") {
+		return c.defs[ns+"."+name]
+	}
+	return nil
+}
+
+// typeName returns the reference of the message or enum of a named type.
+func (c *toConverter) typeName(s *Schema) string {
+	name := s.Name[strings.LastIndexByte(s.Name, '.')+1:]
+	if c.pkg == "" {
+		return "." + name
+	}
+	return "." + c.pkg + "." + name
+}
+
+func (c *toConverter) message(s *Schema, ns string) (*descriptor.Message, error) {
+	m := &descriptor.Message{Name: s.Name[strings.LastIndexByte(s.Name, '.')+1:], Comments: comment(s.Doc)}
+	var optional []*descriptor.Field
+	for _, af := range s.Fields {
+		fields, opt, err := c.fields(m, af, ns)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", af.Name, err)
+		}
+		for _, f := range fields {
+			f.Tag = uint32(len(m.Field) + 1)
+			m.Field = append(m.Field, f)
+		}
+		if opt {
+			optional = append(optional, fields[0])
+		}
+	}
+	// the synthetic oneofs of the optional fields follow the real ones
+	for _, f := range optional {
+		f.Proto3Optional, f.OneOfIndex = true, ptr(int32(len(m.OneOf)))
+		m.OneOf = append(m.OneOf, &descriptor.OneOf{Name: "_" + f.Name})
+	}
+	return m, nil
+}
+
+// fields returns the fields of a record field, several for a oneof, and whether the field is proto3 optional.
+func (c *toConverter) fields(m *descriptor.Message, af *Field, ns string) ([]*descriptor.Field, bool, error) {
+	f := &descriptor.Field{Name: af.Name, Label: descriptor.LabelOptional, Comments: comment(af.Doc)}
+	t := c.deref(af.Type, ns)
+	switch t.Type {
+	case "array":
+		f.Label = descriptor.LabelRepeated
+		return []*descriptor.Field{f}, false, c.single(f, t.Items, ns)
+	case "map":
+		entry := &descriptor.Message{Name: entryName(af.Name), Options: &descriptor.MessageOptions{MapEntry: true}, Field: []*descriptor.Field{
+			{Name: "key", Tag: 1, Label: descriptor.LabelOptional, Type: descriptor.TypeString},
+			{Name: "value", Tag: 2, Label: descriptor.LabelOptional},
+		}}
+		if err := c.single(entry.Field[1], t.Values, ns); err != nil {
+			return nil, false, err
+		}
+		m.Nested = append(m.Nested, entry)
+		f.Label, f.Type, f.TypeName = descriptor.LabelRepeated, descriptor.TypeMessage, c.typeName(&Schema{Name: m.Name})+"."+entry.Name
+		return []*descriptor.Field{f}, false, nil
+	case "":
+		branches := slices.DeleteFunc(slices.Clone(t.Union), func(b *Schema) bool { return b.Type == "null" })
+		switch {
+		case len(branches) == 0:
+			return nil, false, fmt.Errorf("union of nulls only")
+		case len(branches) == 1:
+			if err := c.single(f, branches[0], ns); err != nil {
+				return nil, false, err
+			}
+			nullable := len(t.Union) > 1
+			return []*descriptor.Field{f}, nullable && f.Type != descriptor.TypeMessage, nil
+		default:
+		}
+		i := ptr(int32(len(m.OneOf)))
+		m.OneOf = append(m.OneOf, &descriptor.OneOf{Name: af.Name, Comments: f.Comments})
+		var fields []*descriptor.Field
+		for _, b := range branches {
+			d := c.deref(b, ns)
+			name := d.Type
+			if d.Name != "" {
+				name = d.Name[strings.LastIndexByte(d.Name, '.')+1:]
+			}
+			mf := &descriptor.Field{Name: af.Name + "_" + snake(name), Label: descriptor.LabelOptional, OneOfIndex: i}
+			if err := c.single(mf, b, ns); err != nil {
+				return nil, false, err
+			}
+			fields = append(fields, mf)
+		}
+		return fields, false, nil
+	default:
+	}
+	return []*descriptor.Field{f}, false, c.single(f, t, ns)
+}
+
+// deref returns the definition of a reference to a named type, s for other schemas.
+func (c *toConverter) deref(s *Schema, ns string) *Schema {
+	if _, ok := scalarTypes[s.Type]; ok {
+		return s
+	}
+	if d := c.resolve(s.Type, ns); d != nil {
+		return d
+	}
+	return s
+}
+
+// single sets the type of a field holding single values of s.
+func (c *toConverter) single(f *descriptor.Field, s *Schema, ns string) error {
+	if typ, ok := scalarTypes[s.Type]; ok {
+		f.Type = typ
+		if s.Type == "long" && strings.HasPrefix(s.LogicalType, "timestamp-") {
+			f.Type, f.TypeName, c.timestamps = descriptor.TypeMessage, ".google.protobuf.Timestamp", true
+		}
+		return nil
+	}
+	switch s.Type {
+	case "null":
+		return fmt.Errorf("null outside of a union")
+	case "array", "map", "":
+		return fmt.Errorf("nested %s", map[string]string{"array": "array", "map": "map", "": "union"}[s.Type])
+	case "record", "enum", "fixed":
+	default:
+		d := c.resolve(s.Type, ns)
+		if d == nil {
+			return fmt.Errorf("unknown type %s", s.Type)
+		}
+		s = d
+	}
+	switch s.Type {
+	case "record":
+		f.Type, f.TypeName = descriptor.TypeMessage, c.typeName(s)
+	case "enum":
+		f.Type, f.TypeName = descriptor.TypeEnum, c.typeName(s)
+	default: // fixed
+		f.Type = descriptor.TypeBytes
+	}
+	return nil
+}
+
+// scalarTypes are the types of the primitives.
+var scalarTypes = map[string]uint8{
+	"boolean": descriptor.TypeBool,
+	"int":     descriptor.TypeInt32,
+	"long":    descriptor.TypeInt64,
+	"float":   descriptor.TypeFloat,
+	"double":  descriptor.TypeDouble,
+	"bytes":   descriptor.TypeBytes,
+	"string":  descriptor.TypeString,
+}
+
+func (c *toConverter) enum(s *Schema) *descriptor.Enum {
+	name := s.Name[strings.LastIndexByte(s.Name, '.')+1:]
+	en := &descriptor.Enum{Name: name, Comments: comment(s.Doc)}
+	symbols := s.Symbols
+	if i := slices.Index(symbols, s.Default); i > 0 {
+		// the default is the zero value
+		symbols = slices.Concat([]string{s.Default}, symbols[:i], symbols[i+1:])
+	}
+	for i, sym := range symbols {
+		if c.symbols[sym] {
+			sym = strings.ToUpper(snake(name)) + "_" + sym
+		}
+		c.symbols[sym] = true
+		en.Value = append(en.Value, &descriptor.EnumValue{Name: sym, Number: int32(i)})
+	}
+	return en
+}
+
+// comments copies the comments of the messages of the descriptor parsed from msgs.
+func comments(parsed, msgs []*descriptor.Message) {
+	for i, m := range parsed {
+		m.Comments = msgs[i].Comments
+		for j, f := range m.Field {
+			f.Comments = msgs[i].Field[j].Comments
+		}
+		for j, o := range m.OneOf {
+			o.Comments = msgs[i].OneOf[j].Comments
+		}
+		comments(m.Nested, msgs[i].Nested)
+	}
+}
+
+// comment returns the leading comment holding doc, nil if it is empty.
+func comment(doc string) *descriptor.Comments {
+	if doc == "" {
+		return nil
+	}
+	return &descriptor.Comments{Leading: " " + strings.ReplaceAll(doc, "\n", "\n ") + "\n"}
+}
+
+// entryName returns the name of the entry message of a map field like protoc: foo_bar has FooBarEntry.
+func entryName(field string) string {
+	name := descriptor.JSONName(field)
+	if name != "" && 'a' <= name[0] && name[0] <= 'z' {
+		name = string(name[0]-'a'+'A') + name[1:]
+	}
+	return name + "Entry"
+}
+
+// snake returns a name in snake case, OrderItem becomes order_item.
+func snake(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if 'A' <= ch && ch <= 'Z' {
+			if i > 0 && name[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			ch += 'a' - 'A'
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+func ptr(i int32) *int32 {
+	return &i
+}
@@ -0,0 +1,372 @@
+// Package docs generates reference documentation of packages from the comments of their .proto files.
+//
+// Each package gets a page with its services and methods, messages with a table of their fields,
+// extensions and enums with their values, in the order of the files. Types are anchored by their
+// fully qualified name, field and method types link there, across pages for other packages.
+// An index page lists the packages and their files. Pages are Markdown, or HTML documents.
+package docs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// A Format is the markup of the pages.
+type Format int
+
+const (
+	Markdown Format = iota
+	HTML
+)
+
+var formatNames = []string{"markdown", "html"}
+
+func (f Format) String() string {
+	if int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat returns the format called name, markdown or html.
+func ParseFormat(name string) (Format, error) {
+	i := slices.Index(formatNames, name)
+	if i < 0 {
+		return 0, fmt.Errorf("docs: unknown format %s, not markdown or html", name)
+	}
+	return Format(i), nil
+}
+
+// Options configure the generator.
+type Options struct {
+	Format Format
+}
+
+// A Page is a generated page.
+type Page struct {
+	Name    string // like shop.v1.md, default.md for the files without a package and index.md
+	Content []byte
+}
+
+// Generate returns the pages of the packages of the linked files, sorted by package, and the index page.
+// Only the types of files are linked, the types of other files are plain names.
+func (o Options) Generate(files []*descriptor.File) []*Page {
+	g := &generator{o: o, types: map[any]string{}}
+	packages := map[string][]*descriptor.File{}
+	for _, f := range files {
+		if packages[f.Package] == nil {
+			g.packages = append(g.packages, f.Package)
+		}
+		packages[f.Package] = append(packages[f.Package], f)
+		g.index(f.Package, f.Message, f.Enum)
+	}
+	slices.Sort(g.packages)
+	var pages []*Page
+	for _, pkg := range g.packages {
+		pages = append(pages, &Page{Name: g.page(pkg), Content: g.packagePage(pkg, packages[pkg])})
+	}
+	return append(pages, &Page{Name: "index" + g.ext(), Content: g.indexPage(packages)})
+}
+
+type generator struct {
+	o        Options
+	packages []string         // sorted
+	types    map[any]string   // messages and enums to their packages
+	current  string           // name of the page being generated
+	file     *descriptor.File // being documented
+	w        writer
+}
+
+func (g *generator) index(pkg string, msgs []*descriptor.Message, enums []*descriptor.Enum) {
+	for _, en := range enums {
+		g.types[en] = pkg
+	}
+	for _, m := range msgs {
+		g.types[m] = pkg
+		g.index(pkg, m.Nested, m.Enum)
+	}
+}
+
+func (g *generator) ext() string {
+	if g.o.Format == HTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// page returns the name of the page of a package.
+func (g *generator) page(pkg string) string {
+	if pkg == "" {
+		return "default" + g.ext()
+	}
+	return pkg + g.ext()
+}
+
+func (g *generator) newWriter() {
+	if g.o.Format == HTML {
+		g.w = &htmlWriter{}
+	} else {
+		g.w = &markdownWriter{}
+	}
+}
+
+func (g *generator) indexPage(packages map[string][]*descriptor.File) []byte {
+	g.newWriter()
+	g.w.begin("Packages")
+	var rows [][]string
+	for _, pkg := range g.packages {
+		name := pkg
+		if name == "" {
+			name = "(no package)"
+		}
+		var names []string
+		for _, f := range packages[pkg] {
+			names = append(names, g.w.code(f.Name))
+		}
+		rows = append(rows, []string{g.w.link(g.w.code(name), g.page(pkg)), strings.Join(names, ", ")})
+	}
+	g.w.table([]string{"Package", "Files"}, rows)
+	return g.w.end()
+}
+
+func (g *generator) packagePage(pkg string, files []*descriptor.File) []byte {
+	g.newWriter()
+	g.current = g.page(pkg)
+	title := "Package " + pkg
+	if pkg == "" {
+		title = "Files without a package"
+	}
+	g.w.begin(title)
+	var names []string
+	for _, f := range files {
+		names = append(names, g.w.code(f.Name))
+	}
+	g.w.paragraph(g.w.text("Files: ") + strings.Join(names, ", "))
+	scope := ""
+	if pkg != "" {
+		scope = pkg + "."
+	}
+	for _, f := range files {
+		g.file = f
+		g.w.heading(2, "", f.Name)
+		g.comments(f.Comments)
+		for _, s := range f.Service {
+			g.service(scope, s)
+		}
+		for _, m := range f.Message {
+			g.message(scope, m)
+		}
+		for _, en := range f.Enum {
+			g.enum(scope, en)
+		}
+		g.extensions(f.Extension)
+	}
+	return g.w.end()
+}
+
+// comments prints the comments of an element as paragraphs.
+func (g *generator) comments(c *descriptor.Comments) {
+	for _, p := range paragraphs(c) {
+		g.w.paragraph(g.w.text(p))
+	}
+}
+
+// paragraphs returns the paragraphs of the leading and trailing comment of an element, their lines joined by spaces.
+func paragraphs(c *descriptor.Comments) []string {
+	if c == nil {
+		return nil
+	}
+	var out []string
+	for _, text := range []string{c.Leading, c.Trailing} {
+		var lines []string
+		for _, l := range strings.Split(text, "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				lines = append(lines, l)
+			} else if len(lines) > 0 {
+				out, lines = append(out, strings.Join(lines, " ")), nil
+			}
+		}
+		if len(lines) > 0 {
+			out = append(out, strings.Join(lines, " "))
+		}
+	}
+	return out
+}
+
+// description returns the comments of an element for a table cell, with a note.
+func (g *generator) description(c *descriptor.Comments, notes ...string) string {
+	var parts []string
+	for _, p := range append(paragraphs(c), notes...) {
+		if p != "" {
+			parts = append(parts, g.w.text(p))
+		}
+	}
+	return strings.Join(parts, g.w.lineBreak())
+}
+
+func (g *generator) service(scope string, s *descriptor.Service) {
+	g.w.heading(3, scope+s.Name, "service "+s.Name)
+	g.comments(s.Comments)
+	var rows [][]string
+	for _, m := range s.Method {
+		in, out := g.typeLink(m.Input, m.InputType), g.typeLink(m.Output, m.OutputType)
+		if m.ClientStreaming {
+			in = g.w.text("stream ") + in
+		}
+		if m.ServerStreaming {
+			out = g.w.text("stream ") + out
+		}
+		var notes []string
+		if m.Options != nil && m.Options.Deprecated {
+			notes = append(notes, "Deprecated.")
+		}
+		rows = append(rows, []string{g.w.anchor(scope+s.Name+"."+m.Name, g.w.code(m.Name)), in, out, g.description(m.Comments, notes...)})
+	}
+	if len(rows) > 0 {
+		g.w.table([]string{"Method", "Request", "Response", "Description"}, rows)
+	}
+}
+
+func (g *generator) message(scope string, m *descriptor.Message) {
+	if m.IsMapEntry() {
+		return
+	}
+	name := strings.TrimPrefix(m.FullName(), scope)
+	g.w.heading(3, m.FullName(), "message "+name)
+	g.comments(m.Comments)
+	var rows [][]string
+	for _, f := range m.Field {
+		var notes []string
+		if o := f.RealOneOf(m); o != nil {
+			notes = append(notes, "Part of the oneof "+o.Name+".")
+		}
+		if f.Options != nil && f.Options.Deprecated {
+			notes = append(notes, "Deprecated.")
+		}
+		rows = append(rows, []string{g.w.code(f.Name), g.w.text(fmt.Sprint(f.Tag)), g.fieldType(f), g.w.text(g.label(f)), g.description(f.Comments, notes...)})
+	}
+	if len(rows) > 0 {
+		g.w.table([]string{"Field", "Number", "Type", "Label", "Description"}, rows)
+	} else {
+		g.w.paragraph(g.w.text("No fields."))
+	}
+	g.extensions(m.Extension)
+	for _, nm := range m.Nested {
+		g.message(scope, nm)
+	}
+	for _, en := range m.Enum {
+		g.enum(scope, en)
+	}
+}
+
+func (g *generator) enum(scope string, en *descriptor.Enum) {
+	g.w.heading(3, en.FullName(), "enum "+strings.TrimPrefix(en.FullName(), scope))
+	g.comments(en.Comments)
+	var rows [][]string
+	for _, v := range en.Value {
+		var notes []string
+		if v.Options != nil && v.Options.Deprecated {
+			notes = append(notes, "Deprecated.")
+		}
+		rows = append(rows, []string{g.w.code(v.Name), g.w.text(fmt.Sprint(v.Number)), g.description(v.Comments, notes...)})
+	}
+	g.w.table([]string{"Name", "Number", "Description"}, rows)
+}
+
+func (g *generator) extensions(exts []*descriptor.Field) {
+	if len(exts) == 0 {
+		return
+	}
+	g.w.heading(4, "", "Extensions")
+	var rows [][]string
+	for _, x := range exts {
+		rows = append(rows, []string{g.w.anchor(x.FullName(), g.w.code(x.Name)), g.typeLink(x.ExtendeeType, x.Extendee),
+			g.w.text(fmt.Sprint(x.Tag)), g.fieldType(x), g.description(x.Comments)})
+	}
+	g.w.table([]string{"Extension", "Extends", "Number", "Type", "Description"}, rows)
+}
+
+// label returns the label of a field as written in the .proto file, map fields have none.
+func (g *generator) label(f *descriptor.Field) string {
+	switch {
+	case f.IsMap():
+		return ""
+	case f.Label == descriptor.LabelRepeated:
+		return "repeated"
+	case f.Features().FieldPresence == descriptor.PresenceLegacyRequired:
+		return "required"
+	case f.Proto3Optional, g.file.Syntax == descriptor.SyntaxProto2 && f.Label == descriptor.LabelOptional && f.OneOfIndex == nil:
+		return "optional"
+	default:
+	}
+	return ""
+}
+
+// fieldType returns the type of a field, linked for messages and enums.
+func (g *generator) fieldType(f *descriptor.Field) string {
+	if f.IsMap() {
+		return g.w.text("map<") + g.valueType(f.Map.Key) + g.w.text(", ") + g.valueType(f.Map.Value) + g.w.text(">")
+	}
+	return g.valueType(f)
+}
+
+func (g *generator) valueType(f *descriptor.Field) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		return g.typeLink(f.MessageType, f.TypeName)
+	case descriptor.TypeEnum:
+		return g.typeLink(f.EnumType, f.TypeName)
+	default:
+	}
+	return g.w.code(scalars[f.Type])
+}
+
+// typeLink returns the full name of a message or enum, linked if it is documented.
+// name is used for unlinked types.
+func (g *generator) typeLink(x any, name string) string {
+	var full string
+	switch x := x.(type) {
+	case *descriptor.Message:
+		if x != nil {
+			full = x.FullName()
+		}
+	case *descriptor.Enum:
+		if x != nil {
+			full = x.FullName()
+		}
+	default:
+	}
+	if full == "" {
+		return g.w.code(strings.TrimPrefix(name, "."))
+	}
+	pkg, ok := g.types[x]
+	if !ok {
+		return g.w.code(full)
+	}
+	href := "#" + full
+	if page := g.page(pkg); page != g.current {
+		href = page + href
+	}
+	return g.w.link(g.w.code(full), href)
+}
+
+var scalars = map[uint8]string{
+	descriptor.TypeDouble:   "double",
+	descriptor.TypeFloat:    "float",
+	descriptor.TypeInt64:    "int64",
+	descriptor.TypeUint64:   "uint64",
+	descriptor.TypeInt32:    "int32",
+	descriptor.TypeFixed64:  "fixed64",
+	descriptor.TypeFixed32:  "fixed32",
+	descriptor.TypeBool:     "bool",
+	descriptor.TypeString:   "string",
+	descriptor.TypeBytes:    "bytes",
+	descriptor.TypeUint32:   "uint32",
+	descriptor.TypeSfixed32: "sfixed32",
+	descriptor.TypeSfixed64: "sfixed64",
+	descriptor.TypeSint32:   "sint32",
+	descriptor.TypeSint64:   "sint64",
+}
@@ -0,0 +1,148 @@
+package docs
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// A writer writes a page in a markup. The inline methods return markup used as the argument of the others.
+type writer interface {
+	begin(title string)
+	heading(level int, id, text string) // id anchors the heading if not empty
+	paragraph(inline string)
+	table(header []string, rows [][]string) // of inline cells
+	end() []byte
+
+	text(s string) string
+	code(s string) string
+	link(inline, href string) string
+	anchor(id, inline string) string
+	lineBreak() string
+}
+
+// markdownWriter writes GitHub flavored Markdown, anchors are HTML.
+type markdownWriter struct {
+	buf []byte
+}
+
+func (w *markdownWriter) begin(title string) {
+	w.buf = fmt.Appendf(w.buf, "# %s\n", w.text(title))
+}
+
+func (w *markdownWriter) heading(level int, id, text string) {
+	w.buf = append(w.buf, '\n')
+	if id != "" {
+		w.buf = fmt.Appendf(w.buf, "<a id=\"%s\"></a>\n", html.EscapeString(id))
+	}
+	w.buf = fmt.Appendf(w.buf, "%s %s\n", strings.Repeat("#", level), w.text(text))
+}
+
+func (w *markdownWriter) paragraph(inline string) {
+	w.buf = fmt.Appendf(w.buf, "\n%s\n", inline)
+}
+
+func (w *markdownWriter) table(header []string, rows [][]string) {
+	w.buf = fmt.Appendf(w.buf, "\n| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat(" --- |", len(header)))
+	for _, row := range rows {
+		w.buf = fmt.Appendf(w.buf, "| %s |\n", strings.Join(row, " | "))
+	}
+}
+
+func (w *markdownWriter) end() []byte {
+	return w.buf
+}
+
+// markdownEscaper escapes the characters with a meaning in Markdown text and table cells.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "|", `\|`, "#", `\#`)
+
+func (w *markdownWriter) text(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func (w *markdownWriter) code(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+func (w *markdownWriter) link(inline, href string) string {
+	return "[" + inline + "](" + href + ")"
+}
+
+func (w *markdownWriter) anchor(id, inline string) string {
+	return fmt.Sprintf("<a id=\"%s\"></a>%s", html.EscapeString(id), inline)
+}
+
+func (w *markdownWriter) lineBreak() string {
+	return "<br>"
+}
+
+// htmlWriter writes a standalone HTML document.
+type htmlWriter struct {
+	buf []byte
+}
+
+func (w *htmlWriter) begin(title string) {
+	w.buf = fmt.Appendf(w.buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%[1]s</title>\n%[2]s</head>\n<body>\n<h1>%[1]s</h1>\n",
+		html.EscapeString(title), style)
+}
+
+// style lays out the tables.
+const style = `<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+</style>
+`
+
+func (w *htmlWriter) heading(level int, id, text string) {
+	if id != "" {
+		w.buf = fmt.Appendf(w.buf, "<h%d id=\"%s\">%s</h%[1]d>\n", level, html.EscapeString(id), html.EscapeString(text))
+	} else {
+		w.buf = fmt.Appendf(w.buf, "<h%d>%s</h%[1]d>\n", level, html.EscapeString(text))
+	}
+}
+
+func (w *htmlWriter) paragraph(inline string) {
+	w.buf = fmt.Appendf(w.buf, "<p>%s</p>\n", inline)
+}
+
+func (w *htmlWriter) table(header []string, rows [][]string) {
+	w.buf = append(w.buf, "<table>\n<tr>"...)
+	for _, h := range header {
+		w.buf = fmt.Appendf(w.buf, "<th>%s</th>", h)
+	}
+	w.buf = append(w.buf, "</tr>\n"...)
+	for _, row := range rows {
+		w.buf = append(w.buf, "<tr>"...)
+		for _, c := range row {
+			w.buf = fmt.Appendf(w.buf, "<td>%s</td>", c)
+		}
+		w.buf = append(w.buf, "</tr>\n"...)
+	}
+	w.buf = append(w.buf, "</table>\n"...)
+}
+
+func (w *htmlWriter) end() []byte {
+	return append(w.buf, "</body>\n</html>\n"...)
+}
+
+func (w *htmlWriter) text(s string) string {
+	return html.EscapeString(s)
+}
+
+func (w *htmlWriter) code(s string) string {
+	return "<code>" + html.EscapeString(s) + "</code>"
+}
+
+func (w *htmlWriter) link(inline, href string) string {
+	return "<a href=\"" + html.EscapeString(href) + "\">" + inline + "</a>"
+}
+
+func (w *htmlWriter) anchor(id, inline string) string {
+	return "<span id=\"" + html.EscapeString(id) + "\">" + inline + "</span>"
+}
+
+func (w *htmlWriter) lineBreak() string {
+	return "<br>"
+}
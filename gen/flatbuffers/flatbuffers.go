@@ -0,0 +1,371 @@
+// Package flatbuffers converts .proto files to FlatBuffers schemas, reporting what does not translate.
+//
+// Each .proto file becomes a .fbs file in the namespace of its package,
+// including the .fbs files of the files it uses by their path like imports do.
+// Messages are tables with their fields ordered by number, nested types are named like Outer_Inner
+// and services are rpc_services. Maps become vectors of entry tables keyed by their key field,
+// a oneof of messages a union and other oneofs plain fields, extensions are left out.
+// Generate reports each of these as a Problem, like the enum aliases and defaults FlatBuffers has no form for.
+package flatbuffers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/gen/internal/names"
+)
+
+// A Problem is an element of a .proto file without a FlatBuffers equivalent and what became of it.
+type Problem struct {
+	Element string // fully qualified name
+	Problem string
+}
+
+func (p Problem) String() string {
+	return p.Element + ": " + p.Problem
+}
+
+// Generate generates the schema of f, deps are the linked files of the types it uses.
+// It returns the name of the .fbs file, next to the .proto file, its content and the problems of the translation.
+func Generate(f *descriptor.File, deps []*descriptor.File) (string, []byte, []Problem, error) {
+	g := &generator{file: f, owners: map[any]*descriptor.File{}, names: map[any]string{}, includes: map[string]bool{}}
+	for _, d := range append(deps, f) {
+		g.index(d, "", d.Message, d.Enum)
+	}
+	for _, x := range f.Extension {
+		g.report(x.FullName(), "extensions are left out")
+	}
+	for _, en := range f.Enum {
+		g.enum(en)
+	}
+	for _, m := range f.Message {
+		g.message(m)
+	}
+	for _, s := range f.Service {
+		g.service(s)
+	}
+	if g.err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %w", f.Name, g.err)
+	}
+	return module(f.Name) + ".fbs", g.source(), g.problems, nil
+}
+
+// module returns the name of a .proto file without extension.
+func module(name string) string {
+	return strings.TrimSuffix(name, ".proto")
+}
+
+type generator struct {
+	file     *descriptor.File
+	buf      []byte
+	err      error // the first error, like an unlinked type
+	problems []Problem
+
+	owners   map[any]*descriptor.File // of the messages and enums
+	names    map[any]string           // of the messages and enums, relative to the package
+	includes map[string]bool          // the included .fbs files
+}
+
+// index names the messages and enums of file by their name relative to the package, with underscores for dots.
+func (g *generator) index(file *descriptor.File, scope string, msgs []*descriptor.Message, enums []*descriptor.Enum) {
+	for _, en := range enums {
+		g.owners[en], g.names[en] = file, scope+en.Name
+	}
+	for _, m := range msgs {
+		g.owners[m], g.names[m] = file, scope+m.Name
+		g.index(file, scope+m.Name+"_", m.Nested, m.Enum)
+	}
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.buf = fmt.Appendf(g.buf, format, args...)
+	g.buf = append(g.buf, '\n')
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+func (g *generator) report(element, format string, args ...any) {
+	g.problems = append(g.problems, Problem{element, fmt.Sprintf(format, args...)})
+}
+
+// doc prints the leading comment of an element as a documentation comment, indented by indent.
+func (g *generator) doc(indent string, c *descriptor.Comments) {
+	if c == nil || c.Leading == "" {
+		return
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n") {
+		g.line("%s///%s", indent, l)
+	}
+}
+
+// typeName returns the name of a message or enum, qualified with its namespace if it is in another package.
+func (g *generator) typeName(x any) string {
+	file, ok := g.owners[x]
+	if !ok {
+		g.fail(errors.New("types are not linked"))
+		return "ubyte"
+	}
+	if file != g.file {
+		g.includes[file.Name] = true
+	}
+	if file.Package == g.file.Package || file.Package == "" {
+		return g.names[x]
+	}
+	return file.Package + "." + g.names[x]
+}
+
+func (g *generator) enum(en *descriptor.Enum) {
+	values := slices.Clone(en.Value)
+	slices.SortStableFunc(values, func(a, b *descriptor.EnumValue) int { return cmp.Compare(a.Number, b.Number) })
+	g.line("")
+	g.doc("", en.Comments)
+	g.line("enum %s : int {", g.names[en])
+	for _, v := range values {
+		if k := kept(en, v.Number); k != v {
+			g.report(en.FullName()+"."+v.Name, "the alias of %s is left out", k.Name)
+			continue
+		}
+		g.doc("  ", v.Comments)
+		g.line("  %s = %d,", v.Name, v.Number)
+	}
+	g.line("}")
+}
+
+// kept returns the value of en kept for number, the first one declared.
+func kept(en *descriptor.Enum, number int32) *descriptor.EnumValue {
+	for _, v := range en.Value {
+		if v.Number == number {
+			return v
+		}
+	}
+	return nil
+}
+
+func (g *generator) message(m *descriptor.Message) {
+	name := m.FullName()
+	if len(m.ExtensionRange) > 0 {
+		g.report(name, "the extension ranges are left out")
+	}
+	for _, x := range m.Extension {
+		g.report(x.FullName(), "extensions are left out")
+	}
+	fields := slices.Clone(m.Field)
+	slices.SortStableFunc(fields, func(a, b *descriptor.Field) int { return cmp.Compare(a.Tag, b.Tag) })
+	unions := map[int32]bool{} // the oneofs translated to unions
+	for i, fs := range m.OneOfFields() {
+		o := m.OneOf[i]
+		if o.Synthetic {
+			continue
+		}
+		if g.union(m, o, fs) {
+			unions[int32(i)] = true
+			g.report(name+"."+o.Name, "the oneof is the union %s", g.names[m]+"_"+names.Upper(o.Name))
+		} else {
+			g.report(name+"."+o.Name, "the oneof has fields other than messages or of the same type, its fields are plain fields")
+		}
+	}
+	g.line("")
+	g.doc("", m.Comments)
+	g.line("table %s {", g.names[m])
+	done := map[int32]bool{}
+	for _, f := range fields {
+		if o := f.RealOneOf(m); o != nil && unions[*f.OneOfIndex] {
+			if !done[*f.OneOfIndex] {
+				done[*f.OneOfIndex] = true
+				g.doc("  ", o.Comments)
+				g.line("  %s:%s;", o.Name, g.names[m]+"_"+names.Upper(o.Name))
+			}
+			continue
+		}
+		g.doc("  ", f.Comments)
+		g.line("  %s:%s%s;", f.Name, g.fieldType(f), g.attributes(f))
+	}
+	g.line("}")
+	for _, f := range m.Field {
+		if f.IsMap() {
+			g.report(f.FullName(), "the map is a vector of %s tables, keyed by their key", g.names[f.MessageType])
+		}
+	}
+	for _, en := range m.Enum {
+		g.enum(en)
+	}
+	for _, nm := range m.Nested {
+		if nm.IsMapEntry() {
+			g.entry(nm)
+		} else {
+			g.message(nm)
+		}
+	}
+}
+
+// union prints the union of a oneof of messages of different types, false if it has other fields.
+func (g *generator) union(m *descriptor.Message, o *descriptor.OneOf, fields []*descriptor.Field) bool {
+	var types []string
+	for _, f := range fields {
+		if f.MessageType == nil || f.Type != descriptor.TypeMessage {
+			return false
+		}
+		t := g.typeName(f.MessageType)
+		if slices.Contains(types, t) {
+			return false
+		}
+		types = append(types, t)
+	}
+	g.line("")
+	g.line("union %s { %s }", g.names[m]+"_"+names.Upper(o.Name), strings.Join(types, ", "))
+	return true
+}
+
+// entry prints the table of a map entry, keyed by the key field.
+func (g *generator) entry(m *descriptor.Message) {
+	g.line("")
+	g.line("table %s {", g.names[m])
+	for _, f := range m.Field {
+		attr := ""
+		if f.Tag == 1 {
+			attr = " (key)"
+		}
+		g.line("  %s:%s%s;", f.Name, g.fieldType(f), attr)
+	}
+	g.line("}")
+}
+
+// attributes returns the default and attributes of a field.
+func (g *generator) attributes(f *descriptor.Field) string {
+	var s string
+	scalar := f.Label != descriptor.LabelRepeated && f.Type != descriptor.TypeMessage && f.Type != descriptor.TypeGroup &&
+		f.Type != descriptor.TypeString && f.Type != descriptor.TypeBytes
+	switch {
+	case f.DefaultValue != "" && scalar:
+		s = " = " + f.DefaultValue // inf, nan and enum names are spelled alike
+		if f.Type == descriptor.TypeEnum && f.EnumType != nil {
+			if i := slices.IndexFunc(f.EnumType.Value, func(v *descriptor.EnumValue) bool { return v.Name == f.DefaultValue }); i >= 0 {
+				s = " = " + kept(f.EnumType, f.EnumType.Value[i].Number).Name // but the aliases left out
+			}
+		}
+	case f.DefaultValue != "":
+		g.report(f.FullName(), "the default %q of a string or bytes field is left out", f.DefaultValue)
+	case scalar && f.HasPresence():
+		s = " = null" // an optional scalar
+	case f.Type == descriptor.TypeEnum && f.Label != descriptor.LabelRepeated && f.EnumType != nil &&
+		!slices.ContainsFunc(f.EnumType.Value, func(v *descriptor.EnumValue) bool { return v.Number == 0 }):
+		s = " = " + f.EnumType.Value[0].Name // FlatBuffers defaults to 0
+	default:
+	}
+	switch {
+	case f.Features().FieldPresence != descriptor.PresenceLegacyRequired:
+	case scalar:
+		g.report(f.FullName(), "a required scalar is an optional scalar")
+	default:
+		s += " (required)"
+	}
+	if f.Options != nil && f.Options.Deprecated {
+		s += " (deprecated)"
+	}
+	return s
+}
+
+// fieldType returns the type of f, a vector for repeated fields.
+func (g *generator) fieldType(f *descriptor.Field) string {
+	if f.Label == descriptor.LabelRepeated {
+		return "[" + g.valueType(f) + "]"
+	}
+	return g.valueType(f)
+}
+
+// valueType returns the type of a single value of f.
+func (g *generator) valueType(f *descriptor.Field) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "ubyte"
+		}
+		return g.typeName(f.MessageType)
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "int"
+		}
+		return g.typeName(f.EnumType)
+	case descriptor.TypeBytes:
+		if f.Label == descriptor.LabelRepeated {
+			g.report(f.FullName(), "a vector of byte vectors is a vector of strings")
+			return "string"
+		}
+		return "[ubyte]"
+	default:
+	}
+	return scalars[f.Type]
+}
+
+var scalars = map[uint8]string{
+	descriptor.TypeDouble:   "double",
+	descriptor.TypeFloat:    "float",
+	descriptor.TypeInt64:    "long",
+	descriptor.TypeUint64:   "ulong",
+	descriptor.TypeInt32:    "int",
+	descriptor.TypeFixed64:  "ulong",
+	descriptor.TypeFixed32:  "uint",
+	descriptor.TypeBool:     "bool",
+	descriptor.TypeString:   "string",
+	descriptor.TypeUint32:   "uint",
+	descriptor.TypeSfixed32: "int",
+	descriptor.TypeSfixed64: "long",
+	descriptor.TypeSint32:   "int",
+	descriptor.TypeSint64:   "long",
+}
+
+func (g *generator) service(s *descriptor.Service) {
+	g.line("")
+	g.doc("", s.Comments)
+	g.line("rpc_service %s {", s.Name)
+	for _, m := range s.Method {
+		if m.Input == nil || m.Output == nil {
+			g.fail(fmt.Errorf("%s.%s: types are not linked", s.Name, m.Name))
+			return
+		}
+		var attr string
+		switch {
+		case m.ClientStreaming && m.ServerStreaming:
+			attr = ` (streaming: "bidi")`
+		case m.ClientStreaming:
+			attr = ` (streaming: "client")`
+		case m.ServerStreaming:
+			attr = ` (streaming: "server")`
+		default:
+		}
+		g.doc("  ", m.Comments)
+		g.line("  %s(%s):%s%s;", m.Name, g.typeName(m.Input), g.typeName(m.Output), attr)
+	}
+	g.line("}")
+}
+
+// source returns the header with the includes and the namespace, followed by the definitions.
+func (g *generator) source() []byte {
+	var b []byte
+	b = fmt.Appendf(b, "// Code generated by proton generate flatbuffers. DO NOT EDIT.\n// source: %s\n", g.file.Name)
+	if len(g.includes) > 0 {
+		b = append(b, '\n')
+		files := make([]string, 0, len(g.includes))
+		for name := range g.includes {
+			files = append(files, name)
+		}
+		slices.Sort(files)
+		for _, name := range files {
+			b = fmt.Appendf(b, "include %q;\n", module(name)+".fbs")
+		}
+	}
+	if g.file.Package != "" {
+		b = fmt.Appendf(b, "\nnamespace %s;\n", g.file.Package)
+	}
+	return append(b, g.buf...)
+}
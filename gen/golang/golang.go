@@ -0,0 +1,299 @@
+// Package golang generates Go code for the messages and enums of .proto files.
+//
+// The generated structs encode and decode themselves with the wire package,
+// without the reflection based runtime of google.golang.org/protobuf:
+//
+//	type Order struct {
+//		Id    int32   // 1
+//		Note  *string // 2
+//		Items []*Item // 3
+//
+//		UnknownFields wire.UnknownFieldSet
+//	}
+//
+//	func (m *Order) MarshalBinary() ([]byte, error)
+//	func (m *Order) UnmarshalBinary(data []byte) error
+//	func (m *Order) Encode(e *wire.Encoder)
+//	func (m *Order) Merge(data []byte) error
+//	func (m *Order) MergeDepth(data []byte, depth int) error
+//
+// Names follow protoc-gen-go: fields with presence are pointers, oneofs are interfaces
+// implemented by a wrapper struct per field, enums are named int32 types.
+// Extensions are kept as unknown fields, like the unknown values of closed enums.
+// Imported messages must be generated with this package too, including the well-known types,
+// whose files are mapped to their generated packages with Options.Packages.
+package golang
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/format"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// Suffix is appended to the names of the .proto files, without .proto, to name the generated files.
+const Suffix = ".proton.go"
+
+// Options configure the generator like the parameters of protoc-gen-go.
+type Options struct {
+	// SourceRelative puts the generated files next to their .proto files,
+	// by default the files are placed below their Go import path.
+	SourceRelative bool
+
+	// Module is stripped from the import paths of the generated files if SourceRelative is not set.
+	Module string
+
+	// Packages overrides the go_package option of the files, keyed by their name.
+	Packages map[string]string
+}
+
+// Generate generates the Go file of f, deps are the linked files of the types it uses.
+// It returns the name of the file relative to the output directory and its formatted source.
+func (o Options) Generate(f *descriptor.File, deps []*descriptor.File) (string, []byte, error) {
+	pkg, err := o.goPackage(f)
+	if err != nil {
+		return "", nil, err
+	}
+	name := strings.TrimSuffix(f.Name, ".proto") + Suffix
+	if !o.SourceRelative {
+		name = pkg.path + "/" + path.Base(name)
+		if o.Module != "" {
+			rel, ok := strings.CutPrefix(name, o.Module+"/")
+			if !ok {
+				return "", nil, fmt.Errorf("%s: import path %s is not in module %s", f.Name, pkg.path, o.Module)
+			}
+			name = rel
+		}
+	}
+	g := &generator{
+		o:        o,
+		file:     f,
+		pkg:      pkg,
+		owners:   map[any]*descriptor.File{},
+		names:    map[any]string{},
+		prefixes: map[*descriptor.Enum]string{},
+		imports:  map[string]string{},
+		aliases:  map[string]string{},
+	}
+	for _, p := range []string{"github.com/defsrc/proton/wire", "maps", "math", "slices", "strconv"} {
+		g.aliases[path.Base(p)] = p
+	}
+	for _, d := range append(deps, f) {
+		g.index(d, "", d.Message, d.Enum)
+	}
+	g.body()
+	if g.err != nil {
+		return "", nil, fmt.Errorf("%s: %w", f.Name, g.err)
+	}
+	src, err := format.Source(g.source())
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: formatting the generated code: %w", f.Name, err)
+	}
+	return name, src, nil
+}
+
+// runtimeModule is the module of the packages of protoc-gen-go for the well-known types, like .../types/known/anypb.
+const runtimeModule = "google.golang.org/protobuf"
+
+// A goPackage is a Go package of generated files.
+type goPackage struct {
+	path, name string
+}
+
+// goPackage returns the package of f from Packages or its go_package option,
+// which may end in ;name if the name is not the last element of the path.
+// The go_package options of the well-known types name the packages of google.golang.org/protobuf,
+// whose types lack the generated methods, so their files must be in Packages.
+func (o Options) goPackage(f *descriptor.File) (goPackage, error) {
+	opt, ok := o.Packages[f.Name]
+	if !ok && f.Options != nil {
+		opt = f.Options.GoPackage
+		if strings.HasPrefix(opt, runtimeModule+"/") {
+			return goPackage{}, fmt.Errorf("%s: go_package %s has the types of protoc-gen-go, generate the file with this package and pass M%s=PATH", f.Name, opt, f.Name)
+		}
+	}
+	if opt == "" {
+		return goPackage{}, fmt.Errorf("%s: no Go import path, set the go_package option or pass M%s=PATH", f.Name, f.Name)
+	}
+	p, name, ok := strings.Cut(opt, ";")
+	if !ok {
+		name = path.Base(p)
+	}
+	return goPackage{path: p, name: identifier(name)}, nil
+}
+
+// identifier replaces the characters of s that cannot be part of a Go identifier.
+func identifier(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !isLetter(c) && !isDigit(c) {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || isDigit(b[0]) {
+		b = append([]byte{'_'}, b...)
+	}
+	return string(b)
+}
+
+// camelCase converts a .proto name to an exported Go name like protoc-gen-go:
+// underscores followed by a lower case letter and dots followed by one are dropped and the letter capitalized,
+// other dots become underscores and a leading underscore becomes an X.
+func camelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isLower(s[i+1]):
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+		case isDigit(c):
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool  { return 'a' <= c && c <= 'z' }
+func isDigit(c byte) bool  { return '0' <= c && c <= '9' }
+func isLetter(c byte) bool { return isLower(c) || 'A' <= c && c <= 'Z' || c == '_' }
+
+// methods are the names of the generated methods, fields with these names get trailing underscores.
+var methods = map[string]bool{"MarshalBinary": true, "UnmarshalBinary": true, "Encode": true, "Merge": true, "MergeDepth": true, "UnknownFields": true}
+
+type generator struct {
+	o    Options
+	file *descriptor.File
+	pkg  goPackage
+	buf  []byte
+	err  error // the first error, like a type in a file without a Go package
+
+	owners   map[any]*descriptor.File    // of the messages and enums
+	names    map[any]string              // Go names of the messages and enums
+	prefixes map[*descriptor.Enum]string // of the Go names of the enum values
+	imports  map[string]string           // aliases of the imported packages by path
+	aliases  map[string]string           // paths by alias, including the ones reserved for the packages of the generated code
+}
+
+// index names the messages and enums of file, scope is the name of the enclosing message relative to the package.
+func (g *generator) index(file *descriptor.File, scope string, msgs []*descriptor.Message, enums []*descriptor.Enum) {
+	for _, en := range enums {
+		g.owners[en], g.names[en] = file, camelCase(scope+en.Name)
+		g.prefixes[en] = g.names[en]
+		if scope != "" {
+			g.prefixes[en] = camelCase(strings.TrimSuffix(scope, "."))
+		}
+	}
+	for _, m := range msgs {
+		g.owners[m], g.names[m] = file, camelCase(scope+m.Name)
+		g.index(file, scope+m.Name+".", m.Nested, m.Enum)
+	}
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.buf = fmt.Appendf(g.buf, format, args...)
+	g.buf = append(g.buf, '\n')
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// use imports the package path and returns the qualifier of its identifiers.
+func (g *generator) use(path, name string) string {
+	if path == g.pkg.path {
+		return ""
+	}
+	if alias, ok := g.imports[path]; ok {
+		return alias + "."
+	}
+	alias := name
+	for i := 2; g.aliases[alias] != "" && g.aliases[alias] != path; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+	g.imports[path], g.aliases[alias] = alias, path
+	return alias + "."
+}
+
+// wire returns the qualifier of the wire package.
+func (g *generator) wire() string {
+	return g.use("github.com/defsrc/proton/wire", "wire")
+}
+
+// typeName returns the qualified Go name of a message or enum.
+func (g *generator) typeName(x any) string {
+	return g.qualifier(x) + g.names[x]
+}
+
+// valueName returns the qualified Go name of an enum value, prefixed like protoc-gen-go
+// with the enum for top level enums and with the enclosing message for nested ones.
+func (g *generator) valueName(en *descriptor.Enum, v *descriptor.EnumValue) string {
+	return g.qualifier(en) + g.prefixes[en] + "_" + v.Name
+}
+
+// qualifier imports the package of a message or enum and returns the qualifier of its name.
+func (g *generator) qualifier(x any) string {
+	file, ok := g.owners[x]
+	if !ok {
+		g.fail(errors.New("types are not linked"))
+		return "_."
+	}
+	pkg, err := g.o.goPackage(file)
+	if err != nil {
+		g.fail(err)
+		return "_."
+	}
+	return g.use(pkg.path, pkg.name)
+}
+
+// source returns the header with the imports and the generated declarations.
+func (g *generator) source() []byte {
+	var b []byte
+	b = fmt.Appendf(b, "// Code generated by proton generate go. DO NOT EDIT.\n// source: %s\n\npackage %s\n", g.file.Name, g.pkg.name)
+	if len(g.imports) > 0 {
+		// the standard library first, separated by a blank line
+		paths := slices.SortedFunc(maps.Keys(g.imports), func(a, b string) int {
+			return cmp.Or(cmp.Compare(remote(a), remote(b)), cmp.Compare(a, b))
+		})
+		b = append(b, "\nimport (\n"...)
+		for i, p := range paths {
+			if i > 0 && remote(p) > remote(paths[i-1]) {
+				b = append(b, '\n')
+			}
+			if alias := g.imports[p]; alias == path.Base(p) {
+				b = fmt.Appendf(b, "\t%q\n", p)
+			} else {
+				b = fmt.Appendf(b, "\t%s %q\n", alias, p)
+			}
+		}
+		b = append(b, ")\n"...)
+	}
+	return append(b, g.buf...)
+}
+
+// remote is 1 for import paths outside of the standard library, which start with a domain name.
+func remote(path string) int {
+	if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,571 @@
+package golang
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/internal/cstring"
+)
+
+// A scalar describes the Go type and the encoding of a scalar field type.
+type scalar struct {
+	goType string
+	method string // of wire.Encoder and the wire.Append functions: Varint, Fixed32, Fixed64, String or Bytes
+	encode string // the encoded value of the Go value %[1]s
+	decode string // the Go value of the FieldRef.Value %[1]s or the FieldRef.Bytes of strings and bytes
+	kind   string // of the tag
+}
+
+var scalars = map[uint8]scalar{
+	descriptor.TypeDouble:   {"float64", "Fixed64", "wire.EncodeDouble(%s)", "wire.DecodeDouble(%s)", "Tag64bit"},
+	descriptor.TypeFloat:    {"float32", "Fixed32", "wire.EncodeFloat(%s)", "wire.DecodeFloat(%s)", "Tag32bit"},
+	descriptor.TypeInt64:    {"int64", "Varint", "uint64(%s)", "int64(%s)", "TagUvarint"},
+	descriptor.TypeUint64:   {"uint64", "Varint", "%s", "%s", "TagUvarint"},
+	descriptor.TypeInt32:    {"int32", "Varint", "wire.EncodeInt32(%s)", "wire.DecodeInt32(%s)", "TagUvarint"},
+	descriptor.TypeFixed64:  {"uint64", "Fixed64", "%s", "%s", "Tag64bit"},
+	descriptor.TypeFixed32:  {"uint32", "Fixed32", "%s", "uint32(%s)", "Tag32bit"},
+	descriptor.TypeBool:     {"bool", "Varint", "wire.EncodeBool(%s)", "wire.DecodeBool(%s)", "TagUvarint"},
+	descriptor.TypeString:   {"string", "String", "%s", "string(%s)", "TagSequence"},
+	descriptor.TypeBytes:    {"[]byte", "Bytes", "%s", "append([]byte{}, %s...)", "TagSequence"},
+	descriptor.TypeUint32:   {"uint32", "Varint", "uint64(%s)", "uint32(%s)", "TagUvarint"},
+	descriptor.TypeEnum:     {"", "Varint", "wire.EncodeInt32(int32(%s))", "", "TagUvarint"},
+	descriptor.TypeSfixed32: {"int32", "Fixed32", "uint32(%s)", "wire.DecodeSfixed32(%s)", "Tag32bit"},
+	descriptor.TypeSfixed64: {"int64", "Fixed64", "uint64(%s)", "wire.DecodeSfixed64(%s)", "Tag64bit"},
+	descriptor.TypeSint32:   {"int32", "Varint", "wire.EncodeSint32(%s)", "wire.DecodeSint32(%s)", "TagUvarint"},
+	descriptor.TypeSint64:   {"int64", "Varint", "wire.EncodeSint64(%s)", "wire.DecodeSint64(%s)", "TagUvarint"},
+}
+
+// unpack names the wire function decoding the packed values of a method.
+var unpack = map[string]string{"Varint": "UnpackVarints", "Fixed32": "UnpackFixed32s", "Fixed64": "UnpackFixed64s"}
+
+// A field is a field of a generated struct.
+type field struct {
+	*descriptor.Field
+	name   string // of the struct field, or of the oneof wrapper field
+	wrap   string // the oneof wrapper type, "" for other fields
+	oneof  string // the struct field of the oneof
+	goType string
+	scalar scalar // of scalars, enums and the values of maps
+}
+
+func (g *generator) body() {
+	for _, en := range g.file.Enum {
+		g.enum(en)
+	}
+	for _, m := range g.file.Message {
+		g.message(m)
+	}
+}
+
+// comment prints the leading comment of an element and a deprecation notice.
+func (g *generator) comment(c *descriptor.Comments, deprecated bool) {
+	if c != nil && c.Leading != "" {
+		for _, l := range strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n") {
+			g.line("//%s", l)
+		}
+	}
+	if deprecated {
+		if c != nil && c.Leading != "" {
+			g.line("//")
+		}
+		g.line("// Deprecated: Do not use.")
+	}
+}
+
+func (g *generator) enum(en *descriptor.Enum) {
+	name := g.names[en]
+	g.line("")
+	g.comment(en.Comments, en.Options != nil && en.Options.Deprecated)
+	g.line("type %s int32", name)
+	if len(en.Value) > 0 {
+		g.line("\nconst (")
+		for _, v := range en.Value {
+			g.comment(v.Comments, v.Options != nil && v.Options.Deprecated)
+			g.line("%s %s = %d", g.valueName(en, v), name, v.Number)
+		}
+		g.line(")")
+	}
+	g.line("\n// String returns the name of the value, or its number if it has none.")
+	g.line("func (x %s) String() string {", name)
+	g.line("switch x {")
+	seen := map[int32]bool{}
+	for _, v := range en.Value {
+		if !seen[v.Number] {
+			seen[v.Number] = true
+			g.line("case %s:\nreturn %q", g.valueName(en, v), v.Name)
+		}
+	}
+	g.line("default:\n}")
+	g.line("return %sItoa(int(x))\n}", g.use("strconv", "strconv"))
+}
+
+// fields returns the struct fields of m. Like protoc-gen-go, names conflicting with the methods,
+// the getters or the fields before them get trailing underscores.
+func (g *generator) fields(m *descriptor.Message) []*field {
+	var fields []*field
+	typeNames := map[string]bool{}
+	for _, name := range g.names {
+		typeNames[name] = true
+	}
+	used := maps.Clone(methods)
+	unique := func(name string, getter bool) string {
+		for used[name] || getter && used["Get"+name] {
+			name += "_"
+		}
+		used[name] = true
+		if getter {
+			used["Get"+name] = true
+		}
+		return name
+	}
+	oneofs := map[*descriptor.OneOf]string{}
+	for _, f := range m.Field {
+		x := &field{Field: f, name: camelCase(f.Name)}
+		x.goType, x.scalar = g.goType(f)
+		if o := f.RealOneOf(m); o != nil {
+			if _, ok := oneofs[o]; !ok {
+				oneofs[o] = unique(camelCase(o.Name), false)
+			}
+			x.oneof = oneofs[o]
+			x.wrap = g.names[m] + "_" + x.name
+			for typeNames[x.wrap] {
+				x.wrap += "_"
+			}
+		} else {
+			x.name = unique(x.name, x.getter())
+		}
+		fields = append(fields, x)
+	}
+	return fields
+}
+
+// goType returns the Go type of a field, and the scalar encoding of scalars and map values.
+func (g *generator) goType(f *descriptor.Field) (string, scalar) {
+	elem, s := g.elemType(f)
+	switch {
+	case f.IsMap():
+		k, _ := g.elemType(f.Map.Key)
+		v, vs := g.elemType(f.Map.Value)
+		return "map[" + k + "]" + v, vs
+	case f.Label == descriptor.LabelRepeated:
+		return "[]" + elem, s
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup || f.Type == descriptor.TypeBytes:
+		return elem, s
+	case f.OneOfIndex != nil && !f.Proto3Optional:
+		return elem, s // in a oneof wrapper
+	case f.HasPresence():
+		return "*" + elem, s
+	default:
+	}
+	return elem, s
+}
+
+// elemType returns the Go type of a single value of a field.
+func (g *generator) elemType(f *descriptor.Field) (string, scalar) {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "_", scalar{}
+		}
+		return "*" + g.typeName(f.MessageType), scalar{}
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "_", scalar{}
+		}
+		s := scalars[f.Type]
+		s.goType = g.typeName(f.EnumType)
+		s.decode = s.goType + "(wire.DecodeEnum(%s))"
+		return s.goType, s
+	default:
+	}
+	s, ok := scalars[f.Type]
+	if !ok {
+		g.fail(fmt.Errorf("%s: unknown type %d", f.FullName(), f.Type))
+		return "_", scalar{}
+	}
+	return s.goType, s
+}
+
+// pointer reports whether a scalar field is a pointer, tracking its presence.
+func (x *field) pointer() bool {
+	return strings.HasPrefix(x.goType, "*") && x.scalar.method != ""
+}
+
+// getter reports whether a field has a getter: the pointers and the bytes with a default value.
+func (x *field) getter() bool {
+	if x.wrap != "" {
+		return false
+	}
+	return x.pointer() || x.Type == descriptor.TypeBytes && x.Label != descriptor.LabelRepeated && x.DefaultValue != ""
+}
+
+func (g *generator) message(m *descriptor.Message) {
+	if m.IsMapEntry() {
+		return
+	}
+	name := g.names[m]
+	fields := g.fields(m)
+	g.line("")
+	g.comment(m.Comments, m.Options != nil && m.Options.Deprecated)
+	g.line("type %s struct {", name)
+	var oneofs []string
+	for _, x := range fields {
+		if x.wrap != "" {
+			if slices.Contains(oneofs, x.oneof) {
+				continue
+			}
+			oneofs = append(oneofs, x.oneof)
+			g.line("// Types that are valid to be assigned to %s:", x.oneof)
+			for _, y := range fields {
+				if y.oneof == x.oneof {
+					g.line("//\t*%s", y.wrap)
+				}
+			}
+			g.line("%s is%s_%s", x.oneof, name, x.oneof)
+			continue
+		}
+		g.comment(x.Comments, x.Options != nil && x.Options.Deprecated)
+		g.line("%s %s // %d", x.name, x.goType, x.Tag)
+	}
+	g.line("\nUnknownFields %sUnknownFieldSet\n}", g.wire())
+	for _, o := range oneofs {
+		g.line("\ntype is%s_%s interface {\nis%[1]s_%[2]s()\n}", name, o)
+		for _, x := range fields {
+			if x.oneof == o {
+				g.line("\ntype %s struct {", x.wrap)
+				g.comment(x.Comments, x.Options != nil && x.Options.Deprecated)
+				g.line("%s %s // %d\n}", x.name, x.goType, x.Tag)
+				g.line("\nfunc (*%s) is%s_%s() {}", x.wrap, name, o)
+			}
+		}
+	}
+	g.getters(name, fields)
+	g.encoder(name, fields)
+	g.decoder(name, fields)
+	for _, en := range m.Enum {
+		g.enum(en)
+	}
+	for _, nm := range m.Nested {
+		g.message(nm)
+	}
+}
+
+// getters prints the getters of the fields, which return the default value of unset fields.
+func (g *generator) getters(name string, fields []*field) {
+	for _, x := range fields {
+		if !x.getter() {
+			continue
+		}
+		g.line("\n// Get%s returns the value of %[1]s, or its default if it is not set.", x.name)
+		if !x.pointer() {
+			g.line("func (m *%s) Get%s() %s {", name, x.name, x.goType)
+			g.line("if m != nil && m.%s != nil {\nreturn m.%[1]s\n}", x.name)
+		} else {
+			g.line("func (m *%s) Get%s() %s {", name, x.name, x.goType[1:])
+			g.line("if m != nil && m.%s != nil {\nreturn *m.%[1]s\n}", x.name)
+		}
+		g.line("return %s\n}", g.defaultValue(x))
+	}
+}
+
+// defaultValue returns the Go expression of the default value of a scalar field.
+func (g *generator) defaultValue(x *field) string {
+	d := x.DefaultValue
+	switch x.Type {
+	case descriptor.TypeEnum:
+		for _, v := range x.EnumType.Value {
+			if v.Name == d || d == "" {
+				return g.valueName(x.EnumType, v)
+			}
+		}
+		return "0"
+	case descriptor.TypeString:
+		return strconv.Quote(d)
+	case descriptor.TypeBytes:
+		b, ok := cstring.Unescape(d)
+		if !ok {
+			g.fail(fmt.Errorf("%s: invalid default value %q", x.FullName(), d))
+		}
+		return fmt.Sprintf("[]byte(%q)", b)
+	case descriptor.TypeBool:
+		if d == "" {
+			return "false"
+		}
+		return d
+	case descriptor.TypeFloat, descriptor.TypeDouble:
+		switch d {
+		case "":
+			return "0"
+		case "inf", "-inf":
+			d = fmt.Sprintf("%sInf(%s1)", g.use("math", "math"), d[:len(d)-3])
+		case "nan":
+			d = g.use("math", "math") + "NaN()"
+		default:
+			return d
+		}
+		if x.Type == descriptor.TypeFloat {
+			return "float32(" + d + ")"
+		}
+		return d
+	default:
+	}
+	if d == "" {
+		return "0"
+	}
+	return d
+}
+
+func (g *generator) encoder(name string, fields []*field) {
+	w := g.wire()
+	g.line("\n// MarshalBinary encodes m.")
+	g.line("func (m *%s) MarshalBinary() ([]byte, error) {", name)
+	g.line("var e %sEncoder\nm.Encode(&e)\nreturn e.Bytes(), nil\n}", w)
+	g.line("\n// Encode appends the fields of m to e, ordered by number, followed by the unknown fields.")
+	g.line("func (m *%s) Encode(e *%sEncoder) {", name, w)
+	g.line("if m == nil {\nreturn\n}")
+	sorted := slices.Clone(fields)
+	slices.SortFunc(sorted, func(a, b *field) int { return int(a.Tag) - int(b.Tag) })
+	var done []string
+	for _, x := range sorted {
+		switch {
+		case x.wrap != "":
+			if slices.Contains(done, x.oneof) {
+				continue
+			}
+			done = append(done, x.oneof)
+			g.line("switch x := m.%s.(type) {", x.oneof)
+			for _, y := range sorted {
+				if y.oneof == x.oneof {
+					g.line("case *%s:", y.wrap)
+					g.encodeValue(y.Field, y.scalar, "x."+y.name)
+				}
+			}
+			g.line("default:\n}")
+		case x.IsMap():
+			g.encodeMap(x)
+		case x.Label == descriptor.LabelRepeated && x.IsPacked():
+			g.line("if len(m.%s) > 0 {\nvar b []byte\nfor _, v := range m.%[1]s {", x.name)
+			g.line("b = wire.Append%s(b, %s)\n}", x.scalar.method, fmt.Sprintf(x.scalar.encode, "v"))
+			g.line("e.EncodeBytes(%d, b)\n}", x.Tag)
+		case x.Label == descriptor.LabelRepeated:
+			g.line("for _, v := range m.%s {", x.name)
+			g.encodeValue(x.Field, x.scalar, "v")
+			g.line("}")
+		case x.scalar.method == "" || x.Type == descriptor.TypeBytes && x.HasPresence():
+			g.line("if m.%s != nil {", x.name)
+			g.encodeValue(x.Field, x.scalar, "m."+x.name)
+			g.line("}")
+		case x.pointer():
+			g.line("if m.%s != nil {", x.name)
+			g.encodeValue(x.Field, x.scalar, "*m."+x.name)
+			g.line("}")
+		case x.Type == descriptor.TypeBytes:
+			g.line("if len(m.%s) > 0 {", x.name)
+			g.encodeValue(x.Field, x.scalar, "m."+x.name)
+			g.line("}")
+		default:
+			g.line("if m.%s != %s {", x.name, zero(x.Type))
+			g.encodeValue(x.Field, x.scalar, "m."+x.name)
+			g.line("}")
+		}
+	}
+	g.line("e.EncodeRaw(m.UnknownFields)\n}")
+}
+
+// zero returns the zero value of a scalar type.
+func zero(typ uint8) string {
+	switch typ {
+	case descriptor.TypeString:
+		return `""`
+	case descriptor.TypeBool:
+		return "false"
+	default:
+	}
+	return "0"
+}
+
+// encodeValue prints the encoding of the single value v of field f.
+func (g *generator) encodeValue(f *descriptor.Field, s scalar, v string) {
+	switch f.Type {
+	case descriptor.TypeMessage:
+		g.line("e.EncodeMessage(%d, %s.Encode)", f.Tag, v)
+	case descriptor.TypeGroup:
+		g.line("e.EncodeGroup(%d, %s.Encode)", f.Tag, v)
+	default:
+		g.line("e.Encode%s(%d, %s)", s.method, f.Tag, fmt.Sprintf(s.encode, v))
+	}
+}
+
+// encodeMap prints the encoding of a map field, ordered by key unless it is a bool.
+func (g *generator) encodeMap(x *field) {
+	if x.Map.Key.Type == descriptor.TypeBool {
+		g.line("for k, v := range m.%s {", x.name)
+	} else {
+		g.line("for _, k := range %sSorted(%sKeys(m.%s)) {\nv := m.%[3]s[k]", g.use("slices", "slices"), g.use("maps", "maps"), x.name)
+	}
+	_, ks := g.elemType(x.Map.Key)
+	g.line("e.EncodeMessage(%d, func(e *wire.Encoder) {", x.Tag)
+	g.encodeValue(x.Map.Key, ks, "k")
+	g.encodeValue(x.Map.Value, x.scalar, "v")
+	g.line("})\n}")
+}
+
+func (g *generator) decoder(name string, fields []*field) {
+	w := g.wire()
+	g.line("\n// UnmarshalBinary replaces m with the message encoded in data.")
+	g.line("func (m *%s) UnmarshalBinary(data []byte) error {\n*m = %[1]s{}\nreturn m.Merge(data)\n}", name)
+	g.line("\n// Merge decodes the fields of data into m: scalars are replaced, repeated fields appended to and messages merged.")
+	g.line("// Fields with an unexpected wire type and unknown values of closed enums are kept as unknown fields.")
+	g.line("func (m *%s) Merge(data []byte) error {\nreturn m.MergeDepth(data, 0)\n}", name)
+	g.line("\n// MergeDepth is Merge for m nested at depth, it fails for messages nested deeper than %[1]sMaxDepth.", w)
+	g.line("func (m *%s) MergeDepth(data []byte, depth int) error {", name)
+	g.line("if depth > %sMaxDepth {\nreturn &%[1]sError{Err: %[1]sErrMaxDepth}\n}", w)
+	g.line("for r, err := range %sFields(data) {\nif err != nil {\nreturn err\n}\nswitch {", w)
+	for _, x := range fields {
+		switch {
+		case x.wrap != "":
+			g.oneofCase(x)
+		case x.IsMap():
+			g.mapCase(x)
+		case x.Label == descriptor.LabelRepeated:
+			if x.Packable() {
+				g.line("case r.Tag == %d && r.Kind == wire.TagSequence:", x.Tag)
+				g.line("vs, err := wire.%s(r.Bytes)\nif err != nil {\nreturn err\n}", unpack[x.scalar.method])
+				v := "v"
+				if x.scalar.method == "Fixed32" {
+					v = "uint64(v)"
+				}
+				if values := g.closedValues(x.Field); values != "" {
+					g.line("for _, v := range vs {\nswitch x := %s; x {\ncase %s:\nm.%s = append(m.%[3]s, x)", fmt.Sprintf(x.scalar.decode, v), values, x.name)
+					g.line("default:\nm.UnknownFields = %[1]sAppendVarint(%[1]sAppendTag(m.UnknownFields, %d, %[1]sTagUvarint), v)\n}\n}", w, x.Tag)
+				} else {
+					g.line("for _, v := range vs {\nm.%s = append(m.%[1]s, %s)\n}", x.name, fmt.Sprintf(x.scalar.decode, v))
+				}
+			}
+			g.kindCase(x.Field)
+			if x.scalar.method == "" {
+				g.line("v := &%s{}", x.goType[3:])
+				g.merge("v")
+				g.line("m.%s = append(m.%[1]s, v)", x.name)
+			} else if !g.closed(x.Field, x.scalar, fmt.Sprintf("m.%s = append(m.%[1]s, v)", x.name)) {
+				g.line("m.%s = append(m.%[1]s, %s)", x.name, g.decodeValue(x.Field, x.scalar))
+			}
+		case x.scalar.method == "":
+			g.kindCase(x.Field)
+			g.line("if m.%s == nil {\nm.%[1]s = &%s{}\n}", x.name, x.goType[1:])
+			g.merge("m." + x.name)
+		case x.pointer():
+			g.kindCase(x.Field)
+			if !g.closed(x.Field, x.scalar, fmt.Sprintf("m.%s = &v", x.name)) {
+				g.line("v := %s\nm.%s = &v", g.decodeValue(x.Field, x.scalar), x.name)
+			}
+		default:
+			g.kindCase(x.Field)
+			if !g.closed(x.Field, x.scalar, fmt.Sprintf("m.%s = v", x.name)) {
+				g.line("m.%s = %s", x.name, g.decodeValue(x.Field, x.scalar))
+			}
+		}
+	}
+	g.line("default:\nm.UnknownFields.Add(r)\n}\n}\nreturn nil\n}")
+}
+
+// kindCase prints the case of the tag and the wire type of f.
+func (g *generator) kindCase(f *descriptor.Field) {
+	kind := "TagStart"
+	if f.Type != descriptor.TypeGroup {
+		kind = scalars[f.Type].kind
+		if kind == "" {
+			kind = "TagSequence"
+		}
+	}
+	g.line("case r.Tag == %d && r.Kind == wire.%s:", f.Tag, kind)
+}
+
+// decodeValue returns the decoded value of the field r for a scalar.
+func (g *generator) decodeValue(f *descriptor.Field, s scalar) string {
+	v := "r.Value"
+	if s.kind == "TagSequence" {
+		v = "r.Bytes"
+	}
+	return fmt.Sprintf(s.decode, v)
+}
+
+// merge prints the merge of the message in r.Bytes into v, nested one level deeper than m.
+func (g *generator) merge(v string) {
+	g.line("if err := %s.MergeDepth(r.Bytes, depth+1); err != nil {\nreturn err\n}", v)
+}
+
+// closedValues returns the list of the values of the enum of a closed enum field, "" for other fields.
+func (g *generator) closedValues(f *descriptor.Field) string {
+	if f.Type != descriptor.TypeEnum || f.EnumType == nil || f.EnumType.Features().EnumType != descriptor.EnumClosed {
+		return ""
+	}
+	var values []string
+	seen := map[int32]bool{}
+	for _, v := range f.EnumType.Value {
+		if !seen[v.Number] {
+			seen[v.Number] = true
+			values = append(values, g.valueName(f.EnumType, v))
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// closed prints the decoding of the field r of a closed enum into v and stmt if v is one of the values,
+// r is kept as an unknown field otherwise. It reports false for other fields, printing nothing.
+func (g *generator) closed(f *descriptor.Field, s scalar, stmt string) bool {
+	values := g.closedValues(f)
+	if values == "" {
+		return false
+	}
+	g.line("switch v := %s; v {\ncase %s:\n%s\ndefault:\nm.UnknownFields.Add(r)\n}", g.decodeValue(f, s), values, stmt)
+	return true
+}
+
+func (g *generator) oneofCase(x *field) {
+	g.kindCase(x.Field)
+	if x.scalar.method != "" {
+		if !g.closed(x.Field, x.scalar, fmt.Sprintf("m.%s = &%s{%s: v}", x.oneof, x.wrap, x.name)) {
+			g.line("m.%s = &%s{%s: %s}", x.oneof, x.wrap, x.name, g.decodeValue(x.Field, x.scalar))
+		}
+		return
+	}
+	g.line("x, _ := m.%s.(*%s)\nif x == nil {\nx = &%[2]s{}\nm.%[1]s = x\n}", x.oneof, x.wrap)
+	g.line("if x.%s == nil {\nx.%[1]s = &%s{}\n}", x.name, x.goType[1:])
+	g.merge("x." + x.name)
+}
+
+// mapCase prints the decoding of a map entry, a missing key or value is the zero value.
+func (g *generator) mapCase(x *field) {
+	key, value := x.Map.Key, x.Map.Value
+	kt, ks := g.elemType(key)
+	vt, _ := g.elemType(value)
+	g.kindCase(x.Field)
+	g.line("var k %s", kt)
+	if x.scalar.method == "" {
+		g.line("v := &%s{}", vt[1:])
+	} else {
+		g.line("var v %s", vt)
+	}
+	g.line("for r, err := range wire.Fields(r.Bytes) {\nif err != nil {\nreturn err\n}\nswitch {")
+	g.kindCase(key)
+	g.line("k = %s", g.decodeValue(key, ks))
+	g.kindCase(value)
+	if x.scalar.method == "" {
+		g.merge("v")
+	} else {
+		g.line("v = %s", g.decodeValue(value, x.scalar))
+	}
+	g.line("default:\n}\n}")
+	if values := g.closedValues(value); values != "" {
+		// an entry with an unknown value is an unknown field
+		g.line("switch v {\ncase %s:\ndefault:\nm.UnknownFields.Add(r)\ncontinue\n}", values)
+	}
+	g.line("if m.%s == nil {\nm.%[1]s = %s{}\n}\nm.%[1]s[k] = v", x.name, x.goType)
+}
@@ -0,0 +1,253 @@
+// Package graphql generates GraphQL type definitions of messages and enums, the shape of their JSON encoding.
+//
+// Each .proto file becomes a .graphql file with a type per message and an enum per enum,
+// nested types are named like Outer_Inner and map fields are lists of their entry type with a key and a value.
+// Fields without presence are non-null as they always have a value, the fields of a oneof are nullable fields of their own.
+// 64 bit integers are strings like in JSON since an Int has 32 bits, unsigned 32 bit integers are floats.
+// The well-known types are the scalars of their JSON form, JSON text for Any, Struct, Value and ListValue.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/gen/internal/names"
+)
+
+// Options configure the generator.
+type Options struct {
+	UseProtoNames bool // field names from the .proto file instead of their json_name
+	Qualified     bool // type names start with the package, like ShopV1_Order for shop.v1.Order
+	Inputs        bool // also generate an input type per message, named like the type followed by Input
+}
+
+// Generate generates the definitions of f, deps are the linked files of the types it uses.
+// It returns the name of the .graphql file, next to the .proto file, and its content.
+func (o Options) Generate(f *descriptor.File, deps []*descriptor.File) (string, []byte, error) {
+	g := &generator{o: o, names: map[any]string{}}
+	for _, d := range append(deps, f) {
+		scope := ""
+		if o.Qualified && d.Package != "" {
+			for _, p := range strings.Split(d.Package, ".") {
+				scope += names.Upper(p)
+			}
+			scope += "_"
+		}
+		g.index(scope, d.Message, d.Enum)
+	}
+	g.line("# Code generated by proton generate graphql. DO NOT EDIT.")
+	g.line("# source: %s", f.Name)
+	for _, en := range f.Enum {
+		g.enum(en)
+	}
+	for _, m := range f.Message {
+		g.message(m)
+	}
+	if g.err != nil {
+		return "", nil, fmt.Errorf("%s: %w", f.Name, g.err)
+	}
+	return strings.TrimSuffix(f.Name, ".proto") + ".graphql", g.buf, nil
+}
+
+type generator struct {
+	o     Options
+	buf   []byte
+	err   error          // the first error, like an unlinked type
+	names map[any]string // of the messages and enums
+}
+
+func (g *generator) index(scope string, msgs []*descriptor.Message, enums []*descriptor.Enum) {
+	for _, en := range enums {
+		g.names[en] = scope + en.Name
+	}
+	for _, m := range msgs {
+		g.names[m] = scope + m.Name
+		g.index(scope+m.Name+"_", m.Nested, m.Enum)
+	}
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.buf = fmt.Appendf(g.buf, format, args...)
+	g.buf = append(g.buf, '\n')
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// description prints the leading comment of an element as a block string, indented by indent.
+func (g *generator) description(indent string, c *descriptor.Comments) {
+	if c == nil || c.Leading == "" {
+		return
+	}
+	g.line(`%s"""`, indent)
+	for _, l := range strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n") {
+		g.line("%s%s", indent, strings.ReplaceAll(strings.TrimPrefix(l, " "), `"""`, `\"""`))
+	}
+	g.line(`%s"""`, indent)
+}
+
+func deprecated(yes bool) string {
+	if yes {
+		return " @deprecated"
+	}
+	return ""
+}
+
+func (g *generator) enum(en *descriptor.Enum) {
+	g.line("")
+	g.description("", en.Comments)
+	g.line("enum %s {", g.names[en])
+	for _, v := range en.Value {
+		g.description("  ", v.Comments)
+		g.line("  %s%s", v.Name, deprecated(v.Options != nil && v.Options.Deprecated))
+	}
+	g.line("}")
+}
+
+func (g *generator) message(m *descriptor.Message) {
+	g.object("type", g.names[m], m, false)
+	if g.o.Inputs {
+		g.object("input", g.names[m]+"Input", m, true)
+	}
+	for _, en := range m.Enum {
+		g.enum(en)
+	}
+	for _, nm := range m.Nested {
+		g.message(nm)
+	}
+}
+
+// object prints the type or input type of m. The fields of input types are optional.
+func (g *generator) object(keyword, name string, m *descriptor.Message, input bool) {
+	g.line("")
+	g.description("", m.Comments)
+	g.line("%s %s {", keyword, name)
+	if len(m.Field) == 0 {
+		g.line("  \"The message has no fields, a type needs one.\"")
+		g.line("  _: Boolean")
+	}
+	for _, f := range m.Field {
+		c := f.Comments
+		if o := f.RealOneOf(m); o != nil {
+			note := fmt.Sprintf(" Part of the oneof %s, at most one of its fields is set.", o.Name)
+			if c == nil || c.Leading == "" {
+				c = &descriptor.Comments{Leading: note}
+			} else {
+				c = &descriptor.Comments{Leading: strings.TrimSuffix(c.Leading, "\n") + "\n\n" + note}
+			}
+		}
+		g.description("  ", c)
+		t := g.fieldType(f, input)
+		if !input && (!f.HasPresence() || f.Features().FieldPresence == descriptor.PresenceLegacyRequired) {
+			t += "!"
+		}
+		if input {
+			g.line("  %s: %s", g.name(f), t)
+		} else {
+			g.line("  %s: %s%s", g.name(f), t, deprecated(f.Options != nil && f.Options.Deprecated))
+		}
+	}
+	g.line("}")
+}
+
+// name returns the field name of f.
+func (g *generator) name(f *descriptor.Field) string {
+	switch {
+	case g.o.UseProtoNames:
+		return f.Name
+	case f.JsonName != "":
+		return f.JsonName
+	default:
+	}
+	return descriptor.JSONName(f.Name)
+}
+
+// fieldType returns the type of f without the non-null mark of the field itself.
+func (g *generator) fieldType(f *descriptor.Field, input bool) string {
+	if f.Label == descriptor.LabelRepeated {
+		return "[" + g.valueType(f, input) + "!]"
+	}
+	return g.valueType(f, input)
+}
+
+// valueType returns the type of a single value of f, maps are lists of their entries.
+func (g *generator) valueType(f *descriptor.Field, input bool) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "String"
+		}
+		if t, ok := wellKnown[f.MessageType.FullName()]; ok {
+			return t
+		}
+		return g.typeName(f.MessageType, input)
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "String"
+		}
+		if f.EnumType.FullName() == "google.protobuf.NullValue" {
+			return "String"
+		}
+		return g.typeName(f.EnumType, input)
+	default:
+	}
+	return scalars[f.Type]
+}
+
+func (g *generator) typeName(x any, input bool) string {
+	name, ok := g.names[x]
+	if !ok {
+		g.fail(errors.New("types are not linked"))
+		return "String"
+	}
+	if _, ok := x.(*descriptor.Message); ok && input {
+		name += "Input"
+	}
+	return name
+}
+
+var scalars = map[uint8]string{
+	descriptor.TypeDouble:   "Float",
+	descriptor.TypeFloat:    "Float",
+	descriptor.TypeInt64:    "String",
+	descriptor.TypeUint64:   "String",
+	descriptor.TypeInt32:    "Int",
+	descriptor.TypeFixed64:  "String",
+	descriptor.TypeFixed32:  "Float",
+	descriptor.TypeBool:     "Boolean",
+	descriptor.TypeString:   "String",
+	descriptor.TypeBytes:    "String",
+	descriptor.TypeUint32:   "Float",
+	descriptor.TypeSfixed32: "Int",
+	descriptor.TypeSfixed64: "String",
+	descriptor.TypeSint32:   "Int",
+	descriptor.TypeSint64:   "String",
+}
+
+// wellKnown are the types of the well-known messages with a JSON form of their own.
+var wellKnown = map[string]string{
+	"google.protobuf.Any":         "String",
+	"google.protobuf.Duration":    "String",
+	"google.protobuf.Empty":       "Boolean",
+	"google.protobuf.FieldMask":   "String",
+	"google.protobuf.ListValue":   "String",
+	"google.protobuf.Struct":      "String",
+	"google.protobuf.Timestamp":   "String",
+	"google.protobuf.Value":       "String",
+	"google.protobuf.BoolValue":   "Boolean",
+	"google.protobuf.BytesValue":  "String",
+	"google.protobuf.DoubleValue": "Float",
+	"google.protobuf.FloatValue":  "Float",
+	"google.protobuf.Int32Value":  "Int",
+	"google.protobuf.Int64Value":  "String",
+	"google.protobuf.StringValue": "String",
+	"google.protobuf.UInt32Value": "Float",
+	"google.protobuf.UInt64Value": "String",
+}
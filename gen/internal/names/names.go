@@ -0,0 +1,10 @@
+// Package names converts the names of .proto elements for the generators.
+package names
+
+// Upper returns s with its first letter in upper case.
+func Upper(s string) string {
+	if s != "" && 'a' <= s[0] && s[0] <= 'z' {
+		return string(s[0]-'a'+'A') + s[1:]
+	}
+	return s
+}
@@ -0,0 +1,330 @@
+// Package jsonschema converts messages and enums to JSON Schema documents, draft 2020-12,
+// validating the JSON encoding of the protojson package.
+//
+// Each message and enum gets its own document named after its fully qualified name,
+// like shop.Order.schema.json, and references the documents of the types it uses with relative $refs.
+// The well-known types with a JSON form of their own are inlined.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// Draft is the $schema of the documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// A Schema is a JSON Schema or a document holding one. The zero Schema accepts everything.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+
+	Type            any      `json:"type,omitempty"` // a name or a list of them
+	Enum            []any    `json:"enum,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+	Minimum         *float64 `json:"minimum,omitempty"`
+	Maximum         *float64 `json:"maximum,omitempty"`
+
+	Properties           Properties         `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AllOf []*Schema `json:"allOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+
+	never bool // the false schema, rejecting everything
+}
+
+// False is the schema rejecting everything.
+var False = &Schema{never: true}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+	type schema Schema // without methods
+	return json.Marshal((*schema)(s))
+}
+
+// Properties are the properties of an object schema, in declaration order.
+type Properties []Property
+
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+func (ps Properties) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, p := range ps {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(p.Name)
+		v, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Options configure the conversion.
+type Options struct {
+	UseProtoNames bool   // property names from the .proto file instead of their json_name
+	BaseURI       string // prefix of the $id of the documents like https://example.com/schemas/, relative ids if empty
+	Open          bool   // accept unknown properties
+
+	// Ref returns the $ref of the message or enum called name, BaseURI+Name(name) if nil.
+	Ref func(name string) string
+}
+
+// Name returns the file name of the document of the message or enum called name, without a leading dot.
+func Name(name string) string {
+	return name + ".schema.json"
+}
+
+// Generate returns the documents of the messages and enums of files, but the synthesized map entries.
+// The Title of a document is the fully qualified name of its type.
+func (o Options) Generate(files []*descriptor.File) []*Schema {
+	var docs []*Schema
+	var walk func(msgs []*descriptor.Message, enums []*descriptor.Enum)
+	walk = func(msgs []*descriptor.Message, enums []*descriptor.Enum) {
+		for _, en := range enums {
+			docs = append(docs, o.document(o.Enum(en)))
+		}
+		for _, m := range msgs {
+			if !m.IsMapEntry() {
+				docs = append(docs, o.document(o.Message(m)))
+			}
+			walk(m.Nested, m.Enum)
+		}
+	}
+	for _, f := range files {
+		walk(f.Message, f.Enum)
+	}
+	return docs
+}
+
+func (o Options) document(s *Schema) *Schema {
+	s.Schema, s.ID = Draft, o.BaseURI+Name(s.Title)
+	return s
+}
+
+// Enum returns the schema of an enum: the names of its values or their numbers.
+func (o Options) Enum(en *descriptor.Enum) *Schema {
+	s := &Schema{Title: en.FullName(), Type: []string{"string", "integer"}}
+	describe(s, en.Comments, en.Options != nil && en.Options.Deprecated)
+	for _, v := range en.Value {
+		s.Enum = append(s.Enum, v.Name)
+	}
+	for _, v := range en.Value {
+		s.Enum = append(s.Enum, v.Number)
+	}
+	return s
+}
+
+// Message returns the schema of a message, referencing the documents of the other types.
+// Required fields are required properties and a oneof allows at most one of its fields.
+func (o Options) Message(m *descriptor.Message) *Schema {
+	s := &Schema{Title: m.FullName(), Type: "object"}
+	describe(s, m.Comments, m.Options != nil && m.Options.Deprecated)
+	if !o.Open {
+		s.AdditionalProperties = False
+	}
+	if len(m.ExtensionRange) > 0 {
+		s.PatternProperties = map[string]*Schema{`^\[.+\]$`: {}} // extensions
+	}
+	for _, f := range m.Field {
+		p := o.Field(f)
+		describe(p, f.Comments, f.Options != nil && f.Options.Deprecated)
+		s.Properties = append(s.Properties, Property{o.name(f), p})
+		if f.Features().FieldPresence == descriptor.PresenceLegacyRequired {
+			s.Required = append(s.Required, o.name(f))
+		}
+	}
+	for i, fields := range m.OneOfFields() {
+		if m.OneOf[i].Synthetic || len(fields) < 2 {
+			continue
+		}
+		one := &Schema{}
+		for _, f := range fields {
+			one.OneOf = append(one.OneOf, &Schema{Required: []string{o.name(f)}})
+		}
+		// exactly one of the fields or none of them
+		one.OneOf = append(one.OneOf, &Schema{Not: &Schema{AnyOf: one.OneOf}})
+		s.AllOf = append(s.AllOf, one)
+	}
+	return s
+}
+
+// name returns the property of f.
+func (o Options) name(f *descriptor.Field) string {
+	switch {
+	case o.UseProtoNames:
+		return f.Name
+	case f.JsonName != "":
+		return f.JsonName
+	default:
+	}
+	return descriptor.JSONName(f.Name)
+}
+
+// Field returns the schema of the values of a field.
+func (o Options) Field(f *descriptor.Field) *Schema {
+	switch {
+	case f.IsMap():
+		s := &Schema{Type: "object", AdditionalProperties: o.value(f.Map.Value)}
+		switch key := o.value(f.Map.Key); {
+		case f.Map.Key.Type == descriptor.TypeBool:
+			s.PropertyNames = &Schema{Enum: []any{"true", "false"}}
+		case key.Pattern != "":
+			s.PropertyNames = &Schema{Pattern: key.Pattern}
+		case f.Map.Key.Type != descriptor.TypeString:
+			s.PropertyNames = &Schema{Pattern: `^-?[0-9]+$`}
+		default:
+		}
+		return s
+	case f.Label == descriptor.LabelRepeated:
+		return &Schema{Type: "array", Items: o.value(f)}
+	default:
+	}
+	return o.value(f)
+}
+
+// value returns the schema of a single value of f.
+func (o Options) value(f *descriptor.Field) *Schema {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		name := strings.TrimPrefix(f.TypeName, ".")
+		if f.MessageType != nil {
+			name = f.MessageType.FullName()
+		}
+		if s := wellKnown(name); s != nil {
+			return s
+		}
+		return &Schema{Ref: o.ref(name)}
+	case descriptor.TypeEnum:
+		name := strings.TrimPrefix(f.TypeName, ".")
+		if f.EnumType != nil {
+			name = f.EnumType.FullName()
+		}
+		if name == "google.protobuf.NullValue" {
+			return &Schema{Type: "null"}
+		}
+		return &Schema{Ref: o.ref(name)}
+	default:
+	}
+	return scalar(f.Type)
+}
+
+func (o Options) ref(name string) string {
+	if o.Ref != nil {
+		return o.Ref(name)
+	}
+	return o.BaseURI + Name(name)
+}
+
+// scalar returns the schema of a scalar type, 64 bit integers are strings in JSON but numbers are accepted.
+func scalar(typ uint8) *Schema {
+	switch typ {
+	case descriptor.TypeInt32, descriptor.TypeSint32, descriptor.TypeSfixed32:
+		return integer(math.MinInt32, math.MaxInt32)
+	case descriptor.TypeUint32, descriptor.TypeFixed32:
+		return integer(0, math.MaxUint32)
+	case descriptor.TypeInt64, descriptor.TypeSint64, descriptor.TypeSfixed64:
+		return &Schema{Type: []string{"integer", "string"}, Pattern: `^-?[0-9]+$`}
+	case descriptor.TypeUint64, descriptor.TypeFixed64:
+		return &Schema{Type: []string{"integer", "string"}, Pattern: `^[0-9]+$`, Minimum: ptr(0)}
+	case descriptor.TypeFloat, descriptor.TypeDouble:
+		return &Schema{AnyOf: []*Schema{{Type: "number"}, {Enum: []any{"NaN", "Infinity", "-Infinity"}}}}
+	case descriptor.TypeBool:
+		return &Schema{Type: "boolean"}
+	case descriptor.TypeString:
+		return &Schema{Type: "string"}
+	case descriptor.TypeBytes:
+		return &Schema{Type: "string", ContentEncoding: "base64"}
+	default:
+	}
+	return &Schema{}
+}
+
+func integer(min, max float64) *Schema {
+	return &Schema{Type: "integer", Minimum: ptr(min), Maximum: ptr(max)}
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+// wellKnown returns the schema of the well-known types with a JSON form of their own, nil for others.
+func wellKnown(name string) *Schema {
+	switch name {
+	case "google.protobuf.Any":
+		return &Schema{Type: "object", Properties: Properties{{"@type", &Schema{Type: "string"}}}, Required: []string{"@type"}}
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]{1,9})?s$`}
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string"}
+	case "google.protobuf.Struct":
+		return &Schema{Type: "object"}
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array"}
+	case "google.protobuf.Value":
+		return &Schema{}
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object", AdditionalProperties: False}
+	case "google.protobuf.BoolValue":
+		return scalar(descriptor.TypeBool)
+	case "google.protobuf.BytesValue":
+		return scalar(descriptor.TypeBytes)
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return scalar(descriptor.TypeDouble)
+	case "google.protobuf.Int32Value":
+		return scalar(descriptor.TypeInt32)
+	case "google.protobuf.Int64Value":
+		return scalar(descriptor.TypeInt64)
+	case "google.protobuf.StringValue":
+		return scalar(descriptor.TypeString)
+	case "google.protobuf.UInt32Value":
+		return scalar(descriptor.TypeUint32)
+	case "google.protobuf.UInt64Value":
+		return scalar(descriptor.TypeUint64)
+	default:
+	}
+	return nil
+}
+
+// describe sets the description from the leading comment and marks deprecated elements.
+func describe(s *Schema, c *descriptor.Comments, deprecated bool) {
+	if c != nil && c.Leading != "" {
+		lines := strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimPrefix(l, " ")
+		}
+		s.Description = strings.Join(lines, "\n")
+	}
+	s.Deprecated = deprecated
+}
@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// HTTPExtension is the number of the google.api.http extension of the method options.
+const HTTPExtension = 72295728
+
+// An HTTPRule is a google.api.http option, mapping a method to a REST endpoint.
+type HTTPRule struct {
+	Method             string      // get, put, post, delete, patch or the kind of a custom pattern
+	Path               string      // template like /v1/{name=shelves/*}/books
+	Body               string      // the field holding the request body, * for all the fields not in the path
+	ResponseBody       string      // the field of the response holding the response body, all of it if empty
+	AdditionalBindings []*HTTPRule // only set at the top level
+}
+
+// HTTPRules returns the google.api.http option of a method followed by its additional bindings, nil if it has none.
+// The option is read from the unknown fields of the method options, no descriptor of google/api/http.proto is needed.
+func HTTPRules(m *descriptor.Method) ([]*HTTPRule, error) {
+	if m.Options == nil || !m.Options.UnknownFields.Has(HTTPExtension) {
+		return nil, nil
+	}
+	rule := &HTTPRule{}
+	for r, err := range m.Options.UnknownFields.Fields() {
+		if err != nil {
+			return nil, err
+		}
+		if r.Tag == HTTPExtension && r.Kind == wire.TagSequence {
+			// repeated occurrences of a message field are merged
+			if err := rule.parse(r.Bytes); err != nil {
+				return nil, fmt.Errorf("google.api.http: %w", err)
+			}
+		}
+	}
+	rules := []*HTTPRule{rule}
+	for _, b := range rule.AdditionalBindings {
+		b.AdditionalBindings = nil // bindings do not nest
+		rules = append(rules, b)
+	}
+	for _, r := range rules {
+		if r.Method == "" || r.Path == "" {
+			return nil, fmt.Errorf("google.api.http of %s has no pattern", m.Name)
+		}
+	}
+	return rules, nil
+}
+
+func (h *HTTPRule) parse(msg []byte) error {
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return err
+		}
+		if r.Kind != wire.TagSequence {
+			continue
+		}
+		s := string(r.Bytes)
+		switch r.Tag {
+		case 2:
+			h.Method, h.Path = "get", s
+		case 3:
+			h.Method, h.Path = "put", s
+		case 4:
+			h.Method, h.Path = "post", s
+		case 5:
+			h.Method, h.Path = "delete", s
+		case 6:
+			h.Method, h.Path = "patch", s
+		case 7:
+			h.Body = s
+		case 8: // CustomHttpPattern
+			for c, err := range wire.Fields(r.Bytes) {
+				if err != nil {
+					return err
+				}
+				switch c.Tag {
+				case 1:
+					h.Method = strings.ToLower(string(c.Bytes))
+				case 2:
+					h.Path = string(c.Bytes)
+				default:
+				}
+			}
+		case 11:
+			b := &HTTPRule{}
+			if err := b.parse(r.Bytes); err != nil {
+				return err
+			}
+			h.AdditionalBindings = append(h.AdditionalBindings, b)
+		case 12:
+			h.ResponseBody = s
+		default: // 1 selector
+		}
+	}
+	return nil
+}
+
+// A variable is a path variable of a template like {book.name=shelves/*/books/*}.
+type variable struct {
+	field   string // the field path
+	pattern string // of the path segments, * if empty
+}
+
+// template returns the OpenAPI path of a path template and its variables: {field=pattern} becomes {field}.
+func template(path string) (string, []variable, error) {
+	var b strings.Builder
+	var vars []variable
+	for {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			b.WriteString(path)
+			break
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			return "", nil, fmt.Errorf("path %s: unclosed variable", path)
+		}
+		field, pattern, _ := strings.Cut(path[i+1:i+j], "=")
+		if field == "" {
+			return "", nil, fmt.Errorf("path %s: variable without a field", path)
+		}
+		vars = append(vars, variable{field, pattern})
+		b.WriteString(path[:i] + "{" + field + "}")
+		path = path[i+j+1:]
+	}
+	return b.String(), vars, nil
+}
@@ -0,0 +1,362 @@
+// Package openapi generates OpenAPI 3.1 documents of the REST endpoints of services,
+// mapped by their google.api.http options like the gRPC to JSON transcoding gateways do.
+//
+// Path variables become path parameters, the fields of the request neither in the path nor in the body
+// become query parameters if they are scalars, enums or lists of them.
+// Requests and responses are the JSON encoding of the protojson package, their schemas are the ones
+// of the jsonschema package under components/schemas, keyed by the fully qualified names of the types.
+// Streaming methods are marked with x-streaming: client, server or bidi, their bodies are streams of the messages.
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/gen/jsonschema"
+)
+
+// Version is the openapi version of the documents.
+const Version = "3.1.0"
+
+// A Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []*Tag              `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// A Tag describes a service, the tag of its operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// A PathItem holds the operations of a path by their lower case HTTP method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Streaming   string               `json:"x-streaming,omitempty"` // client, server or bidi
+}
+
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"` // path or query
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Deprecated  bool               `json:"deprecated,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+}
+
+// Options configure the generator.
+type Options struct {
+	UseProtoNames bool   // property and query parameter names from the .proto file instead of their json_name
+	Title         string // of the document, the name of the service if there is one
+	Version       string // of the API, 0.0.0 if empty
+
+	// Unannotated methods, without a google.api.http option, are posted to /package.Service/Method instead of left out.
+	Unannotated bool
+}
+
+// Generate returns the document of the services of files. Their types have to be linked.
+func (o Options) Generate(files []*descriptor.File) (*Document, error) {
+	g := &generator{
+		o:      o,
+		schema: jsonschema.Options{UseProtoNames: o.UseProtoNames, Ref: func(name string) string { return "#/components/schemas/" + name }},
+		doc:    &Document{OpenAPI: Version, Info: Info{Title: o.Title, Version: o.Version}, Paths: map[string]PathItem{}},
+		types:  map[string]any{},
+	}
+	for _, f := range files {
+		for _, s := range f.Service {
+			name := s.Name
+			if f.Package != "" {
+				name = f.Package + "." + s.Name
+			}
+			if err := g.service(name, s); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+	}
+	if len(g.doc.Tags) == 1 && g.doc.Info.Title == "" {
+		g.doc.Info.Title = g.doc.Tags[0].Name
+	}
+	if g.doc.Info.Title == "" {
+		g.doc.Info.Title = "API"
+	}
+	if g.doc.Info.Version == "" {
+		g.doc.Info.Version = "0.0.0"
+	}
+	g.components()
+	return g.doc, nil
+}
+
+type generator struct {
+	o      Options
+	schema jsonschema.Options
+	doc    *Document
+
+	types   map[string]any // the referenced messages and enums by name
+	pending []string       // referenced types without a schema yet
+}
+
+func (g *generator) service(name string, s *descriptor.Service) error {
+	g.doc.Tags = append(g.doc.Tags, &Tag{Name: name, Description: comment(s.Comments)})
+	for _, m := range s.Method {
+		if m.Input == nil || m.Output == nil {
+			return fmt.Errorf("%s.%s: types are not linked", name, m.Name)
+		}
+		rules, err := HTTPRules(m)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", name, m.Name, err)
+		}
+		if rules == nil && g.o.Unannotated {
+			rules = []*HTTPRule{{Method: "post", Path: "/" + name + "/" + m.Name, Body: "*"}}
+		}
+		for i, r := range rules {
+			id := strings.ReplaceAll(name, ".", "_") + "_" + m.Name
+			if i > 0 {
+				id += fmt.Sprint(i + 1)
+			}
+			if err := g.operation(name, id, m, r); err != nil {
+				return fmt.Errorf("%s.%s: %w", name, m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) operation(service, id string, m *descriptor.Method, r *HTTPRule) error {
+	path, vars, err := template(r.Path)
+	if err != nil {
+		return err
+	}
+	op := &Operation{
+		Tags:        []string{service},
+		Description: comment(m.Comments),
+		OperationID: id,
+		Responses:   map[string]*Response{},
+		Deprecated:  m.Options != nil && m.Options.Deprecated,
+	}
+	bound := map[*descriptor.Field]bool{}
+	for _, v := range vars {
+		f, err := lookup(m.Input, v.field)
+		if err != nil {
+			return err
+		}
+		bound[f] = true
+		p := &Parameter{Name: v.field, In: "path", Required: true, Schema: g.value(f)}
+		if strings.Contains(v.pattern, "/") {
+			p.Description = "Spans the path segments matching " + v.pattern + "."
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+	switch r.Body {
+	case "":
+	case "*":
+		op.RequestBody = g.body(g.ref(m.Input))
+	default:
+		f, err := lookup(m.Input, r.Body)
+		if err != nil {
+			return err
+		}
+		bound[f] = true
+		op.RequestBody = g.body(g.value(f))
+	}
+	if r.Body != "*" {
+		// the other fields are query parameters
+		for _, f := range m.Input.Field {
+			if bound[f] || !query(f) {
+				continue
+			}
+			p := &Parameter{Name: g.name(f), In: "query", Description: comment(f.Comments), Schema: g.value(f)}
+			p.Deprecated = f.Options != nil && f.Options.Deprecated
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+	out := g.ref(m.Output)
+	if r.ResponseBody != "" {
+		f, err := lookup(m.Output, r.ResponseBody)
+		if err != nil {
+			return err
+		}
+		out = g.value(f)
+	}
+	op.Responses["200"] = &Response{Description: "OK", Content: map[string]*MediaType{"application/json": {Schema: out}}}
+	op.Responses["default"] = &Response{Description: "An error status", Content: map[string]*MediaType{"application/json": {Schema: &jsonschema.Schema{Ref: g.schema.Ref(statusName)}}}}
+	var note string
+	switch {
+	case m.ClientStreaming && m.ServerStreaming:
+		op.Streaming, note = "bidi", "Streaming: the request and the response are streams of messages."
+	case m.ClientStreaming:
+		op.Streaming, note = "client", "Streaming: the request is a stream of messages."
+	case m.ServerStreaming:
+		op.Streaming, note = "server", "Streaming: the response is a stream of messages."
+	default:
+	}
+	if note != "" && op.Description != "" {
+		op.Description += "\n\n" + note
+	} else if note != "" {
+		op.Description = note
+	}
+	item := g.doc.Paths[path]
+	if item == nil {
+		item = PathItem{}
+		g.doc.Paths[path] = item
+	}
+	if item[r.Method] != nil {
+		return fmt.Errorf("%s %s is bound twice", strings.ToUpper(r.Method), r.Path)
+	}
+	item[r.Method] = op
+	return nil
+}
+
+func (g *generator) body(s *jsonschema.Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: s}}}
+}
+
+// status is the schema of google.rpc.Status, the body of errors, in the components unless the files define it.
+const statusName = "google.rpc.Status"
+
+var status = &jsonschema.Schema{Type: "object", Properties: jsonschema.Properties{
+	{Name: "code", Schema: &jsonschema.Schema{Type: "integer"}},
+	{Name: "message", Schema: &jsonschema.Schema{Type: "string"}},
+	{Name: "details", Schema: &jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "object"}}},
+}}
+
+// comment returns the leading comment of an element.
+func comment(c *descriptor.Comments) string {
+	if c == nil || c.Leading == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lookup returns the field of m at a path of field names like book.id.
+func lookup(m *descriptor.Message, path string) (*descriptor.Field, error) {
+	var f *descriptor.Field
+	for _, name := range strings.Split(path, ".") {
+		if m == nil {
+			return nil, fmt.Errorf("field %s: %s is not a message", path, f.Name)
+		}
+		i := slices.IndexFunc(m.Field, func(f *descriptor.Field) bool { return f.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("field %s: %s has no field %s", path, m.FullName(), name)
+		}
+		f = m.Field[i]
+		m = f.MessageType
+	}
+	return f, nil
+}
+
+// query reports whether a field can be a query parameter: not a map or a message.
+func query(f *descriptor.Field) bool {
+	return !f.IsMap() && f.Type != descriptor.TypeMessage && f.Type != descriptor.TypeGroup
+}
+
+// name returns the query parameter of f.
+func (g *generator) name(f *descriptor.Field) string {
+	switch {
+	case g.o.UseProtoNames:
+		return f.Name
+	case f.JsonName != "":
+		return f.JsonName
+	default:
+	}
+	return descriptor.JSONName(f.Name)
+}
+
+// value returns the schema of a field, noting the type it references.
+func (g *generator) value(f *descriptor.Field) *jsonschema.Schema {
+	s := g.schema.Field(f)
+	v := s
+	switch {
+	case f.IsMap():
+		f, v = f.Map.Value, s.AdditionalProperties
+	case s.Items != nil:
+		v = s.Items
+	default:
+	}
+	switch {
+	case v.Ref == "": // a scalar or an inlined well-known type
+	case f.MessageType != nil:
+		g.use(f.MessageType.FullName(), f.MessageType)
+	case f.EnumType != nil:
+		g.use(f.EnumType.FullName(), f.EnumType)
+	default:
+	}
+	return s
+}
+
+// ref returns the schema of a message, noting it as referenced.
+func (g *generator) ref(m *descriptor.Message) *jsonschema.Schema {
+	return g.value(&descriptor.Field{Type: descriptor.TypeMessage, TypeName: "." + m.FullName(), MessageType: m})
+}
+
+func (g *generator) use(name string, x any) {
+	if _, ok := g.types[name]; !ok {
+		g.types[name] = x
+		g.pending = append(g.pending, name)
+	}
+}
+
+// components adds the schemas of the referenced types and of the types they reference.
+func (g *generator) components() {
+	schemas := map[string]*jsonschema.Schema{}
+	if len(g.doc.Paths) > 0 {
+		schemas[statusName] = status
+	}
+	for len(g.pending) > 0 {
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		switch x := g.types[name].(type) {
+		case *descriptor.Message:
+			for _, f := range x.Field {
+				g.value(f)
+			}
+			schemas[name] = g.schema.Message(x)
+		case *descriptor.Enum:
+			schemas[name] = g.schema.Enum(x)
+		default:
+		}
+	}
+	if len(schemas) > 0 {
+		g.doc.Components = &Components{Schemas: schemas}
+	}
+}
@@ -0,0 +1,303 @@
+// Package sql generates CREATE TABLE statements to land messages in a database, see Options for the dialects.
+//
+// Each message of a .proto file, but the map entries, becomes a table named like its name relative to the package
+// in snake case, Order.Item becomes order_item, with a column per field named like the field.
+// Fields without presence are NOT NULL, enums are stored by value name, message fields as JSON
+// but the timestamps and wrappers, which are columns of their own type.
+// Repeated fields and maps are JSON columns, or child tables with Options.ChildTables.
+// Messages without columns, like empty messages, get a comment instead of a table.
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// A Dialect is the SQL dialect of the statements.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	MySQL
+	SQLite
+)
+
+var dialects = []string{"postgres", "mysql", "sqlite"}
+
+func (d Dialect) String() string {
+	if int(d) < len(dialects) {
+		return dialects[d]
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// ParseDialect returns the dialect called postgres, mysql or sqlite.
+func ParseDialect(name string) (Dialect, error) {
+	i := slices.Index(dialects, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown SQL dialect %s, not one of %s", name, strings.Join(dialects, ", "))
+	}
+	return Dialect(i), nil
+}
+
+// Options configure the generator.
+type Options struct {
+	Dialect Dialect
+
+	// ChildTables stores repeated fields and maps in tables of their own, named like the table and the field,
+	// instead of JSON columns. The tables get a surrogate _id key, referenced by the _parent_id of their child tables.
+	// The rows of a child table hold the _index of the element or the key of the map entry and its value,
+	// the columns of the message for messages. Recursive types are JSON columns.
+	ChildTables bool
+}
+
+// Generate generates the tables of the messages of f, its types have to be linked.
+// It returns the name of the .sql file, next to the .proto file, and its content.
+func (o Options) Generate(f *descriptor.File) (string, []byte, error) {
+	g := &generator{o: o, tables: map[string]bool{}}
+	g.line("-- Code generated by proton generate sql. DO NOT EDIT.")
+	g.line("-- source: %s", f.Name)
+	var walk func(scope string, msgs []*descriptor.Message)
+	walk = func(scope string, msgs []*descriptor.Message) {
+		for _, m := range msgs {
+			if m.IsMapEntry() {
+				continue
+			}
+			g.table(scope+snake(m.Name), m, m.Comments, "", nil, nil)
+			walk(scope+snake(m.Name)+"_", m.Nested)
+		}
+	}
+	walk("", f.Message)
+	if g.err != nil {
+		return "", nil, fmt.Errorf("%s: %w", f.Name, g.err)
+	}
+	return strings.TrimSuffix(f.Name, ".proto") + ".sql", g.buf, nil
+}
+
+type generator struct {
+	o   Options
+	buf []byte
+	err error // the first error, like an unlinked type
+
+	tables map[string]bool // created
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.buf = fmt.Appendf(g.buf, format, args...)
+	g.buf = append(g.buf, '\n')
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// table adds the table of m followed by its child tables.
+// The rows of a child table reference their parent and hold the key of a map entry, or else the index of an element.
+// stack holds the messages with a table up to m, to leave recursive types in JSON columns.
+func (g *generator) table(name string, m *descriptor.Message, c *descriptor.Comments, parent string, key *descriptor.Field, stack []*descriptor.Message) {
+	stack = append(stack, m)
+	columns := g.keys(parent, key)
+	var children []func()
+	for _, f := range m.Field {
+		child := name + "_" + snake(f.Name)
+		switch v := g.element(f); {
+		case !g.o.ChildTables || f.Label != descriptor.LabelRepeated || slices.Contains(stack, v):
+			columns = append(columns, g.column(f))
+		case v != nil:
+			var k *descriptor.Field
+			if f.IsMap() {
+				k = f.Map.Key
+			}
+			children = append(children, func() { g.table(child, v, f.Comments, name, k, stack) })
+		default:
+			children = append(children, func() { g.values(child, f, name) })
+		}
+	}
+	g.create(name, c, columns)
+	for _, child := range children {
+		child()
+	}
+}
+
+// values adds the child table of a repeated field or map of values other than messages.
+func (g *generator) values(name string, f *descriptor.Field, parent string) {
+	var key *descriptor.Field
+	value := f
+	if f.IsMap() {
+		key, value = f.Map.Key, f.Map.Value
+	}
+	columns := g.keys(parent, key)
+	columns = append(columns, g.quote("value")+" "+g.valueType(value, g.quote("value"))+" NOT NULL")
+	g.create(name, f.Comments, columns)
+}
+
+// keys returns the key columns of a table, referencing the parent table unless it is empty.
+func (g *generator) keys(parent string, key *descriptor.Field) []string {
+	if !g.o.ChildTables {
+		return nil
+	}
+	columns := []string{g.quote("_id") + " " + [...]string{
+		Postgres: "BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY",
+		MySQL:    "BIGINT AUTO_INCREMENT PRIMARY KEY",
+		SQLite:   "INTEGER PRIMARY KEY",
+	}[g.o.Dialect]}
+	if parent == "" {
+		return columns
+	}
+	columns = append(columns, fmt.Sprintf("%s BIGINT NOT NULL REFERENCES %s (%s)", g.quote("_parent_id"), g.quote(parent), g.quote("_id")))
+	if key != nil {
+		return append(columns, g.quote("key")+" "+g.valueType(key, g.quote("key"))+" NOT NULL")
+	}
+	return append(columns, g.quote("_index")+" INTEGER NOT NULL")
+}
+
+// element returns the message type of the elements of a repeated field or the values of a map,
+// nil if they are not stored as the columns of a table.
+func (g *generator) element(f *descriptor.Field) *descriptor.Message {
+	if f.IsMap() {
+		f = f.Map.Value
+	}
+	m := f.MessageType
+	if m == nil || columnTypes[m.FullName()] != nil {
+		return nil
+	}
+	return m
+}
+
+// column returns the definition of the column of f.
+func (g *generator) column(f *descriptor.Field) string {
+	col := g.quote(f.Name)
+	if f.Label == descriptor.LabelRepeated {
+		return col + " " + g.json()
+	}
+	def := col + " " + g.valueType(f, col)
+	if !f.HasPresence() || f.Features().FieldPresence == descriptor.PresenceLegacyRequired {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// valueType returns the type of the column col holding a single value of f.
+func (g *generator) valueType(f *descriptor.Field, col string) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return g.json()
+		}
+		if t := columnTypes[f.MessageType.FullName()]; t != nil {
+			return t[g.o.Dialect]
+		}
+		return g.json()
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "TEXT"
+		}
+		var names []string
+		for _, v := range f.EnumType.Value {
+			names = append(names, "'"+strings.ReplaceAll(v.Name, "'", "''")+"'")
+		}
+		if len(names) == 0 {
+			g.fail(errors.New(f.EnumType.FullName() + " has no values"))
+		}
+		if g.o.Dialect == MySQL {
+			return "ENUM(" + strings.Join(names, ", ") + ")"
+		}
+		return fmt.Sprintf("TEXT CHECK (%s IN (%s))", col, strings.Join(names, ", "))
+	default:
+	}
+	return scalars[f.Type][g.o.Dialect]
+}
+
+func (g *generator) json() string {
+	return [...]string{Postgres: "JSONB", MySQL: "JSON", SQLite: "TEXT"}[g.o.Dialect]
+}
+
+// scalars are the column types of the scalar types by dialect.
+var scalars = map[uint8][3]string{
+	descriptor.TypeDouble:   {"DOUBLE PRECISION", "DOUBLE", "REAL"},
+	descriptor.TypeFloat:    {"REAL", "FLOAT", "REAL"},
+	descriptor.TypeInt64:    {"BIGINT", "BIGINT", "INTEGER"},
+	descriptor.TypeUint64:   {"NUMERIC(20)", "BIGINT UNSIGNED", "INTEGER"},
+	descriptor.TypeInt32:    {"INTEGER", "INT", "INTEGER"},
+	descriptor.TypeFixed64:  {"NUMERIC(20)", "BIGINT UNSIGNED", "INTEGER"},
+	descriptor.TypeFixed32:  {"BIGINT", "INT UNSIGNED", "INTEGER"},
+	descriptor.TypeBool:     {"BOOLEAN", "BOOLEAN", "INTEGER"},
+	descriptor.TypeString:   {"TEXT", "LONGTEXT", "TEXT"},
+	descriptor.TypeBytes:    {"BYTEA", "LONGBLOB", "BLOB"},
+	descriptor.TypeUint32:   {"BIGINT", "INT UNSIGNED", "INTEGER"},
+	descriptor.TypeSfixed32: {"INTEGER", "INT", "INTEGER"},
+	descriptor.TypeSfixed64: {"BIGINT", "BIGINT", "INTEGER"},
+	descriptor.TypeSint32:   {"INTEGER", "INT", "INTEGER"},
+	descriptor.TypeSint64:   {"BIGINT", "BIGINT", "INTEGER"},
+}
+
+// columnTypes are the well-known messages stored in columns of their own type instead of JSON.
+var columnTypes = map[string]*[3]string{
+	"google.protobuf.Timestamp":   {"TIMESTAMPTZ", "DATETIME(6)", "TEXT"},
+	"google.protobuf.BoolValue":   ptr(scalars[descriptor.TypeBool]),
+	"google.protobuf.BytesValue":  ptr(scalars[descriptor.TypeBytes]),
+	"google.protobuf.DoubleValue": ptr(scalars[descriptor.TypeDouble]),
+	"google.protobuf.FloatValue":  ptr(scalars[descriptor.TypeFloat]),
+	"google.protobuf.Int32Value":  ptr(scalars[descriptor.TypeInt32]),
+	"google.protobuf.Int64Value":  ptr(scalars[descriptor.TypeInt64]),
+	"google.protobuf.StringValue": ptr(scalars[descriptor.TypeString]),
+	"google.protobuf.UInt32Value": ptr(scalars[descriptor.TypeUint32]),
+	"google.protobuf.UInt64Value": ptr(scalars[descriptor.TypeUint64]),
+}
+
+func ptr(t [3]string) *[3]string {
+	return &t
+}
+
+// create adds a CREATE TABLE statement, preceded by the leading comment of its element,
+// or only a comment for a table without columns.
+func (g *generator) create(name string, c *descriptor.Comments, columns []string) {
+	g.line("")
+	if c != nil && c.Leading != "" {
+		for _, l := range strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n") {
+			g.line("--%s", l)
+		}
+	}
+	if g.tables[name] {
+		g.fail(fmt.Errorf("table %s is created twice", name))
+	}
+	g.tables[name] = true
+	if len(columns) == 0 {
+		// SQL has no tables without columns
+		g.line("-- %s has no columns", g.quote(name))
+		return
+	}
+	g.line("CREATE TABLE %s (\n  %s\n);", g.quote(name), strings.Join(columns, ",\n  "))
+}
+
+// quote quotes an identifier.
+func (g *generator) quote(name string) string {
+	if g.o.Dialect == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// snake returns a name in snake case, OrderItem becomes order_item.
+func snake(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			if i > 0 && name[i-1] != '_' && !('A' <= name[i-1] && name[i-1] <= 'Z' && (i+1 == len(name) || !('a' <= name[i+1] && name[i+1] <= 'z'))) {
+				b.WriteByte('_')
+			}
+			c += 'a' - 'A'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,330 @@
+// Package typescript generates TypeScript declarations of the JSON encoding of messages, see the protojson package.
+//
+// Each .proto file becomes a .d.ts file with an interface per message and a union of the value names per enum.
+// Nested types are named like Outer_Inner, imported files are imported as namespaces.
+// Fields are optional as the encoding leaves out default values, but for required fields of proto2.
+// 64 bit integers are strings, bytes are base64 strings and the well-known types have their own JSON form.
+// Floating point fields are numbers, their types leave out the strings "NaN", "Infinity" and "-Infinity" of the special values.
+package typescript
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+)
+
+// Options configure the generator.
+type Options struct {
+	UseProtoNames  bool // property names from the .proto file instead of their json_name
+	UseEnumNumbers bool // enums are unions of the value numbers instead of their names
+}
+
+// Generate generates the declarations of f, deps are the linked files of the types it uses.
+// It returns the name of the .d.ts file, next to the .proto file, and its content.
+func (o Options) Generate(f *descriptor.File, deps []*descriptor.File) (string, []byte, error) {
+	g := &generator{o: o, file: f, owners: map[any]*descriptor.File{}, names: map[any]string{}, imports: map[string]string{}, namespaces: map[string]bool{}}
+	for _, d := range append(deps, f) {
+		g.index(d, "", d.Message, d.Enum)
+	}
+	for _, en := range f.Enum {
+		g.enum(en)
+	}
+	for _, m := range f.Message {
+		g.message(m)
+	}
+	if g.err != nil {
+		return "", nil, fmt.Errorf("%s: %w", f.Name, g.err)
+	}
+	return module(f.Name) + ".d.ts", g.source(), nil
+}
+
+// module returns the module of the declarations of a .proto file, without extension.
+func module(name string) string {
+	return strings.TrimSuffix(name, ".proto")
+}
+
+type generator struct {
+	o    Options
+	file *descriptor.File
+	buf  []byte
+	err  error // the first error, like an unlinked type
+
+	owners     map[any]*descriptor.File // of the messages and enums
+	names      map[any]string           // of the messages and enums
+	imports    map[string]string        // namespaces of the imported modules by file
+	namespaces map[string]bool          // in use
+}
+
+// index names the messages and enums of file by their name relative to the package, with underscores for dots.
+func (g *generator) index(file *descriptor.File, scope string, msgs []*descriptor.Message, enums []*descriptor.Enum) {
+	for _, en := range enums {
+		g.owners[en], g.names[en] = file, scope+en.Name
+	}
+	for _, m := range msgs {
+		g.owners[m], g.names[m] = file, scope+m.Name
+		g.index(file, scope+m.Name+"_", m.Nested, m.Enum)
+	}
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.buf = fmt.Appendf(g.buf, format, args...)
+	g.buf = append(g.buf, '\n')
+}
+
+// typeName returns the name of a message or enum, qualified with the namespace of its module if it is imported.
+func (g *generator) typeName(x any) string {
+	file, ok := g.owners[x]
+	if !ok {
+		g.fail(errors.New("types are not linked"))
+		return "unknown"
+	}
+	if file == g.file {
+		return g.names[x]
+	}
+	ns, ok := g.imports[file.Name]
+	if !ok {
+		ns = strings.Map(func(r rune) rune {
+			if r < 0x80 && (r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+				return r
+			}
+			return '_'
+		}, module(file.Name))
+		for g.namespaces[ns] {
+			ns += "_"
+		}
+		g.imports[file.Name], g.namespaces[ns] = ns, true
+	}
+	return ns + "." + g.names[x]
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// doc prints the leading comment of an element as a JSDoc comment, indented by indent.
+func (g *generator) doc(indent string, c *descriptor.Comments, deprecated bool) {
+	var lines []string
+	if c != nil && c.Leading != "" {
+		for _, l := range strings.Split(strings.TrimSuffix(c.Leading, "\n"), "\n") {
+			lines = append(lines, strings.ReplaceAll(strings.TrimPrefix(l, " "), "*/", "* /"))
+		}
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	switch len(lines) {
+	case 0:
+	case 1:
+		g.line("%s/** %s */", indent, lines[0])
+	default:
+		g.line("%s/**", indent)
+		for _, l := range lines {
+			g.line("%s * %s", indent, l)
+		}
+		g.line("%s */", indent)
+	}
+}
+
+func (g *generator) enum(en *descriptor.Enum) {
+	var values []string
+	for _, v := range en.Value {
+		s := strconv.Quote(v.Name)
+		if g.o.UseEnumNumbers {
+			s = strconv.Itoa(int(v.Number))
+		}
+		if !slices.Contains(values, s) {
+			values = append(values, s)
+		}
+	}
+	if len(values) == 0 {
+		values = []string{"never"}
+	}
+	g.line("")
+	g.doc("", en.Comments, en.Options != nil && en.Options.Deprecated)
+	g.line("export type %s = %s;", g.names[en], strings.Join(values, " | "))
+}
+
+func (g *generator) message(m *descriptor.Message) {
+	if m.IsMapEntry() {
+		return
+	}
+	g.line("")
+	g.doc("", m.Comments, m.Options != nil && m.Options.Deprecated)
+	g.line("export interface %s {", g.names[m])
+	for _, f := range m.Field {
+		deprecated := f.Options != nil && f.Options.Deprecated
+		c := f.Comments
+		if o := f.RealOneOf(m); o != nil {
+			note := fmt.Sprintf(" Part of the oneof %s, at most one of its fields is set.", o.Name)
+			if c == nil || c.Leading == "" {
+				c = &descriptor.Comments{Leading: note}
+			} else {
+				c = &descriptor.Comments{Leading: strings.TrimSuffix(c.Leading, "\n") + "\n\n" + note}
+			}
+		}
+		g.doc("  ", c, deprecated)
+		optional := "?"
+		if f.Features().FieldPresence == descriptor.PresenceLegacyRequired {
+			optional = ""
+		}
+		g.line("  %s%s: %s;", property(g.name(f)), optional, g.fieldType(f))
+	}
+	if len(m.ExtensionRange) > 0 {
+		g.line("  /** Extensions, keyed by their full name in brackets. */")
+		g.line("  [extension: `[${string}]`]: unknown;")
+	}
+	g.line("}")
+	for _, en := range m.Enum {
+		g.enum(en)
+	}
+	for _, nm := range m.Nested {
+		g.message(nm)
+	}
+}
+
+// name returns the property of f.
+func (g *generator) name(f *descriptor.Field) string {
+	switch {
+	case g.o.UseProtoNames:
+		return f.Name
+	case f.JsonName != "":
+		return f.JsonName
+	default:
+	}
+	return descriptor.JSONName(f.Name)
+}
+
+// property quotes a property name that is not an identifier.
+func property(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+func (g *generator) fieldType(f *descriptor.Field) string {
+	switch {
+	case f.IsMap():
+		// the keys of JSON objects are strings, whatever the key type
+		return fmt.Sprintf("{ [key: string]: %s }", g.valueType(f.Map.Value))
+	case f.Label == descriptor.LabelRepeated:
+		t := g.valueType(f)
+		if strings.ContainsAny(t, " |") {
+			t = "(" + t + ")"
+		}
+		return t + "[]"
+	default:
+	}
+	return g.valueType(f)
+}
+
+// valueType returns the type of a single value of f.
+func (g *generator) valueType(f *descriptor.Field) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "unknown"
+		}
+		if t, ok := wellKnown[f.MessageType.FullName()]; ok {
+			return t
+		}
+		return g.typeName(f.MessageType)
+	case descriptor.TypeEnum:
+		if f.EnumType == nil {
+			g.fail(fmt.Errorf("%s: type %s is not linked", f.FullName(), f.TypeName))
+			return "unknown"
+		}
+		if f.EnumType.FullName() == "google.protobuf.NullValue" {
+			return "null"
+		}
+		return g.typeName(f.EnumType)
+	default:
+	}
+	return scalars[f.Type]
+}
+
+var scalars = map[uint8]string{
+	descriptor.TypeDouble:   "number",
+	descriptor.TypeFloat:    "number",
+	descriptor.TypeInt64:    "string",
+	descriptor.TypeUint64:   "string",
+	descriptor.TypeInt32:    "number",
+	descriptor.TypeFixed64:  "string",
+	descriptor.TypeFixed32:  "number",
+	descriptor.TypeBool:     "boolean",
+	descriptor.TypeString:   "string",
+	descriptor.TypeBytes:    "string",
+	descriptor.TypeUint32:   "number",
+	descriptor.TypeSfixed32: "number",
+	descriptor.TypeSfixed64: "string",
+	descriptor.TypeSint32:   "number",
+	descriptor.TypeSint64:   "string",
+}
+
+// wellKnown are the types of the well-known messages with a JSON form of their own.
+var wellKnown = map[string]string{
+	"google.protobuf.Any":         `{ "@type": string; [key: string]: unknown }`,
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.Empty":       "Record<string, never>",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.ListValue":   "unknown[]",
+	"google.protobuf.Struct":      "{ [key: string]: unknown }",
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.Value":       "unknown",
+	"google.protobuf.BoolValue":   "boolean",
+	"google.protobuf.BytesValue":  "string",
+	"google.protobuf.DoubleValue": "number",
+	"google.protobuf.FloatValue":  "number",
+	"google.protobuf.Int32Value":  "number",
+	"google.protobuf.Int64Value":  "string",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.UInt32Value": "number",
+	"google.protobuf.UInt64Value": "string",
+}
+
+// source returns the header with the imports and the declarations.
+func (g *generator) source() []byte {
+	var b []byte
+	b = fmt.Appendf(b, "// Code generated by proton generate typescript. DO NOT EDIT.\n// source: %s\n", g.file.Name)
+	if len(g.imports) > 0 {
+		b = append(b, '\n')
+		files := make([]string, 0, len(g.imports))
+		for name := range g.imports {
+			files = append(files, name)
+		}
+		slices.Sort(files)
+		dir := path.Dir(g.file.Name)
+		for _, name := range files {
+			b = fmt.Appendf(b, "import type * as %s from %q;\n", g.imports[name], relative(dir, module(name)))
+		}
+	}
+	return append(b, g.buf...)
+}
+
+// relative returns the module specifier of the module target from a module in dir.
+func relative(dir, target string) string {
+	from := strings.Split(dir, "/")
+	if dir == "." {
+		from = nil
+	}
+	to := strings.Split(target, "/")
+	i := 0
+	for i < len(from) && i < len(to)-1 && from[i] == to[i] {
+		i++
+	}
+	rel := strings.Repeat("../", len(from)-i) + strings.Join(to[i:], "/")
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
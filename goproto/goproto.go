@@ -0,0 +1,148 @@
+// Package goproto converts files and dynamic messages to and from those of google.golang.org/protobuf,
+// to hand them to protodesc, protoregistry and dynamicpb when a library needs the official runtime.
+//
+// The conversions go through the binary encoding, which both sides read and write losslessly.
+// It is the only package of the module depending on google.golang.org/protobuf.
+package goproto
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// Files converts files to a registry of protoreflect.FileDescriptor.
+// The well-known files they import but lack are added, any other import must be among files.
+func Files(files []*descriptor.File) (*protoregistry.Files, error) {
+	b, err := descriptor.Marshal(wellknown.Complete(files))
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("goproto: %w", err)
+	}
+	return protodesc.NewFiles(&set)
+}
+
+// File converts f to a protoreflect.FileDescriptor, resolving its imports with r,
+// like protoregistry.GlobalFiles or a registry returned by Files.
+func File(f *descriptor.File, r protodesc.Resolver) (protoreflect.FileDescriptor, error) {
+	b, err := f.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", f.Name, err)
+	}
+	fdp := new(descriptorpb.FileDescriptorProto)
+	if err := proto.Unmarshal(b, fdp); err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", f.Name, err)
+	}
+	return protodesc.NewFile(fdp, r)
+}
+
+// FromFile converts fd to a File.
+func FromFile(fd protoreflect.FileDescriptor) (*descriptor.File, error) {
+	b, err := proto.Marshal(protodesc.ToFileDescriptorProto(fd))
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", fd.Path(), err)
+	}
+	f := new(descriptor.File)
+	if err := f.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", fd.Path(), err)
+	}
+	return f, nil
+}
+
+// FromFiles converts the files of r, each after the files it imports, and links them.
+func FromFiles(r *protoregistry.Files) ([]*descriptor.File, error) {
+	var files []*descriptor.File
+	seen := map[string]bool{}
+	var add func(fd protoreflect.FileDescriptor) error
+	add = func(fd protoreflect.FileDescriptor) error {
+		if seen[fd.Path()] {
+			return nil
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := range imports.Len() {
+			if err := add(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		f, err := FromFile(fd)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		return nil
+	}
+	var err error
+	r.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = add(fd)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := descriptor.Link(files); err != nil {
+		return nil, fmt.Errorf("goproto: %w", err)
+	}
+	return files, nil
+}
+
+// Message returns the message descriptor of r with the full name of desc.
+func Message(desc *descriptor.Message, r *protoregistry.Files) (protoreflect.MessageDescriptor, error) {
+	name := desc.FullName()
+	d, err := r.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("goproto: %s is not a message", name)
+	}
+	return md, nil
+}
+
+// NewMessage converts m to a dynamicpb.Message of the type in r with the same name.
+func NewMessage(m *dynamic.Message, r *protoregistry.Files) (*dynamicpb.Message, error) {
+	md, err := Message(m.Descriptor(), r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %w", err)
+	}
+	pm := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, pm); err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", md.FullName(), err)
+	}
+	return pm, nil
+}
+
+// FromMessage converts m, generated or dynamicpb, to a dynamic message of the type in symbols with the same name.
+func FromMessage(m proto.Message, symbols *descriptor.Symbols) (*dynamic.Message, error) {
+	name := string(m.ProtoReflect().Descriptor().FullName())
+	desc := symbols.Message(name)
+	if desc == nil {
+		return nil, fmt.Errorf("goproto: no message %s", name)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", name, err)
+	}
+	dm, err := dynamic.Unmarshal(desc, b)
+	if err != nil {
+		return nil, fmt.Errorf("goproto: %s: %w", name, err)
+	}
+	return dm, nil
+}
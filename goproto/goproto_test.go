@@ -0,0 +1,96 @@
+package goproto_test
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/goproto"
+	"github.com/defsrc/proton/wellknown"
+)
+
+func TestFiles(t *testing.T) {
+	r, err := goproto.Files(wellknown.Files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, want := r.NumFiles(), len(wellknown.Files()); n != want {
+		t.Errorf("Files: %d files, want %d", n, want)
+	}
+	files, err := goproto.FromFiles(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, f := range files {
+		for _, dep := range f.Dependency {
+			if !seen[dep] {
+				t.Errorf("FromFiles: %s comes before its import %s", f.Name, dep)
+			}
+		}
+		seen[f.Name] = true
+		want, err := wellknown.File(f.Name).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s changes in the round trip:\n% x\nwant\n% x", f.Name, got, want)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	f := &descriptor.File{Name: "a.proto", Package: "a", Dependency: []string{"google/protobuf/struct.proto"}}
+	if _, err := goproto.File(f, new(protoregistry.Files)); err == nil {
+		t.Error("File resolves an import missing from the resolver")
+	}
+	fd, err := goproto.File(f, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd.Path() != "a.proto" || fd.Package() != "a" || fd.Imports().Len() != 1 {
+		t.Errorf("File = %s, package %s, %d imports", fd.Path(), fd.Package(), fd.Imports().Len())
+	}
+}
+
+func TestMessage(t *testing.T) {
+	r, err := goproto.Files(wellknown.Files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := structpb.NewStruct(map[string]any{"a": []any{1.5, "x", nil, true}, "b": map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := goproto.FromMessage(want, wellknown.Symbols())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := goproto.NewMessage(m, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the types come from different registries, compare the encodings
+	if !bytes.Equal(marshal(t, got), marshal(t, want)) {
+		t.Errorf("NewMessage(FromMessage(%v)) = %v", want, got)
+	}
+	if _, err := goproto.Message(wellknown.Message("google.protobuf.Struct"), new(protoregistry.Files)); err == nil {
+		t.Error("Message finds a message missing from the registry")
+	}
+}
+
+func marshal(t *testing.T, m proto.Message) []byte {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
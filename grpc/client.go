@@ -0,0 +1,152 @@
+// Package grpc calls the methods of gRPC servers with encoded messages, over HTTP/2 with TLS
+// or without (h2c), and serves the reflection service of a set of files, see ReflectionServer.
+//
+// See https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// A Client calls the methods of the server at Target.
+type Client struct {
+	Target string      // host:port
+	Header http.Header // metadata sent with every call, may be nil
+	HTTP   *http.Client
+
+	// Plaintext calls over HTTP/2 without TLS, the transport of HTTP must allow unencrypted HTTP/2.
+	Plaintext bool
+
+	reflectPath string // of the reflection version the server implements, see (*Client).reflect
+}
+
+// NewClient returns a client of target, config may be nil for the default TLS settings.
+func NewClient(target string, config *tls.Config) *Client {
+	return &Client{Target: target, HTTP: &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   config,
+		ForceAttemptHTTP2: true,
+	}}}
+}
+
+// NewPlaintextClient returns a client of target over HTTP/2 without TLS, for local servers like grpcurl -plaintext.
+func NewPlaintextClient(target string) *Client {
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	return &Client{Target: target, Plaintext: true, HTTP: &http.Client{Transport: &http.Transport{Protocols: &p}}}
+}
+
+// maxMessage limits the size of the response messages.
+const maxMessage = 64 << 20
+
+// Invoke calls a unary method, like "pkg.Service/Method", with an encoded request and returns the encoded response.
+func (c *Client) Invoke(ctx context.Context, method string, req []byte) ([]byte, error) {
+	resps, err := c.Stream(ctx, method, [][]byte{req})
+	if err != nil {
+		return nil, err
+	}
+	if len(resps) != 1 {
+		return nil, fmt.Errorf("grpc: %s: %d responses to a unary call", method, len(resps))
+	}
+	return resps[0], nil
+}
+
+// Stream sends all the requests and then reads all the responses of a method.
+// It suits every kind of method, but bidirectional ones only see the requests together.
+// A call that fails on the server returns a *Status and the responses read before.
+func (c *Client) Stream(ctx context.Context, method string, reqs [][]byte) ([][]byte, error) {
+	var body []byte
+	for _, req := range reqs {
+		body = append(body, 0) // not compressed
+		body = binary.BigEndian.AppendUint32(body, uint32(len(req)))
+		body = append(body, req...)
+	}
+	scheme := "https://"
+	if c.Plaintext {
+		scheme = "http://"
+	}
+	u := scheme + c.Target + "/" + strings.TrimPrefix(method, "/")
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	r.Header.Set("TE", "trailers")
+	r.Header.Set("User-Agent", "proton")
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("grpc: %s: HTTP status %s", method, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/grpc") {
+		return nil, fmt.Errorf("grpc: %s: unexpected content type %q", method, ct)
+	}
+	var resps [][]byte
+	for {
+		var prefix [5]byte
+		if _, err := io.ReadFull(resp.Body, prefix[:]); err == io.EOF {
+			break
+		} else if err != nil {
+			return resps, err
+		}
+		n := binary.BigEndian.Uint32(prefix[1:])
+		if prefix[0] != 0 {
+			return resps, fmt.Errorf("grpc: %s: compressed responses are not supported", method)
+		}
+		if n > maxMessage {
+			return resps, fmt.Errorf("grpc: %s: response of %d bytes is too large", method, n)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(resp.Body, msg); err != nil {
+			return resps, err
+		}
+		resps = append(resps, msg)
+	}
+	// a trailers-only response sends the status in the headers
+	trailer := resp.Trailer
+	if trailer.Get("Grpc-Status") == "" {
+		trailer = resp.Header
+	}
+	if err := status(trailer); err != nil {
+		return resps, err
+	}
+	return resps, nil
+}
+
+func status(h http.Header) error {
+	s := h.Get("Grpc-Status")
+	if s == "" {
+		return errors.New("grpc: no status in the response")
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("grpc: invalid status %q", s)
+	}
+	if code == int(OK) {
+		return nil
+	}
+	msg := h.Get("Grpc-Message")
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	return &Status{Code: Code(code), Message: msg}
+}
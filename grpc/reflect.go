@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// The methods of the server reflection service, v1 is tried first.
+// https://github.com/grpc/grpc/blob/master/src/proto/grpc/reflection/v1/reflection.proto
+const (
+	reflectV1      = "grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	reflectV1Alpha = "grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// Fields of ServerReflectionRequest.
+const (
+	reqFileByFilename       = 3
+	reqFileContainingSymbol = 4
+	reqListServices         = 7
+)
+
+// reflect sends a ServerReflectionRequest with the string field set and returns the response field
+// of ServerReflectionResponse, failing with a *Status for an error_response.
+func (c *Client) reflect(ctx context.Context, tag wire.TagNum, value string) ([]byte, error) {
+	e := wire.NewEncoder(nil)
+	e.EncodeString(tag, value)
+	paths := []string{reflectV1, reflectV1Alpha}
+	if c.reflectPath != "" {
+		paths = []string{c.reflectPath}
+	}
+	var resp []byte
+	var err error
+	for _, path := range paths {
+		resp, err = c.Invoke(ctx, path, e.Bytes())
+		var s *Status
+		if !errors.As(err, &s) || s.Code != Unimplemented {
+			c.reflectPath = path
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for r, err := range wire.Fields(resp) {
+		if err != nil {
+			return nil, err
+		}
+		switch r.Tag {
+		case 4, 6: // file_descriptor_response, list_services_response
+			return r.Bytes, nil
+		case 7: // error_response
+			s := &Status{}
+			for r, err := range wire.Fields(r.Bytes) {
+				if err != nil {
+					return nil, err
+				}
+				switch r.Tag {
+				case 1:
+					s.Code = Code(r.Value)
+				case 2:
+					s.Message = string(r.Bytes)
+				default:
+				}
+			}
+			return nil, s
+		default: // valid_host, original_request
+		}
+	}
+	return nil, errors.New("grpc: empty reflection response")
+}
+
+// ListServices returns the fully qualified names of the services of the server.
+func (c *Client) ListServices(ctx context.Context) ([]string, error) {
+	resp, err := c.reflect(ctx, reqListServices, "*")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for r, err := range wire.Fields(resp) {
+		if err != nil {
+			return nil, err
+		}
+		if r.Tag != 1 {
+			continue
+		}
+		for r, err := range wire.Fields(r.Bytes) {
+			if err != nil {
+				return nil, err
+			}
+			if r.Tag == 1 {
+				names = append(names, string(r.Bytes))
+			}
+		}
+	}
+	return names, nil
+}
+
+// fileResponse parses the files of a FileDescriptorResponse.
+func fileResponse(resp []byte) ([]*descriptor.File, error) {
+	var files []*descriptor.File
+	for r, err := range wire.Fields(resp) {
+		if err != nil {
+			return nil, err
+		}
+		if r.Tag != 1 {
+			continue
+		}
+		f := &descriptor.File{}
+		if err := f.UnmarshalBinary(r.Bytes); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Files returns the files defining the symbols, like services or messages, and all the files they import.
+// Imports the server does not know are taken from the well-known files.
+func (c *Client) Files(ctx context.Context, symbols ...string) ([]*descriptor.File, error) {
+	var files []*descriptor.File
+	seen := map[string]bool{}
+	var queue []string
+	add := func(x []*descriptor.File) {
+		for _, f := range x {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				files = append(files, f)
+				queue = append(queue, f.Dependency...)
+			}
+		}
+	}
+	for _, sym := range symbols {
+		resp, err := c.reflect(ctx, reqFileContainingSymbol, sym)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sym, err)
+		}
+		x, err := fileResponse(resp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sym, err)
+		}
+		add(x)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		resp, err := c.reflect(ctx, reqFileByFilename, name)
+		if err != nil {
+			if f := wellknown.File(name); f != nil {
+				add([]*descriptor.File{f})
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		x, err := fileResponse(resp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		add(x)
+	}
+	return files, nil
+}
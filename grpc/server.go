@@ -0,0 +1,281 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// Fields of ServerReflectionRequest, besides the ones the client sends.
+const (
+	reqFileContainingExtension   = 5
+	reqAllExtensionNumbersOfType = 6
+)
+
+// A ReflectionServer implements the server reflection service, v1 and v1alpha, over a set of files,
+// so clients can list and describe the services without a server implementing them.
+// It is an http.Handler and needs HTTP/2, see http.Server.ServeTLS and NewPlaintextServer.
+type ReflectionServer struct {
+	files      map[string]*descriptor.File
+	encoded    map[string][]byte           // the binary forms of the files
+	symbols    map[string]string           // full names of the elements to the names of their files
+	extensions map[string]map[int32]string // extendees to the numbers of their extensions to the names of their files
+	services   []string
+}
+
+// NewPlaintextServer returns a server of h over HTTP/2 without TLS (h2c), for http.Server.Serve
+// and clients like grpcurl -plaintext.
+func NewPlaintextServer(h http.Handler) *http.Server {
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	return &http.Server{Handler: h, Protocols: &p}
+}
+
+// NewReflectionServer returns a server of files, which must include their dependencies.
+func NewReflectionServer(files []*descriptor.File) (*ReflectionServer, error) {
+	s := &ReflectionServer{files: map[string]*descriptor.File{}, encoded: map[string][]byte{},
+		symbols: map[string]string{}, extensions: map[string]map[int32]string{}}
+	for _, f := range files {
+		b, err := f.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		s.files[f.Name], s.encoded[f.Name] = f, b
+		scope := ""
+		if f.Package != "" {
+			scope = f.Package + "."
+		}
+		s.index(f.Name, scope, f.Message, f.Enum, f.Extension)
+		for _, svc := range f.Service {
+			s.symbols[scope+svc.Name] = f.Name
+			s.services = append(s.services, scope+svc.Name)
+			for _, m := range svc.Method {
+				s.symbols[scope+svc.Name+"."+m.Name] = f.Name
+			}
+		}
+	}
+	for _, f := range files {
+		for _, dep := range f.Dependency {
+			if s.files[dep] == nil {
+				return nil, fmt.Errorf("%s: dependency %s is missing", f.Name, dep)
+			}
+		}
+	}
+	slices.Sort(s.services)
+	return s, nil
+}
+
+func (s *ReflectionServer) index(file, scope string, msgs []*descriptor.Message, enums []*descriptor.Enum, exts []*descriptor.Field) {
+	for _, en := range enums {
+		s.symbols[scope+en.Name] = file
+	}
+	for _, x := range exts {
+		s.symbols[scope+x.Name] = file
+		extendee := strings.TrimPrefix(x.Extendee, ".")
+		if s.extensions[extendee] == nil {
+			s.extensions[extendee] = map[int32]string{}
+		}
+		s.extensions[extendee][int32(x.Tag)] = file
+	}
+	for _, m := range msgs {
+		s.symbols[scope+m.Name] = file
+		s.index(file, scope+m.Name+".", m.Nested, m.Enum, m.Extension)
+	}
+}
+
+// ServeHTTP answers the requests of a reflection stream in order.
+func (s *ReflectionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC needs HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if r.Method != http.MethodPost || path != reflectV1 && path != reflectV1Alpha {
+		writeStatus(w, &Status{Code: Unimplemented, Message: "unknown method " + path})
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	for {
+		req, err := readMessage(r.Body)
+		if err == io.EOF {
+			w.Header().Set("Grpc-Status", "0")
+			return
+		}
+		if err != nil {
+			w.Header().Set("Grpc-Status", strconv.Itoa(int(InvalidArgument)))
+			w.Header().Set("Grpc-Message", err.Error())
+			return
+		}
+		resp, err := s.answer(req)
+		if err != nil {
+			w.Header().Set("Grpc-Status", strconv.Itoa(int(InvalidArgument)))
+			w.Header().Set("Grpc-Message", err.Error())
+			return
+		}
+		frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(resp)))
+		if _, err := w.Write(append(frame, resp...)); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeStatus sends a response without messages, the status in the headers.
+func writeStatus(w http.ResponseWriter, st *Status) {
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code)))
+	w.Header().Set("Grpc-Message", st.Message)
+	w.WriteHeader(http.StatusOK)
+}
+
+// readMessage reads a length prefixed message, io.EOF at the end of the stream.
+func readMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("grpc: truncated message")
+		}
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("grpc: compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > maxMessage {
+		return nil, fmt.Errorf("grpc: message of %d bytes is too large", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, errors.New("grpc: truncated message")
+	}
+	return msg, nil
+}
+
+// answer returns the ServerReflectionResponse to a ServerReflectionRequest.
+// Unknown names get an error_response, the stream goes on.
+func (s *ReflectionServer) answer(req []byte) ([]byte, error) {
+	e := wire.NewEncoder(nil)
+	var body []byte
+	var tag wire.TagNum
+	var st *Status
+	for r, err := range wire.Fields(req) {
+		if err != nil {
+			return nil, err
+		}
+		switch r.Tag {
+		case 1: // host
+			e.EncodeString(1, string(r.Bytes))
+		case reqFileByFilename:
+			tag = 4
+			body, st = s.fileResponse(string(r.Bytes), "file "+string(r.Bytes))
+		case reqFileContainingSymbol:
+			tag = 4
+			body, st = s.fileResponse(s.symbols[string(r.Bytes)], "symbol "+string(r.Bytes))
+		case reqFileContainingExtension:
+			extendee, number, err := extensionRequest(r.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			tag = 4
+			body, st = s.fileResponse(s.extensions[extendee][number], fmt.Sprintf("extension %d of %s", number, extendee))
+		case reqAllExtensionNumbersOfType:
+			tag, body, st = 5, s.extensionNumbers(string(r.Bytes)), nil
+			if body == nil {
+				st = &Status{Code: NotFound, Message: "type " + string(r.Bytes) + " not found"}
+			}
+		case reqListServices:
+			rs := wire.NewEncoder(nil)
+			for _, name := range s.services {
+				rs.EncodeMessage(1, func(e *wire.Encoder) { e.EncodeString(1, name) })
+			}
+			tag, body, st = 6, rs.Bytes(), nil
+		default:
+		}
+	}
+	e.EncodeBytes(2, req) // original_request
+	if tag == 0 {
+		st = &Status{Code: InvalidArgument, Message: "empty reflection request"}
+	}
+	if st != nil {
+		e.EncodeMessage(7, func(e *wire.Encoder) { // error_response
+			e.EncodeVarint(1, uint64(st.Code))
+			e.EncodeString(2, st.Message)
+		})
+	} else {
+		e.EncodeBytes(tag, body)
+	}
+	return e.Bytes(), nil
+}
+
+// extensionRequest returns the fields of an ExtensionRequest.
+func extensionRequest(b []byte) (string, int32, error) {
+	var extendee string
+	var number int32
+	for r, err := range wire.Fields(b) {
+		if err != nil {
+			return "", 0, err
+		}
+		switch r.Tag {
+		case 1:
+			extendee = string(r.Bytes)
+		case 2:
+			number = int32(r.Value)
+		default:
+		}
+	}
+	return extendee, number, nil
+}
+
+// fileResponse returns the FileDescriptorResponse with a file and its transitive dependencies,
+// or a NotFound status naming what was asked for if there is no file called name.
+func (s *ReflectionServer) fileResponse(name, what string) ([]byte, *Status) {
+	if s.files[name] == nil {
+		return nil, &Status{Code: NotFound, Message: what + " not found"}
+	}
+	e := wire.NewEncoder(nil)
+	seen := map[string]bool{}
+	var add func(name string)
+	add = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		e.EncodeBytes(1, s.encoded[name])
+		for _, dep := range s.files[name].Dependency {
+			add(dep)
+		}
+	}
+	add(name)
+	return e.Bytes(), nil
+}
+
+// extensionNumbers returns the ExtensionNumberResponse of a message, nil if there is none called name.
+func (s *ReflectionServer) extensionNumbers(name string) []byte {
+	if _, ok := s.symbols[name]; !ok {
+		return nil
+	}
+	e := wire.NewEncoder(nil)
+	e.EncodeString(1, name)
+	var numbers []int32
+	for n := range s.extensions[name] {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	for _, n := range numbers {
+		e.EncodeVarint(2, uint64(n))
+	}
+	return e.Bytes()
+}
@@ -0,0 +1,115 @@
+// Package cstring escapes and unescapes the C style strings of .proto files and the text format.
+package cstring
+
+import "fmt"
+
+// Quote double quotes s with C escapes like protoc, bytes outside printable ASCII as three digit octal.
+func Quote(s string) string {
+	b := []byte{'"'}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\n':
+			b = append(b, `\n`...)
+		case '\r':
+			b = append(b, `\r`...)
+		case '\t':
+			b = append(b, `\t`...)
+		case '"', '\'', '\\':
+			b = append(b, '\\', c)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				b = append(b, '\\', '0'+c>>6, '0'+c>>3&7, '0'+c&7)
+			} else {
+				b = append(b, c)
+			}
+		}
+	}
+	return string(append(b, '"'))
+}
+
+// Unescape reverses C escapes, including \u and \U for UTF-8 encoded code points.
+func Unescape(s string) ([]byte, bool) {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b = append(b, c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return nil, false
+		}
+		switch c = s[i]; c {
+		case 'a':
+			b = append(b, '\a')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'v':
+			b = append(b, '\v')
+		case '\\', '\'', '"', '?':
+			b = append(b, c)
+		case 'x', 'X':
+			v, n := digitsValue(s[i+1:], 16, 2)
+			if n == 0 {
+				return nil, false
+			}
+			b = append(b, byte(v))
+			i += n
+		case 'u', 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+			v, n := digitsValue(s[i+1:], 16, size)
+			if n != size || v > 0x10ffff {
+				return nil, false
+			}
+			b = fmt.Appendf(b, "%c", rune(v))
+			i += n
+		default:
+			v, n := digitsValue(s[i:], 8, 3)
+			if n == 0 || v > 0xff {
+				return nil, false
+			}
+			b = append(b, byte(v))
+			i += n - 1
+		}
+	}
+	return b, true
+}
+
+// digitsValue parses up to limit leading digits of s in the base and returns the value and count.
+func digitsValue(s string, base, limit int) (uint32, int) {
+	var v uint32
+	n := 0
+	for ; n < len(s) && n < limit; n++ {
+		d := digitValue(s[n])
+		if d >= base {
+			break
+		}
+		v = v*uint32(base) + uint32(d)
+	}
+	return v, n
+}
+
+func digitValue(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10
+	default:
+	}
+	return 99
+}
@@ -0,0 +1,50 @@
+package cstring
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", `""`},
+		{"a\"b'c\\", `"a\"b\'c\\"`},
+		{"\n\r\t\x00\x7f", `"\n\r\t\000\177"`},
+		{"é", `"\303\251"`},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if got, ok := Unescape(tt.want[1 : len(tt.want)-1]); !ok || !bytes.Equal(got, []byte(tt.in)) {
+			t.Errorf("Unescape(%s) = %q, %v, want %q", tt.want, got, ok, tt.in)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{`\a\b\f\v\?`, "\a\b\f\v?", true},
+		{`\x41\X4a\x4`, "AJ\x04", true},
+		{`\101\0\08`, "A\x00\x008", true},
+		{`é\U0001F600`, "é😀", true},
+		{`\`, "", false},
+		{`\xg`, "", false},
+		{`\u00e`, "", false},
+		{`\U00110000`, "", false},
+		{`\400`, "", false},
+		{`\z`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Unescape(tt.in)
+		if ok != tt.ok || ok && string(got) != tt.want {
+			t.Errorf("Unescape(%s) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package timefmt formats the seconds fractions of the timestamps and durations of the JSON and text formats.
+package timefmt
+
+import "fmt"
+
+// Fraction formats nanos with 0, 3, 6 or 9 digits.
+func Fraction(nanos int32) string {
+	switch {
+	case nanos == 0:
+		return ""
+	case nanos%1e6 == 0:
+		return fmt.Sprintf(".%03d", nanos/1e6)
+	case nanos%1e3 == 0:
+		return fmt.Sprintf(".%06d", nanos/1e3)
+	default:
+	}
+	return fmt.Sprintf(".%09d", nanos)
+}
@@ -0,0 +1,21 @@
+package timefmt
+
+import "testing"
+
+func TestFraction(t *testing.T) {
+	tests := []struct {
+		nanos int32
+		want  string
+	}{
+		{0, ""},
+		{5e8, ".500"},
+		{1e3, ".000001"},
+		{1, ".000000001"},
+		{123456789, ".123456789"},
+	}
+	for _, tt := range tests {
+		if got := Fraction(tt.nanos); got != tt.want {
+			t.Errorf("Fraction(%d) = %q, want %q", tt.nanos, got, tt.want)
+		}
+	}
+}
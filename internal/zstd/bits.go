@@ -0,0 +1,75 @@
+package zstd
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// A forwardBits reads the bits of an FSE table description, from the lowest bit of the first byte on.
+// The bits past the end read as zeros, pos tells whether they were used.
+type forwardBits struct {
+	b   []byte
+	pos int // in bits
+}
+
+// peek returns the next n bits, n <= 32.
+func (r *forwardBits) peek(n int) int {
+	var v uint64
+	for i := min((r.pos+n+7)/8, len(r.b)) - 1; i >= r.pos/8; i-- {
+		v = v<<8 | uint64(r.b[i])
+	}
+	return int(v >> (r.pos % 8) & (1<<n - 1))
+}
+
+func (r *forwardBits) read(n int) int {
+	v := r.peek(n)
+	r.pos += n
+	return v
+}
+
+// A backwardBits reads a bit stream of Huffman coded literals or sequences,
+// from the bit below the highest set bit of the last byte down to the first bit.
+// The bits before the start read as zeros, pos turns negative once they are used.
+type backwardBits struct {
+	b   []byte
+	pos int // the number of unread bits
+}
+
+func newBackwardBits(b []byte) (backwardBits, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return backwardBits{}, errors.New("bit stream without an end mark")
+	}
+	return backwardBits{b: b, pos: 8*(len(b)-1) + bits.Len8(b[len(b)-1]) - 1}, nil
+}
+
+// read returns the next n bits, n <= 32, the first bit read is the highest.
+func (r *backwardBits) read(n int) int {
+	if n == 0 {
+		return 0
+	}
+	r.pos -= n
+	switch {
+	case r.pos >= 0:
+		return r.field(r.pos, n)
+	case n+r.pos > 0:
+		return r.field(0, n+r.pos) << -r.pos
+	default:
+		return 0
+	}
+}
+
+// peek returns the next n bits without consuming them.
+func (r *backwardBits) peek(n int) int {
+	v := r.read(n)
+	r.pos += n
+	return v
+}
+
+// field returns the n bits starting at bit lo.
+func (r *backwardBits) field(lo, n int) int {
+	var v uint64
+	for i := (lo + n - 1) / 8; i >= lo/8; i-- {
+		v = v<<8 | uint64(r.b[i])
+	}
+	return int(v >> (lo % 8) & (1<<n - 1))
+}
@@ -0,0 +1,331 @@
+package zstd
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// An fseTable decodes finite state entropy coded symbols, indexed by the state.
+type fseTable struct {
+	log     int // the accuracy log, the states have log bits
+	entries []fseEntry
+}
+
+type fseEntry struct {
+	symbol uint8
+	bits   uint8  // read to add to base for the next state
+	base   uint16 // of the next state
+}
+
+// readFSE reads a table description of at most maxLog accuracy and maxSymbol and returns the table and its length.
+func readFSE(b []byte, maxLog, maxSymbol int) (*fseTable, int, error) {
+	r := forwardBits{b: b}
+	log := r.read(4) + 5
+	if log > maxLog {
+		return nil, 0, fmt.Errorf("accuracy log %d exceeds %d", log, maxLog)
+	}
+	var probs []int
+	remaining, threshold, n := 1<<log+1, 1<<log, log+1
+	for remaining > 1 && len(probs) <= maxSymbol {
+		// values below max take n-1 bits, the others n
+		max := 2*threshold - 1 - remaining
+		v := r.peek(n)
+		if v&(threshold-1) < max {
+			v &= threshold - 1
+			r.pos += n - 1
+		} else {
+			v &= 2*threshold - 1
+			if v >= threshold {
+				v -= max
+			}
+			r.pos += n
+		}
+		p := v - 1 // -1 is a probability below 1
+		remaining -= abs(p)
+		if remaining < 1 {
+			return nil, 0, errors.New("probabilities exceed the table")
+		}
+		probs = append(probs, p)
+		if p == 0 {
+			// 2 bit counts of further zeros, 3 continues
+			for {
+				zeros := r.read(2)
+				for range zeros {
+					probs = append(probs, 0)
+				}
+				if zeros != 3 {
+					break
+				}
+			}
+		}
+		for remaining < threshold {
+			n--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || len(probs) > maxSymbol+1 || r.pos > 8*len(b) {
+		return nil, 0, errors.New("invalid table description")
+	}
+	t, err := newFSE(probs, log)
+	return t, (r.pos + 7) / 8, err
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// newFSE returns the table of the normalized probabilities of the symbols, which add up to 1<<log.
+func newFSE(probs []int, log int) (*fseTable, error) {
+	size := 1 << log
+	t := &fseTable{log: log, entries: make([]fseEntry, size)}
+	next := make([]int, len(probs))
+	high := size - 1
+	for s, p := range probs {
+		next[s] = p
+		if p == -1 {
+			t.entries[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		}
+	}
+	step, mask, pos := size>>1+size>>3+3, size-1, 0
+	for s, p := range probs {
+		for range max(p, 0) {
+			t.entries[pos].symbol = uint8(s)
+			for pos = (pos + step) & mask; pos > high; pos = (pos + step) & mask {
+			}
+		}
+	}
+	if pos != 0 {
+		return nil, errors.New("probabilities do not fill the table")
+	}
+	for i := range t.entries {
+		e := &t.entries[i]
+		state := next[e.symbol]
+		next[e.symbol]++
+		e.bits = uint8(log + 1 - bits.Len(uint(state)))
+		e.base = uint16(state<<e.bits - size)
+	}
+	return t, nil
+}
+
+// rleFSE returns the table of a single symbol, which takes no bits.
+func rleFSE(symbol uint8) *fseTable {
+	return &fseTable{entries: []fseEntry{{symbol: symbol}}}
+}
+
+func mustFSE(probs []int, log int) *fseTable {
+	t, err := newFSE(probs, log)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// The predefined tables of the literal lengths, match lengths and offsets.
+var (
+	llDefault = mustFSE([]int{4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1, -1, -1, -1, -1}, 6)
+	mlDefault = mustFSE([]int{1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1, -1, -1}, 6)
+	ofDefault = mustFSE([]int{1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1}, 5)
+)
+
+// The baselines and extra bits of the literal and match length codes.
+var (
+	llBase = [36]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536}
+	llBits = [36]int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	mlBase = [53]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051, 4099, 8195, 16387, 32771, 65539}
+	mlBits = [53]int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+)
+
+const (
+	maxLLCode = len(llBase) - 1
+	maxMLCode = len(mlBase) - 1
+	maxOFCode = 31
+)
+
+// An fseState is the state of a stream of symbols decoded with a table.
+type fseState struct {
+	t     *fseTable
+	state int
+}
+
+func newFSEState(t *fseTable, r *backwardBits) fseState {
+	return fseState{t, r.read(t.log)}
+}
+
+func (s *fseState) symbol() int {
+	return int(s.t.entries[s.state].symbol)
+}
+
+func (s *fseState) update(r *backwardBits) {
+	e := s.t.entries[s.state]
+	s.state = int(e.base) + r.read(int(e.bits))
+}
+
+// table returns the table of a symbol compression mode of the sequences section and the length of its description.
+func table(b []byte, mode byte, prev **fseTable, predefined *fseTable, maxLog, maxSymbol int) (int, error) {
+	switch mode {
+	case 0:
+		*prev = predefined
+		return 0, nil
+	case 1:
+		if len(b) == 0 {
+			return 0, errTruncated
+		}
+		if int(b[0]) > maxSymbol {
+			return 0, fmt.Errorf("code %d exceeds %d", b[0], maxSymbol)
+		}
+		*prev = rleFSE(b[0])
+		return 1, nil
+	case 2:
+		t, n, err := readFSE(b, maxLog, maxSymbol)
+		if err != nil {
+			return 0, err
+		}
+		*prev = t
+		return n, nil
+	default:
+		if *prev == nil {
+			return 0, errors.New("repeated table without a previous one")
+		}
+		return 0, nil
+	}
+}
+
+// sequences decodes the sequences section in b and appends the literals and matches they copy to the content.
+func (d *decoder) sequences(b []byte, lits []byte) error {
+	if len(b) == 0 {
+		return errTruncated
+	}
+	count, n := int(b[0]), 1
+	switch {
+	case count == 0:
+		if len(b) != 1 {
+			return errors.New("data after zero sequences")
+		}
+		d.out = append(d.out, lits...)
+		return nil
+	case count < 128:
+	case count < 255:
+		if len(b) < 2 {
+			return errTruncated
+		}
+		count, n = (count-128)<<8|int(b[1]), 2
+	default:
+		if len(b) < 3 {
+			return errTruncated
+		}
+		count, n = int(b[1])|int(b[2])<<8+0x7f00, 3
+	}
+	if len(b) <= n {
+		return errTruncated
+	}
+	modes := b[n]
+	n++
+	if modes&3 != 0 {
+		return errors.New("reserved bits of the compression modes set")
+	}
+	for _, t := range []struct {
+		name          string
+		mode          byte
+		prev          **fseTable
+		predefined    *fseTable
+		log, maxValue int
+	}{
+		{"literal lengths", modes >> 6, &d.ll, llDefault, 9, maxLLCode},
+		{"offsets", modes >> 4 & 3, &d.of, ofDefault, 8, maxOFCode},
+		{"match lengths", modes >> 2 & 3, &d.ml, mlDefault, 9, maxMLCode},
+	} {
+		m, err := table(b[n:], t.mode, t.prev, t.predefined, t.log, t.maxValue)
+		if err != nil {
+			return fmt.Errorf("%s table: %w", t.name, err)
+		}
+		n += m
+	}
+	r, err := newBackwardBits(b[n:])
+	if err != nil {
+		return err
+	}
+	ll, of, ml := newFSEState(d.ll, &r), newFSEState(d.of, &r), newFSEState(d.ml, &r)
+	start := len(d.out)
+	for i := range count {
+		ofCode, mlCode, llCode := of.symbol(), ml.symbol(), ll.symbol()
+		if ofCode > maxOFCode || mlCode > maxMLCode || llCode > maxLLCode {
+			return fmt.Errorf("sequence %d: invalid codes", i)
+		}
+		offset := 1<<ofCode + r.read(ofCode)
+		matchLen := mlBase[mlCode] + r.read(mlBits[mlCode])
+		litLen := llBase[llCode] + r.read(llBits[llCode])
+		if i < count-1 {
+			ll.update(&r)
+			ml.update(&r)
+			of.update(&r)
+		}
+		if r.pos < 0 {
+			return fmt.Errorf("sequence %d: %w", i, errTruncated)
+		}
+
+		offset, err = d.repeat(offset, litLen)
+		if err != nil {
+			return fmt.Errorf("sequence %d: %w", i, err)
+		}
+		if litLen > len(lits) {
+			return fmt.Errorf("sequence %d: %d literals of %d", i, litLen, len(lits))
+		}
+		d.out = append(d.out, lits[:litLen]...)
+		lits = lits[litLen:]
+		if offset > len(d.out)-d.start {
+			return fmt.Errorf("sequence %d: offset %d before the content", i, offset)
+		}
+		if len(d.out)-start+matchLen+len(lits) > maxBlock {
+			return fmt.Errorf("sequence %d: the block exceeds %d bytes", i, maxBlock)
+		}
+		// a match longer than its offset repeats the bytes it copies
+		for matchLen > 0 {
+			from := len(d.out) - offset
+			chunk := min(matchLen, offset)
+			d.out = append(d.out, d.out[from:from+chunk]...)
+			matchLen -= chunk
+		}
+	}
+	if r.pos != 0 {
+		return fmt.Errorf("%d bits left after the sequences", r.pos)
+	}
+	d.out = append(d.out, lits...)
+	return nil
+}
+
+// repeat returns the offset of an offset value and updates the repeated offsets,
+// values 1 to 3 select them, shifted by one without literals.
+func (d *decoder) repeat(value, litLen int) (int, error) {
+	if value > 3 {
+		d.rep = [3]int{value - 3, d.rep[0], d.rep[1]}
+		return d.rep[0], nil
+	}
+	idx := value - 1
+	if litLen == 0 {
+		idx++
+	}
+	switch idx {
+	case 0:
+	case 1:
+		d.rep[0], d.rep[1] = d.rep[1], d.rep[0]
+	case 2:
+		d.rep = [3]int{d.rep[2], d.rep[0], d.rep[1]}
+	default:
+		if d.rep[0] == 1 {
+			return 0, errors.New("repeated offset 0")
+		}
+		d.rep = [3]int{d.rep[0] - 1, d.rep[0], d.rep[1]}
+	}
+	return d.rep[0], nil
+}
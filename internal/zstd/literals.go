@@ -0,0 +1,226 @@
+package zstd
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// maxHuffBits is the maximum length of the Huffman codes of literals.
+const maxHuffBits = 11
+
+// A huffTable decodes Huffman coded literals, indexed by the next log bits of the stream.
+type huffTable struct {
+	log     int
+	entries []huffEntry
+}
+
+type huffEntry struct {
+	symbol uint8
+	bits   uint8 // the length of the code
+}
+
+// readHuffman reads a Huffman tree description and returns the table and its length.
+func readHuffman(b []byte) (*huffTable, int, error) {
+	if len(b) == 0 {
+		return nil, 0, errTruncated
+	}
+	var weights []int
+	n := 1 + int(b[0])
+	if b[0] < 128 {
+		// FSE coded weights, decoded by two interleaved states until the bits run out
+		if len(b) < n {
+			return nil, 0, errTruncated
+		}
+		t, m, err := readFSE(b[1:n], 6, 255)
+		if err != nil {
+			return nil, 0, fmt.Errorf("weights: %w", err)
+		}
+		r, err := newBackwardBits(b[1+m : n])
+		if err != nil {
+			return nil, 0, fmt.Errorf("weights: %w", err)
+		}
+		states := [2]fseState{newFSEState(t, &r), newFSEState(t, &r)}
+		for i := 0; ; i ^= 1 {
+			if len(weights) > 254 {
+				return nil, 0, errors.New("more than 255 weights")
+			}
+			weights = append(weights, states[i].symbol())
+			states[i].update(&r)
+			if r.pos < 0 {
+				weights = append(weights, states[i^1].symbol())
+				break
+			}
+		}
+	} else {
+		// 4 bits per weight, the high ones first
+		count := int(b[0]) - 127
+		n = 1 + (count+1)/2
+		if len(b) < n {
+			return nil, 0, errTruncated
+		}
+		for i := range count {
+			w := b[1+i/2]
+			if i%2 == 0 {
+				w >>= 4
+			}
+			weights = append(weights, int(w&0xf))
+		}
+	}
+	t, err := newHuffman(weights)
+	return t, n, err
+}
+
+// newHuffman returns the table of the weights of the symbols but the last,
+// whose weight fills the code space up to the next power of 2.
+func newHuffman(weights []int) (*huffTable, error) {
+	sum := 0
+	for _, w := range weights {
+		if w > maxHuffBits {
+			return nil, fmt.Errorf("weight %d exceeds %d", w, maxHuffBits)
+		}
+		if w > 0 {
+			sum += 1 << (w - 1)
+		}
+	}
+	if sum == 0 {
+		return nil, errors.New("all weights are zero")
+	}
+	log := bits.Len(uint(sum))
+	left := 1<<log - sum
+	if log > maxHuffBits || left&(left-1) != 0 {
+		return nil, errors.New("weights do not complete a prefix code")
+	}
+	weights = append(weights, bits.Len(uint(left)))
+
+	// codes of lower weights are longer and come first
+	var starts [maxHuffBits + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			starts[w+1] += 1 << (w - 1)
+		}
+	}
+	for w := 1; w < len(starts); w++ {
+		starts[w] += starts[w-1]
+	}
+	t := &huffTable{log: log, entries: make([]huffEntry, 1<<log)}
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		e := huffEntry{symbol: uint8(s), bits: uint8(log + 1 - w)}
+		for i := range 1 << (w - 1) {
+			t.entries[starts[w]+i] = e
+		}
+		starts[w] += 1 << (w - 1)
+	}
+	return t, nil
+}
+
+// decode fills out with the literals of the Huffman coded stream b.
+func (t *huffTable) decode(out, b []byte) error {
+	r, err := newBackwardBits(b)
+	if err != nil {
+		return err
+	}
+	for i := range out {
+		e := t.entries[r.peek(t.log)]
+		out[i] = e.symbol
+		r.pos -= int(e.bits)
+	}
+	if r.pos != 0 {
+		return fmt.Errorf("%d bits left after %d literals", r.pos, len(out))
+	}
+	return nil
+}
+
+// literals decodes the literals section at the start of b and returns the literals and the length of the section.
+func (d *decoder) literals(b []byte) ([]byte, int, error) {
+	if len(b) == 0 {
+		return nil, 0, errTruncated
+	}
+	typ, format := b[0]&3, b[0]>>2&3
+	if typ < 2 {
+		// raw or RLE, with a size of 5, 12 or 20 bits
+		size, n := int(b[0]>>3), 1
+		switch format {
+		case 1:
+			n = 2
+		case 3:
+			n = 3
+		}
+		if len(b) < n {
+			return nil, 0, errTruncated
+		}
+		if n > 1 {
+			size = int(le(b, n) >> 4)
+		}
+		if typ == 0 {
+			if len(b) < n+size {
+				return nil, 0, errTruncated
+			}
+			return b[n : n+size], n + size, nil
+		}
+		if len(b) < n+1 {
+			return nil, 0, errTruncated
+		}
+		lits := make([]byte, size)
+		for i := range lits {
+			lits[i] = b[n]
+		}
+		return lits, n + 1, nil
+	}
+
+	// Huffman coded, with a new table or the previous one, in 1 or 4 streams
+	n, sizeBits, streams := []int{3, 3, 4, 5}[format], []int{10, 10, 14, 18}[format], 4
+	if format == 0 {
+		streams = 1
+	}
+	if len(b) < n {
+		return nil, 0, errTruncated
+	}
+	v := le(b, n) >> 4
+	size, csize := int(v&(1<<sizeBits-1)), int(v>>sizeBits)
+	if size > maxBlock {
+		return nil, 0, fmt.Errorf("%d literals exceed the block", size)
+	}
+	if len(b) < n+csize {
+		return nil, 0, errTruncated
+	}
+	data := b[n : n+csize]
+	if typ == 2 {
+		t, m, err := readHuffman(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Huffman table: %w", err)
+		}
+		d.huff, data = t, data[m:]
+	} else if d.huff == nil {
+		return nil, 0, errors.New("repeated Huffman table without a previous one")
+	}
+	lits := make([]byte, size)
+	if streams == 1 {
+		return lits, n + csize, d.huff.decode(lits, data)
+	}
+	// a jump table of the sizes of the first three streams
+	if len(data) < 6 {
+		return nil, 0, errTruncated
+	}
+	sizes := [4]int{int(le(data, 2)), int(le(data[2:], 2)), int(le(data[4:], 2))}
+	sizes[3] = len(data) - 6 - sizes[0] - sizes[1] - sizes[2]
+	seg := (size + 3) / 4
+	if sizes[3] < 0 || 3*seg > size {
+		return nil, 0, errors.New("invalid jump table")
+	}
+	data = data[6:]
+	for i, s := range sizes {
+		out := lits[i*seg:]
+		if i < 3 {
+			out = out[:seg]
+		}
+		if err := d.huff.decode(out, data[:s]); err != nil {
+			return nil, 0, fmt.Errorf("stream %d: %w", i+1, err)
+		}
+		data = data[s:]
+	}
+	return lits, n + csize, nil
+}
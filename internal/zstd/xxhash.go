@@ -0,0 +1,58 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	prime1 uint64 = 11400714785074694791
+	prime2 uint64 = 14029467366897019727
+	prime3 uint64 = 1609587929392839161
+	prime4 uint64 = 9650029242287828579
+	prime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 returns the XXH64 hash of b with seed 0, the low 32 bits are the content checksum of a frame.
+func xxhash64(b []byte) uint64 {
+	n := uint64(len(b))
+	var h uint64
+	if len(b) >= 32 {
+		v := [4]uint64{6983438078262162902, prime2, 0, 7046029288634856825} // prime1+prime2 and -prime1, wrapped
+		for ; len(b) >= 32; b = b[32:] {
+			for i := range v {
+				v[i] = xxround(v[i], binary.LittleEndian.Uint64(b[8*i:]))
+			}
+		}
+		h = bits.RotateLeft64(v[0], 1) + bits.RotateLeft64(v[1], 7) + bits.RotateLeft64(v[2], 12) + bits.RotateLeft64(v[3], 18)
+		for _, x := range v {
+			h = (h^xxround(0, x))*prime1 + prime4
+		}
+	} else {
+		h = prime5
+	}
+	h += n
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxround(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*prime1 + prime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * prime1
+		h = bits.RotateLeft64(h, 23)*prime2 + prime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * prime5
+		h = bits.RotateLeft64(h, 11) * prime1
+	}
+	h ^= h >> 33
+	h *= prime2
+	h ^= h >> 29
+	h *= prime3
+	h ^= h >> 32
+	return h
+}
+
+func xxround(acc, lane uint64) uint64 {
+	return bits.RotateLeft64(acc+lane*prime2, 31) * prime1
+}
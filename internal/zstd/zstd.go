@@ -0,0 +1,172 @@
+// Package zstd decompresses Zstandard frames, see RFC 8878.
+//
+// It decodes the frames of the reference implementation at any level,
+// but not frames compressed with a dictionary.
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	frameMagic     = 0xfd2fb528
+	skippableMagic = 0x184d2a50 // the low 4 bits are free
+	maxBlock       = 128 << 10  // the largest block content
+)
+
+var errTruncated = errors.New("truncated")
+
+// Decompress returns the concatenated contents of the frames of data, skipping skippable frames.
+func Decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("no frame")
+	}
+	d := new(decoder)
+	for off := 0; off < len(data); {
+		if len(data)-off < 4 {
+			return nil, fmt.Errorf("frame at %d: %w", off, errTruncated)
+		}
+		magic := binary.LittleEndian.Uint32(data[off:])
+		if magic&^0xf == skippableMagic {
+			if len(data)-off < 8 {
+				return nil, fmt.Errorf("skippable frame at %d: %w", off, errTruncated)
+			}
+			size := uint64(binary.LittleEndian.Uint32(data[off+4:]))
+			if size > uint64(len(data)-off-8) {
+				return nil, fmt.Errorf("skippable frame at %d: %w", off, errTruncated)
+			}
+			off += 8 + int(size)
+			continue
+		}
+		if magic != frameMagic {
+			return nil, fmt.Errorf("frame at %d: magic number %#08x is not zstd", off, magic)
+		}
+		n, err := d.frame(data[off+4:])
+		if err != nil {
+			return nil, fmt.Errorf("frame at %d: %w", off, err)
+		}
+		off += 4 + n
+	}
+	return d.out, nil
+}
+
+// A decoder holds the state carried from block to block of a frame.
+type decoder struct {
+	out   []byte // the contents of all frames
+	start int    // of the current frame in out, matches must not reach before it
+
+	rep        [3]int // the repeated offsets
+	huff       *huffTable
+	ll, of, ml *fseTable // the tables of the previous block
+}
+
+// frame decodes the frame in b after the magic number and returns its length.
+func (d *decoder) frame(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, errTruncated
+	}
+	desc := b[0]
+	if desc&0x08 != 0 {
+		return 0, errors.New("reserved bit of the frame header descriptor set")
+	}
+	single, checksum := desc&0x20 != 0, desc&0x04 != 0
+	n := 1
+	if !single {
+		n++ // the window descriptor, the whole content is kept anyway
+	}
+	dictSize := [4]int{0, 1, 2, 4}[desc&3]
+	sizeSize := [4]int{0, 2, 4, 8}[desc>>6]
+	if sizeSize == 0 && single {
+		sizeSize = 1
+	}
+	if len(b) < n+dictSize+sizeSize {
+		return 0, errTruncated
+	}
+	if dictID := le(b[n:], dictSize); dictID != 0 {
+		return 0, fmt.Errorf("dictionary %d: dictionaries are not supported", dictID)
+	}
+	n += dictSize
+	size := le(b[n:], sizeSize)
+	if sizeSize == 2 {
+		size += 256
+	}
+	n += sizeSize
+
+	d.start, d.rep = len(d.out), [3]int{1, 4, 8}
+	d.huff, d.ll, d.of, d.ml = nil, nil, nil, nil
+	for last := false; !last; {
+		if len(b)-n < 3 {
+			return 0, errTruncated
+		}
+		header := int(le(b[n:], 3))
+		n += 3
+		last = header&1 != 0
+		typ, bsize := header>>1&3, header>>3
+		if bsize > maxBlock {
+			return 0, fmt.Errorf("block at %d: %d bytes exceed the maximum of %d", n-3, bsize, maxBlock)
+		}
+		switch typ {
+		case 0: // raw
+			if len(b)-n < bsize {
+				return 0, errTruncated
+			}
+			d.out = append(d.out, b[n:n+bsize]...)
+			n += bsize
+		case 1: // RLE, bsize is the number of repetitions
+			if len(b)-n < 1 {
+				return 0, errTruncated
+			}
+			for range bsize {
+				d.out = append(d.out, b[n])
+			}
+			n++
+		case 2:
+			if len(b)-n < bsize {
+				return 0, errTruncated
+			}
+			if err := d.block(b[n : n+bsize]); err != nil {
+				return 0, fmt.Errorf("block at %d: %w", n-3, err)
+			}
+			n += bsize
+		default:
+			return 0, fmt.Errorf("block at %d: reserved block type", n-3)
+		}
+	}
+	content := d.out[d.start:]
+	if sizeSize > 0 && uint64(len(content)) != size {
+		return 0, fmt.Errorf("%d bytes of content, the header declares %d", len(content), size)
+	}
+	if checksum {
+		if len(b)-n < 4 {
+			return 0, errTruncated
+		}
+		if sum := binary.LittleEndian.Uint32(b[n:]); sum != uint32(xxhash64(content)) {
+			return 0, fmt.Errorf("checksum %#08x, the content has %#08x", sum, uint32(xxhash64(content)))
+		}
+		n += 4
+	}
+	return n, nil
+}
+
+// block decodes a compressed block: literals and the sequences copying them and earlier content.
+func (d *decoder) block(b []byte) error {
+	lits, n, err := d.literals(b)
+	if err != nil {
+		return fmt.Errorf("literals: %w", err)
+	}
+	if err := d.sequences(b[n:], lits); err != nil {
+		return fmt.Errorf("sequences: %w", err)
+	}
+	return nil
+}
+
+// le returns the little endian number in the first n bytes of b.
+func le(b []byte, n int) uint64 {
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+package zstd
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inputs returns the contents compressed in testdata by the zstd command, as NAME.LEVEL.zst,
+// some with --no-check:
+//
+//	text:   repetitive lines in more than one block, with Huffman coded literals and FSE tables
+//	random: incompressible bytes, a raw block
+//	zeros:  RLE blocks
+//	empty:  a frame without content
+func inputs() map[string][]byte {
+	var text bytes.Buffer
+	for i := range 3000 {
+		fmt.Fprintf(&text, "message M%d { int32 f%d = %d; repeated string s = %d; }\n", i%97, i, i%500+1, i%7+2)
+	}
+	random := make([]byte, 2000)
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range random {
+		random[i] = byte(r.Uint32())
+	}
+	return map[string][]byte{"text": text.Bytes(), "random": random, "zeros": make([]byte, 300000), "empty": {}}
+}
+
+func TestDecompress(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.zst")
+	if err != nil || len(names) == 0 {
+		t.Fatal("no testdata", err)
+	}
+	in := inputs()
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := in[strings.Split(filepath.Base(name), ".")[0]]
+		got, err := Decompress(data)
+		if err != nil {
+			t.Errorf("Decompress(%s): %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Decompress(%s) = %d bytes, want %d", name, len(got), len(want))
+		}
+	}
+}
+
+func TestDecompressFrames(t *testing.T) {
+	text, err := os.ReadFile("testdata/text.19.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zeros, err := os.ReadFile("testdata/zeros.3.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	skippable := []byte{0x5e, 0x2a, 0x4d, 0x18, 3, 0, 0, 0, 'a', 'b', 'c'}
+	data := append(append(append([]byte{}, text...), skippable...), zeros...)
+	got, err := Decompress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := inputs()
+	if want := append(in["text"], in["zeros"]...); !bytes.Equal(got, want) {
+		t.Errorf("Decompress of two frames and a skippable one = %d bytes, want %d", len(got), len(want))
+	}
+
+	corrupt := func(i int) []byte {
+		b := append([]byte{}, text...)
+		b[i] ^= 0x10
+		return b
+	}
+	dict := append([]byte{}, text...)
+	dict[4] |= 1 // a dictionary ID follows
+	for name, data := range map[string][]byte{
+		"empty":      nil,
+		"magic":      corrupt(0),
+		"checksum":   corrupt(len(text) - 1),
+		"content":    corrupt(len(text) / 2),
+		"truncated":  text[:len(text)-5],
+		"dictionary": dict,
+		"skippable":  skippable[:10],
+	} {
+		if _, err := Decompress(data); err == nil {
+			t.Errorf("Decompress of %s data succeeds", name)
+		}
+	}
+}
+
+func TestXXHash64(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+	for _, tt := range tests {
+		if got := xxhash64([]byte(tt.in)); got != tt.want {
+			t.Errorf("xxhash64(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func FuzzDecompress(f *testing.F) {
+	names, _ := filepath.Glob("testdata/*.zst")
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Decompress(data)
+	})
+}
@@ -0,0 +1,190 @@
+// Package plugin implements the protoc plugin protocol:
+// protoc writes a CodeGeneratorRequest to the stdin of a protoc-gen-NAME program,
+// which writes a CodeGeneratorResponse with the generated files to stdout.
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// A Request is a google.protobuf.compiler.CodeGeneratorRequest.
+type Request struct {
+	FileToGenerate  []string           // 1, the files named on the command line
+	Parameter       string             // 2, of --NAME_out=PARAMETER:DIR or --NAME_opt
+	CompilerVersion *Version           // 3
+	ProtoFile       []*descriptor.File // 15, the files to generate and their imports, imports first
+
+	// 17, the files to generate with the options of source retention, empty for old compilers
+	SourceFileDescriptors []*descriptor.File
+
+	UnknownFields wire.UnknownFieldSet
+}
+
+// A Version is the version of protoc.
+type Version struct {
+	Major, Minor, Patch int32  // 1, 2, 3
+	Suffix              string // 4, like "rc2"
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Suffix != "" {
+		s += "-" + v.Suffix
+	}
+	return s
+}
+
+// ParseRequest parses a CodeGeneratorRequest, the proto files are linked.
+func ParseRequest(data []byte) (*Request, error) {
+	req := &Request{}
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			return nil, err
+		}
+		switch r.Tag {
+		case 1:
+			req.FileToGenerate = append(req.FileToGenerate, string(r.Bytes))
+		case 2:
+			req.Parameter = string(r.Bytes)
+		case 3:
+			v, err := parseVersion(r.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			req.CompilerVersion = v
+		case 15, 17:
+			f := &descriptor.File{}
+			if err := f.UnmarshalBinary(r.Bytes); err != nil {
+				return nil, fmt.Errorf("plugin: %w", err)
+			}
+			if r.Tag == 15 {
+				req.ProtoFile = append(req.ProtoFile, f)
+			} else {
+				req.SourceFileDescriptors = append(req.SourceFileDescriptors, f)
+			}
+		default:
+			req.UnknownFields.Add(r)
+		}
+	}
+	if _, err := descriptor.Link(req.ProtoFile); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func parseVersion(msg []byte) (*Version, error) {
+	v := &Version{}
+	for r, err := range wire.Fields(msg) {
+		if err != nil {
+			return nil, err
+		}
+		switch r.Tag {
+		case 1:
+			v.Major = wire.DecodeInt32(r.Value)
+		case 2:
+			v.Minor = wire.DecodeInt32(r.Value)
+		case 3:
+			v.Patch = wire.DecodeInt32(r.Value)
+		case 4:
+			v.Suffix = string(r.Bytes)
+		default:
+		}
+	}
+	return v, nil
+}
+
+// ReadRequest reads and parses a CodeGeneratorRequest from r, usually stdin.
+func ReadRequest(r io.Reader) (*Request, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRequest(b)
+}
+
+// Generate returns the files of ProtoFile named by FileToGenerate, in that order.
+func (req *Request) Generate() []*descriptor.File {
+	var files []*descriptor.File
+	for _, name := range req.FileToGenerate {
+		if i := slices.IndexFunc(req.ProtoFile, func(f *descriptor.File) bool { return f.Name == name }); i >= 0 {
+			files = append(files, req.ProtoFile[i])
+		}
+	}
+	return files
+}
+
+// Bits of Response.SupportedFeatures.
+const (
+	FeatureProto3Optional   = 1
+	FeatureSupportsEditions = 2
+)
+
+// A Response is a google.protobuf.compiler.CodeGeneratorResponse.
+type Response struct {
+	Error             string           // 1, reports problems of the input, protoc prints it and fails
+	SupportedFeatures uint64           // 2, of the Feature bits
+	MinimumEdition    int32            // 3, with FeatureSupportsEditions
+	MaximumEdition    int32            // 4
+	File              []*GeneratedFile // 15
+}
+
+// A GeneratedFile is an output file of a plugin.
+type GeneratedFile struct {
+	Name           string // 1, relative to the output directory, with / separators
+	InsertionPoint string // 2, inserts the content into the file Name generated before at @@protoc_insertion_point(NAME)
+	Content        string // 15
+}
+
+// MarshalBinary encodes r as a CodeGeneratorResponse.
+func (r *Response) MarshalBinary() ([]byte, error) {
+	var e wire.Encoder
+	if r.Error != "" {
+		e.EncodeString(1, r.Error)
+	}
+	if r.SupportedFeatures != 0 {
+		e.EncodeVarint(2, r.SupportedFeatures)
+	}
+	if r.MinimumEdition != 0 {
+		e.EncodeVarint(3, wire.EncodeInt32(r.MinimumEdition))
+	}
+	if r.MaximumEdition != 0 {
+		e.EncodeVarint(4, wire.EncodeInt32(r.MaximumEdition))
+	}
+	for _, f := range r.File {
+		e.EncodeMessage(15, func(e *wire.Encoder) {
+			e.EncodeString(1, f.Name)
+			if f.InsertionPoint != "" {
+				e.EncodeString(2, f.InsertionPoint)
+			}
+			e.EncodeString(15, f.Content)
+		})
+	}
+	return e.Bytes(), nil
+}
+
+// Run reads a request from r, usually stdin, calls gen and writes the response to w, usually stdout.
+// Errors of gen are reported in Response.Error, errors reading or writing are returned.
+func Run(r io.Reader, w io.Writer, gen func(*Request, *Response) error) error {
+	req, err := ReadRequest(r)
+	if err != nil {
+		return err
+	}
+	resp := &Response{}
+	if err := gen(req, resp); err != nil {
+		resp.Error = err.Error()
+		resp.File = nil
+	}
+	out, err := resp.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("plugin: writing the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package protojson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+)
+
+// UnmarshalOptions configure the JSON input, the zero value rejects unknown fields.
+type UnmarshalOptions struct {
+	DiscardUnknown bool                       // ignore unknown fields and enum value names
+	Resolver       *descriptor.Symbols        // resolves the type URLs of Any, the well-known types are always known
+	Extensions     *dynamic.ExtensionRegistry // resolves "[ext.name]" keys
+}
+
+// Unmarshal decodes JSON as a message of type desc.
+func Unmarshal(desc *descriptor.Message, data []byte) (*dynamic.Message, error) {
+	return UnmarshalOptions{}.Unmarshal(desc, data)
+}
+
+// Unmarshal decodes JSON as a message of type desc.
+func (o UnmarshalOptions) Unmarshal(desc *descriptor.Message, data []byte) (*dynamic.Message, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	v, err := decodeValue(d)
+	if err != nil {
+		return nil, fmt.Errorf("protojson: %w", err)
+	}
+	if d.More() {
+		return nil, fmt.Errorf("protojson: data after the top level value")
+	}
+	m := dynamic.New(desc)
+	if err := o.message(m, v); err != nil {
+		return nil, fmt.Errorf("protojson: %w", err)
+	}
+	return m, nil
+}
+
+// Encode decodes JSON as a message of type desc and returns its binary encoding.
+func (o UnmarshalOptions) Encode(desc *descriptor.Message, data []byte) ([]byte, error) {
+	m, err := o.Unmarshal(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	return m.MarshalBinary()
+}
+
+// decodeValue decodes the next JSON value like Decode into an any with json.Number numbers,
+// objects with duplicate keys are rejected like the JSON mapping requires.
+func decodeValue(d *json.Decoder) (any, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		obj := map[string]any{}
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := t.(string)
+			if _, dup := obj[key]; dup {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			if obj[key], err = decodeValue(d); err != nil {
+				return nil, err
+			}
+		}
+		_, err := d.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for d.More() {
+			v, err := decodeValue(d)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := d.Token()
+		return list, err
+	default:
+	}
+	return t, nil
+}
+
+// message sets the fields of m from a decoded JSON value.
+func (o UnmarshalOptions) message(m *dynamic.Message, v any) error {
+	if fn := parsers[m.Descriptor().FullName()]; fn != nil {
+		return fn(o, m, v)
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: want an object, not %s", m.Descriptor().FullName(), kind(v))
+	}
+	return o.fields(m, obj)
+}
+
+func (o UnmarshalOptions) fields(m *dynamic.Message, obj map[string]any) error {
+	oneofs := map[*descriptor.OneOf]string{}
+	seen := map[*descriptor.Field]string{}
+	for key, v := range obj {
+		f := o.lookup(m, key)
+		if f == nil {
+			if o.DiscardUnknown {
+				continue
+			}
+			return fmt.Errorf("%s: unknown field %q", m.Descriptor().FullName(), key)
+		}
+		if other, dup := seen[f]; dup {
+			return fmt.Errorf("%s: %q and %q are the same field", m.Descriptor().FullName(), other, key)
+		}
+		seen[f] = key
+		if of := f.RealOneOf(m.Descriptor()); of != nil && v != nil {
+			if other, dup := oneofs[of]; dup {
+				return fmt.Errorf("%s: %q and %q are members of oneof %s", m.Descriptor().FullName(), other, key, of.Name)
+			}
+			oneofs[of] = key
+		}
+		if err := o.field(m, f, v); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// lookup finds the field of a JSON key, by json_name, proto name or extension name.
+func (o UnmarshalOptions) lookup(m *dynamic.Message, key string) *descriptor.Field {
+	if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+		if o.Extensions == nil {
+			return nil
+		}
+		x := o.Extensions.FindByName(key[1 : len(key)-1])
+		if x == nil || strings.TrimPrefix(x.Extendee, ".") != m.Descriptor().FullName() {
+			return nil
+		}
+		return x
+	}
+	for _, f := range m.Descriptor().Field {
+		if f.JsonName == key || f.JsonName == "" && descriptor.JSONName(f.Name) == key {
+			return f
+		}
+	}
+	return m.FieldByName(key)
+}
+
+// field sets f from a JSON value, null leaves the field unset.
+func (o UnmarshalOptions) field(m *dynamic.Message, f *descriptor.Field, v any) error {
+	if v == nil && !acceptsNull(f) {
+		return nil
+	}
+	switch {
+	case f.Map != nil:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("want an object, not %s", kind(v))
+		}
+		for k, x := range obj {
+			key, err := o.mapKey(f.Map.Key, k)
+			if err != nil {
+				return err
+			}
+			val, err := o.single(f.Map.Value, x)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			if val == nil {
+				continue // unknown enum name
+			}
+			if err := m.PutField(f, key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case f.Label == descriptor.LabelRepeated:
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("want an array, not %s", kind(v))
+		}
+		for i, x := range arr {
+			val, err := o.single(f, x)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			if val == nil {
+				continue
+			}
+			if err := m.AddField(f, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+	}
+	val, err := o.single(f, v)
+	if err != nil || val == nil {
+		return err
+	}
+	return m.SetField(f, val)
+}
+
+// acceptsNull reports whether null is a value of f rather than unset.
+func acceptsNull(f *descriptor.Field) bool {
+	switch {
+	case f.Label == descriptor.LabelRepeated:
+		return false
+	case f.Type == descriptor.TypeEnum:
+		return f.EnumType != nil && f.EnumType.FullName() == "google.protobuf.NullValue"
+	case f.Type == descriptor.TypeMessage:
+		return f.MessageType != nil && f.MessageType.FullName() == "google.protobuf.Value"
+	default:
+	}
+	return false
+}
+
+// single converts a JSON value to a value of the type of f,
+// nil without error for unknown enum names that are discarded.
+func (o UnmarshalOptions) single(f *descriptor.Field, v any) (any, error) {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeGroup:
+		if f.MessageType == nil {
+			return nil, &dynamic.UnresolvedError{Field: f}
+		}
+		sub := dynamic.New(f.MessageType)
+		if err := o.message(sub, v); err != nil {
+			return nil, err
+		}
+		return sub, nil
+	case descriptor.TypeEnum:
+		return o.enum(f, v)
+	case descriptor.TypeString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("want a string, not %s", kind(v))
+		}
+		return s, nil
+	case descriptor.TypeBytes:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("want a base64 string, not %s", kind(v))
+		}
+		return decodeBase64(s)
+	case descriptor.TypeBool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("want a bool, not %s", kind(v))
+		}
+		return b, nil
+	case descriptor.TypeFloat, descriptor.TypeDouble:
+		return parseFloat(f.Type, v)
+	default:
+	}
+	return parseInt(f.Type, v)
+}
+
+func (o UnmarshalOptions) enum(f *descriptor.Field, v any) (any, error) {
+	if f.EnumType == nil {
+		return nil, &dynamic.UnresolvedError{Field: f}
+	}
+	switch v := v.(type) {
+	case nil:
+		return int32(0), nil // NullValue
+	case string:
+		for _, ev := range f.EnumType.Value {
+			if ev.Name == v {
+				return ev.Number, nil
+			}
+		}
+		if o.DiscardUnknown {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown %s value %q", f.EnumType.FullName(), v)
+	case json.Number:
+		n, err := strconv.ParseInt(string(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum number %s", v)
+		}
+		return int32(n), nil
+	default:
+	}
+	return nil, fmt.Errorf("want an enum name or number, not %s", kind(v))
+}
+
+// mapKey parses a JSON object key as a value of the map key type.
+func (o UnmarshalOptions) mapKey(f *descriptor.Field, k string) (any, error) {
+	switch f.Type {
+	case descriptor.TypeString:
+		return k, nil
+	case descriptor.TypeBool:
+		switch k {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		default:
+		}
+		return nil, fmt.Errorf("invalid bool map key %q", k)
+	default:
+	}
+	return parseInt(f.Type, k)
+}
+
+// parseInt parses an integer given as a JSON number or string,
+// numbers with a fraction or exponent are accepted if they are integral.
+func parseInt(typ uint8, v any) (any, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return nil, fmt.Errorf("want an integer, not %s", kind(v))
+	}
+	signed, bits := true, 64
+	switch typ {
+	case descriptor.TypeInt32, descriptor.TypeSint32, descriptor.TypeSfixed32:
+		bits = 32
+	case descriptor.TypeUint32, descriptor.TypeFixed32:
+		signed, bits = false, 32
+	case descriptor.TypeUint64, descriptor.TypeFixed64:
+		signed = false
+	default:
+	}
+	var i int64
+	var u uint64
+	var err error
+	if signed {
+		i, err = strconv.ParseInt(s, 10, bits)
+	} else {
+		u, err = strconv.ParseUint(s, 10, bits)
+	}
+	if err != nil {
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil || f != math.Trunc(f) || math.Abs(f) >= 1<<53 {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		i, u = int64(f), uint64(f)
+		minimum, maximum := float64(math.MinInt64), float64(math.MaxInt64)
+		switch {
+		case signed && bits == 32:
+			minimum, maximum = math.MinInt32, math.MaxInt32
+		case !signed && bits == 32:
+			minimum, maximum = 0, math.MaxUint32
+		case !signed:
+			minimum = 0
+		default:
+		}
+		if f < minimum || f > maximum {
+			return nil, fmt.Errorf("integer %q out of range", s)
+		}
+	}
+	switch {
+	case signed && bits == 32:
+		return int32(i), nil
+	case signed:
+		return i, nil
+	case bits == 32:
+		return uint32(u), nil
+	default:
+	}
+	return u, nil
+}
+
+// parseFloat parses a JSON number or a string with a number or one of NaN, Infinity and -Infinity.
+func parseFloat(typ uint8, v any) (any, error) {
+	bits := 64
+	if typ != descriptor.TypeDouble {
+		bits = 32
+	}
+	var f float64
+	switch v := v.(type) {
+	case json.Number:
+		var err error
+		if f, err = strconv.ParseFloat(string(v), bits); err != nil {
+			return nil, floatError(bits, string(v), err)
+		}
+	case string:
+		switch v {
+		case "NaN":
+			f = math.NaN()
+		case "Infinity":
+			f = math.Inf(1)
+		case "-Infinity":
+			f = math.Inf(-1)
+		default:
+			var err error
+			if strings.TrimSpace(v) != v {
+				return nil, fmt.Errorf("invalid number %q", v)
+			}
+			if f, err = strconv.ParseFloat(v, bits); err != nil {
+				return nil, floatError(bits, strconv.Quote(v), err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("want a number, not %s", kind(v))
+	}
+	if bits == 64 {
+		return f, nil
+	}
+	return float32(f), nil
+}
+
+// floatError reports a number s that does not parse, or overflows a float.
+func floatError(bits int, s string, err error) error {
+	if bits == 32 && errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("float %s out of range", s)
+	}
+	return fmt.Errorf("invalid number %s", s)
+}
+
+// decodeBase64 accepts the standard and URL alphabets, padded or not.
+func decodeBase64(s string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if len(s)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	return b, nil
+}
+
+// kind names the JSON type of v for error messages.
+func kind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a bool"
+	case json.Number:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// resolveMessage finds the message type of an Any type URL.
+func (o UnmarshalOptions) resolveMessage(url string) (*descriptor.Message, error) {
+	return MarshalOptions{Resolver: o.Resolver}.resolve(url)
+}
@@ -0,0 +1,82 @@
+package protojson
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wellknown"
+)
+
+func TestUnmarshalRejects(t *testing.T) {
+	tests := []struct {
+		typ, json, err string
+	}{
+		{"google.protobuf.Timestamp", `"2024-01-02T03:04:05.1234567891Z"`, "more than 9 fractional digits"},
+		{"google.protobuf.Duration", `"1.1234567891s"`, "invalid value"},
+		{"google.protobuf.Struct", `{"a": 1, "a": 2}`, `duplicate key "a"`},
+		{"google.protobuf.Struct", `{"a": {"b": 1, "b": 1}}`, `duplicate key "b"`},
+		{"google.protobuf.FieldDescriptorProto", `{"name": "x", "name": "y"}`, `duplicate key "name"`},
+		{"google.protobuf.FieldDescriptorProto", `{"jsonName": "a", "json_name": "b"}`, "are the same field"},
+	}
+	for _, tt := range tests {
+		_, err := Unmarshal(wellknown.Message(tt.typ), []byte(tt.json))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Unmarshal(%s, %s): got error %v, want %q", tt.typ, tt.json, err, tt.err)
+		}
+	}
+}
+
+func TestUnmarshalWellKnown(t *testing.T) {
+	tests := []struct {
+		typ, json string
+		seconds   int64
+		nanos     int32
+	}{
+		{"google.protobuf.Timestamp", `"1970-01-01T00:00:01.123456789Z"`, 1, 123456789},
+		{"google.protobuf.Timestamp", `"1970-01-01T00:00:01.5+00:00"`, 1, 500000000},
+		{"google.protobuf.Duration", `"-1.000000001s"`, -1, -1},
+		{"google.protobuf.Duration", `"3s"`, 3, 0},
+	}
+	for _, tt := range tests {
+		m, err := Unmarshal(wellknown.Message(tt.typ), []byte(tt.json))
+		if err != nil {
+			t.Errorf("Unmarshal(%s, %s): %v", tt.typ, tt.json, err)
+			continue
+		}
+		if s, n := m.Get("seconds"), m.Get("nanos"); s != tt.seconds || n != tt.nanos {
+			t.Errorf("Unmarshal(%s, %s) = %v.%v, want %v.%v", tt.typ, tt.json, s, n, tt.seconds, tt.nanos)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		typ  uint8
+		v    any
+		want any
+	}{
+		{descriptor.TypeFloat, json.Number("3.4028235e+38"), float32(math.MaxFloat32)},
+		{descriptor.TypeFloat, "-3.4028235e+38", float32(-math.MaxFloat32)},
+		{descriptor.TypeFloat, json.Number("1e-50"), float32(0)},
+		{descriptor.TypeFloat, "Infinity", float32(math.Inf(1))},
+		{descriptor.TypeFloat, json.Number("3.5e+38"), nil},
+		{descriptor.TypeFloat, "-1e39", nil},
+		{descriptor.TypeDouble, json.Number("3.5e+38"), 3.5e+38},
+		{descriptor.TypeDouble, json.Number("1e309"), nil},
+	}
+	for _, tt := range tests {
+		got, err := parseFloat(tt.typ, tt.v)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("parseFloat(%d, %v) = %v, want an error", tt.typ, tt.v, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseFloat(%d, %v) = %v, %v, want %v", tt.typ, tt.v, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package protojson converts dynamic messages to and from the canonical proto3 JSON mapping,
+// see https://protobuf.dev/programming-guides/json/.
+package protojson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+)
+
+// MarshalOptions configure the JSON output, the zero value produces compact canonical JSON.
+type MarshalOptions struct {
+	Indent          string                     // per nesting level, empty for a single line
+	UseProtoNames   bool                       // field names from the .proto file instead of their json_name
+	UseEnumNumbers  bool                       // enum values as numbers instead of names
+	EmitUnpopulated bool                       // unset fields with their defaults, except oneof members and extensions
+	Resolver        *descriptor.Symbols        // resolves the type URLs of Any, the well-known types are always known
+	Extensions      *dynamic.ExtensionRegistry // decodes extensions in Format, the descriptor marshalers and Any messages
+}
+
+// Marshal encodes m as compact canonical JSON.
+func Marshal(m *dynamic.Message) ([]byte, error) {
+	return MarshalOptions{}.Marshal(m)
+}
+
+// Marshal encodes m as JSON.
+func (o MarshalOptions) Marshal(m *dynamic.Message) ([]byte, error) {
+	v, err := o.message(m)
+	if err != nil {
+		return nil, err
+	}
+	return layout(nil, v, o.Indent, 0), nil
+}
+
+// Format decodes data as a message of type desc and encodes it as JSON.
+func (o MarshalOptions) Format(desc *descriptor.Message, data []byte) ([]byte, error) {
+	m, err := dynamic.UnmarshalOptions{Extensions: o.Extensions}.Unmarshal(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	return o.Marshal(m)
+}
+
+// MarshalFile encodes a file as the JSON of a google.protobuf.FileDescriptorProto,
+// with the field and enum value names of descriptor.proto instead of the Go names of descriptor.File.
+func (o MarshalOptions) MarshalFile(f *descriptor.File) ([]byte, error) {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return o.Format(wellknown.Message("google.protobuf.FileDescriptorProto"), data)
+}
+
+// MarshalFiles encodes files as the JSON of a google.protobuf.FileDescriptorSet.
+func (o MarshalOptions) MarshalFiles(files []*descriptor.File) ([]byte, error) {
+	data, err := descriptor.Marshal(files)
+	if err != nil {
+		return nil, err
+	}
+	return o.Format(wellknown.Message("google.protobuf.FileDescriptorSet"), data)
+}
+
+// message returns the JSON value of m, the special form of well-known types or an object.
+func (o MarshalOptions) message(m *dynamic.Message) (value, error) {
+	if fn := special[m.Descriptor().FullName()]; fn != nil {
+		return fn(o, m)
+	}
+	return o.fields(m)
+}
+
+func (o MarshalOptions) fields(m *dynamic.Message) (object, error) {
+	obj := object{}
+	for _, f := range m.Fields() {
+		if !m.HasField(f) && (!o.EmitUnpopulated || f.OneOfIndex != nil || f.Extendee != "") {
+			continue
+		}
+		v, err := o.field(f, m.GetField(f))
+		if err != nil {
+			return nil, fmt.Errorf("protojson: field %s: %w", f.FullName(), err)
+		}
+		obj = append(obj, member{o.name(f), v})
+	}
+	return obj, nil
+}
+
+// name returns the JSON key of f.
+func (o MarshalOptions) name(f *descriptor.Field) string {
+	switch {
+	case f.Extendee != "":
+		return "[" + f.FullName() + "]"
+	case o.UseProtoNames:
+		return f.Name
+	case f.JsonName != "":
+		return f.JsonName
+	default:
+	}
+	return descriptor.JSONName(f.Name)
+}
+
+// field returns the JSON value of a field value, nil for unset composite fields.
+func (o MarshalOptions) field(f *descriptor.Field, v any) (value, error) {
+	switch {
+	case f.Map != nil:
+		entries, _ := v.(map[any]any)
+		obj := object{}
+		for _, k := range dynamic.SortedKeys(entries) {
+			val, err := o.single(f.Map.Value, entries[k])
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{fmt.Sprint(k), val})
+		}
+		return obj, nil
+	case f.Label == descriptor.LabelRepeated:
+		list, _ := v.([]any)
+		arr := array{}
+		for _, x := range list {
+			val, err := o.single(f, x)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		return arr, nil
+	default:
+	}
+	return o.single(f, v)
+}
+
+// single returns the JSON value of a single value of the type of f.
+func (o MarshalOptions) single(f *descriptor.Field, v any) (value, error) {
+	switch v := v.(type) {
+	case nil:
+		return literal("null"), nil
+	case *dynamic.Message:
+		return o.message(v)
+	case bool:
+		return literal(strconv.FormatBool(v)), nil
+	case int32:
+		if f.Type == descriptor.TypeEnum {
+			return o.enum(f, v), nil
+		}
+		return literal(strconv.FormatInt(int64(v), 10)), nil
+	case uint32:
+		return literal(strconv.FormatUint(uint64(v), 10)), nil
+	case int64:
+		return literal(`"` + strconv.FormatInt(v, 10) + `"`), nil
+	case uint64:
+		return literal(`"` + strconv.FormatUint(v, 10) + `"`), nil
+	case float32:
+		return formatFloat(float64(v), 32), nil
+	case float64:
+		return formatFloat(v, 64), nil
+	case string:
+		return quote(v)
+	case []byte:
+		return literal(`"` + base64.StdEncoding.EncodeToString(v) + `"`), nil
+	default:
+	}
+	return nil, fmt.Errorf("unexpected value %T", v)
+}
+
+func (o MarshalOptions) enum(f *descriptor.Field, n int32) value {
+	en := f.EnumType
+	if en != nil && en.FullName() == "google.protobuf.NullValue" {
+		return literal("null")
+	}
+	if en != nil && !o.UseEnumNumbers {
+		for _, ev := range en.Value {
+			if ev.Number == n {
+				return literal(appendString(nil, ev.Name))
+			}
+		}
+	}
+	return literal(strconv.FormatInt(int64(n), 10))
+}
+
+// resolve finds the message type of an Any type URL, the part after the last slash.
+func (o MarshalOptions) resolve(url string) (*descriptor.Message, error) {
+	name := url
+	for i := len(url) - 1; i >= 0; i-- {
+		if url[i] == '/' {
+			name = url[i+1:]
+			break
+		}
+	}
+	if o.Resolver != nil {
+		if m := o.Resolver.Message(name); m != nil {
+			return m, nil
+		}
+	}
+	if m := wellknown.Message(name); m != nil {
+		return m, nil
+	}
+	return nil, fmt.Errorf("unresolved Any type %q", url)
+}
@@ -0,0 +1,137 @@
+package protojson
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The marshaler builds a tree of JSON values first and prints it in a second pass,
+// keeping the mapping rules apart from the layout.
+
+type value any // object, array or literal
+
+type object []member // in output order
+
+type member struct {
+	key string
+	val value
+}
+
+type array []value
+
+type literal string // encoded JSON: a number, string, true, false or null
+
+var errUTF8 = errors.New("invalid UTF-8 in string")
+
+func quote(s string) (literal, error) {
+	if !utf8.ValidString(s) {
+		return "", errUTF8
+	}
+	return literal(appendString(nil, s)), nil
+}
+
+// appendString appends s as a JSON string, unlike encoding/json without HTML escaping.
+func appendString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\r':
+			b = append(b, '\\', 'r')
+		case c == '\t':
+			b = append(b, '\\', 't')
+		case c == '\b':
+			b = append(b, '\\', 'b')
+		case c == '\f':
+			b = append(b, '\\', 'f')
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
+
+// formatFloat formats like encoding/json, with the special values as strings.
+func formatFloat(f float64, bits int) literal {
+	switch {
+	case math.IsNaN(f):
+		return `"NaN"`
+	case math.IsInf(f, 1):
+		return `"Infinity"`
+	case math.IsInf(f, -1):
+		return `"-Infinity"`
+	default:
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return literal(s)
+}
+
+// layout lays out v, indenting nested values by indent unless it is empty.
+func layout(b []byte, v value, indent string, depth int) []byte {
+	newline := func(b []byte, depth int) []byte {
+		if indent == "" {
+			return b
+		}
+		b = append(b, '\n')
+		return append(b, strings.Repeat(indent, depth)...)
+	}
+	switch v := v.(type) {
+	case object:
+		if len(v) == 0 {
+			return append(b, "{}"...)
+		}
+		b = append(b, '{')
+		for i, m := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = newline(b, depth+1)
+			b = appendString(b, m.key)
+			b = append(b, ':')
+			if indent != "" {
+				b = append(b, ' ')
+			}
+			b = layout(b, m.val, indent, depth+1)
+		}
+		b = newline(b, depth)
+		return append(b, '}')
+	case array:
+		if len(v) == 0 {
+			return append(b, "[]"...)
+		}
+		b = append(b, '[')
+		for i, x := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = newline(b, depth+1)
+			b = layout(b, x, indent, depth+1)
+		}
+		b = newline(b, depth)
+		return append(b, ']')
+	case literal:
+		return append(b, v...)
+	default:
+	}
+	return append(b, "null"...)
+}
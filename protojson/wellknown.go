@@ -0,0 +1,326 @@
+package protojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/internal/timefmt"
+)
+
+// special holds the marshalers of the well-known types with their own JSON form.
+var special map[string]func(o MarshalOptions, m *dynamic.Message) (value, error)
+
+func init() {
+	special = map[string]func(o MarshalOptions, m *dynamic.Message) (value, error){
+		"google.protobuf.Any":         marshalAny,
+		"google.protobuf.Duration":    marshalDuration,
+		"google.protobuf.FieldMask":   marshalFieldMask,
+		"google.protobuf.ListValue":   marshalListValue,
+		"google.protobuf.Struct":      marshalStruct,
+		"google.protobuf.Timestamp":   marshalTimestamp,
+		"google.protobuf.Value":       marshalValue,
+		"google.protobuf.BoolValue":   marshalWrapper,
+		"google.protobuf.BytesValue":  marshalWrapper,
+		"google.protobuf.DoubleValue": marshalWrapper,
+		"google.protobuf.FloatValue":  marshalWrapper,
+		"google.protobuf.Int32Value":  marshalWrapper,
+		"google.protobuf.Int64Value":  marshalWrapper,
+		"google.protobuf.StringValue": marshalWrapper,
+		"google.protobuf.UInt32Value": marshalWrapper,
+		"google.protobuf.UInt64Value": marshalWrapper,
+	}
+}
+
+// Valid ranges of Timestamp and Duration.
+const (
+	minTimestamp = -62135596800 // 0001-01-01T00:00:00Z
+	maxTimestamp = 253402300799 // 9999-12-31T23:59:59Z
+	maxDuration  = 315576000000 // 10000 years
+)
+
+func marshalAny(o MarshalOptions, m *dynamic.Message) (value, error) {
+	url, _ := m.Get("type_url").(string)
+	data, _ := m.Get("value").([]byte)
+	if url == "" && len(data) == 0 {
+		return object{}, nil
+	}
+	desc, err := o.resolve(url)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := dynamic.UnmarshalOptions{Extensions: o.Extensions}.Unmarshal(desc, data)
+	if err != nil {
+		return nil, fmt.Errorf("Any %s: %w", url, err)
+	}
+	typ, err := quote(url)
+	if err != nil {
+		return nil, err
+	}
+	v, err := o.message(inner)
+	if err != nil {
+		return nil, err
+	}
+	obj := object{{"@type", typ}}
+	if fields, ok := v.(object); ok && special[desc.FullName()] == nil {
+		return append(obj, fields...), nil
+	}
+	return append(obj, member{"value", v}), nil
+}
+
+func marshalTimestamp(o MarshalOptions, m *dynamic.Message) (value, error) {
+	secs, nanos := m.Get("seconds").(int64), m.Get("nanos").(int32)
+	if secs < minTimestamp || secs > maxTimestamp || nanos < 0 || nanos >= 1e9 {
+		return nil, fmt.Errorf("Timestamp %d.%09d out of range", secs, nanos)
+	}
+	s := time.Unix(secs, 0).UTC().Format("2006-01-02T15:04:05") + timefmt.Fraction(nanos) + "Z"
+	return literal(`"` + s + `"`), nil
+}
+
+func marshalDuration(o MarshalOptions, m *dynamic.Message) (value, error) {
+	secs, nanos := m.Get("seconds").(int64), m.Get("nanos").(int32)
+	if secs < -maxDuration || secs > maxDuration || nanos <= -1e9 || nanos >= 1e9 ||
+		secs > 0 && nanos < 0 || secs < 0 && nanos > 0 {
+		return nil, fmt.Errorf("Duration %ds %dns out of range", secs, nanos)
+	}
+	sign := ""
+	if secs < 0 || nanos < 0 {
+		sign, secs, nanos = "-", -secs, -nanos
+	}
+	return literal(fmt.Sprintf(`"%s%d%ss"`, sign, secs, timefmt.Fraction(nanos))), nil
+}
+
+func marshalFieldMask(o MarshalOptions, m *dynamic.Message) (value, error) {
+	paths, _ := m.Get("paths").([]any)
+	camel := make([]string, len(paths))
+	for i, p := range paths {
+		s := p.(string)
+		if strings.ContainsFunc(s, func(r rune) bool { return 'A' <= r && r <= 'Z' }) {
+			return nil, fmt.Errorf("FieldMask path %q is not lower snake case", s)
+		}
+		camel[i] = descriptor.JSONName(s)
+	}
+	return quote(strings.Join(camel, ","))
+}
+
+func marshalWrapper(o MarshalOptions, m *dynamic.Message) (value, error) {
+	f := m.FieldByName("value")
+	return o.single(f, m.GetField(f))
+}
+
+func marshalStruct(o MarshalOptions, m *dynamic.Message) (value, error) {
+	return o.field(m.FieldByName("fields"), m.Get("fields"))
+}
+
+func marshalListValue(o MarshalOptions, m *dynamic.Message) (value, error) {
+	return o.field(m.FieldByName("values"), m.Get("values"))
+}
+
+func marshalValue(o MarshalOptions, m *dynamic.Message) (value, error) {
+	f := m.WhichOneOf("kind")
+	if f == nil {
+		return nil, fmt.Errorf("Value without kind")
+	}
+	if f.Name == "number_value" {
+		if v := formatFloat(m.GetField(f).(float64), 64); v[0] == '"' {
+			return nil, fmt.Errorf("Value number %s is not valid JSON", v)
+		}
+	}
+	return o.single(f, m.GetField(f))
+}
+
+// parsers holds the unmarshalers of the well-known types with their own JSON form.
+var parsers map[string]func(o UnmarshalOptions, m *dynamic.Message, v any) error
+
+func init() {
+	parsers = map[string]func(o UnmarshalOptions, m *dynamic.Message, v any) error{
+		"google.protobuf.Any":         parseAny,
+		"google.protobuf.Duration":    parseDuration,
+		"google.protobuf.FieldMask":   parseFieldMask,
+		"google.protobuf.ListValue":   parseListValue,
+		"google.protobuf.Struct":      parseStruct,
+		"google.protobuf.Timestamp":   parseTimestamp,
+		"google.protobuf.Value":       parseValue,
+		"google.protobuf.BoolValue":   parseWrapper,
+		"google.protobuf.BytesValue":  parseWrapper,
+		"google.protobuf.DoubleValue": parseWrapper,
+		"google.protobuf.FloatValue":  parseWrapper,
+		"google.protobuf.Int32Value":  parseWrapper,
+		"google.protobuf.Int64Value":  parseWrapper,
+		"google.protobuf.StringValue": parseWrapper,
+		"google.protobuf.UInt32Value": parseWrapper,
+		"google.protobuf.UInt64Value": parseWrapper,
+	}
+}
+
+func parseAny(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("Any: want an object, not %s", kind(v))
+	}
+	if len(obj) == 0 {
+		return nil
+	}
+	url, ok := obj["@type"].(string)
+	if !ok {
+		return fmt.Errorf("Any: missing @type")
+	}
+	desc, err := o.resolveMessage(url)
+	if err != nil {
+		return err
+	}
+	inner := dynamic.New(desc)
+	if parsers[desc.FullName()] != nil {
+		err = o.message(inner, obj["value"])
+	} else {
+		rest := map[string]any{}
+		for k, x := range obj {
+			if k != "@type" {
+				rest[k] = x
+			}
+		}
+		err = o.fields(inner, rest)
+	}
+	if err != nil {
+		return fmt.Errorf("Any %s: %w", url, err)
+	}
+	data, err := inner.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	m.Set("type_url", url)
+	return m.Set("value", data)
+}
+
+func parseTimestamp(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("Timestamp: want a string, not %s", kind(v))
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || !strings.Contains(s, "T") {
+		return fmt.Errorf("Timestamp: invalid RFC 3339 time %q", s)
+	}
+	// time.Parse takes any number of fractional digits, the JSON mapping at most 9
+	if _, frac, ok := strings.Cut(s, "."); ok && len(frac)-len(strings.TrimLeft(frac, "0123456789")) > 9 {
+		return fmt.Errorf("Timestamp: more than 9 fractional digits in %q", s)
+	}
+	secs := t.Unix()
+	if secs < minTimestamp || secs > maxTimestamp {
+		return fmt.Errorf("Timestamp %q out of range", s)
+	}
+	m.Set("seconds", secs)
+	return m.Set("nanos", int32(t.Nanosecond()))
+}
+
+func parseDuration(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("Duration: want a string, not %s", kind(v))
+	}
+	bad := fmt.Errorf("Duration: invalid value %q", s)
+	num, ok := strings.CutSuffix(s, "s")
+	neg := strings.HasPrefix(num, "-")
+	num = strings.TrimPrefix(num, "-")
+	whole, frac, dot := strings.Cut(num, ".")
+	if !ok || !digits(whole) || dot && !digits(frac) || len(frac) > 9 {
+		return bad
+	}
+	secs, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || secs > maxDuration {
+		return bad
+	}
+	var nanos int64
+	if frac != "" {
+		nanos, _ = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+	}
+	if neg {
+		secs, nanos = -secs, -nanos
+	}
+	m.Set("seconds", secs)
+	return m.Set("nanos", int32(nanos))
+}
+
+// digits reports whether s is a non-empty string of decimal digits.
+func digits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func parseFieldMask(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("FieldMask: want a string, not %s", kind(v))
+	}
+	if s == "" {
+		return nil
+	}
+	for _, p := range strings.Split(s, ",") {
+		if strings.Contains(p, "_") {
+			return fmt.Errorf("FieldMask: path %q is not lower camel case", p)
+		}
+		var b strings.Builder
+		for _, r := range p {
+			if 'A' <= r && r <= 'Z' {
+				b.WriteByte('_')
+				r += 'a' - 'A'
+			}
+			b.WriteRune(r)
+		}
+		if err := m.Add("paths", b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseWrapper(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	f := m.FieldByName("value")
+	val, err := o.single(f, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.Descriptor().Name, err)
+	}
+	return m.SetField(f, val)
+}
+
+func parseStruct(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	if _, ok := v.(map[string]any); !ok {
+		return fmt.Errorf("Struct: want an object, not %s", kind(v))
+	}
+	return o.field(m, m.FieldByName("fields"), v)
+}
+
+func parseListValue(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	if _, ok := v.([]any); !ok {
+		return fmt.Errorf("ListValue: want an array, not %s", kind(v))
+	}
+	return o.field(m, m.FieldByName("values"), v)
+}
+
+func parseValue(o UnmarshalOptions, m *dynamic.Message, v any) error {
+	var name string
+	switch v.(type) {
+	case nil:
+		name = "null_value"
+	case json.Number:
+		name = "number_value"
+	case string:
+		name = "string_value"
+	case bool:
+		name = "bool_value"
+	case map[string]any:
+		name = "struct_value"
+	case []any:
+		name = "list_value"
+	default:
+	}
+	f := m.FieldByName(name)
+	val, err := o.single(f, v)
+	if err != nil {
+		return fmt.Errorf("Value: %w", err)
+	}
+	return m.SetField(f, val)
+}
@@ -0,0 +1,1366 @@
+package protosrc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/internal/cstring"
+	"github.com/defsrc/proton/prototext"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// UnmarshalOptions configure the compilation of .proto files,
+// the zero value reads them relative to the current directory.
+type UnmarshalOptions struct {
+	ImportPaths []string                          // directories searched in order for the files and their imports
+	ReadFile    func(name string) ([]byte, error) // reads the files instead of searching ImportPaths if set
+	SourceInfo  bool                              // keep the SourceCodeInfo like protoc --include_source_info, comments are attached either way
+}
+
+// Unmarshal parses the .proto source of the file called name, it may only import the well-known types.
+func Unmarshal(name string, src []byte) (*descriptor.File, error) {
+	o := UnmarshalOptions{ReadFile: func(n string) ([]byte, error) {
+		if n == name {
+			return src, nil
+		}
+		return nil, fs.ErrNotExist
+	}}
+	files, err := o.Compile(name)
+	if err != nil {
+		return nil, err
+	}
+	return files[len(files)-1], nil
+}
+
+// Compile parses the named files and the files they import, resolving their types and options like protoc.
+// It returns all of them with the imports before the importing files, as protoc --include_imports does.
+// Imports of the well-known types that are not found use the descriptors of package wellknown.
+func (o UnmarshalOptions) Compile(names ...string) ([]*descriptor.File, error) {
+	c := &compiler{o: o, done: map[string]*descriptor.File{}, loading: map[string]bool{}}
+	for _, name := range names {
+		if _, err := c.load(name); err != nil {
+			return nil, err
+		}
+	}
+	syms, err := descriptor.Link(c.files)
+	if err != nil {
+		return nil, err
+	}
+	exts := dynamic.NewExtensionRegistry(c.files)
+	for _, set := range c.options {
+		if err := set.interpret(syms, exts); err != nil {
+			return nil, err
+		}
+	}
+	if err := descriptor.Validate(c.files); err != nil {
+		return nil, err
+	}
+	if !o.SourceInfo {
+		for _, f := range c.parsed {
+			f.SourceCodeInfo = nil
+		}
+	}
+	return c.files, nil
+}
+
+type compiler struct {
+	o       UnmarshalOptions
+	files   []*descriptor.File // in dependency order
+	parsed  []*descriptor.File // the files parsed from source
+	done    map[string]*descriptor.File
+	loading map[string]bool // to detect import cycles
+	options []*optionSet
+}
+
+func (c *compiler) read(name string) ([]byte, error) {
+	if c.o.ReadFile != nil {
+		return c.o.ReadFile(name)
+	}
+	if len(c.o.ImportPaths) == 0 {
+		return os.ReadFile(filepath.FromSlash(name))
+	}
+	for _, dir := range c.o.ImportPaths {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return b, err
+		}
+	}
+	return nil, fmt.Errorf("protosrc: %s not found in %s: %w", name, strings.Join(c.o.ImportPaths, ", "), fs.ErrNotExist)
+}
+
+// load parses the file called name after its imports.
+func (c *compiler) load(name string) (*descriptor.File, error) {
+	if f, ok := c.done[name]; ok {
+		return f, nil
+	}
+	if c.loading[name] {
+		return nil, fmt.Errorf("protosrc: import cycle through %s", name)
+	}
+	src, err := c.read(name)
+	if errors.Is(err, fs.ErrNotExist) && wellknown.File(name) != nil {
+		f := wellknown.File(name)
+		for _, dep := range f.Dependency {
+			if _, err := c.load(dep); err != nil {
+				return nil, err
+			}
+		}
+		c.done[name] = f
+		c.files = append(c.files, f)
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.loading[name] = true
+	p := &parser{lex: newLexer(name, string(src)), file: &descriptor.File{Name: name}, info: &descriptor.SourceCodeInfo{}}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	for _, dep := range p.file.Dependency {
+		if _, err := c.load(dep); err != nil {
+			return nil, err
+		}
+	}
+	delete(c.loading, name)
+
+	// the round trip sets the back references, map entries and comments of the parsed model
+	p.file.SourceCodeInfo = p.info
+	data, err := p.file.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	f := &descriptor.File{}
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	for _, set := range p.options {
+		set.file = f
+	}
+	c.options = append(c.options, p.options...)
+	c.done[name] = f
+	c.files = append(c.files, f)
+	c.parsed = append(c.parsed, f)
+	return f, nil
+}
+
+// An optionSet holds the options of an element until the extensions they may use are linked.
+type optionSet struct {
+	file  *descriptor.File
+	path  []int32 // of the element, see descriptor.Location
+	typ   string  // the message of the options, like "FieldOptions"
+	scope string  // resolves extension names
+	opts  []option
+	lex   *lexer // reports errors
+}
+
+type option struct {
+	name  []string // the components, extensions in parentheses like "(pkg.ext)"
+	value string   // in the text format, aggregates in braces
+	tok   token
+}
+
+// interpret encodes the options as text format and sets them on the element.
+func (set *optionSet) interpret(syms *descriptor.Symbols, exts *dynamic.ExtensionRegistry) error {
+	desc := wellknown.Message("google.protobuf." + set.typ)
+	var data []byte
+	for _, o := range set.opts {
+		var text, closing strings.Builder
+		for i, part := range o.name {
+			if ext, ok := strings.CutPrefix(part, "("); ok {
+				ext = strings.TrimSuffix(ext, ")")
+				full, sym, ok := syms.Resolve(set.scope, ext)
+				if x, isField := sym.Value.(*descriptor.Field); !ok || !isField || x.Extendee == "" {
+					return set.lex.errorf(o.tok, "unknown extension %s", ext)
+				}
+				part = "[" + full[1:] + "]"
+			}
+			text.WriteString(part)
+			switch {
+			case i < len(o.name)-1:
+				text.WriteString(" { ")
+				closing.WriteString(" }")
+			case !strings.HasPrefix(o.value, "{"):
+				text.WriteString(": ")
+			default:
+				text.WriteString(" ")
+			}
+		}
+		text.WriteString(o.value)
+		text.WriteString(closing.String())
+		b, err := prototext.UnmarshalOptions{Resolver: syms, Extensions: exts}.Encode(desc, []byte(text.String()))
+		if err != nil {
+			return set.lex.errorf(o.tok, "option %s: %v", strings.Join(o.name, "."), err)
+		}
+		data = append(data, b...) // concatenated messages merge
+	}
+	return setOptions(locate(set.file, set.path), data)
+}
+
+// locate returns the element of f at the path of a descriptor.Location.
+func locate(f *descriptor.File, path []int32) any {
+	var x any = f
+	for i := 0; i+1 < len(path); i += 2 {
+		tag, idx := path[i], int(path[i+1])
+		switch v := x.(type) {
+		case *descriptor.File:
+			switch tag {
+			case 4:
+				x = v.Message[idx]
+			case 5:
+				x = v.Enum[idx]
+			case 6:
+				x = v.Service[idx]
+			case 7:
+				x = v.Extension[idx]
+			default:
+			}
+		case *descriptor.Message:
+			switch tag {
+			case 2:
+				x = v.Field[idx]
+			case 3:
+				x = v.Nested[idx]
+			case 4:
+				x = v.Enum[idx]
+			case 5:
+				x = v.ExtensionRange[idx]
+			case 6:
+				x = v.Extension[idx]
+			case 8:
+				x = v.OneOf[idx]
+			default:
+			}
+		case *descriptor.Enum:
+			x = v.Value[idx]
+		case *descriptor.Service:
+			x = v.Method[idx]
+		default:
+		}
+	}
+	return x
+}
+
+func setOptions(x any, data []byte) error {
+	switch x := x.(type) {
+	case *descriptor.File:
+		x.Options = &descriptor.FileOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.Message:
+		x.Options = &descriptor.MessageOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.Field:
+		x.Options = &descriptor.FieldOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.OneOf:
+		x.Options = &descriptor.OneOfOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.ExtensionRange:
+		x.Options = &descriptor.ExtensionRangeOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.Enum:
+		x.Options = &descriptor.EnumOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.EnumValue:
+		x.Options = &descriptor.EnumValueOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.Service:
+		x.Options = &descriptor.ServiceOptions{}
+		return x.Options.UnmarshalBinary(data)
+	case *descriptor.Method:
+		x.Options = &descriptor.MethodOptions{}
+		return x.Options.UnmarshalBinary(data)
+	default:
+	}
+	return fmt.Errorf("protosrc: options for %T", x)
+}
+
+// parser builds the descriptors of a file with relative type references, resolved later by descriptor.Link.
+type parser struct {
+	lex     *lexer
+	last    token // the last consumed token
+	file    *descriptor.File
+	info    *descriptor.SourceCodeInfo
+	scope   string // fully qualified name of the enclosing package or message, with a leading dot
+	depth   int    // of the message being parsed
+	options []*optionSet
+}
+
+func (p *parser) peek() (token, error) {
+	return p.lex.peek()
+}
+
+func (p *parser) next() (token, error) {
+	t, err := p.lex.next()
+	if err == nil {
+		p.last = t
+	}
+	return t, err
+}
+
+// is reports whether t is the punctuation or keyword s.
+func is(t token, s string) bool {
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == s
+}
+
+// accept consumes the next token if it is the punctuation or keyword s.
+func (p *parser) accept(s string) (bool, error) {
+	t, err := p.peek()
+	if err != nil || !is(t, s) {
+		return false, err
+	}
+	_, err = p.next()
+	return true, err
+}
+
+func (p *parser) expect(s string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if !is(t, s) {
+		return p.lex.errorf(t, "expected %q, found %s", s, describe(t))
+	}
+	return nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of file"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (p *parser) ident() (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != tokIdent {
+		return t, p.lex.errorf(t, "expected identifier, found %s", describe(t))
+	}
+	return t, nil
+}
+
+// fullIdent parses a dotted name, with a leading dot if fully qualified.
+func (p *parser) fullIdent() (string, token, error) {
+	first, err := p.peek()
+	if err != nil {
+		return "", first, err
+	}
+	var b strings.Builder
+	if ok, err := p.accept("."); err != nil {
+		return "", first, err
+	} else if ok {
+		b.WriteByte('.')
+	}
+	for {
+		t, err := p.ident()
+		if err != nil {
+			return "", first, err
+		}
+		b.WriteString(t.text)
+		if ok, err := p.accept("."); err != nil || !ok {
+			return b.String(), first, err
+		}
+		b.WriteByte('.')
+	}
+}
+
+func (p *parser) stringLit() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if t.kind != tokString {
+		return "", p.lex.errorf(t, "expected string, found %s", describe(t))
+	}
+	return t.text, nil
+}
+
+// intLit parses a signed decimal, octal or hexadecimal integer.
+func (p *parser) intLit() (int64, token, error) {
+	neg, err := p.accept("-")
+	if err != nil {
+		return 0, p.last, err
+	}
+	t, err := p.next()
+	if err != nil {
+		return 0, t, err
+	}
+	if t.kind != tokNumber {
+		return 0, t, p.lex.errorf(t, "expected number, found %s", describe(t))
+	}
+	text := t.text
+	if len(text) > 1 && text[0] == '0' && text[1] != 'x' && text[1] != 'X' {
+		text = "0o" + text[1:]
+	}
+	v, err := strconv.ParseUint(text, 0, 64)
+	if err != nil || v > math.MaxInt64+1 || !neg && v > math.MaxInt64 {
+		return 0, t, p.lex.errorf(t, "invalid integer %s", t.text)
+	}
+	if neg {
+		return -int64(v), t, nil
+	}
+	return int64(v), t, nil
+}
+
+func (p *parser) number(min, max int64) (int32, error) {
+	v, t, err := p.intLit()
+	if err != nil {
+		return 0, err
+	}
+	if v < min || v > max {
+		return 0, p.lex.errorf(t, "%d out of range", v)
+	}
+	return int32(v), nil
+}
+
+// begin starts the source location of the element at path with the comments before t.
+func (p *parser) begin(path []int32, t token) *descriptor.Location {
+	l := &descriptor.Location{Path: slices.Clone(path), Span: []int32{int32(t.line), int32(t.col)}}
+	l.LeadingComments, l.LeadingDetachedComments = t.leading, t.detached
+	p.info.Location = append(p.info.Location, l)
+	return l
+}
+
+// trail sets the trailing comment of l, the comment after the last token.
+func (p *parser) trail(l *descriptor.Location) error {
+	t, err := p.peek()
+	l.TrailingComments = t.trailing
+	return err
+}
+
+// end completes the span of l at the last token.
+func (p *parser) end(l *descriptor.Location) {
+	if int32(p.last.endLine) != l.Span[0] {
+		l.Span = append(l.Span, int32(p.last.endLine))
+	}
+	l.Span = append(l.Span, int32(p.last.endCol))
+}
+
+// addOption records an option of the element at path.
+func (p *parser) addOption(path []int32, typ string, o option) {
+	for _, set := range p.options {
+		if set.typ == typ && slices.Equal(set.path, path) {
+			set.opts = append(set.opts, o)
+			return
+		}
+	}
+	p.options = append(p.options, &optionSet{path: slices.Clone(path), typ: typ, scope: p.scope, opts: []option{o}, lex: p.lex})
+}
+
+func (p *parser) parseFile() error {
+	f := p.file
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return nil
+		case is(t, ";"):
+			_, err = p.next()
+		case is(t, "syntax"), is(t, "edition"):
+			err = p.syntax()
+		case is(t, "package"):
+			err = p.pkg()
+		case is(t, "import"):
+			err = p.importStmt()
+		case is(t, "option"):
+			err = p.optionStmt(nil, "FileOptions")
+		case is(t, "message"):
+			err = p.message(&f.Message, []int32{4, int32(len(f.Message))})
+		case is(t, "enum"):
+			err = p.enum(&f.Enum, []int32{5, int32(len(f.Enum))})
+		case is(t, "service"):
+			err = p.service([]int32{6, int32(len(f.Service))})
+		case is(t, "extend"):
+			err = p.extend(&f.Extension, &f.Message, 7, nil, 4)
+		default:
+			err = p.lex.errorf(t, "unexpected %s", describe(t))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) syntax() error {
+	kw, _ := p.next()
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	t, _ := p.peek()
+	s, err := p.stringLit()
+	if err != nil {
+		return err
+	}
+	switch {
+	case kw.text == "edition":
+		p.file.Syntax = descriptor.SyntaxEditions
+		switch s {
+		case "2023":
+			p.file.Edition = descriptor.Edition2023
+		case "2024":
+			p.file.Edition = descriptor.Edition2024
+		default:
+			return p.lex.errorf(t, "unknown edition %q", s)
+		}
+	case s == "proto2":
+		p.file.Syntax = descriptor.SyntaxProto2
+	case s == "proto3":
+		p.file.Syntax = descriptor.SyntaxProto3
+	default:
+		return p.lex.errorf(t, "unknown syntax %q", s)
+	}
+	return p.expect(";")
+}
+
+func (p *parser) pkg() error {
+	kw, _ := p.next()
+	l := p.begin([]int32{2}, kw)
+	name, _, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	p.file.Package, p.scope = name, scopeOf(name)
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	p.end(l)
+	return p.trail(l)
+}
+
+func (p *parser) importStmt() error {
+	p.next()
+	f := p.file
+	idx := int32(len(f.Dependency))
+	if ok, err := p.accept("public"); err != nil {
+		return err
+	} else if ok {
+		f.PublicDependency = append(f.PublicDependency, idx)
+	} else if ok, err := p.accept("weak"); err != nil {
+		return err
+	} else if ok {
+		f.WeakDependency = append(f.WeakDependency, idx)
+	}
+	name, err := p.stringLit()
+	if err != nil {
+		return err
+	}
+	f.Dependency = append(f.Dependency, name)
+	return p.expect(";")
+}
+
+// optionStmt parses an option statement of the element at path.
+func (p *parser) optionStmt(path []int32, typ string) error {
+	p.next()
+	o, err := p.option()
+	if err != nil {
+		return err
+	}
+	p.addOption(path, typ, o)
+	return p.expect(";")
+}
+
+// option parses name = value.
+func (p *parser) option() (option, error) {
+	o := option{}
+	o.tok, _ = p.peek()
+	for {
+		if ok, err := p.accept("("); err != nil {
+			return o, err
+		} else if ok {
+			name, _, err := p.fullIdent()
+			if err != nil {
+				return o, err
+			}
+			if err := p.expect(")"); err != nil {
+				return o, err
+			}
+			o.name = append(o.name, "("+name+")")
+		} else {
+			t, err := p.ident()
+			if err != nil {
+				return o, err
+			}
+			o.name = append(o.name, t.text)
+		}
+		if ok, err := p.accept("."); err != nil {
+			return o, err
+		} else if !ok {
+			break
+		}
+	}
+	if err := p.expect("="); err != nil {
+		return o, err
+	}
+	v, err := p.constant()
+	o.value = v
+	return o, err
+}
+
+// constant parses an option value and returns it in the text format.
+func (p *parser) constant() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case t.kind == tokString:
+		return cstring.Quote(t.text), nil
+	case t.kind == tokIdent, t.kind == tokNumber:
+		return t.text, nil
+	case is(t, "-"), is(t, "+"):
+		v, err := p.next()
+		if err != nil {
+			return "", err
+		}
+		if v.kind != tokNumber && v.kind != tokIdent {
+			return "", p.lex.errorf(v, "expected number, found %s", describe(v))
+		}
+		if t.text == "+" {
+			return v.text, nil
+		}
+		return "-" + v.text, nil
+	case is(t, "{"):
+		depth := 1
+		for depth > 0 {
+			c, err := p.next()
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case c.kind == tokEOF:
+				return "", p.lex.errorf(c, "unterminated aggregate value")
+			case is(c, "{"):
+				depth++
+			case is(c, "}"):
+				depth--
+			default:
+			}
+		}
+		return p.lex.src[t.end-1 : p.last.end], nil
+	default:
+	}
+	return "", p.lex.errorf(t, "expected value, found %s", describe(t))
+}
+
+// message parses a message declaration and appends it to msgs.
+func (p *parser) message(msgs *[]*descriptor.Message, path []int32) error {
+	kw, _ := p.next()
+	l := p.begin(path, kw)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	m := &descriptor.Message{Name: name.text}
+	*msgs = append(*msgs, m)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.trail(l); err != nil {
+		return err
+	}
+	if err := p.body(m, path); err != nil {
+		return err
+	}
+	p.end(l)
+	return nil
+}
+
+// body parses the declarations of a message or group up to the closing brace.
+func (p *parser) body(m *descriptor.Message, path []int32) error {
+	if p.depth++; p.depth > descriptor.DefaultMaxDepth {
+		return p.lex.errorf(p.last, "messages nested deeper than %d", descriptor.DefaultMaxDepth)
+	}
+	outer := p.scope
+	p.scope += "." + m.Name
+	defer func() { p.scope, p.depth = outer, p.depth-1 }()
+	sub := func(tag int32, n int) []int32 {
+		return append(slices.Clone(path), tag, int32(n))
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "missing } of message %s", m.Name)
+		case is(t, "}"):
+			p.next()
+			p.synthesizeOneOfs(m)
+			return nil
+		case is(t, ";"):
+			_, err = p.next()
+		case is(t, "option"):
+			err = p.optionStmt(path, "MessageOptions")
+		case is(t, "message"):
+			err = p.message(&m.Nested, sub(3, len(m.Nested)))
+		case is(t, "enum"):
+			err = p.enum(&m.Enum, sub(4, len(m.Enum)))
+		case is(t, "extend"):
+			err = p.extend(&m.Extension, &m.Nested, 6, path, 3)
+		case is(t, "extensions"):
+			err = p.extensionRanges(m, path)
+		case is(t, "reserved"):
+			err = p.reserved(&m.ReservedRange, &m.ReservedName, wire.MaxTag, 1)
+		case is(t, "oneof"):
+			err = p.oneof(m, path)
+		default:
+			err = p.field(&m.Field, &m.Nested, sub(2, len(m.Field)), path, 3, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// field parses a field, map or group declaration and appends it to fields.
+// Groups and map entries are appended to nested, the messages of the scope at parent path
+// with field number nestedTag. oneof is the index of the enclosing oneof.
+func (p *parser) field(fields *[]*descriptor.Field, nested *[]*descriptor.Message, path, parent []int32, nestedTag int32, oneof *int32) error {
+	start, _ := p.peek()
+	l := p.begin(path, start)
+	f := &descriptor.Field{OneOfIndex: oneof}
+	syntax := p.file.Syntax
+	switch {
+	case is(start, "optional"), is(start, "required"), is(start, "repeated"):
+		p.next()
+		if oneof != nil {
+			return p.lex.errorf(start, "fields in oneofs must not have labels")
+		}
+		f.Label = map[string]uint8{"optional": descriptor.LabelOptional, "required": descriptor.LabelRequired, "repeated": descriptor.LabelRepeated}[start.text]
+		if syntax == descriptor.SyntaxProto3 && f.Label == descriptor.LabelOptional {
+			f.Proto3Optional = true
+		}
+	case syntax == descriptor.SyntaxProto2 && oneof == nil && !is(start, "map"):
+		return p.lex.errorf(start, "missing label of field")
+	default:
+		f.Label = descriptor.LabelOptional
+	}
+
+	t, _ := p.peek()
+	var entry *descriptor.Message
+	switch {
+	case is(t, "map"):
+		p.next()
+		if ok, err := p.accept("<"); err != nil || !ok {
+			if err == nil {
+				err = p.lex.errorf(t, "expected < after map")
+			}
+			return err
+		}
+		key, value := &descriptor.Field{Name: "key", Tag: 1, Label: descriptor.LabelOptional, JsonName: "key"},
+			&descriptor.Field{Name: "value", Tag: 2, Label: descriptor.LabelOptional, JsonName: "value"}
+		if err := p.fieldType(key); err != nil {
+			return err
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+		if err := p.fieldType(value); err != nil {
+			return err
+		}
+		if err := p.expect(">"); err != nil {
+			return err
+		}
+		f.Label = descriptor.LabelRepeated
+		entry = &descriptor.Message{Field: []*descriptor.Field{key, value}, Options: &descriptor.MessageOptions{MapEntry: true}}
+	case is(t, "group"):
+		p.next()
+		f.Type = descriptor.TypeGroup
+	default:
+		if err := p.fieldType(f); err != nil {
+			return err
+		}
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	f.Name = name.text
+	if f.Type == descriptor.TypeGroup {
+		f.Name = strings.ToLower(name.text)
+		entry = &descriptor.Message{Name: name.text}
+	} else if entry != nil {
+		entry.Name = mapEntryName(f.Name)
+	}
+	if entry != nil {
+		f.TypeName = p.scope + "." + entry.Name
+		if f.Type == 0 {
+			f.Type = descriptor.TypeMessage
+		}
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	if f.Tag, err = p.fieldNumber(); err != nil {
+		return err
+	}
+	if err := p.fieldOptions(f, path); err != nil {
+		return err
+	}
+	if f.JsonName == "" {
+		f.JsonName = descriptor.JSONName(f.Name)
+	}
+	*fields = append(*fields, f)
+
+	if entry != nil {
+		entryPath := append(slices.Clone(parent), nestedTag, int32(len(*nested)))
+		*nested = append(*nested, entry)
+		if f.Type == descriptor.TypeGroup {
+			el := p.begin(entryPath, start)
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.trail(l); err != nil {
+				return err
+			}
+			if err := p.body(entry, entryPath); err != nil {
+				return err
+			}
+			p.end(el)
+			p.end(l)
+			return nil
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	p.end(l)
+	return p.trail(l)
+}
+
+var scalarTypes = map[string]uint8{
+	"double": descriptor.TypeDouble, "float": descriptor.TypeFloat, "int64": descriptor.TypeInt64,
+	"uint64": descriptor.TypeUint64, "int32": descriptor.TypeInt32, "fixed64": descriptor.TypeFixed64,
+	"fixed32": descriptor.TypeFixed32, "bool": descriptor.TypeBool, "string": descriptor.TypeString,
+	"bytes": descriptor.TypeBytes, "uint32": descriptor.TypeUint32, "sfixed32": descriptor.TypeSfixed32,
+	"sfixed64": descriptor.TypeSfixed64, "sint32": descriptor.TypeSint32, "sint64": descriptor.TypeSint64,
+}
+
+// fieldType parses a scalar type or a message or enum reference.
+func (p *parser) fieldType(f *descriptor.Field) error {
+	name, _, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	if typ, ok := scalarTypes[name]; ok {
+		f.Type = typ
+	} else {
+		f.TypeName = name
+	}
+	return nil
+}
+
+func (p *parser) fieldNumber() (uint32, error) {
+	n, err := p.number(1, wire.MaxTag)
+	return uint32(n), err
+}
+
+// mapEntryName returns the name of the entry message of a map field like protoc: foo_bar has FooBarEntry.
+func mapEntryName(field string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range field {
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteRune(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(c)
+			upper = false
+		}
+	}
+	return b.String() + "Entry"
+}
+
+// fieldOptions parses the bracketed options of a field, default and json_name are set on the field.
+func (p *parser) fieldOptions(f *descriptor.Field, path []int32) error {
+	if ok, err := p.accept("["); err != nil || !ok {
+		return err
+	}
+	for {
+		o, err := p.option()
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(o.name) == 1 && o.name[0] == "default":
+			if f.DefaultValue, err = p.defaultValue(f, o); err != nil {
+				return err
+			}
+		case len(o.name) == 1 && o.name[0] == "json_name":
+			s, ok := []byte(nil), strings.HasPrefix(o.value, `"`)
+			if ok {
+				s, ok = cstring.Unescape(o.value[1 : len(o.value)-1])
+			}
+			if !ok {
+				return p.lex.errorf(o.tok, "json_name must be a string")
+			}
+			f.JsonName = string(s)
+		default:
+			p.addOption(path, "FieldOptions", o)
+		}
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			return p.expect("]")
+		}
+	}
+}
+
+// defaultValue converts a default option to the format of FieldDescriptorProto.default_value:
+// strings unescaped, bytes C escaped and integers in decimal.
+func (p *parser) defaultValue(f *descriptor.Field, o option) (string, error) {
+	v := o.value
+	if strings.HasPrefix(v, `"`) {
+		s, ok := cstring.Unescape(v[1 : len(v)-1])
+		if !ok {
+			return "", p.lex.errorf(o.tok, "invalid default %s", v)
+		}
+		if f.Type == descriptor.TypeBytes {
+			q := cstring.Quote(string(s))
+			return q[1 : len(q)-1], nil
+		}
+		return string(s), nil
+	}
+	text := strings.TrimPrefix(v, "-")
+	if len(text) > 1 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X' || '0' <= text[1] && text[1] <= '7') {
+		if text[1] != 'x' && text[1] != 'X' {
+			text = "0o" + text[1:]
+		}
+		n, err := strconv.ParseUint(text, 0, 64)
+		if err != nil {
+			return "", p.lex.errorf(o.tok, "invalid default %s", v)
+		}
+		if strings.HasPrefix(v, "-") {
+			return "-" + strconv.FormatUint(n, 10), nil
+		}
+		return strconv.FormatUint(n, 10), nil
+	}
+	return v, nil
+}
+
+// extend parses an extend block, appending the extensions to xs at field number tag of the parent path
+// and groups to nested at nestedTag.
+func (p *parser) extend(xs *[]*descriptor.Field, nested *[]*descriptor.Message, tag int32, parent []int32, nestedTag int32) error {
+	p.next()
+	extendee, _, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "missing } of extend %s", extendee)
+		case is(t, "}"):
+			p.next()
+			return nil
+		case is(t, ";"):
+			p.next()
+			continue
+		default:
+		}
+		n := len(*xs)
+		path := append(slices.Clone(parent), tag, int32(n))
+		if err := p.field(xs, nested, path, parent, nestedTag, nil); err != nil {
+			return err
+		}
+		(*xs)[n].Extendee = extendee
+	}
+}
+
+func (p *parser) extensionRanges(m *descriptor.Message, path []int32) error {
+	p.next()
+	first := len(m.ExtensionRange)
+	for {
+		start, err := p.number(1, wire.MaxTag)
+		if err != nil {
+			return err
+		}
+		end := start
+		if ok, err := p.accept("to"); err != nil {
+			return err
+		} else if ok {
+			if ok, err := p.accept("max"); err != nil {
+				return err
+			} else if ok {
+				end = wire.MaxTag
+			} else if end, err = p.number(int64(start), wire.MaxTag); err != nil {
+				return err
+			}
+		}
+		m.ExtensionRange = append(m.ExtensionRange, &descriptor.ExtensionRange{Start: start, End: end + 1})
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+	}
+	if ok, err := p.accept("["); err != nil {
+		return err
+	} else if ok {
+		for {
+			o, err := p.option()
+			if err != nil {
+				return err
+			}
+			for i := first; i < len(m.ExtensionRange); i++ {
+				p.addOption(append(slices.Clone(path), 5, int32(i)), "ExtensionRangeOptions", o)
+			}
+			if ok, err := p.accept(","); err != nil {
+				return err
+			} else if !ok {
+				break
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	}
+	return p.expect(";")
+}
+
+// reserved parses reserved numbers or names, max is the number of the max keyword
+// and adjust is added to range ends, 1 for the exclusive ends of messages.
+func (p *parser) reserved(ranges *[]*descriptor.ReservedRange, names *[]string, max int64, adjust int32) error {
+	p.next()
+	t, err := p.peek()
+	if err != nil {
+		return err
+	}
+	if t.kind == tokString || t.kind == tokIdent {
+		for {
+			t, err := p.next()
+			if err != nil {
+				return err
+			}
+			if t.kind != tokString && t.kind != tokIdent {
+				return p.lex.errorf(t, "expected reserved name, found %s", describe(t))
+			}
+			*names = append(*names, t.text)
+			if ok, err := p.accept(","); err != nil {
+				return err
+			} else if !ok {
+				return p.expect(";")
+			}
+		}
+	}
+	min := int64(math.MinInt32)
+	if adjust == 1 {
+		min = 1
+	}
+	for {
+		start, err := p.number(min, max)
+		if err != nil {
+			return err
+		}
+		end := start
+		if ok, err := p.accept("to"); err != nil {
+			return err
+		} else if ok {
+			if ok, err := p.accept("max"); err != nil {
+				return err
+			} else if ok {
+				end = int32(max)
+			} else if end, err = p.number(int64(start), max); err != nil {
+				return err
+			}
+		}
+		*ranges = append(*ranges, &descriptor.ReservedRange{Start: start, End: end + adjust})
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			return p.expect(";")
+		}
+	}
+}
+
+func (p *parser) oneof(m *descriptor.Message, path []int32) error {
+	kw, _ := p.next()
+	idx := int32(len(m.OneOf))
+	opath := append(slices.Clone(path), 8, idx)
+	l := p.begin(opath, kw)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	m.OneOf = append(m.OneOf, &descriptor.OneOf{Name: name.text})
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.trail(l); err != nil {
+		return err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "missing } of oneof %s", name.text)
+		case is(t, "}"):
+			p.next()
+			p.end(l)
+			return nil
+		case is(t, ";"):
+			_, err = p.next()
+		case is(t, "option"):
+			err = p.optionStmt(opath, "OneofOptions")
+		default:
+			fpath := append(slices.Clone(path), 2, int32(len(m.Field)))
+			err = p.field(&m.Field, &m.Nested, fpath, path, 3, &idx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// synthesizeOneOfs adds the oneofs of proto3 optional fields after the declared ones, named like protoc.
+func (p *parser) synthesizeOneOfs(m *descriptor.Message) {
+	for _, f := range m.Field {
+		if !f.Proto3Optional {
+			continue
+		}
+		name := "_" + f.Name
+		for slices.ContainsFunc(m.Field, func(x *descriptor.Field) bool { return x.Name == name }) ||
+			slices.ContainsFunc(m.OneOf, func(o *descriptor.OneOf) bool { return o.Name == name }) {
+			name = "X" + name
+		}
+		idx := int32(len(m.OneOf))
+		m.OneOf = append(m.OneOf, &descriptor.OneOf{Name: name})
+		f.OneOfIndex = &idx
+	}
+}
+
+// enum parses an enum declaration and appends it to enums.
+func (p *parser) enum(enums *[]*descriptor.Enum, path []int32) error {
+	kw, _ := p.next()
+	l := p.begin(path, kw)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	en := &descriptor.Enum{Name: name.text}
+	*enums = append(*enums, en)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.trail(l); err != nil {
+		return err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "missing } of enum %s", en.Name)
+		case is(t, "}"):
+			p.next()
+			p.end(l)
+			return nil
+		case is(t, ";"):
+			_, err = p.next()
+		case is(t, "option"):
+			err = p.optionStmt(path, "EnumOptions")
+		case is(t, "reserved"):
+			err = p.reserved(&en.ReservedRange, &en.ReservedName, math.MaxInt32, 0)
+		default:
+			err = p.enumValue(en, append(slices.Clone(path), 2, int32(len(en.Value))))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) enumValue(en *descriptor.Enum, path []int32) error {
+	t, _ := p.peek()
+	l := p.begin(path, t)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	n, err := p.number(math.MinInt32, math.MaxInt32)
+	if err != nil {
+		return err
+	}
+	en.Value = append(en.Value, &descriptor.EnumValue{Name: name.text, Number: n})
+	if ok, err := p.accept("["); err != nil {
+		return err
+	} else if ok {
+		for {
+			o, err := p.option()
+			if err != nil {
+				return err
+			}
+			p.addOption(path, "EnumValueOptions", o)
+			if ok, err := p.accept(","); err != nil {
+				return err
+			} else if !ok {
+				break
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	p.end(l)
+	return p.trail(l)
+}
+
+func (p *parser) service(path []int32) error {
+	kw, _ := p.next()
+	l := p.begin(path, kw)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	s := &descriptor.Service{Name: name.text}
+	p.file.Service = append(p.file.Service, s)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.trail(l); err != nil {
+		return err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "missing } of service %s", s.Name)
+		case is(t, "}"):
+			p.next()
+			p.end(l)
+			return nil
+		case is(t, ";"):
+			_, err = p.next()
+		case is(t, "option"):
+			err = p.optionStmt(path, "ServiceOptions")
+		case is(t, "rpc"):
+			err = p.method(s, append(slices.Clone(path), 2, int32(len(s.Method))))
+		default:
+			err = p.lex.errorf(t, "unexpected %s in service", describe(t))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) method(s *descriptor.Service, path []int32) error {
+	kw, _ := p.next()
+	l := p.begin(path, kw)
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	m := &descriptor.Method{Name: name.text}
+	s.Method = append(s.Method, m)
+	typ := func(streaming *bool, ref *string) error {
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		// stream is also a valid message name
+		if t, err := p.peek(); err != nil {
+			return err
+		} else if is(t, "stream") {
+			p.next()
+			if next, err := p.peek(); err != nil {
+				return err
+			} else if is(next, ")") {
+				*ref = "stream"
+				return p.expect(")")
+			}
+			*streaming = true
+		}
+		var err error
+		if *ref, _, err = p.fullIdent(); err != nil {
+			return err
+		}
+		return p.expect(")")
+	}
+	if err := typ(&m.ClientStreaming, &m.InputType); err != nil {
+		return err
+	}
+	if err := p.expect("returns"); err != nil {
+		return err
+	}
+	if err := typ(&m.ServerStreaming, &m.OutputType); err != nil {
+		return err
+	}
+	if ok, err := p.accept("{"); err != nil {
+		return err
+	} else if ok {
+		if err := p.trail(l); err != nil {
+			return err
+		}
+		for {
+			t, err := p.peek()
+			if err != nil {
+				return err
+			}
+			switch {
+			case is(t, "}"):
+				p.next()
+				p.end(l)
+				return nil
+			case is(t, ";"):
+				_, err = p.next()
+			case is(t, "option"):
+				err = p.optionStmt(path, "MethodOptions")
+			default:
+				err = p.lex.errorf(t, "unexpected %s in method", describe(t))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	p.end(l)
+	return p.trail(l)
+}
@@ -0,0 +1,579 @@
+// Package protosrc converts descriptor files to and from .proto source.
+package protosrc
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/internal/cstring"
+	"github.com/defsrc/proton/prototext"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// MarshalOptions configure the source output,
+// the zero value prints type references fully qualified and custom options as comments.
+type MarshalOptions struct {
+	Resolver   *descriptor.Symbols        // shortens type references to the shortest name resolving to the same type
+	Extensions *dynamic.ExtensionRegistry // names custom options
+}
+
+// Marshal prints f as .proto source.
+func Marshal(f *descriptor.File) ([]byte, error) {
+	return MarshalOptions{}.Marshal(f)
+}
+
+// Marshal prints f as .proto source, with the comments of its SourceCodeInfo.
+// Map entries, groups and synthetic oneofs are printed in the syntax that declares them.
+func (o MarshalOptions) Marshal(f *descriptor.File) ([]byte, error) {
+	p := &printer{o: o, file: f, scope: scopeOf(f.Package)}
+	p.header()
+	for _, en := range f.Enum {
+		p.blank()
+		p.enum(en)
+	}
+	for _, m := range f.Message {
+		if p.group(f.Extension, m) == nil {
+			p.blank()
+			p.message(m)
+		}
+	}
+	p.extensions(f.Extension)
+	for _, s := range f.Service {
+		p.blank()
+		p.service(s)
+	}
+	return p.buf, p.err
+}
+
+type printer struct {
+	o     MarshalOptions
+	file  *descriptor.File
+	buf   []byte
+	depth int
+	scope string // fully qualified name of the enclosing package or message, with a leading dot
+	err   error  // the first error decoding options
+}
+
+func scopeOf(pkg string) string {
+	if pkg == "" {
+		return ""
+	}
+	return "." + pkg
+}
+
+func (p *printer) line(format string, args ...any) {
+	for range p.depth {
+		p.buf = append(p.buf, "  "...)
+	}
+	p.buf = fmt.Appendf(p.buf, format, args...)
+	p.buf = append(p.buf, '\n')
+}
+
+// blank separates declarations, but not at the start of the file or a block.
+func (p *printer) blank() {
+	if len(p.buf) > 0 && !bytes.HasSuffix(p.buf, []byte("{\n")) && !bytes.HasSuffix(p.buf, []byte("\n\n")) {
+		p.buf = append(p.buf, '\n')
+	}
+}
+
+func (p *printer) open(c *descriptor.Comments, format string, args ...any) {
+	p.leading(c)
+	p.line(format+" {", args...)
+	p.trailing(c)
+	p.depth++
+}
+
+func (p *printer) close() {
+	p.depth--
+	p.line("}")
+}
+
+// leading prints the detached comments of an element, each followed by a blank line, and its leading comment.
+func (p *printer) leading(c *descriptor.Comments) {
+	if c == nil {
+		return
+	}
+	for _, d := range c.LeadingDetached {
+		p.comment(d)
+		p.buf = append(p.buf, '\n')
+	}
+	p.comment(c.Leading)
+}
+
+// trailing appends a single line trailing comment to the last line, longer ones go below it.
+func (p *printer) trailing(c *descriptor.Comments) {
+	if c == nil || c.Trailing == "" {
+		return
+	}
+	text := strings.TrimSuffix(c.Trailing, "\n")
+	if strings.Contains(text, "\n") {
+		p.comment(text)
+		return
+	}
+	p.buf = append(p.buf[:len(p.buf)-1], " //"...)
+	p.buf = append(p.buf, text...)
+	p.buf = append(p.buf, '\n')
+}
+
+func (p *printer) comment(text string) {
+	if text == "" {
+		return
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		p.line("//%s", l)
+	}
+}
+
+func (p *printer) header() {
+	f := p.file
+	switch f.Syntax {
+	case descriptor.SyntaxProto3:
+		p.line(`syntax = "proto3";`)
+	case descriptor.SyntaxEditions:
+		p.line("edition = %s;", cstring.Quote(editionName(f.Edition)))
+	default:
+		p.line(`syntax = "proto2";`)
+	}
+	if f.Package != "" {
+		p.blank()
+		p.leading(f.Comments)
+		p.line("package %s;", f.Package)
+		p.trailing(f.Comments)
+	}
+	if len(f.Dependency) > 0 {
+		p.blank()
+	}
+	for i, dep := range f.Dependency {
+		switch {
+		case slices.Contains(f.PublicDependency, int32(i)):
+			p.line("import public %s;", cstring.Quote(dep))
+		case slices.Contains(f.WeakDependency, int32(i)):
+			p.line("import weak %s;", cstring.Quote(dep))
+		default:
+			p.line("import %s;", cstring.Quote(dep))
+		}
+	}
+	if f.Options != nil {
+		p.blank()
+		p.statements("FileOptions", f.Options)
+	}
+}
+
+// editionName returns the edition string of the edition statement, like "2023" for EDITION_2023.
+func editionName(edition int32) string {
+	switch edition {
+	case descriptor.Edition2023:
+		return "2023"
+	case descriptor.Edition2024:
+		return "2024"
+	default:
+	}
+	return strconv.Itoa(int(edition))
+}
+
+// enter makes name the scope of type references until the returned function is called.
+func (p *printer) enter(name string) func() {
+	outer := p.scope
+	p.scope += "." + name
+	return func() { p.scope = outer }
+}
+
+func (p *printer) message(m *descriptor.Message) {
+	p.open(m.Comments, "message %s", m.Name)
+	p.body(m)
+	p.close()
+}
+
+// body prints the declarations of a message or group.
+func (p *printer) body(m *descriptor.Message) {
+	defer p.enter(m.Name)()
+	if m.Options != nil {
+		p.statements("MessageOptions", m.Options)
+	}
+	oneofs := m.OneOfFields()
+	done := make([]bool, len(m.OneOf))
+	for _, f := range m.Field {
+		o := f.RealOneOf(m)
+		if o == nil {
+			p.field(m, f)
+			continue
+		}
+		i := *f.OneOfIndex
+		if done[i] {
+			continue
+		}
+		done[i] = true
+		p.open(o.Comments, "oneof %s", o.Name)
+		if o.Options != nil {
+			p.statements("OneofOptions", o.Options)
+		}
+		for _, f := range oneofs[i] {
+			p.field(m, f)
+		}
+		p.close()
+	}
+	for _, en := range m.Enum {
+		p.blank()
+		p.enum(en)
+	}
+	for _, nm := range m.Nested {
+		if nm.IsMapEntry() || p.group(append(slices.Clone(m.Field), m.Extension...), nm) != nil {
+			continue // printed with their field
+		}
+		p.blank()
+		p.message(nm)
+	}
+	p.extensions(m.Extension)
+	if len(m.ExtensionRange) > 0 {
+		p.blank()
+	}
+	for _, r := range m.ExtensionRange {
+		end := "max"
+		if r.End-1 < wire.MaxTag {
+			end = strconv.Itoa(int(r.End - 1))
+		}
+		var opts []string
+		if r.Options != nil {
+			opts = p.options("ExtensionRangeOptions", r.Options)
+		}
+		p.line("extensions %s%s;", span(r.Start, end), brackets(opts))
+	}
+	p.reserved(m.ReservedRange, m.ReservedName, true)
+}
+
+// group returns the group field of fields declaring nm of the current scope as its type, nil if there is none.
+// Editions declare delimited fields with ordinary messages.
+func (p *printer) group(fields []*descriptor.Field, nm *descriptor.Message) *descriptor.Field {
+	if p.file.Syntax == descriptor.SyntaxEditions {
+		return nil
+	}
+	for _, f := range fields {
+		if f.Type == descriptor.TypeGroup && f.TypeName == p.scope+"."+nm.Name && strings.EqualFold(f.Name, nm.Name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// span formats a range of numbers, single numbers without "to".
+func span(start int32, end string) string {
+	if strconv.Itoa(int(start)) == end {
+		return end
+	}
+	return fmt.Sprintf("%d to %s", start, end)
+}
+
+// reserved prints reserved ranges and names, with exclusive range ends for messages.
+func (p *printer) reserved(ranges []*descriptor.ReservedRange, names []string, exclusive bool) {
+	if len(ranges) == 0 && len(names) == 0 {
+		return
+	}
+	p.blank()
+	var spans []string
+	for _, r := range ranges {
+		end := r.End
+		if exclusive {
+			end--
+		}
+		switch {
+		case exclusive && end >= wire.MaxTag, !exclusive && end == math.MaxInt32:
+			spans = append(spans, span(r.Start, "max"))
+		default:
+			spans = append(spans, span(r.Start, strconv.Itoa(int(end))))
+		}
+	}
+	if spans != nil {
+		p.line("reserved %s;", strings.Join(spans, ", "))
+	}
+	if names == nil {
+		return
+	}
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = n
+		if p.file.Syntax != descriptor.SyntaxEditions {
+			quoted[i] = cstring.Quote(n) // editions reserve identifiers
+		}
+	}
+	p.line("reserved %s;", strings.Join(quoted, ", "))
+}
+
+// field prints a field of m, nil for top level extensions.
+func (p *printer) field(m *descriptor.Message, f *descriptor.Field) {
+	label := ""
+	switch {
+	case f.Map != nil, f.RealOneOf(m) != nil:
+	case p.file.Syntax == descriptor.SyntaxEditions:
+		if f.Label == descriptor.LabelRepeated {
+			label = "repeated "
+		}
+	case p.file.Syntax == descriptor.SyntaxProto3:
+		if f.Label == descriptor.LabelRepeated {
+			label = "repeated "
+		} else if f.Proto3Optional {
+			label = "optional "
+		}
+	default:
+		label = descriptor.LabelKeyword(f.Label) + " "
+	}
+
+	var opts []string
+	if f.DefaultValue != "" {
+		opts = append(opts, "default = "+p.defaultValue(f))
+	}
+	if f.JsonName != "" && f.JsonName != descriptor.JSONName(f.Name) {
+		opts = append(opts, "json_name = "+cstring.Quote(f.JsonName))
+	}
+	if f.Options != nil {
+		opts = append(opts, p.options("FieldOptions", f.Options)...)
+	}
+
+	p.leading(f.Comments)
+	switch {
+	case f.Map != nil:
+		p.line("map<%s, %s> %s = %d%s;", p.typeOf(f.Map.Key), p.typeOf(f.Map.Value), f.Name, f.Tag, brackets(opts))
+	case f.Type == descriptor.TypeGroup && f.MessageType != nil && p.file.Syntax != descriptor.SyntaxEditions:
+		p.line("%sgroup %s = %d%s {", label, f.MessageType.Name, f.Tag, brackets(opts))
+		p.trailing(f.Comments)
+		p.depth++
+		p.body(f.MessageType)
+		p.close()
+		return
+	default:
+		p.line("%s%s %s = %d%s;", label, p.typeOf(f), f.Name, f.Tag, brackets(opts))
+	}
+	p.trailing(f.Comments)
+}
+
+// typeOf returns the type of a field, the type name for messages and enums.
+func (p *printer) typeOf(f *descriptor.Field) string {
+	switch f.Type {
+	case descriptor.TypeMessage, descriptor.TypeEnum, descriptor.TypeGroup:
+		return p.ref(f.TypeName)
+	default:
+	}
+	if f.Type == 0 && f.TypeName != "" {
+		return p.ref(f.TypeName) // unlinked, the kind of the type is unknown
+	}
+	return descriptor.TypeKeyword(f.Type)
+}
+
+// ref returns the shortest suffix of the fully qualified name that resolves to it from the current scope.
+func (p *printer) ref(name string) string {
+	if p.o.Resolver == nil || !strings.HasPrefix(name, ".") {
+		return name
+	}
+	parts := strings.Split(name[1:], ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		short := strings.Join(parts[i:], ".")
+		if found, _, ok := p.o.Resolver.Resolve(p.scope, short); ok && found == name {
+			return short
+		}
+	}
+	return name
+}
+
+func (p *printer) defaultValue(f *descriptor.Field) string {
+	switch f.Type {
+	case descriptor.TypeString:
+		return cstring.Quote(f.DefaultValue)
+	case descriptor.TypeBytes:
+		return `"` + f.DefaultValue + `"` // already C escaped
+	default:
+	}
+	return f.DefaultValue
+}
+
+// extensions prints extend blocks, one per run of extensions of the same message.
+func (p *printer) extensions(xs []*descriptor.Field) {
+	for i, x := range xs {
+		if i == 0 || xs[i-1].Extendee != x.Extendee {
+			if i > 0 {
+				p.close()
+			}
+			p.blank()
+			p.line("extend %s {", p.ref(x.Extendee))
+			p.depth++
+		}
+		p.field(nil, x)
+	}
+	if len(xs) > 0 {
+		p.close()
+	}
+}
+
+func (p *printer) enum(en *descriptor.Enum) {
+	p.open(en.Comments, "enum %s", en.Name)
+	if en.Options != nil {
+		p.statements("EnumOptions", en.Options)
+	}
+	for _, v := range en.Value {
+		var opts []string
+		if v.Options != nil {
+			opts = p.options("EnumValueOptions", v.Options)
+		}
+		p.leading(v.Comments)
+		p.line("%s = %d%s;", v.Name, v.Number, brackets(opts))
+		p.trailing(v.Comments)
+	}
+	p.reserved(en.ReservedRange, en.ReservedName, false)
+	p.close()
+}
+
+func (p *printer) service(s *descriptor.Service) {
+	p.open(s.Comments, "service %s", s.Name)
+	if s.Options != nil {
+		p.statements("ServiceOptions", s.Options)
+	}
+	for _, m := range s.Method {
+		in, out := p.ref(m.InputType), p.ref(m.OutputType)
+		if m.ClientStreaming {
+			in = "stream " + in
+		}
+		if m.ServerStreaming {
+			out = "stream " + out
+		}
+		rpc := fmt.Sprintf("rpc %s(%s) returns (%s)", m.Name, in, out)
+		var opts []string
+		if m.Options != nil {
+			opts = p.options("MethodOptions", m.Options)
+		}
+		if len(opts) == 0 {
+			p.leading(m.Comments)
+			p.line("%s;", rpc)
+			p.trailing(m.Comments)
+			continue
+		}
+		p.open(m.Comments, "%s", rpc)
+		for _, o := range opts {
+			p.line("option %s;", o)
+		}
+		p.close()
+	}
+	p.close()
+}
+
+// statements prints options as option statements.
+func (p *printer) statements(typ string, opts encoding.BinaryMarshaler) {
+	for _, o := range p.options(typ, opts) {
+		p.line("option %s;", o)
+	}
+}
+
+func brackets(opts []string) string {
+	if len(opts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(opts, ", ") + "]"
+}
+
+// options returns the set options as name = value, decoding them as the google.protobuf message typ.
+// Options without a known extension are printed as comments.
+func (p *printer) options(typ string, opts encoding.BinaryMarshaler) []string {
+	data, err := opts.MarshalBinary()
+	var m *dynamic.Message
+	if err == nil {
+		m, err = dynamic.UnmarshalOptions{Extensions: p.o.Extensions}.Unmarshal(wellknown.Message("google.protobuf."+typ), data)
+	}
+	if err != nil {
+		if p.err == nil {
+			p.err = fmt.Errorf("protosrc: %s: %w", typ, err)
+		}
+		return nil
+	}
+	return p.entries("", m)
+}
+
+func (p *printer) entries(prefix string, m *dynamic.Message) []string {
+	var out []string
+	m.Range(func(f *descriptor.Field, v any) bool {
+		if f.Name == "map_entry" && f.Extendee == "" {
+			return true // implied by map fields
+		}
+		name := prefix + f.Name
+		if f.Extendee != "" {
+			name = prefix + "(" + p.ref("."+f.FullName()) + ")"
+		}
+		vs := []any{v}
+		if f.Label == descriptor.LabelRepeated {
+			vs = v.([]any)
+		}
+		for _, v := range vs {
+			out = append(out, p.entry(name, f, v)...)
+		}
+		return true
+	})
+	for r, err := range wire.Fields(m.UnknownFields) {
+		if err != nil {
+			break
+		}
+		p.line("// unknown option %s%d", prefix, r.Tag)
+	}
+	return out
+}
+
+// entry formats an option value, features are set one by one like features.field_presence = IMPLICIT,
+// other messages as text format aggregates.
+func (p *printer) entry(name string, f *descriptor.Field, v any) []string {
+	m, ok := v.(*dynamic.Message)
+	switch {
+	case ok && f.Name == "features" && f.Extendee == "":
+		return p.entries(name+".", m)
+	case ok:
+		text, err := prototext.MarshalOptions{Compact: true, Resolver: p.o.Resolver, Extensions: p.o.Extensions}.Marshal(m)
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		if len(text) == 0 {
+			return []string{name + " = {}"}
+		}
+		return []string{name + " = { " + string(text) + " }"}
+	default:
+	}
+	return []string{name + " = " + scalar(f, v)}
+}
+
+func scalar(f *descriptor.Field, v any) string {
+	switch v := v.(type) {
+	case int32:
+		if f.EnumType != nil {
+			for _, ev := range f.EnumType.Value {
+				if ev.Number == v {
+					return ev.Name
+				}
+			}
+		}
+		return strconv.Itoa(int(v))
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case string:
+		return cstring.Quote(v)
+	case []byte:
+		return cstring.Quote(string(v))
+	default:
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	default:
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
@@ -0,0 +1,252 @@
+package protosrc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/internal/cstring"
+)
+
+// A SyntaxError reports invalid .proto source.
+type SyntaxError struct {
+	File         string
+	Line, Column int // 1 based
+	Msg          string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("protosrc: %s:%d:%d: %s", err.File, err.Line, err.Column, err.Msg)
+}
+
+type tokenKind uint8
+
+const (
+	tokEOF    tokenKind = iota
+	tokIdent            // identifiers and keywords, also inf and nan
+	tokNumber           // integer and float literals without sign
+	tokString           // the unescaped value of adjacent quoted strings
+	tokPunct            // a single character like = ; { } [ ] ( ) < > , . : - +
+)
+
+type token struct {
+	kind      tokenKind
+	text      string
+	line, col int // 0 based like SourceCodeInfo spans
+	end       int // offset after the token in the source
+	endLine   int
+	endCol    int
+
+	// comments before the token, see (*lexer).comments
+	leading  string
+	detached []string
+	trailing string // of the previous token
+}
+
+// lexer splits .proto source into tokens, collecting // and /* */ comments for SourceCodeInfo.
+type lexer struct {
+	file      string
+	src       string
+	pos       int
+	line, col int
+	prevLine  int // of the end of the last token, -1 before the first
+	peeked    *token
+}
+
+func newLexer(file, src string) *lexer {
+	return &lexer{file: file, src: src, prevLine: -1}
+}
+
+func (l *lexer) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{File: l.file, Line: t.line + 1, Column: t.col + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) peek() (token, error) {
+	if l.peeked == nil {
+		t, err := l.scan()
+		if err != nil {
+			return t, err
+		}
+		l.peeked = &t
+	}
+	return *l.peeked, nil
+}
+
+func (l *lexer) next() (token, error) {
+	t, err := l.peek()
+	l.peeked = nil
+	return t, err
+}
+
+func (l *lexer) advance(n int) {
+	for _, c := range l.src[l.pos : l.pos+n] {
+		if c == '\n' {
+			l.line, l.col = l.line+1, 0
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+// A comment block is a /* */ comment or a run of // comments on consecutive lines.
+type comment struct {
+	text          string
+	line, endLine int
+	slashes       bool
+}
+
+// comments skips space and attributes the comments before the next token like protoc:
+// a comment on the line of the previous token, or starting on the next line and followed by a blank line, trails it,
+// the comment right before the next token leads it and the others are detached.
+func (l *lexer) comments(t *token) error {
+	var cs []comment
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f':
+			l.advance(1)
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			n := strings.IndexByte(l.src[l.pos:], '\n')
+			if n < 0 {
+				n = len(l.src) - l.pos
+			}
+			text := strings.TrimSuffix(l.src[l.pos+2:l.pos+n], "\r") + "\n"
+			// a comment after the previous token on its line does not continue on the next ones
+			if k := len(cs) - 1; k >= 0 && cs[k].slashes && cs[k].endLine == l.line-1 && cs[k].line != l.prevLine {
+				cs[k].text += text
+				cs[k].endLine = l.line
+			} else {
+				cs = append(cs, comment{text: text, line: l.line, endLine: l.line, slashes: true})
+			}
+			l.advance(n)
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			n := strings.Index(l.src[l.pos+2:], "*/")
+			if n < 0 {
+				return l.errorf(token{line: l.line, col: l.col}, "unterminated comment")
+			}
+			start := l.line
+			text := blockText(l.src[l.pos+2 : l.pos+2+n])
+			l.advance(n + 4)
+			cs = append(cs, comment{text: text, line: start, endLine: l.line})
+		default:
+			return l.attach(t, cs)
+		}
+	}
+	return l.attach(t, cs)
+}
+
+func (l *lexer) attach(t *token, cs []comment) error {
+	if len(cs) > 0 && l.prevLine >= 0 {
+		first := cs[0]
+		next := l.line // the line of the token
+		if len(cs) > 1 {
+			next = cs[1].line
+		}
+		if first.line == l.prevLine || first.line == l.prevLine+1 && next > first.endLine+1 {
+			t.trailing = first.text
+			cs = cs[1:]
+		}
+	}
+	if k := len(cs) - 1; k >= 0 && cs[k].endLine >= l.line-1 && l.pos < len(l.src) {
+		t.leading = cs[k].text
+		cs = cs[:k]
+	}
+	for _, c := range cs {
+		t.detached = append(t.detached, c.text)
+	}
+	return nil
+}
+
+// blockText strips the * starting the lines of /* */ comments.
+func blockText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			trimmed := strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(trimmed, "*") {
+				line = trimmed[1:]
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (l *lexer) scan() (t token, err error) {
+	if err := l.comments(&t); err != nil {
+		return t, err
+	}
+	t.line, t.col = l.line, l.col
+	defer func() {
+		t.end, t.endLine, t.endCol = l.pos, l.line, l.col
+		l.prevLine = l.line
+	}()
+	if l.pos == len(l.src) {
+		return t, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		return l.scanStrings(t)
+	case isIdentStart(c):
+		n := 1
+		for l.pos+n < len(l.src) && isIdent(l.src[l.pos+n]) {
+			n++
+		}
+		t.kind, t.text = tokIdent, l.src[l.pos:l.pos+n]
+	case '0' <= c && c <= '9' || c == '.' && l.pos+1 < len(l.src) && '0' <= l.src[l.pos+1] && l.src[l.pos+1] <= '9':
+		n := 1
+		for l.pos+n < len(l.src) && (isIdent(l.src[l.pos+n]) || l.src[l.pos+n] == '.' ||
+			(l.src[l.pos+n] == '-' || l.src[l.pos+n] == '+') && (l.src[l.pos+n-1] == 'e' || l.src[l.pos+n-1] == 'E') && !strings.HasPrefix(l.src[l.pos:], "0x")) {
+			n++
+		}
+		t.kind, t.text = tokNumber, l.src[l.pos:l.pos+n]
+	case strings.IndexByte("=;{}[]()<>,.:-+/", c) >= 0:
+		t.kind, t.text = tokPunct, l.src[l.pos:l.pos+1]
+	default:
+		return t, l.errorf(t, "unexpected character %q", c)
+	}
+	l.advance(len(t.text))
+	return t, nil
+}
+
+// scanStrings reads adjacent quoted strings as one value, t.end covers all of them.
+func (l *lexer) scanStrings(t token) (token, error) {
+	var b []byte
+	for {
+		q := l.src[l.pos]
+		i := l.pos + 1
+		for ; i < len(l.src) && l.src[i] != q; i++ {
+			if l.src[i] == '\n' {
+				return t, l.errorf(t, "newline in string")
+			}
+			if l.src[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(l.src) {
+			return t, l.errorf(t, "unterminated string")
+		}
+		s, ok := cstring.Unescape(l.src[l.pos+1 : i])
+		if !ok {
+			return t, l.errorf(t, "invalid escape in string")
+		}
+		b = append(b, s...)
+		l.advance(i + 1 - l.pos)
+		rest := strings.TrimLeft(l.src[l.pos:], " \t\r\n")
+		if rest == "" || rest[0] != '"' && rest[0] != '\'' {
+			break
+		}
+		l.advance(len(l.src) - l.pos - len(rest))
+	}
+	t.kind, t.text = tokString, string(b)
+	return t, nil
+}
+
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,458 @@
+package prototext
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// UnmarshalOptions configure the text input, the zero value rejects unknown fields.
+type UnmarshalOptions struct {
+	DiscardUnknown bool                       // skip unknown fields instead of failing
+	Resolver       *descriptor.Symbols        // resolves expanded Any types, the well-known types are always known
+	Extensions     *dynamic.ExtensionRegistry // resolves [ext.name] fields
+}
+
+// Unmarshal parses text format data as a message of type desc.
+func Unmarshal(desc *descriptor.Message, data []byte) (*dynamic.Message, error) {
+	return UnmarshalOptions{}.Unmarshal(desc, data)
+}
+
+// Unmarshal parses text format data as a message of type desc.
+func (o UnmarshalOptions) Unmarshal(desc *descriptor.Message, data []byte) (*dynamic.Message, error) {
+	p := parser{o: o, lex: newLexer(string(data))}
+	m := dynamic.New(desc)
+	if err := p.fields(m, ""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Encode parses text format data as a message of type desc and returns its binary encoding.
+func (o UnmarshalOptions) Encode(desc *descriptor.Message, data []byte) ([]byte, error) {
+	m, err := o.Unmarshal(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	return m.MarshalBinary()
+}
+
+type parser struct {
+	o   UnmarshalOptions
+	lex *lexer
+}
+
+// fields parses fields into m until the closing delimiter, empty for the end of input.
+func (p *parser) fields(m *dynamic.Message, closing string) error {
+	for {
+		t, err := p.lex.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokEOF && closing == "":
+			return nil
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "expected %q", closing)
+		case t.kind == tokPunct && t.text == closing:
+			p.lex.next()
+			return nil
+		default:
+		}
+		if err := p.field(m); err != nil {
+			return err
+		}
+		if _, err := p.lex.accept(";"); err != nil {
+			return err
+		}
+		if _, err := p.lex.accept(","); err != nil {
+			return err
+		}
+	}
+}
+
+// field parses one field with its value or list of values.
+func (p *parser) field(m *dynamic.Message) error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	var f *descriptor.Field
+	switch {
+	case t.kind == tokIdent:
+		f = lookup(m, t.text)
+	case t.kind == tokNumber:
+		return p.unknownField(m, t)
+	case t.kind == tokPunct && t.text == "[":
+		name, err := p.bracketName()
+		if err != nil {
+			return err
+		}
+		if strings.Contains(name, "/") {
+			return p.anyValue(m, t, name)
+		}
+		if p.o.Extensions != nil {
+			f = p.o.Extensions.FindByName(name)
+			if f != nil && strings.TrimPrefix(f.Extendee, ".") != m.Descriptor().FullName() {
+				return p.lex.errorf(t, "extension %s does not extend %s", name, m.Descriptor().FullName())
+			}
+		}
+		t.text = "[" + name + "]"
+	default:
+		return p.lex.errorf(t, "expected a field name, found %q", t.text)
+	}
+	if f == nil {
+		if !p.o.DiscardUnknown {
+			return p.lex.errorf(t, "unknown field %s in %s", t.text, m.Descriptor().FullName())
+		}
+		return p.skipValue()
+	}
+	composite := f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup
+	colon, err := p.lex.accept(":")
+	if err != nil {
+		return err
+	}
+	if !colon && !composite {
+		next, _ := p.lex.peek()
+		return p.lex.errorf(next, "expected \":\" after %s", t.text)
+	}
+	list, err := p.lex.accept("[")
+	if err != nil || !list {
+		if err == nil {
+			err = p.value(m, f)
+		}
+		return err
+	}
+	if f.Label != descriptor.LabelRepeated {
+		return p.lex.errorf(t, "list value for non repeated field %s", f.Name)
+	}
+	if ok, err := p.lex.accept("]"); ok || err != nil {
+		return err
+	}
+	for {
+		if err := p.value(m, f); err != nil {
+			return err
+		}
+		if ok, err := p.lex.accept(","); err != nil || !ok {
+			if err == nil {
+				err = p.lex.expect("]")
+			}
+			return err
+		}
+	}
+}
+
+// lookup finds a field by name, groups also by their type name.
+func lookup(m *dynamic.Message, name string) *descriptor.Field {
+	if f := m.FieldByName(name); f != nil {
+		return f
+	}
+	for _, f := range m.Descriptor().Field {
+		if f.Type == descriptor.TypeGroup && f.MessageType != nil && f.MessageType.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// bracketName reads the rest of an [extension.name] or [type.url/name] after the opening bracket.
+func (p *parser) bracketName() (string, error) {
+	var b strings.Builder
+	for {
+		t, err := p.lex.next()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case t.kind == tokPunct && t.text == "]":
+			return b.String(), nil
+		case t.kind == tokIdent || t.kind == tokPunct && (t.text == "." || t.text == "/"):
+			b.WriteString(t.text)
+		case t.kind == tokNumber && strings.HasPrefix(t.text, "."):
+			b.WriteString(t.text) // a dot followed by digits in a host name
+		default:
+			return "", p.lex.errorf(t, "unexpected %q in name", t.text)
+		}
+	}
+}
+
+// value parses a single value of f and stores it in m.
+func (p *parser) value(m *dynamic.Message, f *descriptor.Field) error {
+	switch {
+	case f.Map != nil:
+		entry := dynamic.New(f.Map.Entry)
+		if err := p.message(entry); err != nil {
+			return err
+		}
+		val := entry.GetField(f.Map.Value)
+		if val == nil {
+			val = entry.NewField(f.Map.Value)
+		}
+		return m.PutField(f, entry.GetField(f.Map.Key), val)
+	case f.Type == descriptor.TypeMessage || f.Type == descriptor.TypeGroup:
+		if f.MessageType == nil {
+			return &dynamic.UnresolvedError{Field: f}
+		}
+		sub := dynamic.New(f.MessageType)
+		if f.Label != descriptor.LabelRepeated && m.HasField(f) {
+			sub = m.GetField(f).(*dynamic.Message)
+		}
+		if err := p.messageValue(sub); err != nil {
+			return err
+		}
+		if f.Label == descriptor.LabelRepeated {
+			return m.AddField(f, sub)
+		}
+		return m.SetField(f, sub)
+	default:
+	}
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	v, ok := scalar(f, t)
+	if !ok {
+		return p.lex.errorf(t, "invalid value %q for field %s", t.text, f.Name)
+	}
+	if f.Label == descriptor.LabelRepeated {
+		return m.AddField(f, v)
+	}
+	return m.SetField(f, v)
+}
+
+// messageValue parses a message block into m, or the single value of a well-known type.
+func (p *parser) messageValue(m *dynamic.Message) error {
+	t, err := p.lex.peek()
+	if err != nil {
+		return err
+	}
+	if t.kind != tokPunct || t.text != "{" && t.text != "<" {
+		if ok, err := p.parseWellKnown(m); ok {
+			return err
+		}
+	}
+	return p.message(m)
+}
+
+// message parses a { ... } or < ... > block into m.
+func (p *parser) message(m *dynamic.Message) error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	switch {
+	case t.kind == tokPunct && t.text == "{":
+		return p.fields(m, "}")
+	case t.kind == tokPunct && t.text == "<":
+		return p.fields(m, ">")
+	default:
+	}
+	return p.lex.errorf(t, "expected \"{\", found %q", t.text)
+}
+
+// anyValue parses an expanded [type.googleapis.com/pkg.Type] { ... } into an Any message.
+func (p *parser) anyValue(m *dynamic.Message, t token, url string) error {
+	if m.Descriptor().FullName() != "google.protobuf.Any" {
+		return p.lex.errorf(t, "expanded Any in %s", m.Descriptor().FullName())
+	}
+	name := url[strings.LastIndexByte(url, '/')+1:]
+	var desc *descriptor.Message
+	if p.o.Resolver != nil {
+		desc = p.o.Resolver.Message(name)
+	}
+	if desc == nil {
+		desc = wellknown.Message(name)
+	}
+	if desc == nil {
+		return p.lex.errorf(t, "unresolved Any type %s", url)
+	}
+	if _, err := p.lex.accept(":"); err != nil {
+		return err
+	}
+	inner := dynamic.New(desc)
+	if err := p.message(inner); err != nil {
+		return err
+	}
+	data, err := inner.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	m.Set("type_url", url)
+	return m.Set("value", data)
+}
+
+// unknownField parses a field given by number, as printed for unknown fields,
+// into the unknown fields of m: integers as varints, 0x with 8 or 16 digits as fixed32 or fixed64,
+// strings and blocks as length prefixed values.
+func (p *parser) unknownField(m *dynamic.Message, t token) error {
+	tag, err := strconv.ParseUint(t.text, 10, 29)
+	if err != nil || tag == 0 {
+		return p.lex.errorf(t, "invalid field number %s", t.text)
+	}
+	if _, err := p.lex.accept(":"); err != nil {
+		return err
+	}
+	v, err := p.lex.peek()
+	if err != nil {
+		return err
+	}
+	if v.kind == tokPunct && (v.text == "{" || v.text == "<") {
+		inner := dynamic.New(&descriptor.Message{Name: "unknown"})
+		if err := p.message(inner); err != nil {
+			return err
+		}
+		data, _ := inner.MarshalBinary()
+		m.UnknownFields = wire.AppendBytes(wire.AppendTag(m.UnknownFields, wire.TagNum(tag), wire.TagSequence), data)
+		return nil
+	}
+	p.lex.next()
+	b := m.UnknownFields
+	switch {
+	case v.kind == tokString:
+		b = wire.AppendString(wire.AppendTag(b, wire.TagNum(tag), wire.TagSequence), v.text)
+	case v.kind == tokNumber && len(v.text) == 10 && strings.HasPrefix(v.text, "0x"):
+		n, err := strconv.ParseUint(v.text[2:], 16, 32)
+		if err != nil {
+			return p.lex.errorf(v, "invalid fixed32 %s", v.text)
+		}
+		b = wire.AppendFixed32(wire.AppendTag(b, wire.TagNum(tag), wire.Tag32bit), uint32(n))
+	case v.kind == tokNumber && len(v.text) == 18 && strings.HasPrefix(v.text, "0x"):
+		n, err := strconv.ParseUint(v.text[2:], 16, 64)
+		if err != nil {
+			return p.lex.errorf(v, "invalid fixed64 %s", v.text)
+		}
+		b = wire.AppendFixed64(wire.AppendTag(b, wire.TagNum(tag), wire.Tag64bit), n)
+	case v.kind == tokNumber:
+		n, err := strconv.ParseUint(v.text, 0, 64)
+		if err != nil {
+			return p.lex.errorf(v, "invalid varint %s", v.text)
+		}
+		b = wire.AppendVarint(wire.AppendTag(b, wire.TagNum(tag), wire.TagUvarint), n)
+	default:
+		return p.lex.errorf(v, "invalid value %q for field %s", v.text, t.text)
+	}
+	m.UnknownFields = b
+	return nil
+}
+
+// skipValue skips the value of an unknown field.
+func (p *parser) skipValue() error {
+	if _, err := p.lex.accept(":"); err != nil {
+		return err
+	}
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != tokPunct {
+		return nil
+	}
+	closing := map[string]string{"{": "}", "<": ">", "[": "]"}[t.text]
+	if closing == "" {
+		return p.lex.errorf(t, "unexpected %q", t.text)
+	}
+	for depth := 1; depth > 0; {
+		t, err := p.lex.next()
+		switch {
+		case err != nil:
+			return err
+		case t.kind == tokEOF:
+			return p.lex.errorf(t, "expected %q", closing)
+		case t.kind == tokPunct && strings.Contains("{<[", t.text):
+			depth++
+		case t.kind == tokPunct && strings.Contains("}>]", t.text):
+			depth--
+		default:
+		}
+	}
+	return nil
+}
+
+// scalar converts a token to a value of the type of f.
+func scalar(f *descriptor.Field, t token) (any, bool) {
+	switch f.Type {
+	case descriptor.TypeString:
+		return t.text, t.kind == tokString
+	case descriptor.TypeBytes:
+		return []byte(t.text), t.kind == tokString
+	case descriptor.TypeBool:
+		switch t.text {
+		case "true", "True", "t", "1":
+			return true, t.kind != tokString
+		case "false", "False", "f", "0":
+			return false, t.kind != tokString
+		default:
+		}
+		return nil, false
+	case descriptor.TypeEnum:
+		if t.kind == tokIdent && f.EnumType != nil {
+			for _, ev := range f.EnumType.Value {
+				if ev.Name == t.text {
+					return ev.Number, true
+				}
+			}
+			return nil, false
+		}
+		if t.kind != tokNumber {
+			return nil, false
+		}
+		v, err := strconv.ParseInt(t.text, 0, 32)
+		return int32(v), err == nil
+	case descriptor.TypeFloat, descriptor.TypeDouble:
+		v, ok := parseFloat(t)
+		if f.Type == descriptor.TypeFloat {
+			return float32(v), ok
+		}
+		return v, ok
+	default:
+	}
+	if t.kind != tokNumber {
+		return nil, false
+	}
+	s := t.text
+	if len(s) > 1 && s[0] == '0' && s[1] != 'x' && s[1] != 'X' || len(s) > 2 && s[:2] == "-0" && s[2] != 'x' && s[2] != 'X' {
+		s = strings.Replace(s, "0", "0o", 1) // octal
+	}
+	switch f.Type {
+	case descriptor.TypeInt32, descriptor.TypeSint32, descriptor.TypeSfixed32:
+		v, err := strconv.ParseInt(s, 0, 32)
+		return int32(v), err == nil
+	case descriptor.TypeInt64, descriptor.TypeSint64, descriptor.TypeSfixed64:
+		v, err := strconv.ParseInt(s, 0, 64)
+		return v, err == nil
+	case descriptor.TypeUint32, descriptor.TypeFixed32:
+		v, err := strconv.ParseUint(s, 0, 32)
+		return uint32(v), err == nil
+	case descriptor.TypeUint64, descriptor.TypeFixed64:
+		v, err := strconv.ParseUint(s, 0, 64)
+		return v, err == nil
+	default:
+	}
+	return nil, false
+}
+
+// parseFloat accepts decimal numbers with an optional f suffix, integers, inf, infinity and nan.
+func parseFloat(t token) (float64, bool) {
+	s := strings.ToLower(t.text)
+	neg := strings.HasPrefix(s, "-")
+	switch strings.TrimPrefix(s, "-") {
+	case "inf", "infinity":
+		if neg {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	default:
+	}
+	if t.kind != tokNumber || strings.HasPrefix(strings.TrimPrefix(s, "-"), "0x") {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "f"), 64)
+	return v, err == nil
+}
@@ -0,0 +1,312 @@
+// Package prototext converts dynamic messages to and from the protobuf text format,
+// as printed by protoc --decode and used in .textproto files.
+package prototext
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/internal/cstring"
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// MarshalOptions configure the text output,
+// the zero value prints like protoc --decode: two space indentation,
+// fields in number order followed by the unknown fields.
+type MarshalOptions struct {
+	Compact        bool                       // everything on one line
+	DiscardUnknown bool                       // leave out unknown fields
+	Resolver       *descriptor.Symbols        // expands Any messages, the well-known types are always known
+	Extensions     *dynamic.ExtensionRegistry // decodes extensions in Format, MarshalFile and Any messages
+	WellKnown      bool                       // print Timestamp, Duration and FieldMask messages as strings and wrappers as their values, like the JSON mapping
+}
+
+// Marshal prints m in the text format.
+func Marshal(m *dynamic.Message) ([]byte, error) {
+	return MarshalOptions{}.Marshal(m)
+}
+
+// Marshal prints m in the text format.
+func (o MarshalOptions) Marshal(m *dynamic.Message) ([]byte, error) {
+	p := printer{o: o}
+	if err := p.fields(m); err != nil {
+		return nil, err
+	}
+	return p.bytes(), nil
+}
+
+// Format decodes data as a message of type desc and prints it.
+func (o MarshalOptions) Format(desc *descriptor.Message, data []byte) ([]byte, error) {
+	m, err := dynamic.UnmarshalOptions{Extensions: o.Extensions}.Unmarshal(desc, data)
+	if err != nil {
+		return nil, err
+	}
+	return o.Marshal(m)
+}
+
+// MarshalFile prints a file as a google.protobuf.FileDescriptorProto.
+func (o MarshalOptions) MarshalFile(f *descriptor.File) ([]byte, error) {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return o.Format(wellknown.Message("google.protobuf.FileDescriptorProto"), data)
+}
+
+type printer struct {
+	o     MarshalOptions
+	buf   []byte
+	depth int
+}
+
+func (p *printer) bytes() []byte {
+	if p.o.Compact {
+		return []byte(strings.TrimSuffix(string(p.buf), " "))
+	}
+	return p.buf
+}
+
+// line starts a line for the next field.
+func (p *printer) line() {
+	if !p.o.Compact {
+		for range p.depth {
+			p.buf = append(p.buf, "  "...)
+		}
+	}
+}
+
+// end ends the line of a field.
+func (p *printer) end() {
+	if p.o.Compact {
+		p.buf = append(p.buf, ' ')
+	} else {
+		p.buf = append(p.buf, '\n')
+	}
+}
+
+func (p *printer) open(name string) {
+	p.line()
+	p.buf = append(p.buf, name...)
+	p.buf = append(p.buf, " {"...)
+	p.end()
+	p.depth++
+}
+
+func (p *printer) close() {
+	p.depth--
+	p.line()
+	p.buf = append(p.buf, '}')
+	p.end()
+}
+
+func (p *printer) scalar(name, v string) {
+	p.line()
+	p.buf = append(p.buf, name...)
+	p.buf = append(p.buf, ": "...)
+	p.buf = append(p.buf, v...)
+	p.end()
+}
+
+// fields prints the fields of m in number order, then its unknown fields.
+func (p *printer) fields(m *dynamic.Message) error {
+	if m.Descriptor().FullName() == "google.protobuf.Any" && p.expandAny(m) {
+		return nil
+	}
+	fields := m.Fields()
+	slices.SortFunc(fields, func(a, b *descriptor.Field) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	for _, f := range fields {
+		if !m.HasField(f) {
+			continue
+		}
+		if err := p.field(f, m.GetField(f)); err != nil {
+			return err
+		}
+	}
+	if !p.o.DiscardUnknown {
+		p.unknown(m.UnknownFields)
+	}
+	return nil
+}
+
+// name returns the text format name of f, the type name for groups.
+func name(f *descriptor.Field) string {
+	switch {
+	case f.Extendee != "":
+		return "[" + f.FullName() + "]"
+	case f.Type == descriptor.TypeGroup && f.MessageType != nil:
+		return f.MessageType.Name
+	default:
+	}
+	return f.Name
+}
+
+func (p *printer) field(f *descriptor.Field, v any) error {
+	switch {
+	case f.Map != nil:
+		entries := v.(map[any]any)
+		for _, k := range dynamic.SortedKeys(entries) {
+			p.open(name(f))
+			if err := p.value(f.Map.Key, "key", k); err != nil {
+				return err
+			}
+			if err := p.value(f.Map.Value, "value", entries[k]); err != nil {
+				return err
+			}
+			p.close()
+		}
+		return nil
+	case f.Label == descriptor.LabelRepeated:
+		for _, x := range v.([]any) {
+			if err := p.value(f, name(f), x); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+	}
+	return p.value(f, name(f), v)
+}
+
+// value prints a single value of the type of f.
+func (p *printer) value(f *descriptor.Field, name string, v any) error {
+	switch v := v.(type) {
+	case *dynamic.Message:
+		if p.o.WellKnown && p.wellKnown(name, v) {
+			return nil
+		}
+		p.open(name)
+		if err := p.fields(v); err != nil {
+			return err
+		}
+		p.close()
+		return nil
+	case int32:
+		if f.Type == descriptor.TypeEnum {
+			p.scalar(name, enumName(f, v))
+			return nil
+		}
+		p.scalar(name, strconv.FormatInt(int64(v), 10))
+	case int64:
+		p.scalar(name, strconv.FormatInt(v, 10))
+	case uint32:
+		p.scalar(name, strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		p.scalar(name, strconv.FormatUint(v, 10))
+	case float32:
+		p.scalar(name, formatFloat(float64(v), 32))
+	case float64:
+		p.scalar(name, formatFloat(v, 64))
+	case bool:
+		p.scalar(name, strconv.FormatBool(v))
+	case string:
+		p.scalar(name, cstring.Quote(v))
+	case []byte:
+		p.scalar(name, cstring.Quote(string(v)))
+	default:
+		return fmt.Errorf("prototext: unexpected value %T for field %s", v, f.Name)
+	}
+	return nil
+}
+
+func enumName(f *descriptor.Field, n int32) string {
+	if f.EnumType != nil {
+		for _, ev := range f.EnumType.Value {
+			if ev.Number == n {
+				return ev.Name
+			}
+		}
+	}
+	return strconv.FormatInt(int64(n), 10)
+}
+
+// formatFloat formats like protoc with shortest round trip precision, inf and nan.
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	default:
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// unknown prints raw fields by number, embedded data that parses as a message as such.
+func (p *printer) unknown(data []byte) {
+	for r, err := range wire.Fields(data) {
+		if err != nil {
+			p.scalar("#", "malformed "+err.Error())
+			return
+		}
+		tag := strconv.FormatUint(uint64(r.Tag), 10)
+		switch r.Kind {
+		case wire.TagUvarint:
+			p.scalar(tag, strconv.FormatUint(r.Value, 10))
+		case wire.Tag32bit:
+			p.scalar(tag, fmt.Sprintf("0x%08x", r.Value))
+		case wire.Tag64bit:
+			p.scalar(tag, fmt.Sprintf("0x%016x", r.Value))
+		case wire.TagStart:
+			p.open(tag)
+			p.unknown(r.Bytes)
+			p.close()
+		default:
+			if len(r.Bytes) > 0 && isMessage(r.Bytes) {
+				p.open(tag)
+				p.unknown(r.Bytes)
+				p.close()
+			} else {
+				p.scalar(tag, cstring.Quote(string(r.Bytes)))
+			}
+		}
+	}
+}
+
+func isMessage(b []byte) bool {
+	for _, err := range wire.Fields(b) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// expandAny prints an Any with a resolvable type as [type_url] { ... }.
+func (p *printer) expandAny(m *dynamic.Message) bool {
+	url, _ := m.Get("type_url").(string)
+	data, _ := m.Get("value").([]byte)
+	name := url[strings.LastIndexByte(url, '/')+1:]
+	var desc *descriptor.Message
+	if p.o.Resolver != nil {
+		desc = p.o.Resolver.Message(name)
+	}
+	if desc == nil {
+		desc = wellknown.Message(name)
+	}
+	if desc == nil || len(m.UnknownFields) > 0 {
+		return false
+	}
+	inner, err := dynamic.UnmarshalOptions{Extensions: p.o.Extensions}.Unmarshal(desc, data)
+	if err != nil {
+		return false
+	}
+	mark := len(p.buf)
+	p.open("[" + url + "]")
+	if err := p.fields(inner); err != nil {
+		p.buf, p.depth = p.buf[:mark], p.depth-1
+		return false
+	}
+	p.close()
+	return true
+}
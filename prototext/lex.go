@@ -0,0 +1,199 @@
+package prototext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defsrc/proton/internal/cstring"
+)
+
+// A SyntaxError reports invalid text format input.
+type SyntaxError struct {
+	Line, Column int // 1 based
+	Msg          string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("prototext: %d:%d: %s", err.Line, err.Column, err.Msg)
+}
+
+type tokenKind uint8
+
+const (
+	tokEOF    tokenKind = iota
+	tokIdent            // identifiers, also true, inf and the like
+	tokNumber           // including a leading minus sign
+	tokString           // the unescaped value of adjacent quoted strings
+	tokPunct            // one of { } < > [ ] : , ; / .
+)
+
+type token struct {
+	kind      tokenKind
+	text      string
+	line, col int
+}
+
+// lexer splits text format input into tokens, skipping whitespace and # comments.
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+	peeked    *token
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+func (l *lexer) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{Line: t.line, Column: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) peek() (token, error) {
+	if l.peeked == nil {
+		t, err := l.scan()
+		if err != nil {
+			return t, err
+		}
+		l.peeked = &t
+	}
+	return *l.peeked, nil
+}
+
+func (l *lexer) next() (token, error) {
+	t, err := l.peek()
+	l.peeked = nil
+	return t, err
+}
+
+// accept consumes the next token if it is the punctuation p.
+func (l *lexer) accept(p string) (bool, error) {
+	t, err := l.peek()
+	if err != nil || t.kind != tokPunct || t.text != p {
+		return false, err
+	}
+	l.peeked = nil
+	return true, nil
+}
+
+// expect consumes the punctuation p or fails.
+func (l *lexer) expect(p string) error {
+	t, err := l.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != tokPunct || t.text != p {
+		return l.errorf(t, "expected %q, found %q", p, t.text)
+	}
+	return nil
+}
+
+func (l *lexer) advance(n int) {
+	for _, c := range l.src[l.pos : l.pos+n] {
+		if c == '\n' {
+			l.line, l.col = l.line+1, 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '#':
+			n := strings.IndexByte(l.src[l.pos:], '\n')
+			if n < 0 {
+				n = len(l.src) - l.pos
+			}
+			l.advance(n)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f':
+			l.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) scan() (token, error) {
+	l.skipSpace()
+	t := token{line: l.line, col: l.col}
+	if l.pos == len(l.src) {
+		return t, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		return l.scanStrings(t)
+	case isIdentStart(c):
+		n := 1
+		for l.pos+n < len(l.src) && isIdent(l.src[l.pos+n]) {
+			n++
+		}
+		t.kind, t.text = tokIdent, l.src[l.pos:l.pos+n]
+	case '0' <= c && c <= '9' || c == '-' || c == '.' && l.pos+1 < len(l.src) && '0' <= l.src[l.pos+1] && l.src[l.pos+1] <= '9':
+		n := 1
+		for l.pos+n < len(l.src) && (isIdent(l.src[l.pos+n]) || l.src[l.pos+n] == '.' ||
+			(l.src[l.pos+n] == '-' || l.src[l.pos+n] == '+') && (l.src[l.pos+n-1] == 'e' || l.src[l.pos+n-1] == 'E')) {
+			n++
+		}
+		t.kind, t.text = tokNumber, l.src[l.pos:l.pos+n]
+		if t.text == "-" {
+			// a minus sign followed by space or an identifier like inf
+			l.advance(1)
+			next, err := l.scan()
+			if err != nil {
+				return next, err
+			}
+			if next.kind != tokIdent && next.kind != tokNumber {
+				return t, l.errorf(t, "unexpected %q", "-")
+			}
+			t.kind, t.text = next.kind, "-"+next.text
+			return t, nil
+		}
+	case strings.IndexByte("{}<>[]:,;/.", c) >= 0:
+		t.kind, t.text = tokPunct, l.src[l.pos:l.pos+1]
+	default:
+		return t, l.errorf(t, "unexpected character %q", c)
+	}
+	l.advance(len(t.text))
+	return t, nil
+}
+
+// scanStrings reads adjacent quoted strings as one value.
+func (l *lexer) scanStrings(t token) (token, error) {
+	var b []byte
+	for l.pos < len(l.src) && (l.src[l.pos] == '"' || l.src[l.pos] == '\'') {
+		q := l.src[l.pos]
+		i := l.pos + 1
+		for ; i < len(l.src) && l.src[i] != q; i++ {
+			if l.src[i] == '\n' {
+				return t, l.errorf(t, "newline in string")
+			}
+			if l.src[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(l.src) {
+			return t, l.errorf(t, "unterminated string")
+		}
+		s, ok := cstring.Unescape(l.src[l.pos+1 : i])
+		if !ok {
+			return t, l.errorf(t, "invalid escape in string")
+		}
+		b = append(b, s...)
+		l.advance(i + 1 - l.pos)
+		l.skipSpace()
+	}
+	t.kind, t.text = tokString, string(b)
+	return t, nil
+}
+
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,114 @@
+package prototext
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defsrc/proton/dynamic"
+	"github.com/defsrc/proton/internal/cstring"
+	"github.com/defsrc/proton/internal/timefmt"
+)
+
+// wellKnown prints a Timestamp, Duration, FieldMask or wrapper message as a single value,
+// false for the other messages and the ones out of range or with unknown fields.
+// FieldMask paths keep their .proto names, unlike in JSON.
+func (p *printer) wellKnown(name string, m *dynamic.Message) bool {
+	if len(m.UnknownFields) > 0 {
+		return false
+	}
+	if v, ok := m.Wrapped(); ok {
+		return p.value(m.FieldByName("value"), name, v) == nil
+	}
+	switch m.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		t, err := m.Time()
+		if err != nil {
+			return false
+		}
+		p.scalar(name, cstring.Quote(t.Format("2006-01-02T15:04:05")+timefmt.Fraction(int32(t.Nanosecond()))+"Z"))
+	case "google.protobuf.Duration":
+		d, err := m.Duration()
+		if err != nil {
+			return false
+		}
+		p.scalar(name, cstring.Quote(formatDuration(d)))
+	case "google.protobuf.FieldMask":
+		var paths []string
+		for _, x := range m.Get("paths").([]any) {
+			if strings.Contains(x.(string), ",") {
+				return false
+			}
+			paths = append(paths, x.(string))
+		}
+		p.scalar(name, cstring.Quote(strings.Join(paths, ",")))
+	default:
+		return false
+	}
+	return true
+}
+
+// formatDuration formats d in seconds like the JSON mapping, "3.500s".
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%d%ss", sign, d/time.Second, timefmt.Fraction(int32(d%time.Second)))
+}
+
+// parseWellKnown parses the single value form of a Timestamp, Duration, FieldMask or wrapper message printed
+// with MarshalOptions.WellKnown into m, false for the other messages.
+func (p *parser) parseWellKnown(m *dynamic.Message) (bool, error) {
+	_, wrapper := m.Wrapped()
+	switch m.Descriptor().FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+	default:
+		if !wrapper {
+			return false, nil
+		}
+	}
+	t, err := p.lex.next()
+	if err != nil {
+		return true, err
+	}
+	if wrapper {
+		f := m.FieldByName("value")
+		v, ok := scalar(f, t)
+		if !ok {
+			return true, p.lex.errorf(t, "invalid value %q for %s", t.text, m.Descriptor().FullName())
+		}
+		return true, m.SetField(f, v)
+	}
+	if t.kind != tokString {
+		return true, p.lex.errorf(t, "expected a string or a message for %s, found %q", m.Descriptor().FullName(), t.text)
+	}
+	switch m.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		v, err := time.Parse(time.RFC3339Nano, t.text)
+		if err == nil {
+			err = m.SetTime(v)
+		}
+		if err != nil {
+			return true, p.lex.errorf(t, "invalid RFC 3339 time %q", t.text)
+		}
+	case "google.protobuf.Duration":
+		v, err := time.ParseDuration(t.text)
+		if err == nil {
+			err = m.SetDuration(v)
+		}
+		if err != nil {
+			return true, p.lex.errorf(t, "invalid duration %q", t.text)
+		}
+	default:
+		if t.text == "" {
+			return true, nil
+		}
+		for _, path := range strings.Split(t.text, ",") {
+			if err := m.Add("paths", path); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,284 @@
+package wellknown
+
+import (
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+// descriptorProto returns google/protobuf/descriptor.proto,
+// without FeatureSetDefaults which only protoc itself consumes.
+func descriptorProto() *descriptor.File {
+	const (
+		tBool    = descriptor.TypeBool
+		tBytes   = descriptor.TypeBytes
+		tDouble  = descriptor.TypeDouble
+		tEnum    = descriptor.TypeEnum
+		tInt32   = descriptor.TypeInt32
+		tInt64   = descriptor.TypeInt64
+		tMessage = descriptor.TypeMessage
+		tString  = descriptor.TypeString
+		tUint64  = descriptor.TypeUint64
+	)
+	features := func(num wire.TagNum) *descriptor.Field {
+		return field("features", num, tMessage, "FeatureSet")
+	}
+	uninterpreted := repeated("uninterpreted_option", 999, tMessage, "UninterpretedOption")
+	deprecated := func(num wire.TagNum) *descriptor.Field {
+		return withDefault(field("deprecated", num, tBool, ""), "false")
+	}
+
+	edition := &descriptor.Enum{Name: "Edition"}
+	for _, v := range []struct {
+		name string
+		num  int32
+	}{
+		{"EDITION_UNKNOWN", 0},
+		{"EDITION_LEGACY", 900},
+		{"EDITION_PROTO2", 998},
+		{"EDITION_PROTO3", 999},
+		{"EDITION_2023", 1000},
+		{"EDITION_2024", 1001},
+		{"EDITION_1_TEST_ONLY", 1},
+		{"EDITION_2_TEST_ONLY", 2},
+		{"EDITION_99997_TEST_ONLY", 99997},
+		{"EDITION_99998_TEST_ONLY", 99998},
+		{"EDITION_99999_TEST_ONLY", 99999},
+		{"EDITION_MAX", 0x7fffffff},
+	} {
+		edition.Value = append(edition.Value, &descriptor.EnumValue{Name: v.name, Number: v.num})
+	}
+
+	fieldType := enum("Type", 1,
+		"TYPE_DOUBLE", "TYPE_FLOAT", "TYPE_INT64", "TYPE_UINT64", "TYPE_INT32", "TYPE_FIXED64",
+		"TYPE_FIXED32", "TYPE_BOOL", "TYPE_STRING", "TYPE_GROUP", "TYPE_MESSAGE", "TYPE_BYTES",
+		"TYPE_UINT32", "TYPE_ENUM", "TYPE_SFIXED32", "TYPE_SFIXED64", "TYPE_SINT32", "TYPE_SINT64")
+	fieldLabel := enum("Label", 1, "LABEL_OPTIONAL", "LABEL_REQUIRED", "LABEL_REPEATED")
+
+	msgs := []*descriptor.Message{
+		message("FileDescriptorSet",
+			repeated("file", 1, tMessage, "FileDescriptorProto")),
+		message("FileDescriptorProto",
+			field("name", 1, tString, ""),
+			field("package", 2, tString, ""),
+			repeated("dependency", 3, tString, ""),
+			repeated("public_dependency", 10, tInt32, ""),
+			repeated("weak_dependency", 11, tInt32, ""),
+			repeated("message_type", 4, tMessage, "DescriptorProto"),
+			repeated("enum_type", 5, tMessage, "EnumDescriptorProto"),
+			repeated("service", 6, tMessage, "ServiceDescriptorProto"),
+			repeated("extension", 7, tMessage, "FieldDescriptorProto"),
+			field("options", 8, tMessage, "FileOptions"),
+			field("source_code_info", 9, tMessage, "SourceCodeInfo"),
+			field("syntax", 12, tString, ""),
+			field("edition", 14, tEnum, "Edition")),
+		nested(message("DescriptorProto",
+			field("name", 1, tString, ""),
+			repeated("field", 2, tMessage, "FieldDescriptorProto"),
+			repeated("extension", 6, tMessage, "FieldDescriptorProto"),
+			repeated("nested_type", 3, tMessage, "DescriptorProto"),
+			repeated("enum_type", 4, tMessage, "EnumDescriptorProto"),
+			repeated("extension_range", 5, tMessage, "DescriptorProto.ExtensionRange"),
+			repeated("oneof_decl", 8, tMessage, "OneofDescriptorProto"),
+			field("options", 7, tMessage, "MessageOptions"),
+			repeated("reserved_range", 9, tMessage, "DescriptorProto.ReservedRange"),
+			repeated("reserved_name", 10, tString, "")),
+			message("ExtensionRange",
+				field("start", 1, tInt32, ""),
+				field("end", 2, tInt32, ""),
+				field("options", 3, tMessage, "ExtensionRangeOptions")),
+			message("ReservedRange",
+				field("start", 1, tInt32, ""),
+				field("end", 2, tInt32, ""))),
+		extensible(enums(nested(message("ExtensionRangeOptions",
+			uninterpreted,
+			repeated("declaration", 2, tMessage, "ExtensionRangeOptions.Declaration"),
+			features(50),
+			withDefault(field("verification", 3, tEnum, "ExtensionRangeOptions.VerificationState"), "UNVERIFIED")),
+			message("Declaration",
+				field("number", 1, tInt32, ""),
+				field("full_name", 2, tString, ""),
+				field("type", 3, tString, ""),
+				field("reserved", 5, tBool, ""),
+				field("repeated", 6, tBool, ""))),
+			enum("VerificationState", 0, "DECLARATION", "UNVERIFIED"))),
+		enums(message("FieldDescriptorProto",
+			field("name", 1, tString, ""),
+			field("number", 3, tInt32, ""),
+			field("label", 4, tEnum, "FieldDescriptorProto.Label"),
+			field("type", 5, tEnum, "FieldDescriptorProto.Type"),
+			field("type_name", 6, tString, ""),
+			field("extendee", 2, tString, ""),
+			field("default_value", 7, tString, ""),
+			field("oneof_index", 9, tInt32, ""),
+			field("json_name", 10, tString, ""),
+			field("options", 8, tMessage, "FieldOptions"),
+			field("proto3_optional", 17, tBool, "")),
+			fieldType, fieldLabel),
+		message("OneofDescriptorProto",
+			field("name", 1, tString, ""),
+			field("options", 2, tMessage, "OneofOptions")),
+		nested(message("EnumDescriptorProto",
+			field("name", 1, tString, ""),
+			repeated("value", 2, tMessage, "EnumValueDescriptorProto"),
+			field("options", 3, tMessage, "EnumOptions"),
+			repeated("reserved_range", 4, tMessage, "EnumDescriptorProto.EnumReservedRange"),
+			repeated("reserved_name", 5, tString, "")),
+			message("EnumReservedRange",
+				field("start", 1, tInt32, ""),
+				field("end", 2, tInt32, ""))),
+		message("EnumValueDescriptorProto",
+			field("name", 1, tString, ""),
+			field("number", 2, tInt32, ""),
+			field("options", 3, tMessage, "EnumValueOptions")),
+		message("ServiceDescriptorProto",
+			field("name", 1, tString, ""),
+			repeated("method", 2, tMessage, "MethodDescriptorProto"),
+			field("options", 3, tMessage, "ServiceOptions")),
+		message("MethodDescriptorProto",
+			field("name", 1, tString, ""),
+			field("input_type", 2, tString, ""),
+			field("output_type", 3, tString, ""),
+			field("options", 4, tMessage, "MethodOptions"),
+			withDefault(field("client_streaming", 5, tBool, ""), "false"),
+			withDefault(field("server_streaming", 6, tBool, ""), "false")),
+		extensible(enums(message("FileOptions",
+			field("java_package", 1, tString, ""),
+			field("java_outer_classname", 8, tString, ""),
+			withDefault(field("java_multiple_files", 10, tBool, ""), "false"),
+			field("java_generate_equals_and_hash", 20, tBool, ""),
+			withDefault(field("java_string_check_utf8", 27, tBool, ""), "false"),
+			withDefault(field("optimize_for", 9, tEnum, "FileOptions.OptimizeMode"), "SPEED"),
+			field("go_package", 11, tString, ""),
+			withDefault(field("cc_generic_services", 16, tBool, ""), "false"),
+			withDefault(field("java_generic_services", 17, tBool, ""), "false"),
+			withDefault(field("py_generic_services", 18, tBool, ""), "false"),
+			deprecated(23),
+			withDefault(field("cc_enable_arenas", 31, tBool, ""), "true"),
+			field("objc_class_prefix", 36, tString, ""),
+			field("csharp_namespace", 37, tString, ""),
+			field("swift_prefix", 39, tString, ""),
+			field("php_class_prefix", 40, tString, ""),
+			field("php_namespace", 41, tString, ""),
+			field("php_metadata_namespace", 44, tString, ""),
+			field("ruby_package", 45, tString, ""),
+			features(50),
+			uninterpreted),
+			enum("OptimizeMode", 1, "SPEED", "CODE_SIZE", "LITE_RUNTIME"))),
+		extensible(message("MessageOptions",
+			withDefault(field("message_set_wire_format", 1, tBool, ""), "false"),
+			withDefault(field("no_standard_descriptor_accessor", 2, tBool, ""), "false"),
+			deprecated(3),
+			field("map_entry", 7, tBool, ""),
+			field("deprecated_legacy_json_field_conflicts", 11, tBool, ""),
+			features(12),
+			uninterpreted)),
+		extensible(enums(nested(message("FieldOptions",
+			withDefault(field("ctype", 1, tEnum, "FieldOptions.CType"), "STRING"),
+			field("packed", 2, tBool, ""),
+			withDefault(field("jstype", 6, tEnum, "FieldOptions.JSType"), "JS_NORMAL"),
+			withDefault(field("lazy", 5, tBool, ""), "false"),
+			withDefault(field("unverified_lazy", 15, tBool, ""), "false"),
+			deprecated(3),
+			withDefault(field("weak", 10, tBool, ""), "false"),
+			withDefault(field("debug_redact", 16, tBool, ""), "false"),
+			field("retention", 17, tEnum, "FieldOptions.OptionRetention"),
+			repeated("targets", 19, tEnum, "FieldOptions.OptionTargetType"),
+			repeated("edition_defaults", 20, tMessage, "FieldOptions.EditionDefault"),
+			features(21),
+			field("feature_support", 22, tMessage, "FieldOptions.FeatureSupport"),
+			uninterpreted),
+			message("EditionDefault",
+				field("edition", 3, tEnum, "Edition"),
+				field("value", 2, tString, "")),
+			message("FeatureSupport",
+				field("edition_introduced", 1, tEnum, "Edition"),
+				field("edition_deprecated", 2, tEnum, "Edition"),
+				field("deprecation_warning", 3, tString, ""),
+				field("edition_removed", 4, tEnum, "Edition"))),
+			enum("CType", 0, "STRING", "CORD", "STRING_PIECE"),
+			enum("JSType", 0, "JS_NORMAL", "JS_STRING", "JS_NUMBER"),
+			enum("OptionRetention", 0, "RETENTION_UNKNOWN", "RETENTION_RUNTIME", "RETENTION_SOURCE"),
+			enum("OptionTargetType", 0, "TARGET_TYPE_UNKNOWN", "TARGET_TYPE_FILE", "TARGET_TYPE_EXTENSION_RANGE",
+				"TARGET_TYPE_MESSAGE", "TARGET_TYPE_FIELD", "TARGET_TYPE_ONEOF", "TARGET_TYPE_ENUM",
+				"TARGET_TYPE_ENUM_ENTRY", "TARGET_TYPE_SERVICE", "TARGET_TYPE_METHOD"))),
+		extensible(message("OneofOptions",
+			features(1),
+			uninterpreted)),
+		extensible(message("EnumOptions",
+			field("allow_alias", 2, tBool, ""),
+			deprecated(3),
+			field("deprecated_legacy_json_field_conflicts", 6, tBool, ""),
+			features(7),
+			uninterpreted)),
+		extensible(message("EnumValueOptions",
+			deprecated(1),
+			features(2),
+			withDefault(field("debug_redact", 3, tBool, ""), "false"),
+			field("feature_support", 4, tMessage, "FieldOptions.FeatureSupport"),
+			uninterpreted)),
+		extensible(message("ServiceOptions",
+			features(34),
+			deprecated(33),
+			uninterpreted)),
+		extensible(enums(message("MethodOptions",
+			deprecated(33),
+			withDefault(field("idempotency_level", 34, tEnum, "MethodOptions.IdempotencyLevel"), "IDEMPOTENCY_UNKNOWN"),
+			features(35),
+			uninterpreted),
+			enum("IdempotencyLevel", 0, "IDEMPOTENCY_UNKNOWN", "NO_SIDE_EFFECTS", "IDEMPOTENT"))),
+		nested(message("UninterpretedOption",
+			repeated("name", 2, tMessage, "UninterpretedOption.NamePart"),
+			field("identifier_value", 3, tString, ""),
+			field("positive_int_value", 4, tUint64, ""),
+			field("negative_int_value", 5, tInt64, ""),
+			field("double_value", 6, tDouble, ""),
+			field("string_value", 7, tBytes, ""),
+			field("aggregate_value", 8, tString, "")),
+			message("NamePart",
+				required("name_part", 1, tString, ""),
+				required("is_extension", 2, tBool, ""))),
+		extensible(enums(message("FeatureSet",
+			field("field_presence", 1, tEnum, "FeatureSet.FieldPresence"),
+			field("enum_type", 2, tEnum, "FeatureSet.EnumType"),
+			field("repeated_field_encoding", 3, tEnum, "FeatureSet.RepeatedFieldEncoding"),
+			field("utf8_validation", 4, tEnum, "FeatureSet.Utf8Validation"),
+			field("message_encoding", 5, tEnum, "FeatureSet.MessageEncoding"),
+			field("json_format", 6, tEnum, "FeatureSet.JsonFormat")),
+			enum("FieldPresence", 0, "FIELD_PRESENCE_UNKNOWN", "EXPLICIT", "IMPLICIT", "LEGACY_REQUIRED"),
+			enum("EnumType", 0, "ENUM_TYPE_UNKNOWN", "OPEN", "CLOSED"),
+			enum("RepeatedFieldEncoding", 0, "REPEATED_FIELD_ENCODING_UNKNOWN", "PACKED", "EXPANDED"),
+			&descriptor.Enum{Name: "Utf8Validation", Value: []*descriptor.EnumValue{
+				{Name: "UTF8_VALIDATION_UNKNOWN", Number: 0},
+				{Name: "VERIFY", Number: 2},
+				{Name: "NONE", Number: 3},
+			}},
+			enum("MessageEncoding", 0, "MESSAGE_ENCODING_UNKNOWN", "LENGTH_PREFIXED", "DELIMITED"),
+			enum("JsonFormat", 0, "JSON_FORMAT_UNKNOWN", "ALLOW", "LEGACY_BEST_EFFORT"))),
+		nested(message("SourceCodeInfo",
+			repeated("location", 1, tMessage, "SourceCodeInfo.Location")),
+			message("Location",
+				packed(repeated("path", 1, tInt32, "")),
+				packed(repeated("span", 2, tInt32, "")),
+				field("leading_comments", 3, tString, ""),
+				field("trailing_comments", 4, tString, ""),
+				repeated("leading_detached_comments", 6, tString, ""))),
+		nested(message("GeneratedCodeInfo",
+			repeated("annotation", 1, tMessage, "GeneratedCodeInfo.Annotation")),
+			enums(message("Annotation",
+				packed(repeated("path", 1, tInt32, "")),
+				field("source_file", 2, tString, ""),
+				field("begin", 3, tInt32, ""),
+				field("end", 4, tInt32, ""),
+				field("semantic", 5, tEnum, "GeneratedCodeInfo.Annotation.Semantic")),
+				enum("Semantic", 0, "NONE", "SET", "ALIAS"))),
+	}
+
+	f := file("google/protobuf/descriptor.proto", "google.golang.org/protobuf/types/descriptorpb", nil, msgs, edition)
+	f.Options.JavaOuterClassname = "DescriptorProtos"
+	f.Options.JavaMultipleFiles = false
+	f.Options.OptimizeFor = 1
+	f.Options.CsharpNamespace = "Google.Protobuf.Reflection"
+	arenas := true
+	f.Options.CcEnableArenas = &arenas
+	return f
+}
@@ -0,0 +1,68 @@
+package wellknown
+
+import "github.com/defsrc/proton/descriptor"
+
+func definitions() []*descriptor.File {
+	return []*descriptor.File{
+		file("google/protobuf/any.proto", "google.golang.org/protobuf/types/known/anypb", nil, []*descriptor.Message{
+			message("Any",
+				field("type_url", 1, descriptor.TypeString, ""),
+				field("value", 2, descriptor.TypeBytes, "")),
+		}),
+		file("google/protobuf/duration.proto", "google.golang.org/protobuf/types/known/durationpb", nil, []*descriptor.Message{
+			message("Duration",
+				field("seconds", 1, descriptor.TypeInt64, ""),
+				field("nanos", 2, descriptor.TypeInt32, "")),
+		}),
+		file("google/protobuf/empty.proto", "google.golang.org/protobuf/types/known/emptypb", nil, []*descriptor.Message{
+			message("Empty"),
+		}),
+		file("google/protobuf/field_mask.proto", "google.golang.org/protobuf/types/known/fieldmaskpb", nil, []*descriptor.Message{
+			message("FieldMask",
+				repeated("paths", 1, descriptor.TypeString, "")),
+		}),
+		file("google/protobuf/source_context.proto", "google.golang.org/protobuf/types/known/sourcecontextpb", nil, []*descriptor.Message{
+			message("SourceContext",
+				field("file_name", 1, descriptor.TypeString, "")),
+		}),
+		structProto(),
+		file("google/protobuf/timestamp.proto", "google.golang.org/protobuf/types/known/timestamppb", nil, []*descriptor.Message{
+			message("Timestamp",
+				field("seconds", 1, descriptor.TypeInt64, ""),
+				field("nanos", 2, descriptor.TypeInt32, "")),
+		}),
+		file("google/protobuf/wrappers.proto", "google.golang.org/protobuf/types/known/wrapperspb", nil, []*descriptor.Message{
+			message("DoubleValue", field("value", 1, descriptor.TypeDouble, "")),
+			message("FloatValue", field("value", 1, descriptor.TypeFloat, "")),
+			message("Int64Value", field("value", 1, descriptor.TypeInt64, "")),
+			message("UInt64Value", field("value", 1, descriptor.TypeUint64, "")),
+			message("Int32Value", field("value", 1, descriptor.TypeInt32, "")),
+			message("UInt32Value", field("value", 1, descriptor.TypeUint32, "")),
+			message("BoolValue", field("value", 1, descriptor.TypeBool, "")),
+			message("StringValue", field("value", 1, descriptor.TypeString, "")),
+			message("BytesValue", field("value", 1, descriptor.TypeBytes, "")),
+		}),
+		descriptorProto(),
+	}
+}
+
+func structProto() *descriptor.File {
+	st := nested(message("Struct",
+		repeated("fields", 1, descriptor.TypeMessage, "Struct.FieldsEntry")),
+		mapEntry("FieldsEntry",
+			field("key", 1, descriptor.TypeString, ""),
+			field("value", 2, descriptor.TypeMessage, "Value")))
+	value := message("Value",
+		inOneOf(0, field("null_value", 1, descriptor.TypeEnum, "NullValue")),
+		inOneOf(0, field("number_value", 2, descriptor.TypeDouble, "")),
+		inOneOf(0, field("string_value", 3, descriptor.TypeString, "")),
+		inOneOf(0, field("bool_value", 4, descriptor.TypeBool, "")),
+		inOneOf(0, field("struct_value", 5, descriptor.TypeMessage, "Struct")),
+		inOneOf(0, field("list_value", 6, descriptor.TypeMessage, "ListValue")))
+	value.OneOf = []*descriptor.OneOf{{Name: "kind"}}
+	list := message("ListValue",
+		repeated("values", 1, descriptor.TypeMessage, "Value"))
+	return file("google/protobuf/struct.proto", "google.golang.org/protobuf/types/known/structpb", nil,
+		[]*descriptor.Message{st, value, list},
+		enum("NullValue", 0, "NULL_VALUE"))
+}
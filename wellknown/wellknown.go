@@ -0,0 +1,191 @@
+// Package wellknown provides the descriptors of the google.protobuf well-known types
+// and of descriptor.proto itself, so descriptor sets compiled without --include_imports
+// still resolve and their payloads can be decoded without a schema at hand.
+//
+// Covered are any, duration, empty, field_mask, source_context, struct, timestamp,
+// wrappers and descriptor.proto, type.proto and api.proto are not.
+package wellknown
+
+import (
+	"sync"
+
+	"github.com/defsrc/proton/descriptor"
+	"github.com/defsrc/proton/wire"
+)
+
+var (
+	once    sync.Once
+	set     []byte
+	files   []*descriptor.File
+	symbols *descriptor.Symbols
+)
+
+// load encodes the definitions and parses them back,
+// the parser fills in what the literals leave out: map links, parents, presence.
+func load() {
+	once.Do(func() {
+		var err error
+		set, err = descriptor.Marshal(definitions())
+		if err == nil {
+			files, err = descriptor.Parse(set)
+		}
+		if err == nil {
+			symbols, err = descriptor.Link(files)
+		}
+		if err != nil {
+			panic("wellknown: " + err.Error())
+		}
+	})
+}
+
+// Set returns the well-known files as an encoded FileDescriptorSet.
+// The caller must not modify it.
+func Set() []byte {
+	load()
+	return set
+}
+
+// Files returns the parsed and linked well-known files, shared by all callers.
+func Files() []*descriptor.File {
+	load()
+	return files
+}
+
+// Symbols returns the index of the linked well-known files.
+func Symbols() *descriptor.Symbols {
+	load()
+	return symbols
+}
+
+// Message returns the well-known message with the fully qualified name, like "google.protobuf.Any", or nil.
+func Message(name string) *descriptor.Message {
+	return Symbols().Message(name)
+}
+
+// File returns the well-known file with the given name, like "google/protobuf/any.proto", or nil.
+func File(name string) *descriptor.File {
+	for _, f := range Files() {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Complete returns files with the well-known files they import but lack prepended.
+func Complete(files []*descriptor.File) []*descriptor.File {
+	have := map[string]bool{}
+	for _, f := range files {
+		have[f.Name] = true
+	}
+	var need []*descriptor.File
+	g := descriptor.NewGraph(append(append([]*descriptor.File(nil), Files()...), files...))
+	for _, m := range descriptor.NewGraph(files).Missing() {
+		if have[m.Dependency] || File(m.Dependency) == nil {
+			continue
+		}
+		closure, _ := g.Closure(m.Dependency)
+		for _, f := range closure {
+			if !have[f.Name] {
+				have[f.Name] = true
+				need = append(need, f)
+			}
+		}
+	}
+	return append(need, files...)
+}
+
+// builders for the definitions
+
+func file(name, goPackage string, deps []string, msgs []*descriptor.Message, enums ...*descriptor.Enum) *descriptor.File {
+	f := &descriptor.File{
+		Name:       name,
+		Package:    "google.protobuf",
+		Dependency: deps,
+		Message:    msgs,
+		Enum:       enums,
+		Options: &descriptor.FileOptions{
+			JavaPackage:       "com.google.protobuf",
+			JavaMultipleFiles: true,
+			GoPackage:         goPackage,
+			ObjcClassPrefix:   "GPB",
+			CsharpNamespace:   "Google.Protobuf.WellKnownTypes",
+		},
+	}
+	if name != "google/protobuf/descriptor.proto" {
+		f.Syntax = descriptor.SyntaxProto3
+	}
+	return f
+}
+
+func message(name string, fields ...*descriptor.Field) *descriptor.Message {
+	return &descriptor.Message{Name: name, Field: fields}
+}
+
+// field returns an optional field, typeName is relative to google.protobuf.
+func field(name string, num wire.TagNum, typ uint8, typeName string) *descriptor.Field {
+	f := &descriptor.Field{Name: name, Tag: num, Label: descriptor.LabelOptional, Type: typ, JsonName: descriptor.JSONName(name)}
+	if typeName != "" {
+		f.TypeName = ".google.protobuf." + typeName
+	}
+	return f
+}
+
+func repeated(name string, num wire.TagNum, typ uint8, typeName string) *descriptor.Field {
+	f := field(name, num, typ, typeName)
+	f.Label = descriptor.LabelRepeated
+	return f
+}
+
+func required(name string, num wire.TagNum, typ uint8, typeName string) *descriptor.Field {
+	f := field(name, num, typ, typeName)
+	f.Label = descriptor.LabelRequired
+	return f
+}
+
+func withDefault(f *descriptor.Field, v string) *descriptor.Field {
+	f.DefaultValue = v
+	return f
+}
+
+func packed(f *descriptor.Field) *descriptor.Field {
+	t := true
+	f.Options = &descriptor.FieldOptions{Packed: &t}
+	return f
+}
+
+func inOneOf(i int32, f *descriptor.Field) *descriptor.Field {
+	f.OneOfIndex = &i
+	return f
+}
+
+// enum returns an enum with the values numbered in order from first.
+func enum(name string, first int32, values ...string) *descriptor.Enum {
+	en := &descriptor.Enum{Name: name}
+	for i, v := range values {
+		en.Value = append(en.Value, &descriptor.EnumValue{Name: v, Number: first + int32(i)})
+	}
+	return en
+}
+
+func nested(m *descriptor.Message, msgs ...*descriptor.Message) *descriptor.Message {
+	m.Nested = append(m.Nested, msgs...)
+	return m
+}
+
+func enums(m *descriptor.Message, en ...*descriptor.Enum) *descriptor.Message {
+	m.Enum = append(m.Enum, en...)
+	return m
+}
+
+// extensible adds the usual options extension range 1000 to max.
+func extensible(m *descriptor.Message) *descriptor.Message {
+	m.ExtensionRange = append(m.ExtensionRange, &descriptor.ExtensionRange{Start: 1000, End: 536870912})
+	return m
+}
+
+func mapEntry(name string, key, value *descriptor.Field) *descriptor.Message {
+	m := message(name, key, value)
+	m.Options = &descriptor.MessageOptions{MapEntry: true}
+	return m
+}
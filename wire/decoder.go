@@ -0,0 +1,304 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// A Decoder reads the wire encoding from an io.Reader.
+//
+// Unlike ReadNext it does not need the whole message in memory,
+// embedded messages can be read through a nested Decoder from Embedded.
+type Decoder struct {
+	// MaxLength limits length prefixed values, 0 means the package MaxLength.
+	// It bounds the allocation of ReadBytes for untrusted input.
+	MaxLength int64
+
+	src *source
+	end int64 // offset after the last readable byte, -1 if unlimited
+}
+
+type source struct {
+	r   *bufio.Reader
+	off int64
+	tag TagNum // of the last key read
+	rec []byte // copy of the bytes read while recording a group
+	on  bool   // recording
+}
+
+// NewDecoder returns a Decoder reading from r.
+// The Decoder buffers and may read more than needed from r.
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Decoder{src: &source{r: br}, end: -1}
+}
+
+// Offset returns the number of bytes consumed from the underlying reader.
+func (d *Decoder) Offset() int64 {
+	return d.src.off
+}
+
+// remaining returns the number of bytes left, or -1 if unlimited.
+func (d *Decoder) remaining() int64 {
+	if d.end < 0 {
+		return -1
+	}
+	return d.end - d.src.off
+}
+
+// ReadByte implements io.ByteReader, it respects the limit of an embedded Decoder.
+func (d *Decoder) ReadByte() (byte, error) {
+	if d.remaining() == 0 {
+		return 0, io.EOF
+	}
+	c, err := d.src.r.ReadByte()
+	if err == nil {
+		d.src.off++
+		if d.src.on {
+			d.src.rec = append(d.src.rec, c)
+		}
+	}
+	return c, err
+}
+
+// read reads exactly len(b) bytes.
+func (d *Decoder) read(b []byte) error {
+	if r := d.remaining(); r >= 0 && int64(len(b)) > r {
+		return io.ErrUnexpectedEOF
+	}
+	n, err := io.ReadFull(d.src.r, b)
+	d.src.off += int64(n)
+	if d.src.on {
+		d.src.rec = append(d.src.rec, b[:n]...)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ReadVarint reads a varint.
+// It returns io.EOF only if no byte was read.
+func (d *Decoder) ReadVarint() (uint64, error) {
+	return binary.ReadUvarint(d)
+}
+
+// ReadTag reads a field key.
+// io.EOF signals the regular end of the message.
+func (d *Decoder) ReadTag() (tag TagNum, kind TagClass, err error) {
+	v, err := d.ReadVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	d.src.tag = TagNum(v >> 3)
+	return d.src.tag, TagClass(v & 0x07), nil
+}
+
+// ReadFixed32 reads a little endian uint32.
+func (d *Decoder) ReadFixed32() (uint32, error) {
+	var b [4]byte
+	if err := d.read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// ReadFixed64 reads a little endian uint64.
+func (d *Decoder) ReadFixed64() (uint64, error) {
+	var b [8]byte
+	if err := d.read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// readLength reads the length prefix of a TagSequence.
+func (d *Decoder) readLength() (int64, error) {
+	v, err := d.ReadVarint()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	max := d.MaxLength
+	if max <= 0 {
+		max = MaxLength
+	}
+	if v > uint64(max) {
+		return 0, &Error{Offset: int(d.Offset()), Tag: d.src.tag, Kind: TagSequence, Err: ErrLength}
+	}
+	if r := d.remaining(); r >= 0 && int64(v) > r {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return int64(v), nil
+}
+
+// ReadBytes reads varint length prefixed bytes into a new slice.
+func (d *Decoder) ReadBytes() ([]byte, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	// grow with the data actually read instead of trusting the prefix
+	b, err := io.ReadAll(io.LimitReader(d.src.r, n))
+	d.src.off += int64(len(b))
+	if d.src.on {
+		d.src.rec = append(d.src.rec, b...)
+	}
+	if err == nil && int64(len(b)) < n {
+		err = io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// Embedded reads a length prefix and returns a Decoder limited to the following bytes.
+// The parent must not be used until the embedded Decoder is closed.
+func (d *Decoder) Embedded() (*Decoder, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{MaxLength: d.MaxLength, src: d.src, end: d.src.off + n}, nil
+}
+
+// Close discards the unread rest of an embedded Decoder.
+// It is a no-op for a Decoder returned by NewDecoder.
+func (d *Decoder) Close() error {
+	if d.end < 0 {
+		return nil
+	}
+	return d.discard(d.remaining())
+}
+
+func (d *Decoder) discard(n int64) error {
+	if r := d.remaining(); r >= 0 && n > r {
+		return io.ErrUnexpectedEOF
+	}
+	w := io.Discard
+	if d.src.on {
+		w = (*recorder)(d.src)
+	}
+	m, err := io.CopyN(w, d.src.r, n)
+	d.src.off += m
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type recorder source
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.rec = append(r.rec, b...)
+	return len(b), nil
+}
+
+// Skip skips the value of a field of the given kind, right after its key has been read.
+// For TagStart the whole group up to its matching end key is skipped.
+func (d *Decoder) Skip(kind TagClass) error {
+	switch kind {
+	case TagUvarint:
+		_, err := d.ReadVarint()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	case Tag32bit:
+		return d.discard(4)
+	case Tag64bit:
+		return d.discard(8)
+	case TagSequence:
+		n, err := d.readLength()
+		if err != nil {
+			return err
+		}
+		return d.discard(n)
+	case TagStart:
+		_, err := d.skipGroup(d.src.tag)
+		return err
+	default:
+	}
+	return &Error{Offset: int(d.Offset()), Kind: kind, Err: ErrTagClass}
+}
+
+// skipGroup skips up to the end key of tag.
+// It returns the length of the recording before the end key.
+// Nested groups are tracked on a stack like in ReadGroup, not by recursion.
+func (d *Decoder) skipGroup(tag TagNum) (int, error) {
+	open := []TagNum{tag}
+	for {
+		mark := len(d.src.rec)
+		t, kind, err := d.ReadTag()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case TagStart:
+			open = append(open, t)
+		case TagEnd:
+			if t != open[len(open)-1] {
+				return 0, &Error{Offset: int(d.Offset()), Tag: t, Kind: kind, Err: ErrGroup}
+			}
+			if open = open[:len(open)-1]; len(open) == 0 {
+				return mark, nil
+			}
+		default:
+			if err := d.Skip(kind); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+// readGroup reads a group body into memory, without the end key.
+func (d *Decoder) readGroup(tag TagNum) ([]byte, error) {
+	d.src.on, d.src.rec = true, nil
+	n, err := d.skipGroup(tag)
+	b := d.src.rec
+	d.src.on, d.src.rec = false, nil
+	if err != nil {
+		return nil, err
+	}
+	return b[:n:n], nil
+}
+
+// Next reads the next field like ReadNext.
+// Varint and fixed values are returned in v, length prefixed values and group bodies in b.
+// io.EOF signals the regular end of the message.
+func (d *Decoder) Next() (v uint64, b []byte, tag TagNum, err error) {
+	tag, kind, err := d.ReadTag()
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if tag == 0 {
+		return 0, nil, tag, &Error{Offset: int(d.Offset()), Kind: kind, Err: ErrZeroTag}
+	}
+	switch kind {
+	case TagUvarint:
+		v, err = d.ReadVarint()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	case Tag32bit:
+		var v32 uint32
+		v32, err = d.ReadFixed32()
+		v = uint64(v32)
+	case Tag64bit:
+		v, err = d.ReadFixed64()
+	case TagSequence:
+		b, err = d.ReadBytes()
+	case TagStart:
+		b, err = d.readGroup(tag)
+	default:
+		return 0, nil, tag, &Error{Offset: int(d.Offset()), Tag: tag, Kind: kind, Err: ErrTagClass}
+	}
+	return v, b, tag, err
+}
@@ -0,0 +1,141 @@
+package wire
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// SizeVarint returns the number of bytes needed to encode v as a varint.
+func SizeVarint(v uint64) int {
+	return 1 + (bits.Len64(v|1)-1)/7
+}
+
+// SizeTag returns the number of bytes of the key of a field with the given number, whatever its wire type.
+func SizeTag(tag TagNum) int {
+	return SizeVarint(uint64(tag) << 3)
+}
+
+// AppendVarint appends v as a varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+// AppendTag appends a field key.
+func AppendTag(b []byte, tag TagNum, kind TagClass) []byte {
+	return binary.AppendUvarint(b, uint64(tag)<<3|uint64(kind&0x07))
+}
+
+// AppendFixed32 appends v in little endian.
+func AppendFixed32(b []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+// AppendFixed64 appends v in little endian.
+func AppendFixed64(b []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+// AppendBytes appends v prefixed with its varint encoded length.
+func AppendBytes(b []byte, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendString is AppendBytes for strings.
+func AppendString(b []byte, v string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// An Encoder builds a message in memory, field by field.
+// The zero value is an empty Encoder ready to use.
+type Encoder struct {
+	buf []byte
+}
+
+// NewEncoder returns an Encoder appending to buf.
+func NewEncoder(buf []byte) *Encoder {
+	return &Encoder{buf: buf}
+}
+
+// Bytes returns the encoded message.
+// It aliases the buffer and is only valid until the next modification.
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// Len returns the number of encoded bytes.
+func (e *Encoder) Len() int {
+	return len(e.buf)
+}
+
+// Reset empties the Encoder but keeps the allocated buffer.
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+}
+
+// WriteTo writes the encoded message to w and resets the Encoder.
+func (e *Encoder) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(e.buf)
+	e.Reset()
+	return int64(n), err
+}
+
+// EncodeVarint encodes a TagUvarint field.
+func (e *Encoder) EncodeVarint(tag TagNum, v uint64) {
+	e.buf = AppendTag(e.buf, tag, TagUvarint)
+	e.buf = AppendVarint(e.buf, v)
+}
+
+// EncodeFixed32 encodes a Tag32bit field.
+func (e *Encoder) EncodeFixed32(tag TagNum, v uint32) {
+	e.buf = AppendTag(e.buf, tag, Tag32bit)
+	e.buf = AppendFixed32(e.buf, v)
+}
+
+// EncodeFixed64 encodes a Tag64bit field.
+func (e *Encoder) EncodeFixed64(tag TagNum, v uint64) {
+	e.buf = AppendTag(e.buf, tag, Tag64bit)
+	e.buf = AppendFixed64(e.buf, v)
+}
+
+// EncodeBytes encodes a TagSequence field.
+func (e *Encoder) EncodeBytes(tag TagNum, v []byte) {
+	e.buf = AppendTag(e.buf, tag, TagSequence)
+	e.buf = AppendBytes(e.buf, v)
+}
+
+// EncodeString encodes a TagSequence field.
+func (e *Encoder) EncodeString(tag TagNum, v string) {
+	e.buf = AppendTag(e.buf, tag, TagSequence)
+	e.buf = AppendString(e.buf, v)
+}
+
+// EncodeRaw appends already encoded fields, like an UnknownFieldSet.
+func (e *Encoder) EncodeRaw(b []byte) {
+	e.buf = append(e.buf, b...)
+}
+
+// EncodeMessage encodes an embedded message written by fn into the same Encoder.
+func (e *Encoder) EncodeMessage(tag TagNum, fn func(e *Encoder)) {
+	e.buf = AppendTag(e.buf, tag, TagSequence)
+	// reserve one byte for the length, most messages are short
+	start := len(e.buf)
+	e.buf = append(e.buf, 0)
+	fn(e)
+	n := len(e.buf) - start - 1
+	if s := SizeVarint(uint64(n)); s > 1 {
+		e.buf = append(e.buf, make([]byte, s-1)...)
+		copy(e.buf[start+s:], e.buf[start+1:start+1+n])
+	}
+	binary.PutUvarint(e.buf[start:], uint64(n))
+}
+
+// EncodeGroup encodes a group with a body written by fn into the same Encoder.
+// Groups are deprecated, use EncodeMessage for new code.
+func (e *Encoder) EncodeGroup(tag TagNum, fn func(e *Encoder)) {
+	e.buf = AppendTag(e.buf, tag, TagStart)
+	fn(e)
+	e.buf = AppendTag(e.buf, tag, TagEnd)
+}
@@ -0,0 +1,44 @@
+package wire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Causes of an *Error, use errors.Is to check for them.
+var (
+	ErrTruncated = errors.New("truncated data")
+	ErrOverflow  = errors.New("varint overflows 64 bit")
+	ErrLength    = errors.New("length prefix out of range")
+	ErrZeroTag   = errors.New("invalid tag 0")
+	ErrTagClass  = errors.New("invalid tag class")
+	ErrGroup     = errors.New("unbalanced group")
+	ErrMaxDepth  = errors.New("nesting exceeds the maximum depth")
+)
+
+// An Error describes malformed wire data.
+type Error struct {
+	Offset int      // of the failing value, relative to the data passed in
+	Tag    TagNum   // 0 if the key could not be read
+	Kind   TagClass // of the failing field
+	Err    error    // the cause, one of the Err variables
+}
+
+func (err *Error) Error() string {
+	if err.Tag == 0 {
+		return fmt.Sprintf("wire: %v at offset %d", err.Err, err.Offset)
+	}
+	return fmt.Sprintf("wire: %v at offset %d (tag %d, class %d)", err.Err, err.Offset, err.Tag, err.Kind)
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// lengthErr translates the n <= 0 results of the Read functions.
+func lengthErr(n int) error {
+	if n < 0 {
+		return ErrOverflow
+	}
+	return ErrTruncated
+}
@@ -0,0 +1,158 @@
+package wire
+
+import (
+	"cmp"
+	"encoding/json"
+	"iter"
+	"slices"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A FieldRef is a field read by Fields.
+type FieldRef struct {
+	Tag    TagNum
+	Kind   TagClass
+	Offset int    // of the key within the message
+	Raw    []byte // the whole field including the key, aliasing the message
+	Value  uint64 // varint and fixed values
+	Bytes  []byte // length prefixed values and group bodies, aliasing the message
+}
+
+// Fields returns an iterator over the top level fields of a message.
+// Iteration ends after the first error, its *Error has an offset relative to data
+// and the yielded FieldRef holds the offset of the failing field.
+func Fields(data []byte) iter.Seq2[FieldRef, error] {
+	return func(yield func(FieldRef, error) bool) {
+		for i := 0; i < len(data); {
+			d, b, tag, kind, n, err := readNext(data[i:])
+			if err != nil {
+				err.(*Error).Offset += i
+				yield(FieldRef{Tag: tag, Kind: kind, Offset: i}, err)
+				return
+			}
+			if !yield(FieldRef{Tag: tag, Kind: kind, Offset: i, Raw: data[i : i+n : i+n], Value: d, Bytes: b}, nil) {
+				return
+			}
+			i += n
+		}
+	}
+}
+
+// An UnknownFieldSet holds fields a parser did not recognize, in their original encoding.
+// Appending it to a message re-emits them byte for byte.
+type UnknownFieldSet []byte
+
+// Add appends a field read by Fields.
+func (s *UnknownFieldSet) Add(r FieldRef) {
+	*s = append(*s, r.Raw...)
+}
+
+// Fields returns an iterator over the contained fields.
+func (s UnknownFieldSet) Fields() iter.Seq2[FieldRef, error] {
+	return Fields(s)
+}
+
+// Has reports whether the set contains a field with the given tag.
+func (s UnknownFieldSet) Has(tag TagNum) bool {
+	for r, err := range Fields(s) {
+		if err == nil && r.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes the fields with the given tag, malformed data after them is kept.
+func (s *UnknownFieldSet) Delete(tag TagNum) {
+	if !s.Has(tag) {
+		return
+	}
+	var out UnknownFieldSet
+	for r, err := range Fields(*s) {
+		if err != nil {
+			out = append(out, (*s)[r.Offset:]...)
+			break
+		}
+		if r.Tag != tag {
+			out = append(out, r.Raw...)
+		}
+	}
+	*s = out
+}
+
+// SortFields returns the top level fields of data stably sorted by tag,
+// repeated fields keep their relative order.
+func SortFields(data []byte) ([]byte, error) {
+	var fs []FieldRef
+	sorted := true
+	for r, err := range Fields(data) {
+		if err != nil {
+			return nil, err
+		}
+		if len(fs) > 0 && r.Tag < fs[len(fs)-1].Tag {
+			sorted = false
+		}
+		fs = append(fs, r)
+	}
+	if sorted {
+		return data, nil
+	}
+	slices.SortStableFunc(fs, func(a, b FieldRef) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	out := make([]byte, 0, len(data))
+	for _, r := range fs {
+		out = append(out, r.Raw...)
+	}
+	return out, nil
+}
+
+// Get returns the last field with the given tag.
+func (s UnknownFieldSet) Get(tag TagNum) (FieldRef, bool) {
+	var last FieldRef
+	found := false
+	for r, err := range Fields(s) {
+		if err == nil && r.Tag == tag {
+			last, found = r, true
+		}
+	}
+	return last, found
+}
+
+// MarshalJSON renders the set for humans as an object of tag numbers to lists of values.
+// Without a schema the types are guessed: numbers stay numbers,
+// printable bytes become strings, valid messages nested objects and the rest base64.
+func (s UnknownFieldSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(guessFields(s))
+}
+
+func guessFields(data []byte) map[string][]any {
+	out := map[string][]any{}
+	for r, err := range Fields(data) {
+		if err != nil {
+			out["error"] = append(out["error"], err.Error())
+			break
+		}
+		k := strconv.FormatUint(uint64(r.Tag), 10)
+		var v any = r.Value
+		if r.Kind == TagSequence || r.Kind == TagStart {
+			v = guessBytes(r.Bytes)
+		}
+		out[k] = append(out[k], v)
+	}
+	return out
+}
+
+func guessBytes(b []byte) any {
+	if utf8.Valid(b) && !slices.ContainsFunc([]rune(string(b)), func(c rune) bool {
+		return !unicode.IsPrint(c) && !unicode.IsSpace(c)
+	}) {
+		return string(b)
+	}
+	if _, err := SortFields(b); err == nil {
+		return guessFields(b)
+	}
+	return b
+}
@@ -0,0 +1,83 @@
+package wire_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/defsrc/proton/wellknown"
+	"github.com/defsrc/proton/wire"
+)
+
+// seeds returns real messages and adversarial fields.
+func seeds() [][]byte {
+	set := wellknown.Set()
+	return [][]byte{
+		set,
+		set[:len(set)/2],
+		{},
+		{0x08, 0x96, 0x01},
+		{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+		{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02},
+		{0x08, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00},
+		{0x12, 0xff, 0xff, 0xff, 0xff, 0x07, 'x'},
+		{0x12, 0x80, 0x80, 0x80, 0x80, 0x08},
+		{0x1b, 0x23, 0x2b, 0x2c, 0x24, 0x1c},
+		{0x1b, 0x23, 0x1c},
+		{0x1c},
+		{0x00},
+		{0x0f},
+		{0x15, 1, 2, 3},
+		{0x19, 1, 2, 3, 4, 5, 6, 7},
+	}
+}
+
+func FuzzReadNext(f *testing.F) {
+	for _, seed := range seeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, b, tag, n, err := wire.ReadNext(data)
+		dec := wire.NewDecoder(bytes.NewReader(data))
+		d2, b2, tag2, err2 := dec.Next()
+		if err != nil {
+			var werr *wire.Error
+			if !errors.As(err, &werr) || n != 0 {
+				t.Fatalf("ReadNext: n = %d, error %v (%T), want n = 0 and a *wire.Error", n, err, err)
+			}
+			if len(data) > 0 && err2 == nil {
+				t.Fatalf("ReadNext fails with %v, Decoder.Next reads tag %d", err, tag2)
+			}
+		} else {
+			if n <= 0 || n > len(data) || tag == 0 {
+				t.Fatalf("ReadNext = tag %d, n %d of %d bytes", tag, n, len(data))
+			}
+			if err2 != nil || d2 != d || !bytes.Equal(b2, b) || tag2 != tag || dec.Offset() != int64(n) {
+				t.Fatalf("Decoder.Next = %d, % x, %d, %v at %d, ReadNext = %d, % x, %d, %d",
+					d2, b2, tag2, err2, dec.Offset(), d, b, tag, n)
+			}
+			if !bytes.Contains(data[:n], b) {
+				t.Fatalf("ReadNext: value % x is not within the field % x", b, data[:n])
+			}
+		}
+
+		// the fields cover the data up to the first error
+		var read []byte
+		for r, err := range wire.Fields(data) {
+			if err != nil {
+				var werr *wire.Error
+				if !errors.As(err, &werr) || werr.Offset < len(read) || werr.Offset > len(data) || r.Offset != len(read) {
+					t.Fatalf("Fields: error %v at field offset %d after %d bytes", err, r.Offset, len(read))
+				}
+				return
+			}
+			if r.Offset != len(read) {
+				t.Fatalf("Fields: field at %d, want %d", r.Offset, len(read))
+			}
+			read = append(read, r.Raw...)
+		}
+		if !bytes.Equal(read, data) {
+			t.Fatalf("Fields: the fields are % x, want % x", read, data)
+		}
+	})
+}
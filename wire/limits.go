@@ -0,0 +1,62 @@
+package wire
+
+import (
+	"errors"
+	"slices"
+)
+
+// Limits bound the resources spent on untrusted data, zero fields are not limited.
+type Limits struct {
+	MessageSize  int // bytes of a message, including the nested ones
+	Fields       int // of a message, counting each value of repeated fields
+	StringLength int // bytes of the values of the string and bytes fields
+	Allocations  int // of the messages and values decoded from the data, see Alloc
+}
+
+// Causes of the *Error of the Limits checks.
+var (
+	ErrMessageSize  = errors.New("message exceeds the size limit")
+	ErrFieldCount   = errors.New("message exceeds the field limit")
+	ErrStringLength = errors.New("string exceeds the length limit")
+	ErrAllocations  = errors.New("data exceeds the allocation limit")
+)
+
+// Check applies the limits but Allocations to the top level fields of a message,
+// strings are the tags of its string and bytes fields. A nil l has no limits.
+// Malformed data is not reported, it is left to the parse.
+func (l *Limits) Check(data []byte, strings ...TagNum) error {
+	if l == nil {
+		return nil
+	}
+	if l.MessageSize > 0 && len(data) > l.MessageSize {
+		return &Error{Err: ErrMessageSize}
+	}
+	if l.Fields <= 0 && l.StringLength <= 0 {
+		return nil
+	}
+	n := 0
+	for r, err := range Fields(data) {
+		if err != nil {
+			return nil
+		}
+		if n++; l.Fields > 0 && n > l.Fields {
+			return &Error{Offset: r.Offset, Tag: r.Tag, Kind: r.Kind, Err: ErrFieldCount}
+		}
+		if l.StringLength > 0 && r.Kind == TagSequence && len(r.Bytes) > l.StringLength && slices.Contains(strings, r.Tag) {
+			return &Error{Offset: r.Offset, Tag: r.Tag, Kind: r.Kind, Err: ErrStringLength}
+		}
+	}
+	return nil
+}
+
+// Alloc adds n to the allocations counted in *count and reports ErrAllocations past the limit,
+// the decoders using l count the allocations of a whole input in one count.
+func (l *Limits) Alloc(count *int, n int) error {
+	if l == nil || l.Allocations <= 0 {
+		return nil
+	}
+	if *count += n; *count > l.Allocations {
+		return &Error{Err: ErrAllocations}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package wire
+
+import "math"
+
+// Packed repeated fields store scalars back to back in a single TagSequence value.
+// Parsers have to accept both packed and unpacked encodings of repeated scalars,
+// the descriptor decides which fields may be packed, IsPacked only checks the shape.
+
+// IsPacked reports whether b is a valid packed sequence of kind values.
+// Any string or message can also be valid packed data,
+// it should only be used as a heuristic in the absence of a descriptor.
+func IsPacked(b []byte, kind TagClass) bool {
+	switch kind {
+	case TagUvarint:
+		return countVarints(b) >= 0
+	case Tag32bit:
+		return len(b)%4 == 0
+	case Tag64bit:
+		return len(b)%8 == 0
+	default:
+	}
+	return false
+}
+
+// countVarints returns the number of varints in b or -1 if b is invalid.
+func countVarints(b []byte) int {
+	n := 0
+	for i := 0; i < len(b); {
+		_, m := ReadVarint(b[i:])
+		if m <= 0 {
+			return -1
+		}
+		i += m
+		n++
+	}
+	return n
+}
+
+// UnpackVarints decodes packed varints.
+func UnpackVarints(b []byte) ([]uint64, error) {
+	n := countVarints(b)
+	if n < 0 {
+		return nil, packedErr(b, TagUvarint)
+	}
+	vs := make([]uint64, 0, n)
+	for i := 0; i < len(b); {
+		v, m := ReadVarint(b[i:])
+		vs = append(vs, v)
+		i += m
+	}
+	return vs, nil
+}
+
+// UnpackInt32s decodes packed int32 or enum values.
+func UnpackInt32s(b []byte) ([]int32, error) {
+	vs, err := UnpackVarints(b)
+	if err != nil {
+		return nil, err
+	}
+	is := make([]int32, len(vs))
+	for i, v := range vs {
+		is[i] = int32(v)
+	}
+	return is, nil
+}
+
+// UnpackFixed32s decodes packed fixed32 values.
+func UnpackFixed32s(b []byte) ([]uint32, error) {
+	if len(b)%4 != 0 {
+		return nil, packedErr(b, Tag32bit)
+	}
+	vs := make([]uint32, len(b)/4)
+	for i := range vs {
+		vs[i], _ = ReadFixed32(b[4*i:])
+	}
+	return vs, nil
+}
+
+// UnpackFixed64s decodes packed fixed64 values.
+func UnpackFixed64s(b []byte) ([]uint64, error) {
+	if len(b)%8 != 0 {
+		return nil, packedErr(b, Tag64bit)
+	}
+	vs := make([]uint64, len(b)/8)
+	for i := range vs {
+		vs[i], _ = ReadFixed64(b[8*i:])
+	}
+	return vs, nil
+}
+
+// UnpackFloats decodes packed float values.
+func UnpackFloats(b []byte) ([]float32, error) {
+	vs, err := UnpackFixed32s(b)
+	if err != nil {
+		return nil, err
+	}
+	fs := make([]float32, len(vs))
+	for i, v := range vs {
+		fs[i] = math.Float32frombits(v)
+	}
+	return fs, nil
+}
+
+// UnpackDoubles decodes packed double values.
+func UnpackDoubles(b []byte) ([]float64, error) {
+	vs, err := UnpackFixed64s(b)
+	if err != nil {
+		return nil, err
+	}
+	fs := make([]float64, len(vs))
+	for i, v := range vs {
+		fs[i] = math.Float64frombits(v)
+	}
+	return fs, nil
+}
+
+func packedErr(b []byte, kind TagClass) error {
+	return &Error{Offset: len(b), Kind: kind, Err: ErrTruncated}
+}
+
+// AppendPackedVarints appends vs as a length prefixed packed value.
+func AppendPackedVarints(b []byte, vs []uint64) []byte {
+	n := 0
+	for _, v := range vs {
+		n += SizeVarint(v)
+	}
+	b = AppendVarint(b, uint64(n))
+	for _, v := range vs {
+		b = AppendVarint(b, v)
+	}
+	return b
+}
+
+// AppendPackedFixed32s appends vs as a length prefixed packed value.
+func AppendPackedFixed32s(b []byte, vs []uint32) []byte {
+	b = AppendVarint(b, uint64(4*len(vs)))
+	for _, v := range vs {
+		b = AppendFixed32(b, v)
+	}
+	return b
+}
+
+// AppendPackedFixed64s appends vs as a length prefixed packed value.
+func AppendPackedFixed64s(b []byte, vs []uint64) []byte {
+	b = AppendVarint(b, uint64(8*len(vs)))
+	for _, v := range vs {
+		b = AppendFixed64(b, v)
+	}
+	return b
+}
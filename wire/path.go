@@ -0,0 +1,43 @@
+package wire
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GetByPath returns the fields at a path of field numbers joined by dots, like "5.2.1" for the fields 1
+// of the messages in the fields 2 of the messages in the fields 5 of data, without decoding the others.
+// All occurrences are returned in order with offsets relative to data: the occurrences of a message
+// merge, the last one is the value of a singular field. Malformed messages on the path are an *Error.
+func GetByPath(data []byte, path string) ([]FieldRef, error) {
+	var tags []TagNum
+	for _, s := range strings.Split(path, ".") {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || n == 0 || n > MaxTag {
+			return nil, fmt.Errorf("wire: invalid field number %q in path %q", s, path)
+		}
+		tags = append(tags, TagNum(n))
+	}
+	return Get(data, tags...)
+}
+
+// Get is GetByPath with the numbers of the path, none returns no fields.
+func Get(data []byte, path ...TagNum) ([]FieldRef, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	var out []FieldRef
+	err := Walk(data, VisitFunc(func(p []FieldRef, r FieldRef) Action {
+		switch {
+		case r.Tag != path[len(p)]:
+			return Continue
+		case len(p) == len(path)-1:
+			out = append(out, r)
+			return Continue
+		default:
+		}
+		return Descend
+	}))
+	return out, err
+}
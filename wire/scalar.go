@@ -0,0 +1,84 @@
+package wire
+
+import "math"
+
+// Conversions between the raw values returned by ReadNext and the protobuf scalar types.
+// Encode functions return the raw value for AppendVarint, AppendFixed32 or AppendFixed64.
+
+// DecodeSint32 decodes a zigzag encoded sint32.
+func DecodeSint32(v uint64) int32 {
+	return int32(uint32(v)>>1) ^ -int32(v&1)
+}
+
+// DecodeSint64 decodes a zigzag encoded sint64.
+func DecodeSint64(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// EncodeSint32 zigzag encodes v.
+func EncodeSint32(v int32) uint64 {
+	return uint64(uint32(v<<1) ^ uint32(v>>31))
+}
+
+// EncodeSint64 zigzag encodes v.
+func EncodeSint64(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// DecodeInt32 decodes an int32, negative values are sign extended to 10 bytes on the wire.
+func DecodeInt32(v uint64) int32 {
+	return int32(v)
+}
+
+// EncodeInt32 sign extends v like protoc does.
+func EncodeInt32(v int32) uint64 {
+	return uint64(int64(v))
+}
+
+// DecodeSfixed32 decodes a sfixed32.
+func DecodeSfixed32(v uint64) int32 {
+	return int32(uint32(v))
+}
+
+// DecodeSfixed64 decodes a sfixed64.
+func DecodeSfixed64(v uint64) int64 {
+	return int64(v)
+}
+
+// DecodeBool decodes a bool, any non-zero value is true.
+func DecodeBool(v uint64) bool {
+	return v != 0
+}
+
+// EncodeBool encodes a bool.
+func EncodeBool(v bool) uint64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// DecodeEnum decodes an enum number, enums are int32 on the wire.
+func DecodeEnum(v uint64) int32 {
+	return int32(v)
+}
+
+// DecodeFloat decodes a float from a fixed32.
+func DecodeFloat(v uint64) float32 {
+	return math.Float32frombits(uint32(v))
+}
+
+// EncodeFloat encodes a float as fixed32.
+func EncodeFloat(v float32) uint32 {
+	return math.Float32bits(v)
+}
+
+// DecodeDouble decodes a double from a fixed64.
+func DecodeDouble(v uint64) float64 {
+	return math.Float64frombits(v)
+}
+
+// EncodeDouble encodes a double as fixed64.
+func EncodeDouble(v float64) uint64 {
+	return math.Float64bits(v)
+}
@@ -0,0 +1,102 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"iter"
+)
+
+// A StreamReader reads a stream of length delimited messages, each preceded by its size as a varint.
+// It is the framing of writeDelimitedTo in Java and SerializeDelimitedToOstream in C++.
+type StreamReader struct {
+	// MaxLength limits the size of a message, 0 means the package MaxLength.
+	MaxLength int64
+
+	r   *bufio.Reader
+	off int64
+}
+
+// NewStreamReader returns a StreamReader reading from r.
+// It buffers and may read more than needed from r.
+func NewStreamReader(r io.Reader) *StreamReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &StreamReader{r: br}
+}
+
+// Offset returns the number of bytes consumed, the offset of the next size prefix.
+func (s *StreamReader) Offset() int64 {
+	return s.off
+}
+
+// Next returns the next message in a new slice, io.EOF at the end of the stream.
+// Malformed sizes and truncated messages are an *Error.
+func (s *StreamReader) Next() ([]byte, error) {
+	start := s.off
+	prefix, err := s.r.Peek(binary.MaxVarintLen64)
+	if len(prefix) == 0 {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	v, n := ReadVarint(prefix)
+	if n <= 0 {
+		return nil, &Error{Offset: int(start), Err: lengthErr(n)}
+	}
+	s.r.Discard(n)
+	s.off += int64(n)
+	max := s.MaxLength
+	if max <= 0 {
+		max = MaxLength
+	}
+	if v > uint64(max) {
+		return nil, &Error{Offset: int(start), Err: ErrLength}
+	}
+	// grow with the data actually read instead of trusting the prefix
+	b, err := io.ReadAll(io.LimitReader(s.r, int64(v)))
+	s.off += int64(len(b))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < int(v) {
+		return nil, &Error{Offset: int(start), Err: ErrTruncated}
+	}
+	return b, nil
+}
+
+// All returns an iterator over the messages of the stream, iteration ends after the first error.
+func (s *StreamReader) All() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		for {
+			msg, err := s.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(msg, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// A StreamWriter writes a stream of length delimited messages, see StreamReader.
+type StreamWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewStreamWriter returns a StreamWriter writing to w, which is not buffered.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: w}
+}
+
+// WriteMessage writes the size of msg followed by msg.
+func (s *StreamWriter) WriteMessage(msg []byte) error {
+	s.buf = append(AppendVarint(s.buf[:0], uint64(len(msg))), msg...)
+	_, err := s.w.Write(s.buf)
+	return err
+}
@@ -0,0 +1,73 @@
+package wire
+
+import (
+	"errors"
+	"slices"
+	"unicode/utf8"
+)
+
+// MaxTag is the largest valid field number.
+const MaxTag = 1<<29 - 1
+
+// The field numbers reserved for the protocol buffers implementation.
+const (
+	FirstReservedTag TagNum = 19000
+	LastReservedTag  TagNum = 19999
+)
+
+// Causes of the *Error of CheckStrict.
+var (
+	ErrTagRange   = errors.New("tag out of range 1..536870911")
+	ErrReserved   = errors.New("tag in the implementation reserved range 19000..19999")
+	ErrUTF8       = errors.New("invalid UTF-8 in string field")
+	ErrNonMinimal = errors.New("non-minimal varint")
+	ErrTrailing   = errors.New("trailing zero bytes after the last field")
+)
+
+// CheckStrict reports problems of the top level fields of data that Fields accepts or reports vaguely:
+// tags outside 1..MaxTag or between FirstReservedTag and LastReservedTag, varint keys, values
+// and lengths longer than necessary, invalid UTF-8 in the fields with the string tags, and zero padding.
+// Other malformed data is reported like by Fields.
+func CheckStrict(data []byte, strings ...TagNum) error {
+	for i := 0; i < len(data); {
+		key, kn := ReadVarint(data[i:])
+		if kn > 0 && key>>3 > MaxTag {
+			return &Error{Offset: i, Kind: TagClass(key & 7), Err: ErrTagRange}
+		}
+		d, b, tag, kind, n, err := readNext(data[i:])
+		if err != nil {
+			if allZero(data[i:]) {
+				return &Error{Offset: i, Err: ErrTrailing}
+			}
+			err.(*Error).Offset += i
+			return err
+		}
+		fail := func(cause error) error {
+			return &Error{Offset: i, Tag: tag, Kind: kind, Err: cause}
+		}
+		switch {
+		case FirstReservedTag <= tag && tag <= LastReservedTag:
+			return fail(ErrReserved)
+		case SizeVarint(key) != kn:
+			return fail(ErrNonMinimal)
+		case kind == TagUvarint && SizeVarint(d) != n-kn:
+			return fail(ErrNonMinimal)
+		case kind == TagSequence && SizeVarint(uint64(len(b))) != n-kn-len(b):
+			return fail(ErrNonMinimal)
+		case kind == TagSequence && slices.Contains(strings, tag) && !utf8.Valid(b):
+			return fail(ErrUTF8)
+		default:
+		}
+		i += n
+	}
+	return nil
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package wire
+
+import (
+	"cmp"
+	"slices"
+)
+
+// A View gives access to the fields of a message without decoding it into structs.
+// The fields are indexed on first access, all values alias the message.
+type View struct {
+	data    []byte
+	fields  []FieldRef // stably sorted by tag
+	err     error
+	indexed bool
+}
+
+// NewView returns a View of the message in data.
+func NewView(data []byte) *View {
+	return &View{data: data}
+}
+
+// Bytes returns the viewed message.
+func (v *View) Bytes() []byte {
+	return v.data
+}
+
+func (v *View) index() {
+	if v.indexed {
+		return
+	}
+	v.indexed = true
+	for r, err := range Fields(v.data) {
+		if err != nil {
+			v.err = err
+			break
+		}
+		v.fields = append(v.fields, r)
+	}
+	slices.SortStableFunc(v.fields, func(a, b FieldRef) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+}
+
+// Err returns the error that stopped indexing, fields before it are still accessible.
+func (v *View) Err() error {
+	v.index()
+	return v.err
+}
+
+// All returns all occurrences of tag in message order.
+// The result aliases the index.
+func (v *View) All(tag TagNum) []FieldRef {
+	v.index()
+	i, ok := slices.BinarySearchFunc(v.fields, tag, func(r FieldRef, t TagNum) int {
+		return cmp.Compare(r.Tag, t)
+	})
+	if !ok {
+		return nil
+	}
+	j := i
+	for j < len(v.fields) && v.fields[j].Tag == tag {
+		j++
+	}
+	return v.fields[i:j:j]
+}
+
+// Get returns the last occurrence of tag, following the protobuf rule that the last value wins.
+func (v *View) Get(tag TagNum) (FieldRef, bool) {
+	all := v.All(tag)
+	if len(all) == 0 {
+		return FieldRef{}, false
+	}
+	return all[len(all)-1], true
+}
+
+// Has reports whether tag is present.
+func (v *View) Has(tag TagNum) bool {
+	return len(v.All(tag)) > 0
+}
+
+// Message returns a View of the embedded message of tag. Its occurrences merge like those
+// of a singular message field, the View of more than one copies their concatenation.
+func (v *View) Message(tag TagNum) (*View, bool) {
+	all := v.All(tag)
+	if len(all) == 0 {
+		return nil, false
+	}
+	data := all[0].Bytes
+	for i, r := range all {
+		if r.Kind != TagSequence && r.Kind != TagStart {
+			return nil, false
+		}
+		if i > 0 {
+			data = append(data[:len(data):len(data)], r.Bytes...)
+		}
+	}
+	return NewView(data), true
+}
+
+// Tags returns the distinct tags present in ascending order.
+func (v *View) Tags() []TagNum {
+	v.index()
+	var tags []TagNum
+	for _, r := range v.fields {
+		if len(tags) == 0 || tags[len(tags)-1] != r.Tag {
+			tags = append(tags, r.Tag)
+		}
+	}
+	return tags
+}
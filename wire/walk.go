@@ -0,0 +1,88 @@
+package wire
+
+// An Action tells Walk how to go on after a field, fields without length prefix or group body always continue.
+type Action uint8
+
+const (
+	Continue   Action = iota // with the next field, without descending into this one
+	Descend                  // into the fields of a length prefixed value or group, malformed ones are an error
+	TryDescend               // into the fields of the value if it is a well-formed message, continue otherwise
+	Stop                     // end the walk without error
+)
+
+// A Visitor is called by Walk for the fields of a message.
+//
+// path holds the fields enclosing r, outermost first. It is reused, it must not be retained or modified.
+// The offsets of the fields are relative to the walked data, to locate a field from r.Offset to r.Offset+len(r.Raw).
+// Descending is bounded by the size of the data only, visitors of untrusted data limit the length of path.
+type Visitor interface {
+	Enter(path []FieldRef, r FieldRef) Action // for each field, in order
+	Leave(path []FieldRef, r FieldRef)        // after the fields of a field Enter descended into
+}
+
+// VisitFunc is a Visitor calling f in Enter.
+type VisitFunc func(path []FieldRef, r FieldRef) Action
+
+func (f VisitFunc) Enter(path []FieldRef, r FieldRef) Action { return f(path, r) }
+
+func (f VisitFunc) Leave(path []FieldRef, r FieldRef) {}
+
+// Walk calls v for the fields of data and of the nested messages it descends into, depth first.
+// A malformed message is an *Error with an offset relative to data, after the fields before it were visited.
+func Walk(data []byte, v Visitor) error {
+	w := walker{v: v}
+	_, err := w.walk(data, 0)
+	return err
+}
+
+type walker struct {
+	v    Visitor
+	path []FieldRef
+}
+
+// walk visits the fields of data at offset base of the walked data, false if the visitor stopped.
+func (w *walker) walk(data []byte, base int) (bool, error) {
+	for r, err := range Fields(data) {
+		if err != nil {
+			err.(*Error).Offset += base
+			return false, err
+		}
+		r.Offset += base
+		action := w.v.Enter(w.path, r)
+		nested := r.Kind == TagSequence || r.Kind == TagStart
+		switch {
+		case action == Stop:
+			return false, nil
+		case action == TryDescend && nested && !wellFormed(r.Bytes), action == Continue, !nested:
+			continue
+		default:
+		}
+		w.path = append(w.path, r)
+		ok, err := w.walk(r.Bytes, r.Offset+bodyOffset(r))
+		w.path = w.path[:len(w.path)-1]
+		if !ok || err != nil {
+			return false, err
+		}
+		w.v.Leave(w.path, r)
+	}
+	return true, nil
+}
+
+// bodyOffset returns the offset of r.Bytes within r.Raw.
+func bodyOffset(r FieldRef) int {
+	if r.Kind == TagStart {
+		_, n := ReadVarint(r.Raw)
+		return n
+	}
+	return len(r.Raw) - len(r.Bytes)
+}
+
+// wellFormed reports whether data parses as the fields of a message, nested fields are not checked.
+func wellFormed(data []byte) bool {
+	for _, err := range Fields(data) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,246 @@
+// Package wire implements the lowest level of protocol buffers: the wire encoding.
+//
+// See https://developers.google.com/protocol-buffers/docs/encoding
+package wire
+
+import "encoding/binary"
+
+// TagNum is a field number.
+//
+// https://developers.google.com/protocol-buffers/docs/proto#simple
+type TagNum = uint32 // just 30 bit really - and 0 is invalid
+
+// TagClass is the wire type stored in the lower 3 bits of a field key.
+type TagClass = byte
+
+// https://developers.google.com/protocol-buffers/docs/encoding
+const (
+	TagUvarint  TagClass = 0 // int32, int64, uint32, uint64, sint32, sint64, bool, enum
+	Tag64bit    TagClass = 1 // fixed64, sfixed64, double
+	TagSequence TagClass = 2 // length prefixed bytes: string, bytes, embedded message, packed repeated fields
+	TagStart    TagClass = 3 // start group - deprecated
+	TagEnd      TagClass = 4 // end group - deprecated
+	Tag32bit    TagClass = 5 // fixed32, sfixed32, float
+)
+
+// ReadVarint reads a varint from the start of data.
+// Like binary.Uvarint, n == 0 if data is too short
+// and n < 0 if the value overflows 64 bit.
+func ReadVarint(data []byte) (v uint64, n int) {
+	if len(data) > 0 && data[0] < 0x80 {
+		return uint64(data[0]), 1
+	}
+	if len(data) >= binary.MaxVarintLen64 {
+		return readVarint10(data)
+	}
+	return binary.Uvarint(data)
+}
+
+// readVarint10 is ReadVarint unrolled for data holding at least 10 bytes with a first byte above 0x7f,
+// the single bounds check up front saves one per byte.
+// Each step adds the next byte with its continuation bit and subtracts the bit if it continues.
+func readVarint10(buf []byte) (uint64, int) {
+	data := buf[:binary.MaxVarintLen64]
+	v := uint64(data[0]) - 0x80
+	y := uint64(data[1])
+	v += y << 7
+	if y < 0x80 {
+		return v, 2
+	}
+	v -= 0x80 << 7
+	y = uint64(data[2])
+	v += y << 14
+	if y < 0x80 {
+		return v, 3
+	}
+	v -= 0x80 << 14
+	y = uint64(data[3])
+	v += y << 21
+	if y < 0x80 {
+		return v, 4
+	}
+	v -= 0x80 << 21
+	y = uint64(data[4])
+	v += y << 28
+	if y < 0x80 {
+		return v, 5
+	}
+	v -= 0x80 << 28
+	y = uint64(data[5])
+	v += y << 35
+	if y < 0x80 {
+		return v, 6
+	}
+	v -= 0x80 << 35
+	y = uint64(data[6])
+	v += y << 42
+	if y < 0x80 {
+		return v, 7
+	}
+	v -= 0x80 << 42
+	y = uint64(data[7])
+	v += y << 49
+	if y < 0x80 {
+		return v, 8
+	}
+	v -= 0x80 << 49
+	y = uint64(data[8])
+	v += y << 56
+	if y < 0x80 {
+		return v, 9
+	}
+	v -= 0x80 << 56
+	y = uint64(data[9])
+	if y > 1 {
+		return binary.Uvarint(buf) // the overflow, reported like binary.Uvarint does
+	}
+	return v + y<<63, 10
+}
+
+// ReadTag reads a field key from the start of data.
+// n follows the conventions of ReadVarint.
+func ReadTag(data []byte) (tag TagNum, kind TagClass, n int) {
+	v, n := ReadVarint(data)
+	if n <= 0 {
+		return 0, 0, n
+	}
+	return TagNum(v >> 3), TagClass(v & 0x07), n
+}
+
+// ReadFixed32 reads a little endian uint32 from the start of data.
+// n == 0 if data is too short.
+func ReadFixed32(data []byte) (v uint32, n int) {
+	if len(data) < 4 {
+		return 0, 0
+	}
+	return binary.LittleEndian.Uint32(data), 4
+}
+
+// ReadFixed64 reads a little endian uint64 from the start of data.
+// n == 0 if data is too short.
+func ReadFixed64(data []byte) (v uint64, n int) {
+	if len(data) < 8 {
+		return 0, 0
+	}
+	return binary.LittleEndian.Uint64(data), 8
+}
+
+// MaxLength is the largest length prefix accepted, protobuf messages are limited to 2GiB.
+const MaxLength = 1<<31 - 1
+
+// MaxDepth is the default limit of nested messages, like the recursion limit of protoc.
+// The outermost message of the data is at depth 0, messages deeper than the limit are rejected.
+const MaxDepth = 100
+
+// ReadBytes reads varint length prefixed bytes from the start of data.
+// The result aliases data, its capacity is capped to its length.
+// n == 0 if data is too short and n < 0 if the length is invalid or above MaxLength.
+func ReadBytes(data []byte) (b []byte, n int) {
+	v, pos := ReadVarint(data)
+	if pos <= 0 {
+		return nil, pos
+	}
+	if v > MaxLength {
+		return nil, -pos
+	}
+	// compare unsigned, start + int(v) could overflow
+	if v > uint64(len(data)-pos) {
+		return nil, 0
+	}
+	n = pos + int(v)
+	return data[pos:n:n], n
+}
+
+// ReadNext reads the next field.
+// Varint and fixed values are returned in d, length prefixed values and group bodies in b aliasing data.
+// n is the number of bytes read, errors are reported as *Error with n == 0.
+func ReadNext(data []byte) (d uint64, b []byte, tag TagNum, n int, err error) {
+	d, b, tag, _, n, err = readNext(data)
+	return d, b, tag, n, err
+}
+
+// readNext is ReadNext also returning the tag class.
+func readNext(data []byte) (d uint64, b []byte, tag TagNum, kind TagClass, n int, err error) {
+	tag, kind, pos := ReadTag(data)
+	if pos <= 0 {
+		return 0, nil, 0, 0, 0, &Error{Err: lengthErr(pos)}
+	}
+	if tag == 0 { // valid iff tag > 0 && tag < ((1<<30) - 1)
+		return 0, nil, tag, kind, 0, &Error{Kind: kind, Err: ErrZeroTag}
+	}
+	var m int
+	switch kind {
+	case TagUvarint:
+		d, m = ReadVarint(data[pos:])
+	case Tag32bit:
+		var v uint32
+		v, m = ReadFixed32(data[pos:])
+		d = uint64(v)
+	case Tag64bit:
+		d, m = ReadFixed64(data[pos:])
+	case TagSequence:
+		b, m = ReadBytes(data[pos:])
+		if m < 0 {
+			return 0, nil, tag, kind, 0, &Error{Offset: pos, Tag: tag, Kind: kind, Err: ErrLength}
+		}
+	case TagStart:
+		b, m, err = ReadGroup(data[pos:], tag)
+		if err != nil {
+			err.(*Error).Offset += pos
+			return 0, nil, tag, kind, 0, err
+		}
+	default:
+		return 0, nil, tag, kind, 0, &Error{Tag: tag, Kind: kind, Err: ErrTagClass}
+	}
+	if m <= 0 {
+		return 0, nil, tag, kind, 0, &Error{Offset: pos, Tag: tag, Kind: kind, Err: lengthErr(m)}
+	}
+	return d, b, tag, kind, pos + m, nil
+}
+
+// ReadGroup reads the body of the group started by tag, data starts right after the start key.
+// The body in b excludes the end key, n includes it.
+// Nested groups are skipped, but their end keys have to match.
+func ReadGroup(data []byte, tag TagNum) (b []byte, n int, err error) {
+	open := []TagNum{tag}
+	for i := 0; ; {
+		t, kind, pos := ReadTag(data[i:])
+		if pos <= 0 {
+			return nil, 0, &Error{Offset: i, Tag: open[len(open)-1], Kind: TagStart, Err: lengthErr(pos)}
+		}
+		var m int
+		switch kind {
+		case TagStart:
+			open = append(open, t)
+			i += pos
+			continue
+		case TagEnd:
+			if t != open[len(open)-1] {
+				return nil, 0, &Error{Offset: i, Tag: t, Kind: kind, Err: ErrGroup}
+			}
+			open = open[:len(open)-1]
+			if len(open) == 0 {
+				return data[:i:i], i + pos, nil
+			}
+			i += pos
+			continue
+		case TagUvarint:
+			_, m = ReadVarint(data[i+pos:])
+		case Tag32bit:
+			_, m = ReadFixed32(data[i+pos:])
+		case Tag64bit:
+			_, m = ReadFixed64(data[i+pos:])
+		case TagSequence:
+			_, m = ReadBytes(data[i+pos:])
+			if m < 0 {
+				return nil, 0, &Error{Offset: i + pos, Tag: t, Kind: kind, Err: ErrLength}
+			}
+		default:
+			return nil, 0, &Error{Offset: i, Tag: t, Kind: kind, Err: ErrTagClass}
+		}
+		if m <= 0 {
+			return nil, 0, &Error{Offset: i + pos, Tag: t, Kind: kind, Err: lengthErr(m)}
+		}
+		i += pos + m
+	}
+}
@@ -0,0 +1,295 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// varintInputs returns encodings of the boundary values 2^7k-1 and 2^7k, MaxUint64,
+// overlong and truncated varints.
+func varintInputs() [][]byte {
+	var inputs [][]byte
+	for k := 1; k < 10; k++ {
+		for _, v := range []uint64{1<<(7*k) - 1, 1 << (7 * k)} {
+			inputs = append(inputs, binary.AppendUvarint(nil, v))
+		}
+	}
+	maxV := binary.AppendUvarint(nil, math.MaxUint64)
+	inputs = append(inputs,
+		maxV,
+		[]byte{0},
+		[]byte{0x80, 0}, // overlong zero
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02},       // overflows 64 bit
+		[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, // longer than 10 bytes
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		maxV[:9], // truncated
+		[]byte{0x80},
+		nil,
+	)
+	return inputs
+}
+
+func TestReadVarint(t *testing.T) {
+	for _, in := range varintInputs() {
+		// trailing bytes take data of at least 10 bytes through the unrolled path
+		for _, data := range [][]byte{in, append(append([]byte{}, in...), make([]byte, 10)...)} {
+			v, n := ReadVarint(data)
+			wantV, wantN := binary.Uvarint(data)
+			if v != wantV || n != wantN {
+				t.Errorf("ReadVarint(% x) = %d, %d, want %d, %d", data, v, n, wantV, wantN)
+			}
+		}
+	}
+}
+
+func TestReadTag(t *testing.T) {
+	tests := []struct {
+		data []byte
+		tag  TagNum
+		kind TagClass
+		n    int
+	}{
+		{[]byte{0x08}, 1, TagUvarint, 1},
+		{[]byte{0x7d}, 15, Tag32bit, 1},
+		{[]byte{0x82, 0x01}, 16, TagSequence, 2},
+		{[]byte{0xfb, 0xff, 0xff, 0xff, 0x0f}, MaxTag, TagStart, 5},
+		{[]byte{0x82}, 0, 0, 0},
+		{nil, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		tag, kind, n := ReadTag(tt.data)
+		if tag != tt.tag || kind != tt.kind || n != tt.n {
+			t.Errorf("ReadTag(% x) = %d, %d, %d, want %d, %d, %d", tt.data, tag, kind, n, tt.tag, tt.kind, tt.n)
+		}
+	}
+}
+
+func TestReadFixed(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	if v, n := ReadFixed32(data); v != 0x04030201 || n != 4 {
+		t.Errorf("ReadFixed32 = %#x, %d", v, n)
+	}
+	if v, n := ReadFixed64(data); v != 0x0807060504030201 || n != 8 {
+		t.Errorf("ReadFixed64 = %#x, %d", v, n)
+	}
+	if _, n := ReadFixed32(data[:3]); n != 0 {
+		t.Errorf("ReadFixed32 of 3 bytes: n = %d", n)
+	}
+	if _, n := ReadFixed64(data[:7]); n != 0 {
+		t.Errorf("ReadFixed64 of 7 bytes: n = %d", n)
+	}
+}
+
+func TestReadBytes(t *testing.T) {
+	tests := []struct {
+		data []byte
+		b    []byte
+		n    int
+	}{
+		{[]byte{0}, []byte{}, 1},
+		{[]byte{3, 'a', 'b', 'c', 'd'}, []byte("abc"), 4},
+		{[]byte{4, 'a', 'b', 'c'}, nil, 0},                                             // truncated
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x08}, nil, -5},                                // above MaxLength
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, nil, -10}, // overflows int
+		{nil, nil, 0},
+	}
+	for _, tt := range tests {
+		b, n := ReadBytes(tt.data)
+		if !bytes.Equal(b, tt.b) || n != tt.n {
+			t.Errorf("ReadBytes(% x) = %q, %d, want %q, %d", tt.data, b, n, tt.b, tt.n)
+		}
+		if cap(b) != len(b) {
+			t.Errorf("ReadBytes(% x): capacity %d, want %d", tt.data, cap(b), len(b))
+		}
+	}
+}
+
+func TestReadNext(t *testing.T) {
+	tests := []struct {
+		data []byte
+		d    uint64
+		b    []byte
+		tag  TagNum
+		n    int
+		err  error
+	}{
+		{[]byte{0x08, 0x96, 0x01}, 150, nil, 1, 3, nil},
+		{[]byte{0x15, 1, 0, 0, 0}, 1, nil, 2, 5, nil},
+		{[]byte{0x19, 1, 0, 0, 0, 0, 0, 0, 0}, 1, nil, 3, 9, nil},
+		{[]byte{0x22, 2, 'h', 'i'}, 0, []byte("hi"), 4, 4, nil},
+		{[]byte{0x2b, 0x08, 0x01, 0x2c}, 0, []byte{0x08, 0x01}, 5, 4, nil},
+		{[]byte{0x2b, 0x33, 0x34, 0x2c}, 0, []byte{0x33, 0x34}, 5, 4, nil}, // nested group
+		{[]byte{0x00, 0x01}, 0, nil, 0, 0, ErrZeroTag},
+		{[]byte{0x0e}, 0, nil, 1, 0, ErrTagClass},
+		{[]byte{0x0c}, 0, nil, 1, 0, ErrTagClass}, // end key without start
+		{[]byte{0x08}, 0, nil, 1, 0, ErrTruncated},
+		{[]byte{0x15, 1, 0}, 0, nil, 2, 0, ErrTruncated},
+		{[]byte{0x22, 5, 'h'}, 0, nil, 4, 0, ErrTruncated},
+		{[]byte{0x2b, 0x08, 0x01}, 0, nil, 5, 0, ErrTruncated},
+		{[]byte{0x2b, 0x34}, 0, nil, 5, 0, ErrGroup}, // mismatched end key
+		{[]byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 0, nil, 1, 0, ErrOverflow},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 0, nil, 0, 0, ErrOverflow},
+	}
+	for _, tt := range tests {
+		d, b, tag, n, err := ReadNext(tt.data)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) || n != 0 {
+				t.Errorf("ReadNext(% x): n = %d, error %v, want %v", tt.data, n, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || d != tt.d || !bytes.Equal(b, tt.b) || tag != tt.tag || n != tt.n {
+			t.Errorf("ReadNext(% x) = %d, % x, %d, %d, %v, want %d, % x, %d, %d", tt.data, d, b, tag, n, err, tt.d, tt.b, tt.tag, tt.n)
+		}
+	}
+}
+
+func TestScalars(t *testing.T) {
+	for _, v := range []int32{0, 1, -1, 2, -2, math.MaxInt32, math.MinInt32} {
+		if got := DecodeSint32(EncodeSint32(v)); got != v {
+			t.Errorf("DecodeSint32(EncodeSint32(%d)) = %d", v, got)
+		}
+		if got := DecodeInt32(EncodeInt32(v)); got != v {
+			t.Errorf("DecodeInt32(EncodeInt32(%d)) = %d", v, got)
+		}
+	}
+	for _, v := range []int64{0, 1, -1, math.MaxInt64, math.MinInt64} {
+		if got := DecodeSint64(EncodeSint64(v)); got != v {
+			t.Errorf("DecodeSint64(EncodeSint64(%d)) = %d", v, got)
+		}
+	}
+	zigzag := []struct {
+		v   int64
+		raw uint64
+	}{{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {math.MaxInt64, math.MaxUint64 - 1}, {math.MinInt64, math.MaxUint64}}
+	for _, z := range zigzag {
+		if raw := EncodeSint64(z.v); raw != z.raw {
+			t.Errorf("EncodeSint64(%d) = %d, want %d", z.v, raw, z.raw)
+		}
+	}
+	if raw := EncodeInt32(-1); SizeVarint(raw) != 10 {
+		t.Errorf("EncodeInt32(-1) takes %d bytes, want 10", SizeVarint(raw))
+	}
+	if !DecodeBool(2) || DecodeBool(0) {
+		t.Error("DecodeBool: any non-zero value is true")
+	}
+	if f := DecodeFloat(uint64(EncodeFloat(1.5))); f != 1.5 {
+		t.Errorf("DecodeFloat(EncodeFloat(1.5)) = %g", f)
+	}
+	if f := DecodeDouble(EncodeDouble(-2.25)); f != -2.25 {
+		t.Errorf("DecodeDouble(EncodeDouble(-2.25)) = %g", f)
+	}
+}
+
+func TestSizeVarint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 1<<14 - 1, 1 << 14, 1<<63 - 1, 1 << 63, math.MaxUint64} {
+		if got, want := SizeVarint(v), len(AppendVarint(nil, v)); got != want {
+			t.Errorf("SizeVarint(%d) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestPacked(t *testing.T) {
+	// the appended values are length prefixed
+	body := func(b []byte) []byte {
+		v, _ := ReadBytes(b)
+		return v
+	}
+	vs := []uint64{0, 1, 300, math.MaxUint64}
+	got, err := UnpackVarints(body(AppendPackedVarints(nil, vs)))
+	if err != nil || !slices.Equal(got, vs) {
+		t.Errorf("UnpackVarints = %v, %v, want %v", got, err, vs)
+	}
+	f32 := []uint32{0, 1, math.MaxUint32}
+	if got, err := UnpackFixed32s(body(AppendPackedFixed32s(nil, f32))); err != nil || !slices.Equal(got, f32) {
+		t.Errorf("UnpackFixed32s = %v, %v, want %v", got, err, f32)
+	}
+	f64 := []uint64{0, 1, math.MaxUint64}
+	if got, err := UnpackFixed64s(body(AppendPackedFixed64s(nil, f64))); err != nil || !slices.Equal(got, f64) {
+		t.Errorf("UnpackFixed64s = %v, %v, want %v", got, err, f64)
+	}
+	for _, bad := range []struct {
+		b    []byte
+		kind TagClass
+	}{{[]byte{0x80}, TagUvarint}, {[]byte{1, 2, 3}, Tag32bit}, {[]byte{1, 2, 3, 4, 5, 6, 7}, Tag64bit}} {
+		if IsPacked(bad.b, bad.kind) {
+			t.Errorf("IsPacked(% x, %d) = true", bad.b, bad.kind)
+		}
+	}
+}
+
+func TestViewMessage(t *testing.T) {
+	// field 1 holds {1: 1} and then {2: 2}, the other fields are not messages
+	v := NewView([]byte{0x0a, 0x02, 0x08, 0x01, 0x10, 0x05, 0x0a, 0x02, 0x10, 0x02})
+	m, ok := v.Message(1)
+	if !ok {
+		t.Fatal("Message(1) = false")
+	}
+	if got, want := m.Bytes(), []byte{0x08, 0x01, 0x10, 0x02}; !bytes.Equal(got, want) {
+		t.Errorf("Message(1) = % x, want % x", got, want)
+	}
+	if _, ok := v.Message(2); ok {
+		t.Error("Message(2) of a varint = true")
+	}
+	if _, ok := v.Message(3); ok {
+		t.Error("Message(3) of a missing field = true")
+	}
+}
+
+func TestDecoderSkipGroups(t *testing.T) {
+	// group 1 holding 100000 nested groups 2, deeper than a recursive skip could go
+	var data []byte
+	data = append(data, 0x0b)
+	for range 100000 {
+		data = append(data, 0x13)
+	}
+	for range 100000 {
+		data = append(data, 0x14)
+	}
+	data = append(data, 0x0c, 0x10, 0x01)
+	d := NewDecoder(bytes.NewReader(data))
+	if _, _, err := d.ReadTag(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Skip(TagStart); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+	if tag, _, err := d.ReadTag(); err != nil || tag != 2 {
+		t.Errorf("ReadTag after Skip = %d, %v, want 2", tag, err)
+	}
+
+	d = NewDecoder(bytes.NewReader([]byte{0x0b, 0x13, 0x0c, 0x14}))
+	d.ReadTag()
+	if err := d.Skip(TagStart); !errors.Is(err, ErrGroup) {
+		t.Errorf("Skip of crossed groups: %v, want %v", err, ErrGroup)
+	}
+}
+
+func BenchmarkReadVarint(b *testing.B) {
+	inputs := []struct {
+		name string
+		data []byte
+	}{
+		{"1byte", binary.AppendUvarint(make([]byte, 0, 16), 100)},
+		{"2bytes", binary.AppendUvarint(make([]byte, 0, 16), 300)},
+		{"5bytes", binary.AppendUvarint(make([]byte, 0, 16), math.MaxUint32)},
+		{"10bytes", binary.AppendUvarint(make([]byte, 0, 16), math.MaxUint64)},
+	}
+	for _, in := range inputs {
+		data := in.data[:10:10] // the unrolled path needs 10 bytes
+		b.Run(in.name+"/wire", func(b *testing.B) {
+			for b.Loop() {
+				ReadVarint(data)
+			}
+		})
+		b.Run(in.name+"/binary", func(b *testing.B) {
+			for b.Loop() {
+				binary.Uvarint(data)
+			}
+		})
+	}
+}